@@ -112,3 +112,12 @@ Check if any Gateway API sources are enabled
 true
 {{- end -}}
 {{- end }}
+
+{{/*
+Check if any of the sources watches namespaces cluster-wide
+*/}}
+{{- define "external-dns.watchesNamespaces" -}}
+{{- if or (include "external-dns.hasGatewaySources" .) (has "istio-gateway" .Values.sources) -}}
+true
+{{- end -}}
+{{- end }}
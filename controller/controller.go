@@ -20,6 +20,8 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"slices"
+	"strings"
 	"sync"
 	"time"
 
@@ -84,6 +86,13 @@ var (
 			Help:      "Number of reconcile loops ending up with no changes on the DNS provider side.",
 		},
 	)
+	controllerSkippedPlansTotal = metrics.NewCounterWithOpts(
+		prometheus.CounterOpts{
+			Subsystem: "controller",
+			Name:      "skipped_plans_total",
+			Help:      "Number of reconcile loops whose changes were not applied because they would delete too many records.",
+		},
+	)
 	deprecatedRegistryErrors = metrics.NewCounterWithOpts(
 		prometheus.CounterOpts{
 			Subsystem: "registry",
@@ -145,6 +154,7 @@ func init() {
 	metrics.RegisterMetric.MustRegister(deprecatedRegistryErrors)
 	metrics.RegisterMetric.MustRegister(deprecatedSourceErrors)
 	metrics.RegisterMetric.MustRegister(controllerNoChangesTotal)
+	metrics.RegisterMetric.MustRegister(controllerSkippedPlansTotal)
 
 	metrics.RegisterMetric.MustRegister(registryRecords)
 	metrics.RegisterMetric.MustRegister(sourceRecords)
@@ -180,6 +190,8 @@ type Controller struct {
 	ExcludeRecordTypes []string
 	// MinEventSyncInterval is used as a window for batching events
 	MinEventSyncInterval time.Duration
+	// MaxDeletionRatio aborts a reconciliation that would delete a larger fraction of the managed records, zero disables it
+	MaxDeletionRatio float64
 }
 
 // RunOnce runs a single iteration of a reconciliation loop.
@@ -227,17 +239,25 @@ func (c *Controller) RunOnce(ctx context.Context) error {
 	registryFilter := c.Registry.GetDomainFilter()
 
 	plan := &plan.Plan{
-		Policies:       []plan.Policy{c.Policy},
-		Current:        regRecords,
-		Desired:        endpoints,
-		DomainFilter:   endpoint.MatchAllDomainFilters{c.DomainFilter, registryFilter},
-		ManagedRecords: c.ManagedRecordTypes,
-		ExcludeRecords: c.ExcludeRecordTypes,
-		OwnerID:        c.Registry.OwnerID(),
+		Policies:         []plan.Policy{c.Policy},
+		Current:          regRecords,
+		Desired:          endpoints,
+		DomainFilter:     endpoint.MatchAllDomainFilters{c.DomainFilter, registryFilter},
+		ManagedRecords:   c.ManagedRecordTypes,
+		ExcludeRecords:   c.ExcludeRecordTypes,
+		OwnerID:          c.Registry.OwnerID(),
+		MaxDeletionRatio: c.MaxDeletionRatio,
 	}
 
 	plan = plan.Calculate()
 
+	// Skip the changes rather than fail, a source recovering from a transient issue
+	// does not have to restart external-dns for its records to be reconciled again.
+	if err := plan.CheckDeletionRatio(); err != nil {
+		controllerSkippedPlansTotal.Counter.Inc()
+		return provider.NewSoftErrorf("refusing to apply changes: %w", err)
+	}
+
 	if plan.Changes.HasChanges() {
 		err = c.Registry.ApplyChanges(ctx, plan.Changes)
 		if err != nil {
@@ -255,6 +275,44 @@ func (c *Controller) RunOnce(ctx context.Context) error {
 	return nil
 }
 
+// DeleteAllManaged deletes the records owned by the owner ID of the registry that match the domain filters
+// and the managed record types, along with their ownership records, e.g. to clean up when external-dns is
+// decommissioned. Records created manually or owned by other instances are left untouched.
+func (c *Controller) DeleteAllManaged(ctx context.Context) error {
+	ownerID := c.Registry.OwnerID()
+	if ownerID == "" {
+		return errors.New("refusing to delete records without an owner ID")
+	}
+
+	regRecords, err := c.Registry.Records(ctx)
+	if err != nil {
+		registryErrorsTotal.Counter.Inc()
+		return err
+	}
+
+	domainFilter := endpoint.MatchAllDomainFilters{c.DomainFilter, c.Registry.GetDomainFilter()}
+	var deletes []*endpoint.Endpoint
+	for _, ep := range regRecords {
+		if ep.Labels[endpoint.OwnerLabelKey] != ownerID || !domainFilter.Match(ep.DNSName) ||
+			len(c.ManagedRecordTypes) > 0 && !slices.Contains(c.ManagedRecordTypes, ep.RecordType) {
+			continue
+		}
+		log.Infof("Deleting %s record %s -> %s", ep.RecordType, ep.DNSName, strings.Join(ep.Targets, ","))
+		deletes = append(deletes, ep)
+	}
+	if len(deletes) == 0 {
+		log.Info("No records owned by this instance to delete")
+		return nil
+	}
+
+	// The registry deletes the ownership records along with the records.
+	if err := c.Registry.ApplyChanges(ctx, &plan.Changes{Delete: deletes}); err != nil {
+		registryErrorsTotal.Counter.Inc()
+		return err
+	}
+	return nil
+}
+
 func earliest(r time.Time, times ...time.Time) time.Time {
 	for _, t := range times {
 		if t.Before(r) {
@@ -341,7 +399,11 @@ func (c *Controller) Run(ctx context.Context) {
 	for {
 		if c.ShouldRunOnce(time.Now()) {
 			if err := c.RunOnce(ctx); err != nil {
-				if errors.Is(err, provider.SoftError) {
+				if errors.Is(err, plan.ErrDeletionRatioExceeded) {
+					softErrorCount++
+					consecutiveSoftErrors.Gauge.Set(float64(softErrorCount))
+					log.Warnf("Skipping changes: %v (consecutive soft errors: %d)", err, softErrorCount)
+				} else if errors.Is(err, provider.SoftError) {
 					softErrorCount++
 					consecutiveSoftErrors.Gauge.Set(float64(softErrorCount))
 					log.Errorf("Failed to do run once: %v (consecutive soft errors: %d)", err, softErrorCount)
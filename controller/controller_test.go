@@ -32,6 +32,8 @@ import (
 	"sigs.k8s.io/external-dns/provider"
 	"sigs.k8s.io/external-dns/registry"
 
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	log "github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -387,6 +389,160 @@ func TestControllerSkipsEmptyChanges(t *testing.T) {
 	)
 }
 
+func TestControllerRefusesExcessiveDeletions(t *testing.T) {
+	source := new(testutils.MockSource)
+	source.On("Endpoints").Return([]*endpoint.Endpoint{
+		endpoint.NewEndpoint("kept.used.tld", endpoint.RecordTypeA, "1.2.3.4"),
+	}, nil)
+	dnsProvider := &filteredMockProvider{
+		RecordsStore: []*endpoint.Endpoint{
+			endpoint.NewEndpoint("kept.used.tld", endpoint.RecordTypeA, "1.2.3.4"),
+			endpoint.NewEndpoint("gone-1.used.tld", endpoint.RecordTypeA, "1.2.3.5"),
+			endpoint.NewEndpoint("gone-2.used.tld", endpoint.RecordTypeA, "1.2.3.6"),
+		},
+	}
+	r, err := registry.NewNoopRegistry(dnsProvider)
+	require.NoError(t, err)
+
+	ctrl := &Controller{
+		Source:             source,
+		Registry:           r,
+		Policy:             &plan.SyncPolicy{},
+		ManagedRecordTypes: []string{endpoint.RecordTypeA},
+		MaxDeletionRatio:   0.5,
+	}
+
+	err = ctrl.RunOnce(context.Background())
+	require.ErrorIs(t, err, plan.ErrDeletionRatioExceeded)
+	require.ErrorIs(t, err, provider.SoftError)
+	assert.Empty(t, dnsProvider.ApplyChangesCalls)
+
+	ctrl.MaxDeletionRatio = 0.7
+	require.NoError(t, ctrl.RunOnce(context.Background()))
+	require.Len(t, dnsProvider.ApplyChangesCalls, 1)
+	assert.Len(t, dnsProvider.ApplyChangesCalls[0].Delete, 2)
+}
+
+// TestRunSurvivesExcessiveDeletions tests that Run skips the changes of a plan deleting too many records
+// and keeps running, instead of exiting.
+func TestRunSurvivesExcessiveDeletions(t *testing.T) {
+	source := new(testutils.MockSource)
+	source.On("Endpoints").Return([]*endpoint.Endpoint{}, nil)
+	provider := &filteredMockProvider{
+		RecordsStore: []*endpoint.Endpoint{
+			endpoint.NewEndpoint("gone-1.used.tld", endpoint.RecordTypeA, "1.2.3.5"),
+			endpoint.NewEndpoint("gone-2.used.tld", endpoint.RecordTypeA, "1.2.3.6"),
+		},
+	}
+	r, err := registry.NewNoopRegistry(provider)
+	require.NoError(t, err)
+
+	exited := false
+	logger := log.StandardLogger()
+	exitFunc := logger.ExitFunc
+	logger.ExitFunc = func(int) { exited = true }
+	defer func() { logger.ExitFunc = exitFunc }()
+
+	ctrl := &Controller{
+		Source:             source,
+		Registry:           r,
+		Policy:             &plan.SyncPolicy{},
+		ManagedRecordTypes: []string{endpoint.RecordTypeA},
+		MaxDeletionRatio:   0.5,
+	}
+	ctrl.nextRunAt = time.Now().Add(-time.Millisecond)
+	skipped := testutil.ToFloat64(controllerSkippedPlansTotal.Counter)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stopped := make(chan struct{})
+	go func() {
+		ctrl.Run(ctx)
+		close(stopped)
+	}()
+	time.Sleep(1500 * time.Millisecond)
+	cancel()
+	<-stopped
+
+	assert.False(t, exited, "Run exited on a plan deleting too many records")
+	assert.Empty(t, provider.ApplyChangesCalls)
+	assert.Greater(t, testutil.ToFloat64(controllerSkippedPlansTotal.Counter), skipped)
+	assert.Positive(t, testutil.ToFloat64(consecutiveSoftErrors.Gauge))
+}
+
+func TestDeleteAllManaged(t *testing.T) {
+	const (
+		owned      = `"heritage=external-dns,external-dns/owner=default"`
+		otherOwner = `"heritage=external-dns,external-dns/owner=other"`
+	)
+	newController := func(ownerID string) (*Controller, *filteredMockProvider) {
+		dnsProvider := &filteredMockProvider{
+			RecordsStore: []*endpoint.Endpoint{
+				endpoint.NewEndpoint("test1.example.com", endpoint.RecordTypeA, "192.168.1.1"),
+				endpoint.NewEndpoint("a-test1.example.com", endpoint.RecordTypeTXT, owned),
+				endpoint.NewEndpoint("www.example.com", endpoint.RecordTypeCNAME, "test1.example.com"),
+				endpoint.NewEndpoint("cname-www.example.com", endpoint.RecordTypeTXT, owned),
+				// Created manually in the same domain
+				endpoint.NewEndpoint("manual.example.com", endpoint.RecordTypeA, "192.168.1.2"),
+				// Owned by another instance
+				endpoint.NewEndpoint("other.example.com", endpoint.RecordTypeA, "192.168.1.3"),
+				endpoint.NewEndpoint("a-other.example.com", endpoint.RecordTypeTXT, otherOwner),
+				// Outside of the domain filter
+				endpoint.NewEndpoint("router.lan", endpoint.RecordTypeA, "192.168.1.254"),
+				endpoint.NewEndpoint("a-router.lan", endpoint.RecordTypeTXT, owned),
+			},
+		}
+		r, err := registry.NewTXTRegistry(dnsProvider, "", "", ownerID, 0, "", nil, nil, false, nil)
+		require.NoError(t, err)
+		return &Controller{
+			Registry:           r,
+			DomainFilter:       endpoint.NewDomainFilter([]string{"example.com"}),
+			ManagedRecordTypes: []string{endpoint.RecordTypeA, endpoint.RecordTypeCNAME},
+		}, dnsProvider
+	}
+	names := func(eps []*endpoint.Endpoint) []string {
+		var names []string
+		for _, ep := range eps {
+			names = append(names, ep.RecordType+" "+ep.DNSName)
+		}
+		sort.Strings(names)
+		return names
+	}
+
+	t.Run("deletes the owned records matching the domain filter", func(t *testing.T) {
+		hook := testutils.LogsUnderTestWithLogLevel(log.InfoLevel, t)
+
+		ctrl, dnsProvider := newController("default")
+		require.NoError(t, ctrl.DeleteAllManaged(context.Background()))
+
+		require.Len(t, dnsProvider.ApplyChangesCalls, 1)
+		changes := dnsProvider.ApplyChangesCalls[0]
+		assert.Equal(t, []string{
+			"A test1.example.com", "CNAME www.example.com",
+			"TXT a-test1.example.com", "TXT cname-www.example.com",
+		}, names(changes.Delete))
+		assert.Empty(t, changes.Create)
+		assert.Empty(t, changes.UpdateNew)
+		testutils.TestHelperLogContains("Deleting A record test1.example.com -> 192.168.1.1", hook, t)
+		testutils.TestHelperLogNotContains("manual.example.com", hook, t)
+		testutils.TestHelperLogNotContains("router.lan", hook, t)
+	})
+
+	t.Run("nothing to delete", func(t *testing.T) {
+		ctrl, dnsProvider := newController("unknown")
+		require.NoError(t, ctrl.DeleteAllManaged(context.Background()))
+		assert.Empty(t, dnsProvider.ApplyChangesCalls)
+	})
+
+	t.Run("requires an owner ID", func(t *testing.T) {
+		dnsProvider := &filteredMockProvider{}
+		r, err := registry.NewNoopRegistry(dnsProvider)
+		require.NoError(t, err)
+		ctrl := &Controller{Registry: r}
+		require.Error(t, ctrl.DeleteAllManaged(context.Background()))
+		assert.Empty(t, dnsProvider.ApplyChangesCalls)
+	})
+}
+
 func TestWhenNoFilterControllerConsidersAllComain(t *testing.T) {
 	testControllerFiltersDomains(
 		t,
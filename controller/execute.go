@@ -22,6 +22,7 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"sync/atomic"
 	"syscall"
 	"time"
 
@@ -99,7 +100,8 @@ func Execute() {
 
 	ctx, cancel := context.WithCancel(context.Background())
 
-	go serveMetrics(cfg.MetricsAddress)
+	readiness := &providerReadiness{}
+	go serveMetrics(cfg.MetricsAddress, readiness)
 	go handleSigterm(cancel)
 
 	endpointsSource, err := buildSource(ctx, cfg)
@@ -107,12 +109,16 @@ func Execute() {
 		log.Fatal(err)
 	}
 
-	domainFilter := createDomainFilter(cfg)
+	domainFilter, err := createDomainFilter(cfg)
+	if err != nil {
+		log.Fatal(err)
+	}
 
 	prvdr, err := buildProvider(ctx, cfg, domainFilter)
 	if err != nil {
 		log.Fatal(err)
 	}
+	readiness.setProvider(prvdr)
 
 	if cfg.WebhookServer {
 		webhookapi.StartHTTPApi(prvdr, nil, cfg.WebhookProviderReadTimeout, cfg.WebhookProviderWriteTimeout, "127.0.0.1:8888")
@@ -124,6 +130,14 @@ func Execute() {
 		log.Fatal(err)
 	}
 
+	if cfg.DeleteAllManaged {
+		if err := ctrl.DeleteAllManaged(ctx); err != nil {
+			log.Fatal(err)
+		}
+
+		os.Exit(0)
+	}
+
 	if cfg.Once {
 		err := ctrl.RunOnce(ctx)
 		if err != nil {
@@ -326,6 +340,19 @@ func buildProvider(
 				DomainFilter:          domainFilter,
 				DryRun:                cfg.DryRun,
 				APIVersion:            cfg.PiholeApiVersion,
+				UserAgent:             cfg.PiholeUserAgent,
+				ExtraHeaders:          cfg.PiholeExtraHeaders,
+				ManagedRecordTypes:    cfg.ManagedDNSRecordTypes,
+				OwnershipMode:         cfg.PiholeOwnershipMode,
+				CNAMETargetConflict:   cfg.PiholeCNAMETargetConflict,
+				CACertFile:            cfg.PiholeCACertFile,
+				RequestTimeout:        cfg.PiholeRequestTimeout,
+				AuthPath:              cfg.PiholeAuthPath,
+				BatchWrites:           cfg.PiholeBatchWrites,
+				OrderCreates:          cfg.PiholeOrderCreates,
+				StrictDecoding:        cfg.PiholeStrictDecoding,
+				PreserveNameCase:      cfg.PiholePreserveNameCase,
+				RecordsCacheTTL:       cfg.PiholeRecordsCacheTTL,
 			},
 		)
 	case "plural":
@@ -362,6 +389,7 @@ func buildController(cfg *externaldns.Config, src source.Source, p provider.Prov
 		ManagedRecordTypes:   cfg.ManagedDNSRecordTypes,
 		ExcludeRecordTypes:   cfg.ExcludeDNSRecordTypes,
 		MinEventSyncInterval: cfg.MinEventSyncInterval,
+		MaxDeletionRatio:     cfg.MaxDeletionRatio,
 	}, nil
 }
 
@@ -434,12 +462,19 @@ func buildSource(ctx context.Context, cfg *externaldns.Config) (source.Source, e
 }
 
 // RegexDomainFilter overrides DomainFilter
-func createDomainFilter(cfg *externaldns.Config) *endpoint.DomainFilter {
+func createDomainFilter(cfg *externaldns.Config) (*endpoint.DomainFilter, error) {
 	if cfg.RegexDomainFilter != nil && cfg.RegexDomainFilter.String() != "" {
-		return endpoint.NewRegexDomainFilter(cfg.RegexDomainFilter, cfg.RegexDomainExclusion)
-	} else {
-		return endpoint.NewDomainFilterWithExclusions(cfg.DomainFilter, cfg.ExcludeDomains)
+		return endpoint.NewRegexDomainFilter(cfg.RegexDomainFilter, cfg.RegexDomainExclusion), nil
+	}
+	domainFilter, err := endpoint.ExpandDomainFilterFiles(cfg.DomainFilter)
+	if err != nil {
+		return nil, err
 	}
+	excludeDomains, err := endpoint.ExpandDomainFilterFiles(cfg.ExcludeDomains)
+	if err != nil {
+		return nil, err
+	}
+	return endpoint.NewDomainFilterWithExclusions(domainFilter, excludeDomains), nil
 }
 
 // handleSigterm listens for a SIGTERM signal and triggers the provided cancel function
@@ -452,17 +487,45 @@ func handleSigterm(cancel func()) {
 	cancel()
 }
 
+// providerReadiness serves the readiness of the provider, which is not ready until it is built
+// and then as long as it can reach its DNS service, see provider.Healthchecker.
+type providerReadiness struct {
+	provider atomic.Pointer[provider.Provider]
+}
+
+func (r *providerReadiness) setProvider(p provider.Provider) {
+	r.provider.Store(&p)
+}
+
+func (r *providerReadiness) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	p := r.provider.Load()
+	if p == nil {
+		http.Error(w, "provider not initialized", http.StatusServiceUnavailable)
+		return
+	}
+	if err := provider.Healthcheck(req.Context(), *p); err != nil {
+		log.Warnf("Provider health check failed: %v", err)
+		http.Error(w, fmt.Sprintf("provider health check failed: %v", err), http.StatusServiceUnavailable)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write([]byte("OK"))
+}
+
 // serveMetrics starts an HTTP server that serves health and metrics endpoints.
 // The /healthz endpoint returns a 200 OK status to indicate the service is healthy.
+// The /readyz endpoint returns a 200 OK status once the provider is built and can reach its DNS service.
 // The /metrics endpoint serves Prometheus metrics.
 // The server listens on the specified address and logs debug information about the endpoints.
-func serveMetrics(address string) {
+func serveMetrics(address string, readiness *providerReadiness) {
 	http.HandleFunc("/healthz", func(w http.ResponseWriter, _ *http.Request) {
 		w.WriteHeader(http.StatusOK)
 		_, _ = w.Write([]byte("OK"))
 	})
+	http.Handle("/readyz", readiness)
 
 	log.Debugf("serving 'healthz' on '%s/healthz'", address)
+	log.Debugf("serving 'readyz' on '%s/readyz'", address)
 	log.Debugf("serving 'metrics' on '%s/metrics'", address)
 	log.Debugf("registered '%d' metrics", len(metrics.RegisterMetric.Metrics))
 
@@ -19,14 +19,17 @@ package controller
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"net"
 	"net/http"
 	"net/http/httptest"
 	"os"
 	"os/signal"
+	"path/filepath"
 	"reflect"
 	"regexp"
+	"strings"
 	"syscall"
 	"testing"
 	"time"
@@ -183,13 +186,37 @@ func TestCreateDomainFilter(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			filter := createDomainFilter(tt.cfg)
+			filter, err := createDomainFilter(tt.cfg)
+			require.NoError(t, err)
 			assert.Equal(t, tt.isConfigured, filter.IsConfigured())
 			assert.Equal(t, tt.expectedDomainFilter, filter)
 		})
 	}
 }
 
+func TestCreateDomainFilterWithFileReferences(t *testing.T) {
+	dir := t.TempDir()
+	domains := filepath.Join(dir, "domains.txt")
+	require.NoError(t, os.WriteFile(domains, []byte("foo.example.org\nbar.example.org\n"), 0o600))
+	excluded := filepath.Join(dir, "excluded.txt")
+	require.NoError(t, os.WriteFile(excluded, []byte("internal.foo.example.org\n"), 0o600))
+
+	filter, err := createDomainFilter(&externaldns.Config{
+		DomainFilter:   []string{"example.com", "file://" + domains},
+		ExcludeDomains: []string{"file://" + excluded},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, endpoint.NewDomainFilterWithExclusions(
+		[]string{"example.com", "foo.example.org", "bar.example.org"},
+		[]string{"internal.foo.example.org"},
+	), filter)
+
+	_, err = createDomainFilter(&externaldns.Config{
+		DomainFilter: []string{"file://" + filepath.Join(dir, "missing.txt")},
+	})
+	assert.Error(t, err)
+}
+
 func TestHandleSigterm(t *testing.T) {
 	cancelCalled := make(chan bool, 1)
 	cancel := func() {
@@ -240,7 +267,8 @@ func TestServeMetrics(t *testing.T) {
 	require.NoError(t, err)
 	addresse := fmt.Sprintf("localhost:%d", port)
 
-	go serveMetrics(fmt.Sprintf(":%d", port))
+	readiness := &providerReadiness{}
+	go serveMetrics(fmt.Sprintf(":%d", port), readiness)
 
 	// Wait for the TCP socket to be ready
 	require.Eventually(t, func() bool {
@@ -259,6 +287,68 @@ func TestServeMetrics(t *testing.T) {
 	resp, err = http.Get(fmt.Sprintf("http://%s/metrics", addresse))
 	require.NoError(t, err)
 	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	resp, err = http.Get(fmt.Sprintf("http://%s/readyz", addresse))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+
+	readiness.setProvider(&MockProvider{})
+	resp, err = http.Get(fmt.Sprintf("http://%s/readyz", addresse))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+}
+
+type unhealthyProvider struct {
+	provider.BaseProvider
+	err error
+}
+
+func (p *unhealthyProvider) Records(_ context.Context) ([]*endpoint.Endpoint, error) {
+	return nil, nil
+}
+
+func (p *unhealthyProvider) ApplyChanges(_ context.Context, _ *plan.Changes) error {
+	return nil
+}
+
+func (p *unhealthyProvider) Healthcheck(_ context.Context) error {
+	return p.err
+}
+
+func TestProviderReadiness(t *testing.T) {
+	for _, tt := range []struct {
+		name       string
+		provider   provider.Provider
+		wantStatus int
+		wantBody   string
+	}{
+		{name: "provider not built", wantStatus: http.StatusServiceUnavailable, wantBody: "provider not initialized"},
+		{name: "provider without health check", provider: &MockProvider{}, wantStatus: http.StatusOK, wantBody: "OK"},
+		{name: "healthy provider", provider: &unhealthyProvider{}, wantStatus: http.StatusOK, wantBody: "OK"},
+		{
+			name:       "unhealthy provider",
+			provider:   &unhealthyProvider{err: errors.New("connection refused")},
+			wantStatus: http.StatusServiceUnavailable,
+			wantBody:   "provider health check failed: connection refused",
+		},
+		{
+			name:       "unhealthy cached provider",
+			provider:   provider.NewCachedProvider(&unhealthyProvider{err: errors.New("connection refused")}, time.Minute),
+			wantStatus: http.StatusServiceUnavailable,
+			wantBody:   "provider health check failed: connection refused",
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			readiness := &providerReadiness{}
+			if tt.provider != nil {
+				readiness.setProvider(tt.provider)
+			}
+			rec := httptest.NewRecorder()
+			readiness.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+			assert.Equal(t, tt.wantStatus, rec.Code)
+			assert.Equal(t, tt.wantBody, strings.TrimSpace(rec.Body.String()))
+		})
+	}
 }
 
 func TestConfigureLogger(t *testing.T) {
@@ -0,0 +1,107 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package endpoint
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Builder assembles an Endpoint and validates it when built, so that malformed records are reported
+// where they are created rather than deep in a provider.
+type Builder struct {
+	dnsName          string
+	recordType       string
+	targets          Targets
+	ttl              TTL
+	setIdentifier    string
+	labels           Labels
+	providerSpecific ProviderSpecific
+}
+
+// NewBuilder returns a Builder of an endpoint with the given DNS name.
+func NewBuilder(dnsName string) *Builder {
+	return &Builder{dnsName: dnsName}
+}
+
+// WithType sets the record type of the endpoint.
+func (b *Builder) WithType(recordType string) *Builder {
+	b.recordType = recordType
+	return b
+}
+
+// WithTargets sets the targets of the endpoint, replacing the ones set before.
+func (b *Builder) WithTargets(targets ...string) *Builder {
+	b.targets = targets
+	return b
+}
+
+// WithTTL sets the TTL of the endpoint.
+func (b *Builder) WithTTL(ttl TTL) *Builder {
+	b.ttl = ttl
+	return b
+}
+
+// WithSetIdentifier sets the set identifier of the endpoint.
+func (b *Builder) WithSetIdentifier(setIdentifier string) *Builder {
+	b.setIdentifier = setIdentifier
+	return b
+}
+
+// WithLabel adds a label to the endpoint.
+func (b *Builder) WithLabel(key, value string) *Builder {
+	if b.labels == nil {
+		b.labels = NewLabels()
+	}
+	b.labels[key] = value
+	return b
+}
+
+// WithProviderSpecific adds a provider-specific property to the endpoint.
+func (b *Builder) WithProviderSpecific(key, value string) *Builder {
+	b.providerSpecific = append(b.providerSpecific, ProviderSpecificProperty{Name: key, Value: value})
+	return b
+}
+
+// Build returns the endpoint, or an error if its DNS name or record type is missing, a label of its
+// DNS name is longer than 63 characters, or its targets do not fit its record type, see Targets.Validate.
+func (b *Builder) Build() (*Endpoint, error) {
+	if strings.TrimSuffix(b.dnsName, ".") == "" {
+		return nil, errors.New("endpoint DNS name is empty")
+	}
+	if b.recordType == "" {
+		return nil, fmt.Errorf("endpoint %s: record type is empty", b.dnsName)
+	}
+
+	ep := NewEndpointWithTTL(b.dnsName, b.recordType, b.ttl, b.targets...)
+	if ep == nil {
+		return nil, fmt.Errorf("endpoint %s %s: a label is longer than 63 characters", b.dnsName, b.recordType)
+	}
+	if err := ep.Targets.Validate(ep.RecordType); err != nil {
+		return nil, fmt.Errorf("endpoint %s %s: %w", ep.DNSName, ep.RecordType, err)
+	}
+
+	ep.SetIdentifier = b.setIdentifier
+	for key, value := range b.labels {
+		ep.Labels[key] = value
+	}
+	for _, property := range b.providerSpecific {
+		ep.SetProviderSpecificProperty(property.Name, property.Value)
+	}
+	return ep, nil
+}
@@ -0,0 +1,104 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package endpoint
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuilder(t *testing.T) {
+	ep, err := NewBuilder("www.example.com.").
+		WithType(RecordTypeA).
+		WithTargets("1.2.3.4", "5.6.7.8").
+		WithTTL(300).
+		WithSetIdentifier("eu-west").
+		WithLabel(ResourceLabelKey, "service/default/www").
+		WithProviderSpecific("alias", "false").
+		Build()
+	require.NoError(t, err)
+
+	expected := NewEndpointWithTTL("www.example.com", RecordTypeA, 300, "1.2.3.4", "5.6.7.8").
+		WithSetIdentifier("eu-west").
+		WithLabel(ResourceLabelKey, "service/default/www").
+		WithProviderSpecific("alias", "false")
+	assert.Equal(t, expected, ep)
+}
+
+func TestBuilderInvalid(t *testing.T) {
+	for _, tt := range []struct {
+		name    string
+		builder *Builder
+		err     string
+	}{
+		{
+			name:    "empty DNS name",
+			builder: NewBuilder(".").WithType(RecordTypeA).WithTargets("1.2.3.4"),
+			err:     "endpoint DNS name is empty",
+		},
+		{
+			name:    "missing record type",
+			builder: NewBuilder("www.example.com").WithTargets("1.2.3.4"),
+			err:     "endpoint www.example.com: record type is empty",
+		},
+		{
+			name:    "label too long",
+			builder: NewBuilder(strings.Repeat("a", 64) + ".example.com").WithType(RecordTypeA).WithTargets("1.2.3.4"),
+			err:     "a label is longer than 63 characters",
+		},
+		{
+			name:    "A record with a hostname target",
+			builder: NewBuilder("www.example.com").WithType(RecordTypeA).WithTargets("lb.example.com"),
+			err:     `endpoint www.example.com A: target "lb.example.com" is not a valid IPv4 address`,
+		},
+		{
+			name:    "AAAA record with an IPv4 target",
+			builder: NewBuilder("www.example.com").WithType(RecordTypeAAAA).WithTargets("1.2.3.4"),
+			err:     `endpoint www.example.com AAAA: target "1.2.3.4" is not a valid IPv6 address`,
+		},
+		{
+			name:    "CNAME record with several targets",
+			builder: NewBuilder("www.example.com").WithType(RecordTypeCNAME).WithTargets("a.example.com", "b.example.com"),
+			err:     "endpoint www.example.com CNAME: CNAME record must have exactly one target, got 2",
+		},
+		{
+			name:    "MX record without preference",
+			builder: NewBuilder("example.com").WithType(RecordTypeMX).WithTargets("mail.example.com"),
+			err:     "endpoint example.com MX:",
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			ep, err := tt.builder.Build()
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.err)
+			assert.Nil(t, ep)
+		})
+	}
+}
+
+func TestBuilderWithTargetsReplaces(t *testing.T) {
+	ep, err := NewBuilder("www.example.com").
+		WithType(RecordTypeCNAME).
+		WithTargets("a.example.com", "b.example.com").
+		WithTargets("lb.example.com.").
+		Build()
+	require.NoError(t, err)
+	assert.Equal(t, Targets{"lb.example.com"}, ep.Targets)
+}
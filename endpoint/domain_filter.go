@@ -20,9 +20,13 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"os"
 	"regexp"
+	"slices"
 	"sort"
 	"strings"
+	"unicode"
+	"unicode/utf8"
 
 	log "github.com/sirupsen/logrus"
 
@@ -43,20 +47,63 @@ func (f MatchAllDomainFilters) Match(domain string) bool {
 	return true
 }
 
+// MatchWithType checks whether all the filters match the domain, calling MatchWithType instead of Match
+// on the filters that also constrain record types.
+func (f MatchAllDomainFilters) MatchWithType(domain, recordType string) bool {
+	for _, filter := range f {
+		if filter == nil {
+			continue
+		}
+		if typeFilter, ok := filter.(domainTypeFilter); ok {
+			if !typeFilter.MatchWithType(domain, recordType) {
+				return false
+			}
+		} else if !filter.Match(domain) {
+			return false
+		}
+	}
+	return true
+}
+
 type DomainFilterInterface interface {
 	Match(domain string) bool
 }
 
+// domainTypeFilter is implemented by domain filters that can also constrain record types.
+type domainTypeFilter interface {
+	MatchWithType(domain, recordType string) bool
+}
+
 // DomainFilter holds a lists of valid domain names
 type DomainFilter struct {
 	// Filters define what domains to match
 	Filters []string
 	// exclude define what domains not to match
 	exclude []string
+	// excludeGlobs holds the compiled form of the exclusions containing a '*' wildcard
+	excludeGlobs []*regexp.Regexp
 	// regex defines a regular expression to match the domains
 	regex *regexp.Regexp
 	// regexExclusion defines a regular expression to exclude the domains matched
 	regexExclusion *regexp.Regexp
+	// disableParentMatching keeps MatchParent from matching the parents of the filters
+	disableParentMatching bool
+	// includeTypes defines what record types to match, all of them when empty
+	includeTypes []string
+	// excludeTypes defines what record types not to match
+	excludeTypes []string
+}
+
+// DomainFilterOptions tunes how a DomainFilter matches domains. The zero value keeps the default behavior.
+type DomainFilterOptions struct {
+	// DisableParentMatching keeps MatchParent from matching parents of the included domains, so that
+	// zone discovery does not select a zone like example.com for a filter of a.example.com.
+	DisableParentMatching bool
+	// IncludeTypes restricts MatchWithType to records of the given types, e.g. to manage A records only.
+	// All record types are matched when empty.
+	IncludeTypes []string
+	// ExcludeTypes keeps MatchWithType from matching records of the given types, e.g. to leave TXT records alone.
+	ExcludeTypes []string
 }
 
 var _ DomainFilterInterface = &DomainFilter{}
@@ -67,6 +114,11 @@ type domainFilterSerde struct {
 	Exclude      []string `json:"exclude,omitempty"`
 	RegexInclude string   `json:"regexInclude,omitempty"`
 	RegexExclude string   `json:"regexExclude,omitempty"`
+	// DisableParentMatching is only serialized when set, so that the wire format of default filters is unchanged.
+	DisableParentMatching bool `json:"disableParentMatching,omitempty"`
+	// IncludeTypes and ExcludeTypes are only serialized when set, for the same reason.
+	IncludeTypes []string `json:"includeTypes,omitempty"`
+	ExcludeTypes []string `json:"excludeTypes,omitempty"`
 }
 
 // prepareFilters provides consistent trimming for filters/exclude params
@@ -80,9 +132,116 @@ func prepareFilters(filters []string) []string {
 	return fs
 }
 
-// NewDomainFilterWithExclusions returns a new DomainFilter, given a list of matches and exclusions
+// prepareGlobs compiles the filters containing a '*' wildcard into anchored regular expressions.
+// A '*' matches any sequence of characters within a single label, so `*-internal.example.org`
+// matches `api-internal.example.org` but not `api.internal.example.org`.
+func prepareGlobs(filters []string) []*regexp.Regexp {
+	var globs []*regexp.Regexp
+	for _, filter := range filters {
+		if !strings.Contains(filter, "*") {
+			continue
+		}
+		pattern := strings.ReplaceAll(regexp.QuoteMeta(filter), `\*`, `[^.]*`)
+		globs = append(globs, regexp.MustCompile("^"+pattern+"$"))
+	}
+	return globs
+}
+
+// prepareTypes upper-cases and sorts the record types, dropping blank and duplicated ones.
+func prepareTypes(recordTypes []string) []string {
+	var types []string
+	for _, recordType := range recordTypes {
+		if recordType = strings.ToUpper(strings.TrimSpace(recordType)); recordType != "" && !slices.Contains(types, recordType) {
+			types = append(types, recordType)
+		}
+	}
+	slices.Sort(types)
+	return types
+}
+
+// domainFileScheme prefixes domain filter entries that reference a file of domains.
+const domainFileScheme = "file://"
+
+// ExpandDomainFilterFiles replaces every "file://<path>" entry with the domains listed in that file,
+// one per line. Blank lines and lines starting with '#' are ignored. Other entries are kept as-is.
+func ExpandDomainFilterFiles(filters []string) ([]string, error) {
+	var expanded []string
+	for _, filter := range filters {
+		path, ok := strings.CutPrefix(strings.TrimSpace(filter), domainFileScheme)
+		if !ok {
+			expanded = append(expanded, filter)
+			continue
+		}
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("reading domain filter file: %w", err)
+		}
+		for line := range strings.Lines(string(content)) {
+			line = strings.TrimSpace(line)
+			if line == "" || strings.HasPrefix(line, "#") {
+				continue
+			}
+			expanded = append(expanded, line)
+		}
+	}
+	return expanded, nil
+}
+
+// NewDomainFilterWithExclusions returns a new DomainFilter, given a list of matches and exclusions.
+// Exclusions may contain '*' wildcards, see prepareGlobs.
 func NewDomainFilterWithExclusions(domainFilters []string, excludeDomains []string) *DomainFilter {
-	return &DomainFilter{Filters: prepareFilters(domainFilters), exclude: prepareFilters(excludeDomains)}
+	return NewDomainFilterWithOptions(domainFilters, excludeDomains, DomainFilterOptions{})
+}
+
+// NewDomainFilterWithOptions returns a new DomainFilter like NewDomainFilterWithExclusions, tuned by the given options.
+func NewDomainFilterWithOptions(domainFilters []string, excludeDomains []string, opts DomainFilterOptions) *DomainFilter {
+	exclude := prepareFilters(excludeDomains)
+	return &DomainFilter{
+		Filters:               prepareFilters(domainFilters),
+		exclude:               exclude,
+		excludeGlobs:          prepareGlobs(exclude),
+		disableParentMatching: opts.DisableParentMatching,
+		includeTypes:          prepareTypes(opts.IncludeTypes),
+		excludeTypes:          prepareTypes(opts.ExcludeTypes),
+	}
+}
+
+// NewDomainFilterStrict returns a new DomainFilter like NewDomainFilterWithExclusions, but fails
+// listing the offending entries when some of them are not valid DNS names. Blank entries are ignored,
+// a leading '.' is allowed and exclusions may contain '*' wildcards.
+func NewDomainFilterStrict(include, exclude []string) (*DomainFilter, error) {
+	var invalid []string
+	for _, entries := range []struct {
+		filters   []string
+		wildcards bool
+	}{{include, false}, {exclude, true}} {
+		for _, entry := range entries.filters {
+			if err := validateFilterEntry(entry, entries.wildcards); err != nil {
+				invalid = append(invalid, err.Error())
+			}
+		}
+	}
+	if len(invalid) > 0 {
+		return nil, fmt.Errorf("invalid domain filter entries: %s", strings.Join(invalid, "; "))
+	}
+	return NewDomainFilterWithExclusions(include, exclude), nil
+}
+
+// validateFilterEntry checks that a domain filter entry is a valid DNS name once normalized.
+func validateFilterEntry(entry string, wildcards bool) error {
+	domain := strings.TrimSpace(entry)
+	if domain == "" {
+		return nil
+	}
+	name := strings.TrimPrefix(normalizeDomain(domain), ".")
+	if wildcards {
+		// a '*' stands for any sequence of valid characters within a label, see prepareGlobs
+		name = strings.ReplaceAll(name, "*", "x")
+	}
+	if err := validateHostname(name); err != nil {
+		return fmt.Errorf("%q: %w", entry, err)
+	}
+	return nil
 }
 
 // NewDomainFilter returns a new DomainFilter given a comma separated list of domains
@@ -98,14 +257,101 @@ func NewRegexDomainFilter(regexDomainFilter *regexp.Regexp, regexDomainExclusion
 // Match checks whether a domain can be found in the DomainFilter.
 // RegexFilter takes precedence over Filters
 func (df *DomainFilter) Match(domain string) bool {
+	if df == nil || df.matchesAll() {
+		return true // nil or empty filter matches everything
+	}
+	return df.matchNormalized(normalizeDomain(domain))
+}
+
+// MatchAny checks whether any of the domains can be found in the DomainFilter, see Match.
+func (df *DomainFilter) MatchAny(domains []string) bool {
+	if df == nil || df.matchesAll() {
+		return len(domains) > 0
+	}
+	return slices.ContainsFunc(domains, func(domain string) bool {
+		return df.matchNormalized(normalizeDomain(domain))
+	})
+}
+
+// FilterMatching returns the domains that can be found in the DomainFilter, see Match,
+// as given and in the same order.
+func (df *DomainFilter) FilterMatching(domains []string) []string {
+	if df == nil || df.matchesAll() {
+		return slices.Clone(domains)
+	}
+	var matching []string
+	for _, domain := range domains {
+		if df.matchNormalized(normalizeDomain(domain)) {
+			matching = append(matching, domain)
+		}
+	}
+	return matching
+}
+
+// matchesAll reports whether the DomainFilter has no rule at all, in which case every domain matches
+// without having to be normalized.
+func (df *DomainFilter) matchesAll() bool {
+	return !df.isRegex() && len(df.Filters) == 0 && len(df.exclude) == 0 && len(df.excludeGlobs) == 0
+}
+
+// isRegex reports whether the DomainFilter matches domains with regular expressions rather than with Filters.
+func (df *DomainFilter) isRegex() bool {
+	return df.regex != nil && df.regex.String() != "" || df.regexExclusion != nil && df.regexExclusion.String() != ""
+}
+
+// matchNormalized is Match for a domain already normalized with normalizeDomain,
+// so that it is normalized once for all the rules. RegexFilter takes precedence over Filters.
+func (df *DomainFilter) matchNormalized(strippedDomain string) bool {
+	if df.isRegex() {
+		if df.regexExclusion != nil && df.regexExclusion.String() != "" {
+			return !df.regexExclusion.MatchString(strippedDomain)
+		}
+		return df.regex.MatchString(strippedDomain)
+	}
+
+	return (len(df.Filters) == 0 || matchFilterEntries(df.Filters, strippedDomain)) &&
+		!matchFilterEntries(df.exclude, strippedDomain) &&
+		!matchGlobEntries(df.excludeGlobs, strippedDomain)
+}
+
+// MatchWithType checks whether a domain can be found in the DomainFilter, see Match, and whether the filter
+// manages records of the given type: the type must be one of the included types, if any, and not one of
+// the excluded types. Without type constraints it behaves like Match.
+func (df *DomainFilter) MatchWithType(domain, recordType string) bool {
+	if !df.Match(domain) {
+		return false
+	}
 	if df == nil {
-		return true // nil filter matches everything
+		return true
 	}
-	if df.regex != nil && df.regex.String() != "" || df.regexExclusion != nil && df.regexExclusion.String() != "" {
-		return matchRegex(df.regex, df.regexExclusion, domain)
+	return df.matchType(recordType)
+}
+
+// matchType determines if records of the given type are managed by the DomainFilter.
+func (df *DomainFilter) matchType(recordType string) bool {
+	recordType = strings.ToUpper(recordType)
+	if len(df.includeTypes) > 0 && !slices.Contains(df.includeTypes, recordType) {
+		return false
 	}
+	return !slices.Contains(df.excludeTypes, recordType)
+}
 
-	return matchFilter(df.Filters, domain, true) && !matchFilter(df.exclude, domain, false)
+// matchGlobs determines if any of the compiled glob `globs` match `domain`.
+func matchGlobs(globs []*regexp.Regexp, domain string) bool {
+	if len(globs) == 0 {
+		return false
+	}
+	return matchGlobEntries(globs, normalizeDomain(domain))
+}
+
+// matchGlobEntries determines if any of the compiled glob `globs` match the normalized `strippedDomain`.
+func matchGlobEntries(globs []*regexp.Regexp, strippedDomain string) bool {
+	for _, glob := range globs {
+		if glob.MatchString(strippedDomain) {
+			return true
+		}
+	}
+	return false
 }
 
 // matchFilter determines if any `filters` match `domain`.
@@ -115,37 +361,65 @@ func matchFilter(filters []string, domain string, emptyval bool) bool {
 	if len(filters) == 0 {
 		return emptyval
 	}
+	return matchFilterEntries(filters, normalizeDomain(domain))
+}
 
-	strippedDomain := normalizeDomain(domain)
+// matchFilterEntries determines if any of the `filters` match the normalized `strippedDomain`.
+func matchFilterEntries(filters []string, strippedDomain string) bool {
 	for _, filter := range filters {
-		if filter == "" {
-			continue
-		}
-
-		if strings.HasPrefix(filter, ".") && strings.HasSuffix(strippedDomain, filter) {
-			return true
-		} else if strings.Count(strippedDomain, ".") == strings.Count(filter, ".") {
-			if strippedDomain == filter {
-				return true
-			}
-		} else if strings.HasSuffix(strippedDomain, "."+filter) {
+		if matchFilterEntry(filter, strippedDomain) {
 			return true
 		}
 	}
 	return false
 }
 
-// matchRegex determines if a domain matches the configured regular expressions in DomainFilter.
-// negativeRegex, if set, takes precedence over regex.  Therefore, matchRegex returns true when
-// only regex regular expression matches the domain
-// Otherwise, if either negativeRegex matches or regex does not match the domain, it returns false
-func matchRegex(regex *regexp.Regexp, negativeRegex *regexp.Regexp, domain string) bool {
+// matchFilterEntry determines if a single filter entry matches the normalized `domain`.
+func matchFilterEntry(filter, strippedDomain string) bool {
+	if filter == "" {
+		return false
+	}
+
+	if strings.HasPrefix(filter, ".") && strings.HasSuffix(strippedDomain, filter) {
+		return true
+	} else if strings.Count(strippedDomain, ".") == strings.Count(filter, ".") {
+		return strippedDomain == filter
+	}
+	return strings.HasSuffix(strippedDomain, "."+filter)
+}
+
+// MatchingIncludes returns all include entries of the DomainFilter that cover the domain,
+// which helps to understand why a domain is in scope when includes overlap.
+// Exclusions are not taken into account and regex filters have no include entries.
+func (df *DomainFilter) MatchingIncludes(domain string) []string {
+	if df == nil {
+		return nil
+	}
+
 	strippedDomain := normalizeDomain(domain)
+	var matches []string
+	for _, filter := range df.Filters {
+		if matchFilterEntry(filter, strippedDomain) {
+			matches = append(matches, filter)
+		}
+	}
+	return matches
+}
 
-	if negativeRegex != nil && negativeRegex.String() != "" {
-		return !negativeRegex.MatchString(strippedDomain)
+// AffectedByFilterChange returns the endpoints whose DNS name is matched by newFilter but not by oldFilter,
+// and the ones matched by oldFilter but not by newFilter, e.g. to report scope changes before swapping filters.
+// A nil filter matches everything.
+func AffectedByFilterChange(eps []*Endpoint, oldFilter, newFilter *DomainFilter) (newlyIncluded, newlyExcluded []*Endpoint) {
+	for _, ep := range eps {
+		oldMatch, newMatch := oldFilter.Match(ep.DNSName), newFilter.Match(ep.DNSName)
+		switch {
+		case newMatch && !oldMatch:
+			newlyIncluded = append(newlyIncluded, ep)
+		case oldMatch && !newMatch:
+			newlyExcluded = append(newlyExcluded, ep)
+		}
 	}
-	return regex.MatchString(strippedDomain)
+	return newlyIncluded, newlyExcluded
 }
 
 // IsConfigured returns true if any inclusion or exclusion rules have been specified.
@@ -180,13 +454,18 @@ func (df *DomainFilter) MarshalJSON() ([]byte, error) {
 		return json.Marshal(domainFilterSerde{
 			RegexInclude: include,
 			RegexExclude: exclude,
+			IncludeTypes: df.includeTypes,
+			ExcludeTypes: df.excludeTypes,
 		})
 	}
 	sort.Strings(df.Filters)
 	sort.Strings(df.exclude)
 	return json.Marshal(domainFilterSerde{
-		Include: df.Filters,
-		Exclude: df.exclude,
+		Include:               df.Filters,
+		Exclude:               df.exclude,
+		DisableParentMatching: df.disableParentMatching,
+		IncludeTypes:          df.includeTypes,
+		ExcludeTypes:          df.excludeTypes,
 	})
 }
 
@@ -198,7 +477,11 @@ func (df *DomainFilter) UnmarshalJSON(b []byte) error {
 	}
 
 	if deserialized.RegexInclude == "" && deserialized.RegexExclude == "" {
-		*df = *NewDomainFilterWithExclusions(deserialized.Include, deserialized.Exclude)
+		*df = *NewDomainFilterWithOptions(deserialized.Include, deserialized.Exclude, DomainFilterOptions{
+			DisableParentMatching: deserialized.DisableParentMatching,
+			IncludeTypes:          deserialized.IncludeTypes,
+			ExcludeTypes:          deserialized.ExcludeTypes,
+		})
 		return nil
 	}
 
@@ -220,19 +503,26 @@ func (df *DomainFilter) UnmarshalJSON(b []byte) error {
 		}
 	}
 	*df = *NewRegexDomainFilter(include, exclude)
+	df.includeTypes = prepareTypes(deserialized.IncludeTypes)
+	df.excludeTypes = prepareTypes(deserialized.ExcludeTypes)
 	return nil
 }
 
+// MatchParent checks whether the domain is a parent of one of the included domains, unless parent
+// matching is disabled by DomainFilterOptions. A filter without included domains matches every domain.
 func (df *DomainFilter) MatchParent(domain string) bool {
 	if df == nil {
 		return true // nil filter matches everything
 	}
-	if matchFilter(df.exclude, domain, false) {
+	if matchFilter(df.exclude, domain, false) || matchGlobs(df.excludeGlobs, domain) {
 		return false
 	}
 	if len(df.Filters) == 0 {
 		return true
 	}
+	if df.disableParentMatching {
+		return false
+	}
 
 	strippedDomain := normalizeDomain(domain)
 	for _, filter := range df.Filters {
@@ -247,12 +537,45 @@ func (df *DomainFilter) MatchParent(domain string) bool {
 	return false
 }
 
+// maxNormalizeRounds bounds the number of times normalizeDomain maps a domain while looking for its canonical form.
+const maxNormalizeRounds = 8
+
 // normalizeDomain converts a domain to a canonical form, so that we can filter on it
-// it: trim "." suffix, get Unicode version of domain compliant with Section 5 of RFC 5891
+// it: drop invalid UTF-8 and control characters, trim "." suffix, get Unicode version of domain
+// compliant with Section 5 of RFC 5891.
+// Since labels decoded from punycode are not mapped themselves, and may for instance hold uppercase letters,
+// the conversion is repeated until the domain no longer changes, so that normalizing a normalized domain
+// returns it unchanged.
 func normalizeDomain(domain string) string {
-	s, err := idna.Profile.ToUnicode(strings.TrimSuffix(domain, "."))
+	s, err := normalizeDomainOnce(domain)
 	if err != nil {
-		log.Warnf(`Got error while parsing domain %s: %v`, domain, err)
+		log.Warnf(`Got error while parsing domain %q: %v`, domain, err)
+	}
+	for range maxNormalizeRounds {
+		next, _ := normalizeDomainOnce(s)
+		if next == s {
+			break
+		}
+		s = next
 	}
 	return s
 }
+
+// normalizeDomainOnce sanitizes the domain, trims its "." suffix and converts it to Unicode.
+func normalizeDomainOnce(domain string) (string, error) {
+	return idna.Profile.ToUnicode(strings.TrimRight(sanitizeDomain(domain), "."))
+}
+
+// sanitizeDomain removes the invalid UTF-8 sequences and the control characters from domain,
+// which cannot be part of a DNS name and would otherwise make comparisons unpredictable.
+func sanitizeDomain(domain string) string {
+	if utf8.ValidString(domain) && !strings.ContainsFunc(domain, unicode.IsControl) {
+		return domain
+	}
+	return strings.Map(func(r rune) rune {
+		if r == utf8.RuneError || unicode.IsControl(r) {
+			return -1
+		}
+		return r
+	}, strings.ToValidUTF8(domain, ""))
+}
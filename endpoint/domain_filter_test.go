@@ -19,9 +19,13 @@ package endpoint
 import (
 	"encoding/json"
 	"fmt"
+	"os"
+	"path/filepath"
 	"regexp"
+	"slices"
 	"strings"
 	"testing"
+	"unicode/utf8"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -348,6 +352,36 @@ var domainFilterTests = []domainFilterTest{
 			"exclude": {"api.example.org"},
 		},
 	},
+	{
+		[]string{"example.org"},
+		[]string{"*-internal.example.org"},
+		[]string{"api.example.org", "api.internal.example.org", "internal.example.org", "foo.api-internal.example.org"},
+		true,
+		map[string][]string{
+			"include": {"example.org"},
+			"exclude": {"*-internal.example.org"},
+		},
+	},
+	{
+		[]string{"example.org"},
+		[]string{"*-internal.example.org"},
+		[]string{"api-internal.example.org", "db-internal.example.org", "API-Internal.Example.Org"},
+		false,
+		map[string][]string{
+			"include": {"example.org"},
+			"exclude": {"*-internal.example.org"},
+		},
+	},
+	{
+		[]string{"example.org"},
+		[]string{"api.*.example.org", "staging.example.org"},
+		[]string{"api.eu.example.org", "api.us.example.org", "web.staging.example.org"},
+		false,
+		map[string][]string{
+			"include": {"example.org"},
+			"exclude": {"api.*.example.org", "staging.example.org"},
+		},
+	},
 }
 
 var regexDomainFilterTests = []regexDomainFilterTest{
@@ -838,6 +872,132 @@ func TestDomainFilterMatchParent(t *testing.T) {
 	}
 }
 
+func TestDomainFilterMatchParentDisabled(t *testing.T) {
+	opts := DomainFilterOptions{DisableParentMatching: true}
+	for _, tt := range []struct {
+		name     string
+		include  []string
+		exclude  []string
+		domain   string
+		expected bool
+	}{
+		{name: "parent of an included domain", include: []string{"a.example.com"}, domain: "example.com", expected: false},
+		{name: "included domain", include: []string{"a.example.com"}, domain: "a.example.com", expected: false},
+		{name: "no included domain", domain: "example.com", expected: true},
+		{name: "excluded domain without included domain", exclude: []string{"example.com"}, domain: "example.com", expected: false},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			domainFilter := NewDomainFilterWithOptions(tt.include, tt.exclude, opts)
+			assert.Equal(t, tt.expected, domainFilter.MatchParent(tt.domain))
+			// Matching itself is not affected
+			assert.Equal(t, NewDomainFilterWithExclusions(tt.include, tt.exclude).Match(tt.domain), domainFilter.Match(tt.domain))
+		})
+	}
+
+	// Parent matching stays enabled by default
+	assert.True(t, NewDomainFilterWithOptions([]string{"a.example.com"}, nil, DomainFilterOptions{}).MatchParent("example.com"))
+}
+
+func TestDomainFilterDisableParentMatchingSerialization(t *testing.T) {
+	domainFilter := NewDomainFilterWithOptions([]string{"a.example.com"}, nil, DomainFilterOptions{DisableParentMatching: true})
+	data, err := json.Marshal(domainFilter)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"include": ["a.example.com"], "disableParentMatching": true}`, string(data))
+
+	var deserialized DomainFilter
+	require.NoError(t, json.Unmarshal(data, &deserialized))
+	assert.False(t, deserialized.MatchParent("example.com"))
+	assert.True(t, deserialized.Match("a.example.com"))
+}
+
+func TestDomainFilterMatchWithType(t *testing.T) {
+	for _, tt := range []struct {
+		name       string
+		include    []string
+		opts       DomainFilterOptions
+		domain     string
+		recordType string
+		expected   bool
+	}{
+		{name: "no type constraint", include: []string{"example.org"}, domain: "www.example.org", recordType: RecordTypeTXT, expected: true},
+		{name: "domain not matched", include: []string{"example.org"}, domain: "www.example.com", recordType: RecordTypeA, expected: false},
+		{name: "included type", include: []string{"example.org"}, opts: DomainFilterOptions{IncludeTypes: []string{"A", "AAAA"}}, domain: "www.example.org", recordType: RecordTypeA, expected: true},
+		{name: "type not included", include: []string{"example.org"}, opts: DomainFilterOptions{IncludeTypes: []string{"A", "AAAA"}}, domain: "www.example.org", recordType: RecordTypeCNAME, expected: false},
+		{name: "excluded type", include: []string{"example.org"}, opts: DomainFilterOptions{ExcludeTypes: []string{"TXT"}}, domain: "www.example.org", recordType: RecordTypeTXT, expected: false},
+		{name: "type not excluded", include: []string{"example.org"}, opts: DomainFilterOptions{ExcludeTypes: []string{"TXT"}}, domain: "www.example.org", recordType: RecordTypeA, expected: true},
+		{name: "exclusion wins over inclusion", opts: DomainFilterOptions{IncludeTypes: []string{"A", "TXT"}, ExcludeTypes: []string{"TXT"}}, domain: "www.example.org", recordType: RecordTypeTXT, expected: false},
+		{name: "types are case insensitive", opts: DomainFilterOptions{IncludeTypes: []string{" a "}}, domain: "www.example.org", recordType: "a", expected: true},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			domainFilter := NewDomainFilterWithOptions(tt.include, nil, tt.opts)
+			assert.Equal(t, tt.expected, domainFilter.MatchWithType(tt.domain, tt.recordType))
+			// Matching names alone is not affected
+			assert.Equal(t, NewDomainFilter(tt.include).Match(tt.domain), domainFilter.Match(tt.domain))
+		})
+	}
+
+	var nilFilter *DomainFilter
+	assert.True(t, nilFilter.MatchWithType("example.org", RecordTypeA))
+}
+
+func TestMatchAllDomainFiltersMatchWithType(t *testing.T) {
+	filters := MatchAllDomainFilters{
+		NewDomainFilterWithOptions([]string{"example.org"}, nil, DomainFilterOptions{ExcludeTypes: []string{RecordTypeTXT}}),
+		nil,
+		nameOnlyDomainFilter("www.example.org"),
+	}
+	assert.True(t, filters.MatchWithType("www.example.org", RecordTypeA))
+	assert.False(t, filters.MatchWithType("www.example.org", RecordTypeTXT))
+	assert.False(t, filters.MatchWithType("api.example.org", RecordTypeA))
+}
+
+// nameOnlyDomainFilter matches a single domain and has no notion of record types.
+type nameOnlyDomainFilter string
+
+func (f nameOnlyDomainFilter) Match(domain string) bool {
+	return domain == string(f)
+}
+
+func TestDomainFilterTypesSerialization(t *testing.T) {
+	for _, tt := range []struct {
+		name     string
+		filter   *DomainFilter
+		expected string
+	}{
+		{
+			name:     "domain list",
+			filter:   NewDomainFilterWithOptions([]string{"example.org"}, nil, DomainFilterOptions{IncludeTypes: []string{"aaaa", "A"}, ExcludeTypes: []string{"TXT"}}),
+			expected: `{"include": ["example.org"], "includeTypes": ["A", "AAAA"], "excludeTypes": ["TXT"]}`,
+		},
+		{
+			name: "regex",
+			filter: func() *DomainFilter {
+				df := NewRegexDomainFilter(regexp.MustCompile(`example\.org$`), nil)
+				df.excludeTypes = []string{RecordTypeTXT}
+				return df
+			}(),
+			expected: `{"regexInclude": "example\\.org$", "excludeTypes": ["TXT"]}`,
+		},
+		{
+			name:     "no types",
+			filter:   NewDomainFilter([]string{"example.org"}),
+			expected: `{"include": ["example.org"]}`,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := json.Marshal(tt.filter)
+			require.NoError(t, err)
+			assert.JSONEq(t, tt.expected, string(data))
+
+			var deserialized DomainFilter
+			require.NoError(t, json.Unmarshal(data, &deserialized))
+			for _, recordType := range []string{RecordTypeA, RecordTypeAAAA, RecordTypeCNAME, RecordTypeTXT} {
+				assert.Equal(t, tt.filter.MatchWithType("www.example.org", recordType), deserialized.MatchWithType("www.example.org", recordType), recordType)
+			}
+		})
+	}
+}
+
 func TestSimpleDomainFilterWithExclusion(t *testing.T) {
 	test := []struct {
 		domainFilter    []string
@@ -950,8 +1110,245 @@ func TestDomainFilterNormalizeDomain(t *testing.T) {
 	}
 }
 
+func FuzzNormalizeDomain(f *testing.F) {
+	for _, seed := range []string{
+		"",
+		".",
+		"example.org.",
+		"3AAAA.FOO.BAR.COM",
+		"*.example.org",
+		".example.org",
+		"xn--c1yn36f.org.",
+		"xn--nordic--w1a.xn--xn--kItty-pd34d-hn01b3542b.com",
+		"nordic-ø.kitty😸.COM",
+		"exa\xffmple.org",
+		"exa\x00mple\t.org\n",
+		"xn--.org",
+		"Xn--0000-71A",
+		"Xn--0000-001B",
+	} {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, domain string) {
+		normalized := normalizeDomain(domain)
+		if !utf8.ValidString(normalized) {
+			t.Errorf("normalizeDomain(%q) = %q is not valid UTF-8", domain, normalized)
+		}
+		if again := normalizeDomain(normalized); again != normalized {
+			t.Errorf("normalizeDomain is not idempotent: %q -> %q -> %q", domain, normalized, again)
+		}
+	})
+}
+
+func TestDomainFilterMatchingIncludes(t *testing.T) {
+	df := NewDomainFilterWithExclusions(
+		[]string{"example.com", "api.example.com", ".example.com", "other.org", "v1.api.example.com."},
+		[]string{"internal.api.example.com"},
+	)
+
+	for _, tt := range []struct {
+		domain   string
+		expected []string
+	}{
+		{domain: "v1.api.example.com", expected: []string{"example.com", "api.example.com", ".example.com", "v1.api.example.com"}},
+		{domain: "api.example.com.", expected: []string{"example.com", "api.example.com", ".example.com"}},
+		{domain: "example.com", expected: []string{"example.com"}},
+		{domain: "internal.api.example.com", expected: []string{"example.com", "api.example.com", ".example.com"}},
+		{domain: "example.org"},
+	} {
+		t.Run(tt.domain, func(t *testing.T) {
+			assert.Equal(t, tt.expected, df.MatchingIncludes(tt.domain))
+		})
+	}
+
+	var nilFilter *DomainFilter
+	assert.Nil(t, nilFilter.MatchingIncludes("example.com"))
+	assert.Nil(t, NewRegexDomainFilter(regexp.MustCompile(`example\.com$`), nil).MatchingIncludes("example.com"))
+}
+
+func TestDomainFilterMatchAnyAndFilterMatching(t *testing.T) {
+	var nilFilter *DomainFilter
+	for _, tt := range []struct {
+		title    string
+		filter   *DomainFilter
+		domains  []string
+		expected []string
+	}{
+		{
+			title:    "nil filter",
+			filter:   nilFilter,
+			domains:  []string{"example.com", "example.org"},
+			expected: []string{"example.com", "example.org"},
+		},
+		{
+			title:    "empty filter",
+			filter:   &DomainFilter{},
+			domains:  []string{"example.com", "example.org"},
+			expected: []string{"example.com", "example.org"},
+		},
+		{
+			title:   "no domains",
+			filter:  NewDomainFilter([]string{"example.com"}),
+			domains: nil,
+		},
+		{
+			title:    "include and exclude",
+			filter:   NewDomainFilterWithExclusions([]string{"example.com"}, []string{"internal.example.com"}),
+			domains:  []string{"example.org", "API.example.com.", "db.internal.example.com", "example.com"},
+			expected: []string{"API.example.com.", "example.com"},
+		},
+		{
+			title:   "none matching",
+			filter:  NewDomainFilterWithExclusions([]string{"example.com"}, []string{"internal.example.com"}),
+			domains: []string{"example.org", "internal.example.com"},
+		},
+		{
+			title:    "regex",
+			filter:   NewRegexDomainFilter(regexp.MustCompile(`example\.com$`), nil),
+			domains:  []string{"api.example.com", "example.org", "www.example.com"},
+			expected: []string{"api.example.com", "www.example.com"},
+		},
+		{
+			title:    "regex exclusion",
+			filter:   NewRegexDomainFilter(nil, regexp.MustCompile(`^internal\.`)),
+			domains:  []string{"internal.example.com", "api.example.com"},
+			expected: []string{"api.example.com"},
+		},
+	} {
+		t.Run(tt.title, func(t *testing.T) {
+			assert.Equal(t, tt.expected, tt.filter.FilterMatching(tt.domains))
+			assert.Equal(t, len(tt.expected) > 0, tt.filter.MatchAny(tt.domains))
+			for _, domain := range tt.domains {
+				assert.Equal(t, slices.Contains(tt.expected, domain), tt.filter.Match(domain), domain)
+			}
+		})
+	}
+}
+
+func TestAffectedByFilterChange(t *testing.T) {
+	apiEP := NewEndpoint("api.example.com", RecordTypeA, "1.2.3.4")
+	internalEP := NewEndpoint("db.internal.example.com", RecordTypeA, "10.0.0.1")
+	orgEP := NewEndpoint("www.example.org", RecordTypeCNAME, "lb.example.org")
+	eps := []*Endpoint{apiEP, internalEP, orgEP}
+
+	for _, tt := range []struct {
+		name             string
+		oldFilter        *DomainFilter
+		newFilter        *DomainFilter
+		expectedIncluded []*Endpoint
+		expectedExcluded []*Endpoint
+	}{
+		{
+			name:      "unchanged filter",
+			oldFilter: NewDomainFilter([]string{"example.com"}),
+			newFilter: NewDomainFilter([]string{"example.com"}),
+		},
+		{
+			name:             "added domain newly includes endpoints",
+			oldFilter:        NewDomainFilter([]string{"example.com"}),
+			newFilter:        NewDomainFilter([]string{"example.com", "example.org"}),
+			expectedIncluded: []*Endpoint{orgEP},
+		},
+		{
+			name:             "added exclusion newly excludes endpoints",
+			oldFilter:        NewDomainFilter([]string{"example.com"}),
+			newFilter:        NewDomainFilterWithExclusions([]string{"example.com"}, []string{"internal.example.com"}),
+			expectedExcluded: []*Endpoint{internalEP},
+		},
+		{
+			name:             "switched domain includes and excludes endpoints",
+			oldFilter:        NewDomainFilter([]string{"example.org"}),
+			newFilter:        NewDomainFilter([]string{"example.com"}),
+			expectedIncluded: []*Endpoint{apiEP, internalEP},
+			expectedExcluded: []*Endpoint{orgEP},
+		},
+		{
+			name:             "regex filter replacing nil filter",
+			newFilter:        NewRegexDomainFilter(regexp.MustCompile(`^api\.`), nil),
+			expectedExcluded: []*Endpoint{internalEP, orgEP},
+		},
+		{
+			name:             "nil filter replacing domain filter",
+			oldFilter:        NewDomainFilter([]string{"example.org"}),
+			expectedIncluded: []*Endpoint{apiEP, internalEP},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			included, excluded := AffectedByFilterChange(eps, tt.oldFilter, tt.newFilter)
+			assert.Equal(t, tt.expectedIncluded, included)
+			assert.Equal(t, tt.expectedExcluded, excluded)
+		})
+	}
+}
+
+func TestNewDomainFilterStrict(t *testing.T) {
+	for _, tt := range []struct {
+		name    string
+		include []string
+		exclude []string
+		wantErr []string
+	}{
+		{
+			name:    "valid entries",
+			include: []string{"example.com", " .example.org. ", "", "bücher.example", "_tcp.example.net"},
+			exclude: []string{"internal.example.com", "*-internal.example.org", "*.staging.example.org"},
+		},
+		{
+			name: "no entries",
+		},
+		{
+			name:    "entry with a space",
+			include: []string{"example.com", "foo bar.example.com"},
+			wantErr: []string{`"foo bar.example.com": invalid character ' '`},
+		},
+		{
+			name:    "several invalid entries",
+			include: []string{"exa$mple.com", "example..com"},
+			exclude: []string{"10.0.0.1", "ok.example.com"},
+			wantErr: []string{`"exa$mple.com": invalid character '$'`, `"example..com": label "" has an invalid length`, `"10.0.0.1": an IP address is not a hostname`},
+		},
+		{
+			name:    "wildcard in include",
+			include: []string{"*.example.com"},
+			wantErr: []string{`"*.example.com": invalid character '*'`},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			df, err := NewDomainFilterStrict(tt.include, tt.exclude)
+			if len(tt.wantErr) == 0 {
+				require.NoError(t, err)
+				assert.Equal(t, NewDomainFilterWithExclusions(tt.include, tt.exclude), df)
+				return
+			}
+			require.Error(t, err)
+			assert.Nil(t, df)
+			for _, want := range tt.wantErr {
+				assert.ErrorContains(t, err, want)
+			}
+		})
+	}
+}
+
 func TestMatchTargetFilterReturnsProperEmptyVal(t *testing.T) {
 	var emptyFilters []string
 	assert.True(t, matchFilter(emptyFilters, "sometarget.com", true))
 	assert.False(t, matchFilter(emptyFilters, "sometarget.com", false))
 }
+
+func TestExpandDomainFilterFiles(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "domains.txt")
+	require.NoError(t, os.WriteFile(path, []byte("# managed zones\nfoo.example.org\n\n  bar.example.org  \n"), 0o600))
+
+	filters, err := ExpandDomainFilterFiles([]string{"example.com", "file://" + path, "example.net"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"example.com", "foo.example.org", "bar.example.org", "example.net"}, filters)
+
+	filter := NewDomainFilter(filters)
+	assert.True(t, filter.Match("www.example.com"))
+	assert.True(t, filter.Match("www.bar.example.org"))
+	assert.False(t, filter.Match("baz.example.org"))
+
+	_, err = ExpandDomainFilterFiles([]string{"file://" + filepath.Join(t.TempDir(), "missing.txt")})
+	assert.Error(t, err)
+}
@@ -0,0 +1,87 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package endpoint
+
+import (
+	"maps"
+	"slices"
+)
+
+// EndpointDTO is the provider-neutral wire format of an Endpoint, meant for API servers and webhooks.
+// It only uses plain types with explicit JSON tags, so that the wire format does not change along
+// with the internal Endpoint struct.
+type EndpointDTO struct {
+	DNSName          string                        `json:"dnsName"`
+	Targets          []string                      `json:"targets,omitempty"`
+	RecordType       string                        `json:"recordType"`
+	SetIdentifier    string                        `json:"setIdentifier,omitempty"`
+	RecordTTL        int64                         `json:"recordTTL,omitempty"`
+	Labels           map[string]string             `json:"labels,omitempty"`
+	ProviderSpecific []ProviderSpecificPropertyDTO `json:"providerSpecific,omitempty"`
+}
+
+// ProviderSpecificPropertyDTO is the wire format of a ProviderSpecificProperty.
+type ProviderSpecificPropertyDTO struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// ToDTO converts the Endpoint to its wire format. The returned DTO shares no memory with the Endpoint.
+func (e *Endpoint) ToDTO() *EndpointDTO {
+	if e == nil {
+		return nil
+	}
+
+	dto := &EndpointDTO{
+		DNSName:       e.DNSName,
+		Targets:       slices.Clone([]string(e.Targets)),
+		RecordType:    e.RecordType,
+		SetIdentifier: e.SetIdentifier,
+		RecordTTL:     int64(e.RecordTTL),
+		Labels:        maps.Clone(map[string]string(e.Labels)),
+	}
+	if e.ProviderSpecific != nil {
+		dto.ProviderSpecific = make([]ProviderSpecificPropertyDTO, 0, len(e.ProviderSpecific))
+		for _, property := range e.ProviderSpecific {
+			dto.ProviderSpecific = append(dto.ProviderSpecific, ProviderSpecificPropertyDTO(property))
+		}
+	}
+	return dto
+}
+
+// FromDTO converts the wire format of an Endpoint back to an Endpoint. The returned Endpoint shares no memory with the DTO.
+func FromDTO(dto *EndpointDTO) *Endpoint {
+	if dto == nil {
+		return nil
+	}
+
+	e := &Endpoint{
+		DNSName:       dto.DNSName,
+		Targets:       Targets(slices.Clone(dto.Targets)),
+		RecordType:    dto.RecordType,
+		SetIdentifier: dto.SetIdentifier,
+		RecordTTL:     TTL(dto.RecordTTL),
+		Labels:        Labels(maps.Clone(dto.Labels)),
+	}
+	if dto.ProviderSpecific != nil {
+		e.ProviderSpecific = make(ProviderSpecific, 0, len(dto.ProviderSpecific))
+		for _, property := range dto.ProviderSpecific {
+			e.ProviderSpecific = append(e.ProviderSpecific, ProviderSpecificProperty(property))
+		}
+	}
+	return e
+}
@@ -0,0 +1,120 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package endpoint
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEndpointDTORoundTrip(t *testing.T) {
+	for _, tt := range []struct {
+		name     string
+		endpoint *Endpoint
+	}{
+		{
+			name: "all fields",
+			endpoint: NewEndpointWithTTL("www.example.com", RecordTypeA, 300, "1.2.3.4", "5.6.7.8").
+				WithSetIdentifier("eu-west").
+				WithLabel(OwnerLabelKey, "default").
+				WithLabel(ResourceLabelKey, "ingress/default/www").
+				WithProviderSpecific("aws/weight", "10").
+				WithProviderSpecific("alias", "true"),
+		},
+		{
+			name:     "only required fields",
+			endpoint: &Endpoint{DNSName: "txt.example.com", RecordType: RecordTypeTXT},
+		},
+		{
+			name:     "empty collections",
+			endpoint: &Endpoint{DNSName: "example.com", RecordType: RecordTypeCNAME, Targets: Targets{}, Labels: Labels{}, ProviderSpecific: ProviderSpecific{}},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.endpoint, FromDTO(tt.endpoint.ToDTO()))
+		})
+	}
+}
+
+func TestEndpointDTOJSONRoundTrip(t *testing.T) {
+	ep := NewEndpointWithTTL("www.example.com", RecordTypeA, 300, "1.2.3.4", "5.6.7.8").
+		WithSetIdentifier("eu-west").
+		WithLabel(OwnerLabelKey, "default").
+		WithLabel(ResourceLabelKey, "ingress/default/www").
+		WithProviderSpecific("aws/weight", "10").
+		WithProviderSpecific("alias", "true")
+
+	data, err := json.Marshal(ep.ToDTO())
+	require.NoError(t, err)
+	var decoded EndpointDTO
+	require.NoError(t, json.Unmarshal(data, &decoded))
+	assert.Equal(t, ep, FromDTO(&decoded))
+}
+
+func TestEndpointDTOWireFormat(t *testing.T) {
+	ep := NewEndpointWithTTL("www.example.com", RecordTypeA, 300, "1.2.3.4").
+		WithSetIdentifier("eu-west").
+		WithLabel(OwnerLabelKey, "default").
+		WithProviderSpecific("alias", "false")
+
+	data, err := json.Marshal(ep.ToDTO())
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+		"dnsName": "www.example.com",
+		"targets": ["1.2.3.4"],
+		"recordType": "A",
+		"setIdentifier": "eu-west",
+		"recordTTL": 300,
+		"labels": {"owner": "default"},
+		"providerSpecific": [{"name": "alias", "value": "false"}]
+	}`, string(data))
+
+	data, err = json.Marshal((&Endpoint{DNSName: "example.com", RecordType: RecordTypeTXT, ProviderSpecific: ProviderSpecific{{Name: "empty"}}}).ToDTO())
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"dnsName": "example.com", "recordType": "TXT", "providerSpecific": [{"name": "empty", "value": ""}]}`, string(data))
+}
+
+func TestEndpointDTOIsDecoupled(t *testing.T) {
+	ep := NewEndpoint("www.example.com", RecordTypeA, "1.2.3.4").
+		WithLabel(OwnerLabelKey, "default").
+		WithProviderSpecific("alias", "false")
+
+	dto := ep.ToDTO()
+	dto.Targets[0] = "9.9.9.9"
+	dto.Labels[OwnerLabelKey] = "other"
+	dto.ProviderSpecific[0].Value = "true"
+	assert.Equal(t, Targets{"1.2.3.4"}, ep.Targets)
+	assert.Equal(t, "default", ep.Labels[OwnerLabelKey])
+	assert.Equal(t, "false", ep.ProviderSpecific[0].Value)
+
+	converted := FromDTO(dto)
+	converted.Targets[0] = "8.8.8.8"
+	converted.Labels[OwnerLabelKey] = "another"
+	converted.ProviderSpecific[0].Value = "maybe"
+	assert.Equal(t, []string{"9.9.9.9"}, dto.Targets)
+	assert.Equal(t, "other", dto.Labels[OwnerLabelKey])
+	assert.Equal(t, "true", dto.ProviderSpecific[0].Value)
+}
+
+func TestEndpointDTONil(t *testing.T) {
+	var ep *Endpoint
+	assert.Nil(t, ep.ToDTO())
+	assert.Nil(t, FromDTO(nil))
+}
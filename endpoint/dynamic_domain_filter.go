@@ -0,0 +1,119 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package endpoint
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"sync/atomic"
+)
+
+// Keys read from the data of a ConfigMap describing a DomainFilter.
+// They mirror the JSON representation of DomainFilter.
+const (
+	DomainFilterIncludeKey      = "include"
+	DomainFilterExcludeKey      = "exclude"
+	DomainFilterRegexIncludeKey = "regexInclude"
+	DomainFilterRegexExcludeKey = "regexExclude"
+)
+
+// DynamicDomainFilter is a DomainFilterInterface whose underlying DomainFilter can be
+// replaced at runtime, e.g. by a controller watching a ConfigMap. Swaps are atomic, so
+// concurrent Match callers always see either the previous or the new filter in full.
+type DynamicDomainFilter struct {
+	current atomic.Pointer[DomainFilter]
+}
+
+var _ DomainFilterInterface = &DynamicDomainFilter{}
+
+// NewDynamicDomainFilter returns a DynamicDomainFilter starting out with the given filter.
+// A nil filter matches everything.
+func NewDynamicDomainFilter(initial *DomainFilter) *DynamicDomainFilter {
+	f := &DynamicDomainFilter{}
+	f.current.Store(initial)
+	return f
+}
+
+// Match checks whether a domain matches the currently active DomainFilter.
+func (f *DynamicDomainFilter) Match(domain string) bool {
+	return f.current.Load().Match(domain)
+}
+
+// Load returns the currently active DomainFilter.
+func (f *DynamicDomainFilter) Load() *DomainFilter {
+	return f.current.Load()
+}
+
+// Store atomically replaces the active DomainFilter.
+func (f *DynamicDomainFilter) Store(filter *DomainFilter) {
+	f.current.Store(filter)
+}
+
+// UpdateFromConfigMapData builds a DomainFilter from the data of a ConfigMap and swaps it in.
+// If the data is invalid, the error is returned and the active filter is left unchanged.
+func (f *DynamicDomainFilter) UpdateFromConfigMapData(data map[string]string) error {
+	filter, err := NewDomainFilterFromConfigMapData(data)
+	if err != nil {
+		return err
+	}
+	f.Store(filter)
+	return nil
+}
+
+// NewDomainFilterFromConfigMapData returns a new DomainFilter built from the data of a ConfigMap.
+// The "include" and "exclude" keys hold lists of domains separated by commas or newlines,
+// "regexInclude" and "regexExclude" hold regular expressions. As with the command line flags,
+// the regular expressions take precedence over the domain lists when set.
+func NewDomainFilterFromConfigMapData(data map[string]string) (*DomainFilter, error) {
+	regexInclude, err := compileConfigMapRegex(data, DomainFilterRegexIncludeKey)
+	if err != nil {
+		return nil, err
+	}
+	regexExclude, err := compileConfigMapRegex(data, DomainFilterRegexExcludeKey)
+	if err != nil {
+		return nil, err
+	}
+	if regexInclude != nil || regexExclude != nil {
+		return NewRegexDomainFilter(regexInclude, regexExclude), nil
+	}
+	return NewDomainFilterWithExclusions(
+		splitConfigMapList(data[DomainFilterIncludeKey]),
+		splitConfigMapList(data[DomainFilterExcludeKey]),
+	), nil
+}
+
+// compileConfigMapRegex compiles the regular expression stored under key, if any.
+func compileConfigMapRegex(data map[string]string, key string) (*regexp.Regexp, error) {
+	expr := strings.TrimSpace(data[key])
+	if expr == "" {
+		return nil, nil
+	}
+	regex, err := regexp.Compile(expr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %q: %w", key, err)
+	}
+	return regex, nil
+}
+
+// splitConfigMapList splits a ConfigMap value on commas and newlines.
+// Empty entries are dropped by prepareFilters.
+func splitConfigMapList(value string) []string {
+	return strings.FieldsFunc(value, func(r rune) bool {
+		return r == ',' || r == '\n'
+	})
+}
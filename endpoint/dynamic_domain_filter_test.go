@@ -0,0 +1,131 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package endpoint
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewDomainFilterFromConfigMapData(t *testing.T) {
+	for _, tt := range []struct {
+		name      string
+		data      map[string]string
+		matches   []string
+		noMatches []string
+		wantErr   bool
+	}{
+		{
+			name:    "empty data matches everything",
+			data:    map[string]string{},
+			matches: []string{"example.org", "foo.example.com"},
+		},
+		{
+			name:      "comma and newline separated lists",
+			data:      map[string]string{"include": "example.org,\nexample.com\n", "exclude": " internal.example.org\n*-private.example.com"},
+			matches:   []string{"foo.example.org", "foo.example.com"},
+			noMatches: []string{"foo.internal.example.org", "db-private.example.com", "example.net"},
+		},
+		{
+			name:      "regex takes precedence over lists",
+			data:      map[string]string{"include": "example.com", "regexInclude": `\.example\.org$`},
+			matches:   []string{"foo.example.org"},
+			noMatches: []string{"foo.example.com"},
+		},
+		{
+			name:      "regex exclusion",
+			data:      map[string]string{"include": "example.com", "regexExclude": `^internal\.`},
+			matches:   []string{"foo.example.org"},
+			noMatches: []string{"internal.example.com"},
+		},
+		{
+			name:    "invalid regex",
+			data:    map[string]string{"regexInclude": "("},
+			wantErr: true,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			filter, err := NewDomainFilterFromConfigMapData(tt.data)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			for _, domain := range tt.matches {
+				assert.True(t, filter.Match(domain), domain)
+			}
+			for _, domain := range tt.noMatches {
+				assert.False(t, filter.Match(domain), domain)
+			}
+		})
+	}
+}
+
+func TestDynamicDomainFilterConfigMapUpdate(t *testing.T) {
+	filter := NewDynamicDomainFilter(nil)
+	assert.True(t, filter.Match("foo.example.com"))
+
+	require.NoError(t, filter.UpdateFromConfigMapData(map[string]string{"include": "example.org"}))
+	assert.True(t, filter.Match("foo.example.org"))
+	assert.False(t, filter.Match("foo.example.com"))
+
+	// an updated ConfigMap replaces the previous filter entirely
+	require.NoError(t, filter.UpdateFromConfigMapData(map[string]string{"include": "example.com", "exclude": "internal.example.com"}))
+	assert.False(t, filter.Match("foo.example.org"))
+	assert.True(t, filter.Match("foo.example.com"))
+	assert.False(t, filter.Match("foo.internal.example.com"))
+
+	// an invalid update keeps the active filter
+	require.Error(t, filter.UpdateFromConfigMapData(map[string]string{"regexInclude": "("}))
+	assert.True(t, filter.Match("foo.example.com"))
+	assert.Equal(t, []string{"example.com"}, filter.Load().Filters)
+}
+
+func TestDynamicDomainFilterConcurrentUpdates(t *testing.T) {
+	orgOnly := map[string]string{"include": "example.org"}
+	comOnly := map[string]string{"include": "example.com"}
+	filter := NewDynamicDomainFilter(NewDomainFilter([]string{"example.org"}))
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := range 1000 {
+			data := orgOnly
+			if i%2 == 0 {
+				data = comOnly
+			}
+			assert.NoError(t, filter.UpdateFromConfigMapData(data))
+		}
+	}()
+	for range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range 1000 {
+				// every published filter matches exactly one of the two domains
+				current := filter.Load()
+				assert.NotEqual(t, current.Match("foo.example.org"), current.Match("foo.example.com"))
+				filter.Match("foo.example.org")
+			}
+		}()
+	}
+	wg.Wait()
+}
@@ -17,6 +17,7 @@ limitations under the License.
 package endpoint
 
 import (
+	"errors"
 	"fmt"
 	"net/netip"
 	"slices"
@@ -25,6 +26,8 @@ import (
 	"strings"
 
 	log "github.com/sirupsen/logrus"
+
+	"sigs.k8s.io/external-dns/internal/idna"
 )
 
 const (
@@ -46,6 +49,8 @@ const (
 	RecordTypeMX = "MX"
 	// RecordTypeNAPTR is a RecordType enum value
 	RecordTypeNAPTR = "NAPTR"
+	// RecordTypeCAA is a RecordType enum value
+	RecordTypeCAA = "CAA"
 )
 
 var (
@@ -72,6 +77,14 @@ func (ttl TTL) IsConfigured() bool {
 // Targets is a representation of a list of targets for an endpoint.
 type Targets []string
 
+// CAATarget represents a single CAA (Certification Authority Authorization) record target,
+// made of its flags, property tag and property value.
+type CAATarget struct {
+	flags uint8
+	tag   string
+	value string
+}
+
 // MXTarget represents a single MX (Mail Exchange) record target, including its priority and host.
 type MXTarget struct {
 	priority uint16
@@ -93,18 +106,10 @@ func (t Targets) Len() int {
 	return len(t)
 }
 
+// Less reports whether the target at index i sorts before the one at index j, see compareTargets.
+// It makes sort.Sort the canonical, deterministic ordering of targets.
 func (t Targets) Less(i, j int) bool {
-	ipi, err := netip.ParseAddr(t[i])
-	if err != nil {
-		return t[i] < t[j]
-	}
-
-	ipj, err := netip.ParseAddr(t[j])
-	if err != nil {
-		return t[i] < t[j]
-	}
-
-	return ipi.String() < ipj.String()
+	return compareTargets(t[i], t[j]) < 0
 }
 
 func (t Targets) Swap(i, j int) {
@@ -205,6 +210,42 @@ func (t Targets) IsLess(o Targets) bool {
 	return false
 }
 
+// SortStable sorts the targets in the same order as sort.Sort, see compareTargets.
+func (t Targets) SortStable() {
+	slices.SortStableFunc(t, compareTargets)
+}
+
+// compareTargets defines the canonical ordering of targets: IPv4 addresses first, then IPv6 addresses,
+// then any other target (e.g. hostnames). IP addresses are ordered numerically and other targets
+// lexicographically. Different spellings of the same IP address are ordered lexicographically,
+// so that distinct targets never compare equal and the ordering does not depend on the input order.
+func compareTargets(a, b string) int {
+	rankA, ipA := targetRank(a)
+	rankB, ipB := targetRank(b)
+	if rankA != rankB {
+		return rankA - rankB
+	}
+	if rankA < 2 {
+		if c := ipA.Compare(ipB); c != 0 {
+			return c
+		}
+	}
+	return strings.Compare(a, b)
+}
+
+// targetRank returns the rank of a target in the canonical ordering, along with its parsed IP address.
+func targetRank(target string) (int, netip.Addr) {
+	ip, err := netip.ParseAddr(target)
+	switch {
+	case err != nil:
+		return 2, ip
+	case ip.Is4():
+		return 0, ip
+	default:
+		return 1, ip
+	}
+}
+
 // ProviderSpecificProperty holds the name and value of a configuration which is specific to individual DNS providers
 type ProviderSpecificProperty struct {
 	Name  string `json:"name,omitempty"`
@@ -404,6 +445,183 @@ func RemoveDuplicates(endpoints []*Endpoint) []*Endpoint {
 	return result
 }
 
+// CanonicalizeNames returns the endpoints with trailing dots stripped from their DNS names.
+// Endpoints that collide once stripped are merged into the first of them, which gains
+// any targets it was missing. The given endpoints are left untouched.
+func CanonicalizeNames(eps []*Endpoint) []*Endpoint {
+	result := make([]*Endpoint, 0, len(eps))
+	index := make(map[EndpointKey]int, len(eps))
+
+	for _, ep := range eps {
+		key := ep.Key()
+		key.DNSName = strings.TrimSuffix(ep.DNSName, ".")
+
+		i, found := index[key]
+		if !found {
+			if key.DNSName != ep.DNSName {
+				ep = ep.DeepCopy()
+				ep.DNSName = key.DNSName
+			}
+			index[key] = len(result)
+			result = append(result, ep)
+			continue
+		}
+
+		log.Debugf("Merging duplicated endpoint %v into %v", ep, result[i])
+		merged := result[i].DeepCopy()
+		for _, target := range ep.Targets {
+			if !slices.Contains(merged.Targets, target) {
+				merged.Targets = append(merged.Targets, target)
+			}
+		}
+		result[i] = merged
+	}
+
+	return result
+}
+
+// MergeByNameType returns the endpoints with those sharing a DNS name, record type and set identifier
+// merged into the first of them. The merged endpoint holds the targets of all of them, without duplicates,
+// and the highest TTL configured among them. Its labels and provider-specific properties are the ones
+// of the first endpoint. Endpoints are returned in the order of their first occurrence and the given
+// endpoints are left untouched.
+func MergeByNameType(eps []*Endpoint) []*Endpoint {
+	result := make([]*Endpoint, 0, len(eps))
+	index := make(map[EndpointKey]int, len(eps))
+
+	for _, ep := range eps {
+		key := ep.Key()
+		i, found := index[key]
+		if !found {
+			index[key] = len(result)
+			merged := ep.DeepCopy()
+			if ep.Targets != nil {
+				merged.Targets = mergeTargets(make(Targets, 0, len(ep.Targets)), ep.Targets)
+			}
+			result = append(result, merged)
+			continue
+		}
+
+		log.Debugf("Merging duplicated endpoint %v into %v", ep, result[i])
+		result[i].Targets = mergeTargets(result[i].Targets, ep.Targets)
+		result[i].RecordTTL = max(result[i].RecordTTL, ep.RecordTTL)
+	}
+
+	return result
+}
+
+// mergeTargets appends to targets the given ones it does not hold yet, see sameTarget.
+func mergeTargets(targets, others Targets) Targets {
+	for _, target := range others {
+		if !slices.ContainsFunc(targets, func(t string) bool { return sameTarget(t, target) }) {
+			targets = append(targets, target)
+		}
+	}
+	return targets
+}
+
+// ComputeTargetDeltas returns the single-target endpoints to create and delete in order to turn
+// current into desired, for providers storing one record per target. Targets present in both are
+// left alone, so other attributes such as the TTL have to be compared by the caller.
+// Either endpoint may be nil.
+func ComputeTargetDeltas(current, desired *Endpoint) (creates, deletes []*Endpoint) {
+	var oldTargets, newTargets Targets
+	if current != nil {
+		oldTargets = current.Targets
+	}
+	if desired != nil {
+		newTargets = desired.Targets
+	}
+
+	for _, target := range newTargets {
+		if !slices.ContainsFunc(oldTargets, func(t string) bool { return sameTarget(t, target) }) {
+			creates = append(creates, withSingleTarget(desired, target))
+		}
+	}
+	for _, target := range oldTargets {
+		if !slices.ContainsFunc(newTargets, func(t string) bool { return sameTarget(t, target) }) {
+			deletes = append(deletes, withSingleTarget(current, target))
+		}
+	}
+	return creates, deletes
+}
+
+// sameTarget compares two targets case-insensitively, parsing IP addresses since IPv6 can be shortened.
+func sameTarget(a, b string) bool {
+	if strings.EqualFold(a, b) {
+		return true
+	}
+	ipA, errA := netip.ParseAddr(a)
+	ipB, errB := netip.ParseAddr(b)
+	return errA == nil && errB == nil && ipA == ipB
+}
+
+// withSingleTarget returns a copy of ep with target as its only target.
+func withSingleTarget(ep *Endpoint, target string) *Endpoint {
+	single := ep.DeepCopy()
+	single.Targets = Targets{target}
+	return single
+}
+
+// relativeName returns dnsName relative to zone, or false if dnsName is not within zone.
+// The relative name of the zone apex is empty. Names are compared case-insensitively
+// and trailing dots are ignored.
+func relativeName(dnsName, zone string) (string, bool) {
+	name := strings.ToLower(strings.TrimSuffix(dnsName, "."))
+	zone = strings.ToLower(strings.TrimSuffix(zone, "."))
+	if name == zone {
+		return "", true
+	}
+	if rel, ok := strings.CutSuffix(name, "."+zone); ok {
+		return rel, true
+	}
+	return "", false
+}
+
+// ClassifyApex partitions endpoints into those sitting at the apex of one of the given zones
+// and all others. Endpoints outside of every zone are returned with the subdomains.
+func ClassifyApex(eps []*Endpoint, zones []string) (apex, sub []*Endpoint) {
+	for _, ep := range eps {
+		isApex := slices.ContainsFunc(zones, func(zone string) bool {
+			rel, ok := relativeName(ep.DNSName, zone)
+			return ok && rel == ""
+		})
+		if isApex {
+			apex = append(apex, ep)
+		} else {
+			sub = append(sub, ep)
+		}
+	}
+	return apex, sub
+}
+
+// CapEndpoints limits eps to at most limit endpoints as a safety valve against runaway sources.
+// When exceeded, the endpoints are sorted by DNS name, record type, set identifier and targets,
+// so the same endpoints are kept on every run, and true is returned. A limit of zero or less disables the cap.
+// The given slice is left untouched.
+func CapEndpoints(eps []*Endpoint, limit int) ([]*Endpoint, bool) {
+	if limit <= 0 || len(eps) <= limit {
+		return eps, false
+	}
+
+	log.Warnf("Got %d endpoints, which exceeds the limit of %d; dropping %d endpoints", len(eps), limit, len(eps)-limit)
+
+	sorted := slices.Clone(eps)
+	slices.SortStableFunc(sorted, func(a, b *Endpoint) int {
+		if c := strings.Compare(a.DNSName, b.DNSName); c != 0 {
+			return c
+		}
+		if c := strings.Compare(a.RecordType, b.RecordType); c != 0 {
+			return c
+		}
+		if c := strings.Compare(a.SetIdentifier, b.SetIdentifier); c != 0 {
+			return c
+		}
+		return strings.Compare(a.Targets.String(), b.Targets.String())
+	})
+	return sorted[:limit], true
+}
+
 // CheckEndpoint Check if endpoint is properly formatted according to RFC standards
 func (e *Endpoint) CheckEndpoint() bool {
 	switch recordType := e.RecordType; recordType {
@@ -411,6 +629,8 @@ func (e *Endpoint) CheckEndpoint() bool {
 		return e.Targets.ValidateMXRecord()
 	case RecordTypeSRV:
 		return e.Targets.ValidateSRVRecord()
+	case RecordTypeCAA:
+		return e.Targets.ValidateCAARecord()
 	}
 	return true
 }
@@ -444,6 +664,70 @@ func (m *MXTarget) GetHost() *string {
 	return &m.host
 }
 
+// NewCAARecord parses a string representation of a CAA record target (e.g., `0 issue "letsencrypt.org"`)
+// and returns a CAATarget struct. The value may be quoted or not. Returns an error if the input is invalid.
+func NewCAARecord(target string) (*CAATarget, error) {
+	parts := strings.SplitN(strings.TrimSpace(target), " ", 3)
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("invalid CAA record target: %s. CAA records must have flags, a tag and a value, e.g. '0 issue \"letsencrypt.org\"'", target)
+	}
+
+	flags, err := strconv.ParseUint(parts[0], 10, 8)
+	if err != nil {
+		return nil, fmt.Errorf("invalid integer value in target: %s", target)
+	}
+	tag := parts[1]
+	if tag == "" || strings.IndexFunc(tag, func(r rune) bool {
+		return (r < 'a' || r > 'z') && (r < 'A' || r > 'Z') && (r < '0' || r > '9')
+	}) >= 0 {
+		return nil, fmt.Errorf("invalid CAA record tag in target: %s. Tags are made of letters and digits", target)
+	}
+	value := strings.TrimSpace(parts[2])
+	if unquoted, ok := strings.CutPrefix(value, "\""); ok {
+		if value, ok = strings.CutSuffix(unquoted, "\""); !ok {
+			return nil, fmt.Errorf("invalid CAA record value in target: %s. The value is missing its closing quote", target)
+		}
+	}
+
+	return &CAATarget{
+		flags: uint8(flags),
+		tag:   strings.ToLower(tag),
+		value: value,
+	}, nil
+}
+
+// GetFlags returns the flags of the CAA record target.
+func (c *CAATarget) GetFlags() uint8 {
+	return c.flags
+}
+
+// GetTag returns the property tag of the CAA record target, in lower case.
+func (c *CAATarget) GetTag() string {
+	return c.tag
+}
+
+// GetValue returns the property value of the CAA record target, without quotes.
+func (c *CAATarget) GetValue() string {
+	return c.value
+}
+
+// String returns the CAA record target in its canonical form, with a quoted value.
+func (c *CAATarget) String() string {
+	return fmt.Sprintf("%d %s \"%s\"", c.flags, c.tag, c.value)
+}
+
+// ValidateCAARecord checks that every target is a valid CAA record target, see NewCAARecord.
+func (t Targets) ValidateCAARecord() bool {
+	for _, target := range t {
+		if _, err := NewCAARecord(target); err != nil {
+			log.Debugf("Invalid CAA record target: %s. %v", target, err)
+			return false
+		}
+	}
+
+	return true
+}
+
 func (t Targets) ValidateMXRecord() bool {
 	for _, target := range t {
 		_, err := NewMXRecord(target)
@@ -476,3 +760,117 @@ func (t Targets) ValidateSRVRecord() bool {
 	}
 	return true
 }
+
+// ValidateTargetTypes checks the targets of each endpoint against its record type, see Targets.Validate.
+// It returns one error per offending endpoint, or nil if every endpoint is valid.
+func ValidateTargetTypes(eps []*Endpoint) []error {
+	var errs []error
+	for _, ep := range eps {
+		if ep == nil {
+			continue
+		}
+		if err := ep.Targets.Validate(ep.RecordType); err != nil {
+			errs = append(errs, fmt.Errorf("endpoint %s %s: %w", ep.DNSName, ep.RecordType, err))
+		}
+	}
+	return errs
+}
+
+// Validate checks that the targets have the shape required by the given record type, so that
+// providers can reject malformed records before issuing API writes:
+// A targets must be IPv4 addresses, AAAA targets IPv6 addresses, a CNAME must have a single hostname target,
+// MX targets must be "<preference> <host>", SRV targets "<priority> <weight> <port> <host>"
+// and CAA targets "<flags> <tag> <value>".
+// Other record types are not checked.
+func (t Targets) Validate(recordType string) error {
+	if recordType == RecordTypeCNAME && len(t) != 1 {
+		return fmt.Errorf("CNAME record must have exactly one target, got %d", len(t))
+	}
+	for _, target := range t {
+		if err := validateTarget(recordType, target); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validateTarget checks a single target against the given record type, see Targets.Validate.
+func validateTarget(recordType, target string) error {
+	switch recordType {
+	case RecordTypeA:
+		if addr, err := netip.ParseAddr(target); err != nil || !addr.Is4() {
+			return fmt.Errorf("target %q is not a valid IPv4 address", target)
+		}
+	case RecordTypeAAAA:
+		if addr, err := netip.ParseAddr(target); err != nil || !addr.Is6() {
+			return fmt.Errorf("target %q is not a valid IPv6 address", target)
+		}
+	case RecordTypeCNAME:
+		return validateTargetHostname(target, target)
+	case RecordTypeMX:
+		mx, err := NewMXRecord(target)
+		if err != nil {
+			return err
+		}
+		return validateTargetHostname(target, mx.host)
+	case RecordTypeSRV:
+		parts := strings.Fields(target)
+		if len(parts) != 4 {
+			return fmt.Errorf("invalid SRV record target: %s. SRV records must have a priority, weight, and port value, e.g. '10 5 5060 example.com'", target)
+		}
+		for _, part := range parts[:3] {
+			if _, err := strconv.ParseUint(part, 10, 16); err != nil {
+				return fmt.Errorf("invalid integer value in target: %s", target)
+			}
+		}
+		// A target of "." means that the service is not available at this domain (RFC 2782).
+		if parts[3] == "." {
+			return nil
+		}
+		return validateTargetHostname(target, parts[3])
+	case RecordTypeCAA:
+		_, err := NewCAARecord(target)
+		return err
+	}
+	return nil
+}
+
+// validateTargetHostname checks the hostname part of a target, see validateHostname.
+func validateTargetHostname(target, hostname string) error {
+	if err := validateHostname(hostname); err != nil {
+		return fmt.Errorf("target %q: %w", target, err)
+	}
+	return nil
+}
+
+// validateHostname checks that name is a hostname made of labels of 1 to 63 letters, digits,
+// hyphens or underscores, and at most 253 characters long. Internationalized names are checked
+// in their ASCII form, which also rejects labels starting or ending with a hyphen.
+// The returned errors do not repeat the name, so that callers can add it for context.
+func validateHostname(name string) error {
+	name = strings.TrimSuffix(name, ".")
+	if name == "" {
+		return errors.New("name is empty")
+	}
+	if _, err := netip.ParseAddr(name); err == nil {
+		return errors.New("an IP address is not a hostname")
+	}
+	name, err := idna.Profile.ToASCII(name)
+	if err != nil {
+		return fmt.Errorf("not a valid hostname: %w", err)
+	}
+	if len(name) > 253 {
+		return errors.New("longer than 253 characters")
+	}
+	for label := range strings.SplitSeq(name, ".") {
+		if len(label) == 0 || len(label) > 63 {
+			return fmt.Errorf("label %q has an invalid length", label)
+		}
+		for _, c := range label {
+			if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '-' || c == '_') {
+				return fmt.Errorf("invalid character %q", c)
+			}
+		}
+	}
+	return nil
+}
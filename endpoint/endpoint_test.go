@@ -19,9 +19,13 @@ package endpoint
 import (
 	"fmt"
 	"reflect"
+	"slices"
+	"sort"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestNewEndpoint(t *testing.T) {
@@ -189,6 +193,73 @@ func TestIsLess(t *testing.T) {
 	}
 }
 
+func TestTargetsSortStable(t *testing.T) {
+	tests := []struct {
+		name     string
+		targets  Targets
+		expected Targets
+	}{
+		{
+			name:     "empty",
+			targets:  Targets{},
+			expected: Targets{},
+		},
+		{
+			name:     "IPv4 sorted numerically",
+			targets:  Targets{"10.0.0.10", "10.0.0.9", "1.2.3.4"},
+			expected: Targets{"1.2.3.4", "10.0.0.9", "10.0.0.10"},
+		},
+		{
+			name:     "IPv6 sorted numerically",
+			targets:  Targets{"2001:db8::10", "::1", "2001:db8::9"},
+			expected: Targets{"::1", "2001:db8::9", "2001:db8::10"},
+		},
+		{
+			name:     "mixed targets",
+			targets:  Targets{"b.example.com", "2001:db8::1", "10.0.0.2", "a.example.com", "::1", "10.0.0.1"},
+			expected: Targets{"10.0.0.1", "10.0.0.2", "::1", "2001:db8::1", "a.example.com", "b.example.com"},
+		},
+		{
+			name:     "hostnames that look like IPs sort after IPs",
+			targets:  Targets{"1-2-3-4.example.com", "1.2.3.4"},
+			expected: Targets{"1.2.3.4", "1-2-3-4.example.com"},
+		},
+		{
+			name:     "IPv4-mapped IPv6 addresses sort with IPv6",
+			targets:  Targets{"::ffff:10.0.0.1", "example.com", "10.0.0.2", "::1"},
+			expected: Targets{"10.0.0.2", "::1", "::ffff:10.0.0.1", "example.com"},
+		},
+		{
+			name:     "spellings of the same IPv6 address sorted lexically",
+			targets:  Targets{"2001:db8::1", "2001:DB8::1", "2001:0db8::1", "2001:db8::"},
+			expected: Targets{"2001:db8::", "2001:0db8::1", "2001:DB8::1", "2001:db8::1"},
+		},
+		{
+			name:     "hostnames sorted lexically",
+			targets:  Targets{"b.example.com", "B.example.com", "a.example.com."},
+			expected: Targets{"B.example.com", "a.example.com.", "b.example.com"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			unstable := slices.Clone(tt.targets)
+			tt.targets.SortStable()
+			assert.Equal(t, tt.expected, tt.targets)
+
+			// sort.Sort, as used by sources, yields the same canonical ordering
+			sort.Sort(unstable)
+			assert.Equal(t, tt.expected, unstable)
+
+			// Sorting is deterministic regardless of the input order
+			reversed := slices.Clone(tt.expected)
+			slices.Reverse(reversed)
+			reversed.SortStable()
+			assert.Equal(t, tt.expected, reversed)
+		})
+	}
+}
+
 func TestGetProviderSpecificProperty(t *testing.T) {
 	e := &Endpoint{
 		ProviderSpecific: []ProviderSpecificProperty{
@@ -721,6 +792,303 @@ func TestDuplicatedEndpointsWithOverlappingZones(t *testing.T) {
 	}
 }
 
+func TestCanonicalizeNames(t *testing.T) {
+	tests := []struct {
+		name string
+		eps  []*Endpoint
+		want []*Endpoint
+	}{
+		{
+			name: "nil",
+			eps:  nil,
+			want: []*Endpoint{},
+		},
+		{
+			name: "trailing dot is stripped",
+			eps:  []*Endpoint{{DNSName: "foo.com.", RecordType: RecordTypeA, Targets: Targets{"1.2.3.4"}}},
+			want: []*Endpoint{{DNSName: "foo.com", RecordType: RecordTypeA, Targets: Targets{"1.2.3.4"}}},
+		},
+		{
+			name: "names with and without trailing dot collapse",
+			eps: []*Endpoint{
+				{DNSName: "foo.com", RecordType: RecordTypeA, Targets: Targets{"1.2.3.4"}},
+				{DNSName: "foo.com.", RecordType: RecordTypeA, Targets: Targets{"1.2.3.4"}},
+			},
+			want: []*Endpoint{{DNSName: "foo.com", RecordType: RecordTypeA, Targets: Targets{"1.2.3.4"}}},
+		},
+		{
+			name: "targets of collapsed endpoints are merged",
+			eps: []*Endpoint{
+				{DNSName: "foo.com.", RecordType: RecordTypeA, Targets: Targets{"1.2.3.4"}},
+				{DNSName: "foo.com", RecordType: RecordTypeA, Targets: Targets{"1.2.3.4", "5.6.7.8"}},
+			},
+			want: []*Endpoint{{DNSName: "foo.com", RecordType: RecordTypeA, Targets: Targets{"1.2.3.4", "5.6.7.8"}}},
+		},
+		{
+			name: "different record types and set identifiers are kept",
+			eps: []*Endpoint{
+				{DNSName: "foo.com.", RecordType: RecordTypeA, Targets: Targets{"1.2.3.4"}},
+				{DNSName: "foo.com", RecordType: RecordTypeAAAA, Targets: Targets{"2001:db8::1"}},
+				{DNSName: "foo.com", RecordType: RecordTypeA, Targets: Targets{"1.2.3.4"}, SetIdentifier: "west"},
+			},
+			want: []*Endpoint{
+				{DNSName: "foo.com", RecordType: RecordTypeA, Targets: Targets{"1.2.3.4"}},
+				{DNSName: "foo.com", RecordType: RecordTypeAAAA, Targets: Targets{"2001:db8::1"}},
+				{DNSName: "foo.com", RecordType: RecordTypeA, Targets: Targets{"1.2.3.4"}, SetIdentifier: "west"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := CanonicalizeNames(tt.eps); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("CanonicalizeNames() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCanonicalizeNamesDoesNotModifyInput(t *testing.T) {
+	first := &Endpoint{DNSName: "foo.com", RecordType: RecordTypeA, Targets: Targets{"1.2.3.4"}}
+	second := &Endpoint{DNSName: "foo.com.", RecordType: RecordTypeA, Targets: Targets{"5.6.7.8"}}
+
+	CanonicalizeNames([]*Endpoint{first, second})
+
+	if first.DNSName != "foo.com" || !first.Targets.Same(Targets{"1.2.3.4"}) {
+		t.Errorf("first endpoint was modified: %v", first)
+	}
+	if second.DNSName != "foo.com." {
+		t.Errorf("second endpoint was modified: %v", second)
+	}
+}
+
+func TestRelativeName(t *testing.T) {
+	tests := []struct {
+		dnsName  string
+		zone     string
+		expected string
+		ok       bool
+	}{
+		{"example.com", "example.com", "", true},
+		{"example.com.", "Example.com", "", true},
+		{"www.example.com", "example.com.", "www", true},
+		{"a.b.example.com", "example.com", "a.b", true},
+		{"notexample.com", "example.com", "", false},
+		{"example.org", "example.com", "", false},
+	}
+	for _, tt := range tests {
+		rel, ok := relativeName(tt.dnsName, tt.zone)
+		if rel != tt.expected || ok != tt.ok {
+			t.Errorf("relativeName(%q, %q) = %q, %t, want %q, %t", tt.dnsName, tt.zone, rel, ok, tt.expected, tt.ok)
+		}
+	}
+}
+
+func TestClassifyApex(t *testing.T) {
+	zones := []string{"example.com", "example.org.", "sub.example.org"}
+	eps := []*Endpoint{
+		NewEndpoint("example.com", RecordTypeA, "1.2.3.4"),
+		NewEndpoint("www.example.com", RecordTypeCNAME, "example.com"),
+		NewEndpoint("example.org", RecordTypeA, "1.2.3.4"),
+		NewEndpoint("sub.example.org", RecordTypeA, "1.2.3.4"),
+		NewEndpoint("api.sub.example.org", RecordTypeA, "1.2.3.4"),
+		NewEndpoint("example.net", RecordTypeA, "1.2.3.4"),
+	}
+
+	apex, sub := ClassifyApex(eps, zones)
+
+	assert.Equal(t, []*Endpoint{eps[0], eps[2], eps[3]}, apex)
+	assert.Equal(t, []*Endpoint{eps[1], eps[4], eps[5]}, sub)
+}
+
+func TestCapEndpoints(t *testing.T) {
+	eps := []*Endpoint{
+		NewEndpoint("c.example.org", RecordTypeA, "1.2.3.4"),
+		NewEndpoint("a.example.org", RecordTypeTXT, "\"heritage=external-dns\""),
+		NewEndpoint("b.example.org", RecordTypeA, "5.6.7.8").WithSetIdentifier("two"),
+		NewEndpoint("a.example.org", RecordTypeA, "1.2.3.4"),
+		NewEndpoint("b.example.org", RecordTypeA, "1.2.3.4").WithSetIdentifier("one"),
+	}
+
+	capped, exceeded := CapEndpoints(eps, 3)
+	assert.True(t, exceeded)
+	assert.Equal(t, []*Endpoint{eps[3], eps[1], eps[4]}, capped)
+
+	reversed := slices.Clone(eps)
+	slices.Reverse(reversed)
+	cappedReversed, exceeded := CapEndpoints(reversed, 3)
+	assert.True(t, exceeded)
+	assert.Equal(t, capped, cappedReversed, "truncation must not depend on the input order")
+	assert.Equal(t, "c.example.org", eps[0].DNSName, "input must not be reordered")
+
+	for _, limit := range []int{0, -1, len(eps), len(eps) + 1} {
+		uncapped, exceeded := CapEndpoints(eps, limit)
+		assert.False(t, exceeded, "limit %d", limit)
+		assert.Equal(t, eps, uncapped, "limit %d", limit)
+	}
+}
+
+func TestMergeByNameType(t *testing.T) {
+	for _, tt := range []struct {
+		name     string
+		input    []*Endpoint
+		expected []*Endpoint
+	}{
+		{
+			name:     "no endpoints",
+			input:    nil,
+			expected: []*Endpoint{},
+		},
+		{
+			name: "targets are merged",
+			input: []*Endpoint{
+				NewEndpoint("example.com", RecordTypeA, "1.1.1.1"),
+				NewEndpoint("example.com", RecordTypeA, "2.2.2.2", "1.1.1.1"),
+			},
+			expected: []*Endpoint{
+				NewEndpoint("example.com", RecordTypeA, "1.1.1.1", "2.2.2.2"),
+			},
+		},
+		{
+			name: "record types are kept apart",
+			input: []*Endpoint{
+				NewEndpoint("example.com", RecordTypeA, "1.1.1.1"),
+				NewEndpoint("example.com", RecordTypeAAAA, "2001:db8::1"),
+				NewEndpoint("example.com", RecordTypeA, "2.2.2.2"),
+			},
+			expected: []*Endpoint{
+				NewEndpoint("example.com", RecordTypeA, "1.1.1.1", "2.2.2.2"),
+				NewEndpoint("example.com", RecordTypeAAAA, "2001:db8::1"),
+			},
+		},
+		{
+			name: "set identifiers are kept apart",
+			input: []*Endpoint{
+				NewEndpoint("example.com", RecordTypeA, "1.1.1.1").WithSetIdentifier("eu"),
+				NewEndpoint("example.com", RecordTypeA, "2.2.2.2").WithSetIdentifier("us"),
+			},
+			expected: []*Endpoint{
+				NewEndpoint("example.com", RecordTypeA, "1.1.1.1").WithSetIdentifier("eu"),
+				NewEndpoint("example.com", RecordTypeA, "2.2.2.2").WithSetIdentifier("us"),
+			},
+		},
+		{
+			name: "duplicated targets are dropped",
+			input: []*Endpoint{
+				NewEndpoint("example.com", RecordTypeAAAA, "2001:db8::1", "2001:0db8:0:0:0:0:0:1"),
+				NewEndpoint("www.example.com", RecordTypeCNAME, "LB.example.com"),
+				NewEndpoint("www.example.com", RecordTypeCNAME, "lb.example.com"),
+			},
+			expected: []*Endpoint{
+				NewEndpoint("example.com", RecordTypeAAAA, "2001:db8::1"),
+				NewEndpoint("www.example.com", RecordTypeCNAME, "LB.example.com"),
+			},
+		},
+		{
+			name: "highest TTL is kept",
+			input: []*Endpoint{
+				NewEndpoint("example.com", RecordTypeA, "1.1.1.1"),
+				NewEndpointWithTTL("example.com", RecordTypeA, 600, "2.2.2.2"),
+				NewEndpointWithTTL("example.com", RecordTypeA, 300, "3.3.3.3"),
+			},
+			expected: []*Endpoint{
+				NewEndpointWithTTL("example.com", RecordTypeA, 600, "1.1.1.1", "2.2.2.2", "3.3.3.3"),
+			},
+		},
+		{
+			name: "labels and properties of the first endpoint are kept",
+			input: []*Endpoint{
+				NewEndpoint("example.com", RecordTypeA, "1.1.1.1").WithLabel(ResourceLabelKey, "service/default/a").WithProviderSpecific("alias", "false"),
+				NewEndpoint("example.com", RecordTypeA, "2.2.2.2").WithLabel(ResourceLabelKey, "service/default/b").WithProviderSpecific("alias", "true"),
+			},
+			expected: []*Endpoint{
+				NewEndpoint("example.com", RecordTypeA, "1.1.1.1", "2.2.2.2").WithLabel(ResourceLabelKey, "service/default/a").WithProviderSpecific("alias", "false"),
+			},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, MergeByNameType(tt.input))
+		})
+	}
+}
+
+func TestMergeByNameTypeDoesNotModifyInput(t *testing.T) {
+	first := NewEndpoint("example.com", RecordTypeA, "1.1.1.1")
+	second := NewEndpoint("example.com", RecordTypeA, "2.2.2.2")
+
+	merged := MergeByNameType([]*Endpoint{first, second})
+	merged[0].Targets[0] = "9.9.9.9"
+
+	assert.Equal(t, Targets{"1.1.1.1"}, first.Targets)
+	assert.Equal(t, Targets{"2.2.2.2"}, second.Targets)
+}
+
+func TestComputeTargetDeltas(t *testing.T) {
+	current := NewEndpointWithTTL("example.org", RecordTypeA, 300, "1.2.3.4", "5.6.7.8").WithSetIdentifier("one")
+	desired := NewEndpointWithTTL("example.org", RecordTypeA, 600, "1.2.3.4", "9.9.9.9").WithSetIdentifier("one")
+
+	creates, deletes := ComputeTargetDeltas(current, desired)
+
+	assert.Equal(t, []*Endpoint{NewEndpointWithTTL("example.org", RecordTypeA, 600, "9.9.9.9").WithSetIdentifier("one")}, creates)
+	assert.Equal(t, []*Endpoint{NewEndpointWithTTL("example.org", RecordTypeA, 300, "5.6.7.8").WithSetIdentifier("one")}, deletes)
+	assert.Equal(t, Targets{"1.2.3.4", "5.6.7.8"}, current.Targets, "current endpoint must not be modified")
+	assert.Equal(t, Targets{"1.2.3.4", "9.9.9.9"}, desired.Targets, "desired endpoint must not be modified")
+}
+
+func TestComputeTargetDeltasEdgeCases(t *testing.T) {
+	for _, tt := range []struct {
+		name            string
+		current         *Endpoint
+		desired         *Endpoint
+		expectedCreates []*Endpoint
+		expectedDeletes []*Endpoint
+	}{
+		{
+			name:    "unchanged targets",
+			current: NewEndpoint("example.org", RecordTypeA, "1.2.3.4", "5.6.7.8"),
+			desired: NewEndpoint("example.org", RecordTypeA, "5.6.7.8", "1.2.3.4"),
+		},
+		{
+			name:    "targets compared case-insensitively",
+			current: NewEndpoint("example.org", RecordTypeCNAME, "LB.example.com"),
+			desired: NewEndpoint("example.org", RecordTypeCNAME, "lb.example.com"),
+		},
+		{
+			name:    "IPv6 targets compared as addresses",
+			current: NewEndpoint("example.org", RecordTypeAAAA, "2001:db8:0:0:0:0:0:1"),
+			desired: NewEndpoint("example.org", RecordTypeAAAA, "2001:db8::1"),
+		},
+		{
+			name:    "new endpoint",
+			desired: NewEndpoint("example.org", RecordTypeA, "1.2.3.4", "5.6.7.8"),
+			expectedCreates: []*Endpoint{
+				NewEndpoint("example.org", RecordTypeA, "1.2.3.4"),
+				NewEndpoint("example.org", RecordTypeA, "5.6.7.8"),
+			},
+		},
+		{
+			name:    "removed endpoint",
+			current: NewEndpoint("example.org", RecordTypeA, "1.2.3.4", "5.6.7.8"),
+			expectedDeletes: []*Endpoint{
+				NewEndpoint("example.org", RecordTypeA, "1.2.3.4"),
+				NewEndpoint("example.org", RecordTypeA, "5.6.7.8"),
+			},
+		},
+		{
+			name:            "all targets replaced",
+			current:         NewEndpoint("example.org", RecordTypeA, "1.2.3.4"),
+			desired:         NewEndpoint("example.org", RecordTypeA, "5.6.7.8"),
+			expectedCreates: []*Endpoint{NewEndpoint("example.org", RecordTypeA, "5.6.7.8")},
+			expectedDeletes: []*Endpoint{NewEndpoint("example.org", RecordTypeA, "1.2.3.4")},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			creates, deletes := ComputeTargetDeltas(tt.current, tt.desired)
+			assert.Equal(t, tt.expectedCreates, creates)
+			assert.Equal(t, tt.expectedDeletes, deletes)
+		})
+	}
+}
+
 func TestPDNScheckEndpoint(t *testing.T) {
 	tests := []struct {
 		description string
@@ -865,6 +1233,76 @@ func TestNewMXTarget(t *testing.T) {
 	}
 }
 
+func TestNewCAATarget(t *testing.T) {
+	for _, tt := range []struct {
+		description string
+		target      string
+		expected    *CAATarget
+		expectError bool
+	}{
+		{
+			description: "Quoted value",
+			target:      `0 issue "letsencrypt.org"`,
+			expected:    &CAATarget{flags: 0, tag: "issue", value: "letsencrypt.org"},
+		},
+		{
+			description: "Unquoted value",
+			target:      "0 issuewild letsencrypt.org",
+			expected:    &CAATarget{flags: 0, tag: "issuewild", value: "letsencrypt.org"},
+		},
+		{
+			description: "Critical flag and upper case tag",
+			target:      `128 IODEF "mailto:security@example.com"`,
+			expected:    &CAATarget{flags: 128, tag: "iodef", value: "mailto:security@example.com"},
+		},
+		{
+			description: "Value with spaces",
+			target:      `0 issue "ca.example.net; account=230123"`,
+			expected:    &CAATarget{flags: 0, tag: "issue", value: "ca.example.net; account=230123"},
+		},
+		{
+			description: "Missing value",
+			target:      "0 issue",
+			expectError: true,
+		},
+		{
+			description: "Non-integer flags",
+			target:      `critical issue "letsencrypt.org"`,
+			expectError: true,
+		},
+		{
+			description: "Invalid tag",
+			target:      `0 is-sue "letsencrypt.org"`,
+			expectError: true,
+		},
+		{
+			description: "Unterminated quote",
+			target:      `0 issue "letsencrypt.org`,
+			expectError: true,
+		},
+	} {
+		t.Run(tt.description, func(t *testing.T) {
+			actual, err := NewCAARecord(tt.target)
+			if tt.expectError {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.expected, actual)
+			}
+		})
+	}
+}
+
+func TestCAATargetString(t *testing.T) {
+	caa, err := NewCAARecord("0 ISSUE letsencrypt.org")
+	require.NoError(t, err)
+	assert.Equal(t, `0 issue "letsencrypt.org"`, caa.String())
+
+	roundTrip, err := NewCAARecord(caa.String())
+	require.NoError(t, err)
+	assert.Equal(t, caa, roundTrip)
+}
+
 func TestCheckEndpoint(t *testing.T) {
 	tests := []struct {
 		description string
@@ -907,6 +1345,24 @@ func TestCheckEndpoint(t *testing.T) {
 			},
 			expected: false,
 		},
+		{
+			description: "Valid CAA record target",
+			endpoint: Endpoint{
+				DNSName:    "example.com",
+				RecordType: RecordTypeCAA,
+				Targets:    Targets{`0 issue "letsencrypt.org"`},
+			},
+			expected: true,
+		},
+		{
+			description: "Invalid CAA record target",
+			endpoint: Endpoint{
+				DNSName:    "example.com",
+				RecordType: RecordTypeCAA,
+				Targets:    Targets{"issue letsencrypt.org"},
+			},
+			expected: false,
+		},
 		{
 			description: "Non-MX/SRV record type",
 			endpoint: Endpoint{
@@ -968,3 +1424,159 @@ func TestEndpoint_UniqueOrderedTargets(t *testing.T) {
 		})
 	}
 }
+
+func TestEndpoint_DeepCopy(t *testing.T) {
+	original := NewEndpointWithTTL("www.example.com", RecordTypeA, 300, "1.2.3.4", "5.6.7.8").
+		WithSetIdentifier("eu-west").
+		WithLabel(OwnerLabelKey, "default").
+		WithProviderSpecific("alias", "false")
+	// spare capacity, which appending to a shallow copy would write into
+	original.Targets = slices.Grow(original.Targets, 1)
+	expected := NewEndpointWithTTL("www.example.com", RecordTypeA, 300, "1.2.3.4", "5.6.7.8").
+		WithSetIdentifier("eu-west").
+		WithLabel(OwnerLabelKey, "default").
+		WithProviderSpecific("alias", "false")
+
+	copied := original.DeepCopy()
+	assert.Equal(t, original, copied)
+
+	copied.DNSName = "other.example.com"
+	copied.RecordType = RecordTypeAAAA
+	copied.RecordTTL = 60
+	copied.SetIdentifier = "us-east"
+	copied.Targets[0] = "9.9.9.9"
+	copied.Targets = append(copied.Targets, "8.8.8.8")
+	copied.Labels[OwnerLabelKey] = "other"
+	copied.Labels[ResourceLabelKey] = "service/default/other"
+	copied.ProviderSpecific[0].Value = "true"
+	copied.ProviderSpecific = append(copied.ProviderSpecific, ProviderSpecificProperty{Name: "aws/weight", Value: "10"})
+
+	assert.Equal(t, expected, original)
+	assert.Empty(t, original.Targets[:3][2])
+
+	var nilEndpoint *Endpoint
+	assert.Nil(t, nilEndpoint.DeepCopy())
+}
+
+func TestValidateTargetTypes(t *testing.T) {
+	tests := []struct {
+		name      string
+		endpoints []*Endpoint
+		errCount  int
+	}{
+		{
+			name: "all valid",
+			endpoints: []*Endpoint{
+				NewEndpoint("a.example.com", RecordTypeA, "1.2.3.4", "5.6.7.8"),
+				NewEndpoint("aaaa.example.com", RecordTypeAAAA, "2001:db8::1"),
+				NewEndpoint("cname.example.com", RecordTypeCNAME, "target.example.com"),
+				NewEndpoint("txt.example.com", RecordTypeTXT, "some text"),
+			},
+			errCount: 0,
+		},
+		{
+			name: "IPv6 target on A record",
+			endpoints: []*Endpoint{
+				NewEndpoint("a.example.com", RecordTypeA, "2001:db8::1"),
+			},
+			errCount: 1,
+		},
+		{
+			name: "IPv4 target on AAAA record",
+			endpoints: []*Endpoint{
+				NewEndpoint("aaaa.example.com", RecordTypeAAAA, "1.2.3.4"),
+			},
+			errCount: 1,
+		},
+		{
+			name: "hostname target on A record",
+			endpoints: []*Endpoint{
+				NewEndpoint("a.example.com", RecordTypeA, "1.2.3.4", "target.example.com"),
+			},
+			errCount: 1,
+		},
+		{
+			name: "IP target on CNAME record",
+			endpoints: []*Endpoint{
+				NewEndpoint("cname.example.com", RecordTypeCNAME, "1.2.3.4"),
+			},
+			errCount: 1,
+		},
+		{
+			name: "invalid hostname target on CNAME record",
+			endpoints: []*Endpoint{
+				NewEndpoint("cname.example.com", RecordTypeCNAME, "lb!.example.com"),
+			},
+			errCount: 1,
+		},
+		{
+			name: "invalid MX record",
+			endpoints: []*Endpoint{
+				NewEndpoint("mx.example.com", RecordTypeMX, "mail.example.com"),
+			},
+			errCount: 1,
+		},
+		{
+			name: "one error per mismatched endpoint",
+			endpoints: []*Endpoint{
+				NewEndpoint("a.example.com", RecordTypeA, "foo.example.com", "bar.example.com"),
+				NewEndpoint("ok.example.com", RecordTypeA, "1.2.3.4"),
+				NewEndpoint("cname.example.com", RecordTypeCNAME, "::1"),
+			},
+			errCount: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			errs := ValidateTargetTypes(tt.endpoints)
+			assert.Len(t, errs, tt.errCount)
+		})
+	}
+}
+
+func TestTargetsValidate(t *testing.T) {
+	for _, tt := range []struct {
+		name       string
+		recordType string
+		targets    Targets
+		wantErr    string
+	}{
+		{name: "A with IPv4 addresses", recordType: RecordTypeA, targets: NewTargets("1.2.3.4", "5.6.7.8")},
+		{name: "A with IPv6 address", recordType: RecordTypeA, targets: NewTargets("1.2.3.4", "2001:db8::1"), wantErr: "not a valid IPv4 address"},
+		{name: "A with hostname", recordType: RecordTypeA, targets: NewTargets("example.com"), wantErr: "not a valid IPv4 address"},
+		{name: "AAAA with IPv6 address", recordType: RecordTypeAAAA, targets: NewTargets("2001:db8::1", "::ffff:1.2.3.4")},
+		{name: "AAAA with IPv4 address", recordType: RecordTypeAAAA, targets: NewTargets("1.2.3.4"), wantErr: "not a valid IPv6 address"},
+		{name: "CNAME with hostname", recordType: RecordTypeCNAME, targets: NewTargets("lb-1.example.com.")},
+		{name: "CNAME with internationalized hostname", recordType: RecordTypeCNAME, targets: NewTargets("bücher.example.com")},
+		{name: "CNAME with underscore label", recordType: RecordTypeCNAME, targets: NewTargets("_acme-challenge.example.com")},
+		{name: "CNAME with several targets", recordType: RecordTypeCNAME, targets: NewTargets("a.example.com", "b.example.com"), wantErr: "exactly one target"},
+		{name: "CNAME without target", recordType: RecordTypeCNAME, wantErr: "exactly one target"},
+		{name: "CNAME with IP address", recordType: RecordTypeCNAME, targets: NewTargets("1.2.3.4"), wantErr: "an IP address is not a hostname"},
+		{name: "CNAME with empty label", recordType: RecordTypeCNAME, targets: NewTargets("a..example.com"), wantErr: `target "a..example.com": label "" has an invalid length`},
+		{name: "CNAME with long label", recordType: RecordTypeCNAME, targets: NewTargets(strings.Repeat("a", 64) + ".example.com"), wantErr: "has an invalid length"},
+		{name: "CNAME with hyphen edge", recordType: RecordTypeCNAME, targets: NewTargets("-lb.example.com"), wantErr: "not a valid hostname"},
+		{name: "CNAME with invalid character", recordType: RecordTypeCNAME, targets: NewTargets("lb!.example.com"), wantErr: `target "lb!.example.com": invalid character '!'`},
+		{name: "MX with preference and host", recordType: RecordTypeMX, targets: NewTargets("10 mail.example.com", "20 backup.example.com.")},
+		{name: "MX without preference", recordType: RecordTypeMX, targets: NewTargets("mail.example.com"), wantErr: "invalid MX record target"},
+		{name: "MX with out of range preference", recordType: RecordTypeMX, targets: NewTargets("70000 mail.example.com"), wantErr: "invalid integer value"},
+		{name: "MX with IP address host", recordType: RecordTypeMX, targets: NewTargets("10 1.2.3.4"), wantErr: "an IP address is not a hostname"},
+		{name: "SRV with all fields", recordType: RecordTypeSRV, targets: NewTargets("10 5 5060 sip.example.com.")},
+		{name: "SRV with unavailable service", recordType: RecordTypeSRV, targets: NewTargets("0 0 0 .")},
+		{name: "SRV without port", recordType: RecordTypeSRV, targets: NewTargets("10 5 sip.example.com"), wantErr: "invalid SRV record target"},
+		{name: "SRV with invalid weight", recordType: RecordTypeSRV, targets: NewTargets("10 x 5060 sip.example.com"), wantErr: "invalid integer value"},
+		{name: "CAA with quoted value", recordType: RecordTypeCAA, targets: NewTargets(`0 issue "letsencrypt.org"`, `128 iodef "mailto:security@example.com"`)},
+		{name: "CAA without value", recordType: RecordTypeCAA, targets: NewTargets("0 issue"), wantErr: "invalid CAA record target"},
+		{name: "CAA with out of range flags", recordType: RecordTypeCAA, targets: NewTargets(`256 issue "letsencrypt.org"`), wantErr: "invalid integer value"},
+		{name: "TXT is not checked", recordType: RecordTypeTXT, targets: NewTargets("any text, really")},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.targets.Validate(tt.recordType)
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorContains(t, err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -104,6 +104,37 @@ func NewLabelsFromString(labelText string, aesKey []byte) (Labels, error) {
 	return NewLabelsFromStringPlain(labelText)
 }
 
+// IsOwnershipTXT reports whether an endpoint is a TXT ownership record, i.e. a TXT record
+// with a target holding the external-dns heritage. Encrypted targets are not recognized.
+func IsOwnershipTXT(e *Endpoint) bool {
+	_, ok := ownershipLabels(e)
+	return ok
+}
+
+// OwnerFromTXT returns the owner recorded by a TXT ownership record.
+// It returns false if the endpoint is not a TXT ownership record or does not record an owner.
+func OwnerFromTXT(e *Endpoint) (string, bool) {
+	labels, ok := ownershipLabels(e)
+	if !ok {
+		return "", false
+	}
+	owner := labels[OwnerLabelKey]
+	return owner, owner != ""
+}
+
+// ownershipLabels returns the labels of the first target of a TXT endpoint holding the external-dns heritage.
+func ownershipLabels(e *Endpoint) (Labels, bool) {
+	if e == nil || e.RecordType != RecordTypeTXT {
+		return nil, false
+	}
+	for _, target := range e.Targets {
+		if labels, err := NewLabelsFromStringPlain(strings.TrimSpace(target)); err == nil {
+			return labels, true
+		}
+	}
+	return nil, false
+}
+
 // SerializePlain transforms endpoints labels into a external-dns recognizable format string
 // withQuotes adds additional quotes
 func (l Labels) SerializePlain(withQuotes bool) string {
@@ -23,6 +23,7 @@ import (
 	"testing"
 
 	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/suite"
 )
 
@@ -181,3 +182,70 @@ func (suite *LabelsSuite) TestDeserialize() {
 func TestLabels(t *testing.T) {
 	suite.Run(t, new(LabelsSuite))
 }
+
+func TestOwnershipTXT(t *testing.T) {
+	for _, tt := range []struct {
+		name      string
+		endpoint  *Endpoint
+		ownership bool
+		owner     string
+		hasOwner  bool
+	}{
+		{
+			name:      "ownership record",
+			endpoint:  NewEndpoint("foo.example.org", RecordTypeTXT, "heritage=external-dns,external-dns/owner=default,external-dns/resource=service/default/foo"),
+			ownership: true,
+			owner:     "default",
+			hasOwner:  true,
+		},
+		{
+			name:      "quoted ownership record",
+			endpoint:  NewEndpoint("foo.example.org", RecordTypeTXT, ` "heritage=external-dns,external-dns/owner=cluster-a" `),
+			ownership: true,
+			owner:     "cluster-a",
+			hasOwner:  true,
+		},
+		{
+			name:      "ownership among other targets",
+			endpoint:  NewEndpoint("foo.example.org", RecordTypeTXT, "v=spf1 -all", "heritage=external-dns,external-dns/owner=default"),
+			ownership: true,
+			owner:     "default",
+			hasOwner:  true,
+		},
+		{
+			name:      "ownership record without owner",
+			endpoint:  NewEndpoint("foo.example.org", RecordTypeTXT, "heritage=external-dns,external-dns/resource=service/default/foo"),
+			ownership: true,
+		},
+		{
+			name:     "regular TXT record",
+			endpoint: NewEndpoint("foo.example.org", RecordTypeTXT, "v=spf1 include:example.com -all"),
+		},
+		{
+			name:     "other heritage",
+			endpoint: NewEndpoint("foo.example.org", RecordTypeTXT, "heritage=mate,external-dns/owner=default"),
+		},
+		{
+			name:     "malformed heritage",
+			endpoint: NewEndpoint("foo.example.org", RecordTypeTXT, "heritage:external-dns;external-dns/owner=default"),
+		},
+		{
+			name:     "not a TXT record",
+			endpoint: NewEndpoint("foo.example.org", RecordTypeCNAME, "heritage=external-dns,external-dns/owner=default"),
+		},
+		{
+			name:     "no targets",
+			endpoint: NewEndpoint("foo.example.org", RecordTypeTXT),
+		},
+		{
+			name: "nil endpoint",
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.ownership, IsOwnershipTXT(tt.endpoint))
+			owner, ok := OwnerFromTXT(tt.endpoint)
+			assert.Equal(t, tt.hasOwner, ok)
+			assert.Equal(t, tt.owner, owner)
+		})
+	}
+}
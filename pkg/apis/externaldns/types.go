@@ -49,6 +49,12 @@ type Config struct {
 	AnnotationFilter                              string
 	LabelFilter                                   string
 	IngressClassNames                             []string
+	IstioGatewayNamespaces                        []string
+	IstioGatewayVSHosts                           bool
+	IstioGatewayCredentialHosts                   bool
+	IstioGatewayUnmatched                         string
+	IstioGatewayResolveLBHostname                 bool
+	IstioGatewayHostConflict                      string
 	FQDNTemplate                                  string
 	CombineFQDNAndAnnotation                      bool
 	IgnoreHostnameAnnotation                      bool
@@ -139,6 +145,7 @@ type Config struct {
 	TLSClientCert                                 string
 	TLSClientCertKey                              string
 	Policy                                        string
+	MaxDeletionRatio                              float64
 	Registry                                      string
 	TXTOwnerID                                    string
 	TXTPrefix                                     string
@@ -148,6 +155,7 @@ type Config struct {
 	Interval                                      time.Duration
 	MinEventSyncInterval                          time.Duration
 	Once                                          bool
+	DeleteAllManaged                              bool
 	DryRun                                        bool
 	UpdateEvents                                  bool
 	LogFormat                                     string
@@ -202,6 +210,18 @@ type Config struct {
 	PiholePassword                                string `secure:"yes"`
 	PiholeTLSInsecureSkipVerify                   bool
 	PiholeApiVersion                              string
+	PiholeUserAgent                               string
+	PiholeExtraHeaders                            map[string]string `secure:"yes"`
+	PiholeOwnershipMode                           string
+	PiholeCNAMETargetConflict                     string
+	PiholeCACertFile                              string
+	PiholeRequestTimeout                          time.Duration
+	PiholeAuthPath                                string
+	PiholeBatchWrites                             bool
+	PiholeOrderCreates                            bool
+	PiholeStrictDecoding                          bool
+	PiholePreserveNameCase                        bool
+	PiholeRecordsCacheTTL                         time.Duration
 	PluralCluster                                 string
 	PluralProvider                                string
 	WebhookProviderURL                            string
@@ -296,11 +316,14 @@ var defaultConfig = &Config{
 	IngressClassNames:            nil,
 	InMemoryZones:                []string{},
 	Interval:                     time.Minute,
+	IstioGatewayHostConflict:     "ignore",
+	IstioGatewayUnmatched:        "skip",
 	KubeConfig:                   "",
 	LabelFilter:                  labels.Everything().String(),
 	LogFormat:                    "text",
 	LogLevel:                     logrus.InfoLevel.String(),
 	ManagedDNSRecordTypes:        []string{endpoint.RecordTypeA, endpoint.RecordTypeAAAA, endpoint.RecordTypeCNAME},
+	MaxDeletionRatio:             0,
 	MetricsAddress:               ":7979",
 	MinEventSyncInterval:         5 * time.Second,
 	Namespace:                    "",
@@ -311,6 +334,7 @@ var defaultConfig = &Config{
 	OCIZoneCacheDuration:         0 * time.Second,
 	OCIZoneScope:                 "GLOBAL",
 	Once:                         false,
+	DeleteAllManaged:             false,
 	OVHApiRateLimit:              20,
 	OVHEnableCNAMERelative:       false,
 	OVHEndpoint:                  "ovh-eu",
@@ -319,6 +343,17 @@ var defaultConfig = &Config{
 	PDNSServerID:                 "localhost",
 	PDNSSkipTLSVerify:            false,
 	PiholeApiVersion:             "5",
+	PiholeExtraHeaders:           map[string]string{},
+	PiholeOwnershipMode:          "none",
+	PiholeCNAMETargetConflict:    "ignore",
+	PiholeCACertFile:             "",
+	PiholeRequestTimeout:         30 * time.Second,
+	PiholeAuthPath:               "/api/auth",
+	PiholeBatchWrites:            false,
+	PiholeOrderCreates:           false,
+	PiholeStrictDecoding:         false,
+	PiholePreserveNameCase:       false,
+	PiholeRecordsCacheTTL:        0,
 	PiholePassword:               "",
 	PiholeServer:                 "",
 	PiholeTLSInsecureSkipVerify:  false,
@@ -381,7 +416,8 @@ var defaultConfig = &Config{
 // NewConfig returns new Config object
 func NewConfig() *Config {
 	return &Config{
-		AWSSDCreateTag: map[string]string{},
+		AWSSDCreateTag:     map[string]string{},
+		PiholeExtraHeaders: map[string]string{},
 	}
 }
 
@@ -393,12 +429,21 @@ func (cfg *Config) String() string {
 	for i := 0; i < t.NumField(); i++ {
 		f := t.Field(i)
 		if val, ok := f.Tag.Lookup("secure"); ok && val == "yes" {
-			if f.Type.Kind() != reflect.String {
-				continue
-			}
 			v := reflect.ValueOf(&temp).Elem().Field(i)
-			if v.String() != "" {
-				v.SetString(passwordMask)
+			switch {
+			case f.Type.Kind() == reflect.String:
+				if v.String() != "" {
+					v.SetString(passwordMask)
+				}
+			case f.Type.Kind() == reflect.Map && f.Type.Elem().Kind() == reflect.String:
+				// Mask the values only, on a copy of the map shared with cfg.
+				if v.Len() > 0 {
+					masked := reflect.MakeMapWithSize(f.Type, v.Len())
+					for _, key := range v.MapKeys() {
+						masked.SetMapIndex(key, reflect.ValueOf(passwordMask))
+					}
+					v.Set(masked)
+				}
 			}
 		}
 	}
@@ -473,7 +518,13 @@ func App(cfg *Config) *kingpin.Application {
 	app.Flag("ignore-ingress-tls-spec", "Ignore the spec.tls section in Ingress resources (default: false)").BoolVar(&cfg.IgnoreIngressTLSSpec)
 	app.Flag("ignore-non-host-network-pods", "Ignore pods not running on host network when using pod source (default: false)").BoolVar(&cfg.IgnoreNonHostNetworkPods)
 	app.Flag("ingress-class", "Require an Ingress to have this class name; specify multiple times to allow more than one class (optional; defaults to any class)").StringsVar(&cfg.IngressClassNames)
-	app.Flag("label-filter", "Filter resources queried for endpoints by label selector; currently supported by source types crd, gateway-httproute, gateway-grpcroute, gateway-tlsroute, gateway-tcproute, gateway-udproute, ingress, node, openshift-route, service and ambassador-host").Default(defaultConfig.LabelFilter).StringVar(&cfg.LabelFilter)
+	app.Flag("istio-gateway-namespace", "Limit Istio Gateways to a specific namespace; specify multiple times for multiple namespaces (default: the value of --namespace)").StringsVar(&cfg.IstioGatewayNamespaces)
+	app.Flag("istio-gateway-virtualservice-hosts", "Publish the hosts of VirtualServices bound to wildcard hosts of Istio Gateways, valid only when using istio-gateway source (default: false)").BoolVar(&cfg.IstioGatewayVSHosts)
+	app.Flag("istio-gateway-credential-hosts", "Publish the DNS names of the certificates referenced by TLS servers of Istio Gateways without hosts, which requires watching Secrets; valid only when using istio-gateway source (default: false)").BoolVar(&cfg.IstioGatewayCredentialHosts)
+	app.Flag("istio-gateway-unmatched-selector", "What to do with Istio Gateways whose selector matches no service, valid only when using istio-gateway source (default: skip, options: skip, error)").Default(defaultConfig.IstioGatewayUnmatched).EnumVar(&cfg.IstioGatewayUnmatched, "skip", "error")
+	app.Flag("istio-gateway-resolve-load-balancer-hostname", "Resolve the hostname targets of Istio Gateways to IP addresses in order to create DNS A/AAAA records instead of CNAMEs, valid only when using istio-gateway source (default: false)").BoolVar(&cfg.IstioGatewayResolveLBHostname)
+	app.Flag("istio-gateway-host-conflict", "What to do with hosts declared by several Istio Gateways with different targets, valid only when using istio-gateway source (default: ignore, options: ignore, merge, first)").Default(defaultConfig.IstioGatewayHostConflict).EnumVar(&cfg.IstioGatewayHostConflict, "ignore", "merge", "first")
+	app.Flag("label-filter", "Filter resources queried for endpoints by label selector; currently supported by source types crd, gateway-httproute, gateway-grpcroute, gateway-tlsroute, gateway-tcproute, gateway-udproute, ingress, istio-gateway, node, openshift-route, service and ambassador-host").Default(defaultConfig.LabelFilter).StringVar(&cfg.LabelFilter)
 	managedRecordTypesHelp := fmt.Sprintf("Record types to manage; specify multiple times to include many; (default: %s) (supported records: A, AAAA, CNAME, NS, SRV, TXT)", strings.Join(defaultConfig.ManagedDNSRecordTypes, ","))
 	app.Flag("managed-record-types", managedRecordTypesHelp).Default(defaultConfig.ManagedDNSRecordTypes...).StringsVar(&cfg.ManagedDNSRecordTypes)
 	app.Flag("namespace", "Limit resources queried for endpoints to a specific namespace (default: all namespaces)").Default(defaultConfig.Namespace).StringVar(&cfg.Namespace)
@@ -492,8 +543,8 @@ func App(cfg *Config) *kingpin.Application {
 	providers := []string{"akamai", "alibabacloud", "aws", "aws-sd", "azure", "azure-dns", "azure-private-dns", "civo", "cloudflare", "coredns", "digitalocean", "dnsimple", "exoscale", "gandi", "godaddy", "google", "inmemory", "linode", "ns1", "oci", "ovh", "pdns", "pihole", "plural", "rfc2136", "scaleway", "skydns", "transip", "webhook"}
 	app.Flag("provider", "The DNS provider where the DNS records will be created (required, options: "+strings.Join(providers, ", ")+")").Required().PlaceHolder("provider").EnumVar(&cfg.Provider, providers...)
 	app.Flag("provider-cache-time", "The time to cache the DNS provider record list requests.").Default(defaultConfig.ProviderCacheTime.String()).DurationVar(&cfg.ProviderCacheTime)
-	app.Flag("domain-filter", "Limit possible target zones by a domain suffix; specify multiple times for multiple domains (optional)").Default("").StringsVar(&cfg.DomainFilter)
-	app.Flag("exclude-domains", "Exclude subdomains (optional)").Default("").StringsVar(&cfg.ExcludeDomains)
+	app.Flag("domain-filter", "Limit possible target zones by a domain suffix; specify multiple times for multiple domains, or as file:///path to read one domain per line from a file (optional)").Default("").StringsVar(&cfg.DomainFilter)
+	app.Flag("exclude-domains", "Exclude subdomains; also accepts file:///path references (optional)").Default("").StringsVar(&cfg.ExcludeDomains)
 	app.Flag("regex-domain-filter", "Limit possible domains and target zones by a Regex filter; Overrides domain-filter (optional)").Default(defaultConfig.RegexDomainFilter.String()).RegexpVar(&cfg.RegexDomainFilter)
 	app.Flag("regex-domain-exclusion", "Regex filter that excludes domains and target zones matched by regex-domain-filter (optional); Require 'regex-domain-filter' ").Default(defaultConfig.RegexDomainExclusion.String()).RegexpVar(&cfg.RegexDomainExclusion)
 	app.Flag("zone-name-filter", "Filter target zones by zone domain (For now, only AzureDNS provider is using this flag); specify multiple times for multiple zones (optional)").Default("").StringsVar(&cfg.ZoneNameFilter)
@@ -606,6 +657,18 @@ func App(cfg *Config) *kingpin.Application {
 	app.Flag("pihole-password", "When using the Pihole provider, the password to the server if it is protected").Default(defaultConfig.PiholePassword).StringVar(&cfg.PiholePassword)
 	app.Flag("pihole-tls-skip-verify", "When using the Pihole provider, disable verification of any TLS certificates").BoolVar(&cfg.PiholeTLSInsecureSkipVerify)
 	app.Flag("pihole-api-version", "When using the Pihole provider, specify the pihole API version (default: 5, options: 5, 6)").Default(defaultConfig.PiholeApiVersion).StringVar(&cfg.PiholeApiVersion)
+	app.Flag("pihole-user-agent", "When using the Pihole provider, the User-Agent header of the requests to the Pihole web server (default: ExternalDNS/<version>)").Default(defaultConfig.PiholeUserAgent).StringVar(&cfg.PiholeUserAgent)
+	app.Flag("pihole-extra-header", "When using the Pihole provider, a header added to every request to the Pihole web server, e.g. for an authenticating proxy in front of it (API version 6 only); specify multiple times for multiple headers, e.g. --pihole-extra-header=X-Auth-Token=token").StringMapVar(&cfg.PiholeExtraHeaders)
+	app.Flag("pihole-ownership-mode", "When using the Pihole provider, whether the TXT records tracking ownership are stored in the dnsmasq lines of the Pihole configuration, as comments or as served txt-record lines (API version 6 only, default: none, options: none, txt, comment)").Default(defaultConfig.PiholeOwnershipMode).EnumVar(&cfg.PiholeOwnershipMode, "none", "txt", "comment")
+	app.Flag("pihole-cname-target-conflict", "When using the Pihole provider, what to do with CNAME records pointing at a name also managed as an A or AAAA record (default: ignore, options: ignore, warn, reject)").Default(defaultConfig.PiholeCNAMETargetConflict).EnumVar(&cfg.PiholeCNAMETargetConflict, "ignore", "warn", "reject")
+	app.Flag("pihole-ca-cert-file", "When using the Pihole provider, the path to a PEM encoded CA certificate to verify the Pihole web server with, taking precedence over --pihole-tls-skip-verify (API version 6 only)").Default(defaultConfig.PiholeCACertFile).StringVar(&cfg.PiholeCACertFile)
+	app.Flag("pihole-request-timeout", "When using the Pihole provider, the timeout of the requests to the Pihole web server (API version 6 only)").Default(defaultConfig.PiholeRequestTimeout.String()).DurationVar(&cfg.PiholeRequestTimeout)
+	app.Flag("pihole-auth-path", "When using the Pihole provider, the path of the authentication endpoint of the Pihole web server (API version 6 only)").Default(defaultConfig.PiholeAuthPath).StringVar(&cfg.PiholeAuthPath)
+	app.Flag("pihole-batch-writes", "When using the Pihole provider, write all changes in a single configuration update instead of one request per record, falling back to the latter when unsupported (API version 6 only, default: disabled)").BoolVar(&cfg.PiholeBatchWrites)
+	app.Flag("pihole-order-creates", "When using the Pihole provider, create records before the CNAME records pointing at them (default: disabled)").BoolVar(&cfg.PiholeOrderCreates)
+	app.Flag("pihole-strict-decoding", "When using the Pihole provider, reject record listings of an unexpected shape instead of reading them as empty (API version 6 only, default: disabled)").BoolVar(&cfg.PiholeStrictDecoding)
+	app.Flag("pihole-preserve-name-case", "When using the Pihole provider, keep the case of DNS names instead of lowercasing them (default: disabled)").BoolVar(&cfg.PiholePreserveNameCase)
+	app.Flag("pihole-records-cache-ttl", "When using the Pihole provider, how long the records listed from the Pihole web server are cached, invalidated by every change (0s to disable)").Default(defaultConfig.PiholeRecordsCacheTTL.String()).DurationVar(&cfg.PiholeRecordsCacheTTL)
 
 	// Flags related to the Plural provider
 	app.Flag("plural-cluster", "When using the plural provider, specify the cluster name you're running with").Default(defaultConfig.PluralCluster).StringVar(&cfg.PluralCluster)
@@ -613,6 +676,7 @@ func App(cfg *Config) *kingpin.Application {
 
 	// Flags related to policies
 	app.Flag("policy", "Modify how DNS records are synchronized between sources and providers (default: sync, options: sync, upsert-only, create-only)").Default(defaultConfig.Policy).EnumVar(&cfg.Policy, "sync", "upsert-only", "create-only")
+	app.Flag("max-deletion-ratio", "Abort a synchronization without applying any change if it would delete more than this fraction of the managed records, e.g. 0.2 (default: 0, disabled)").Default(strconv.FormatFloat(defaultConfig.MaxDeletionRatio, 'f', -1, 64)).Float64Var(&cfg.MaxDeletionRatio)
 
 	// Flags related to the registry
 	app.Flag("registry", "The registry implementation to use to keep track of DNS record ownership (default: txt, options: txt, noop, dynamodb, aws-sd)").Default(defaultConfig.Registry).EnumVar(&cfg.Registry, "txt", "noop", "dynamodb", "aws-sd")
@@ -630,6 +694,7 @@ func App(cfg *Config) *kingpin.Application {
 	app.Flag("interval", "The interval between two consecutive synchronizations in duration format (default: 1m)").Default(defaultConfig.Interval.String()).DurationVar(&cfg.Interval)
 	app.Flag("min-event-sync-interval", "The minimum interval between two consecutive synchronizations triggered from kubernetes events in duration format (default: 5s)").Default(defaultConfig.MinEventSyncInterval.String()).DurationVar(&cfg.MinEventSyncInterval)
 	app.Flag("once", "When enabled, exits the synchronization loop after the first iteration (default: disabled)").BoolVar(&cfg.Once)
+	app.Flag("delete-all-managed", "When enabled, deletes the records owned by --txt-owner-id that match the domain filter and the managed record types, along with their ownership records, then exits, e.g. to clean up when decommissioning ExternalDNS; respects --dry-run (default: disabled)").BoolVar(&cfg.DeleteAllManaged)
 	app.Flag("dry-run", "When enabled, prints DNS record changes rather than actually performing them (default: disabled)").BoolVar(&cfg.DryRun)
 	app.Flag("events", "When enabled, in addition to running every interval, the reconciliation loop will get triggered when supported sources change (default: disabled)").BoolVar(&cfg.UpdateEvents)
 
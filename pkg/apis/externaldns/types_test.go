@@ -38,6 +38,8 @@ var (
 		SkipperRouteGroupVersion:               "zalando.org/v1",
 		Sources:                                []string{"service"},
 		Namespace:                              "",
+		IstioGatewayUnmatched:                  "skip",
+		IstioGatewayHostConflict:               "ignore",
 		FQDNTemplate:                           "",
 		Compatibility:                          "",
 		Provider:                               "google",
@@ -68,6 +70,11 @@ var (
 		AWSZoneCacheDuration:                   0 * time.Second,
 		AWSSDServiceCleanup:                    false,
 		AWSSDCreateTag:                         map[string]string{},
+		PiholeExtraHeaders:                     map[string]string{},
+		PiholeOwnershipMode:                    "none",
+		PiholeCNAMETargetConflict:              "ignore",
+		PiholeRequestTimeout:                   30 * time.Second,
+		PiholeAuthPath:                         "/api/auth",
 		AWSDynamoDBTable:                       "external-dns",
 		AzureConfigFile:                        "/etc/kubernetes/azure.json",
 		AzureResourceGroup:                     "",
@@ -104,6 +111,7 @@ var (
 		Interval:                                      time.Minute,
 		MinEventSyncInterval:                          5 * time.Second,
 		Once:                                          false,
+		DeleteAllManaged:                              false,
 		DryRun:                                        false,
 		UpdateEvents:                                  false,
 		LogFormat:                                     "text",
@@ -139,6 +147,10 @@ var (
 		SkipperRouteGroupVersion:               "zalando.org/v2",
 		Sources:                                []string{"service", "ingress", "connector"},
 		Namespace:                              "namespace",
+		IstioGatewayUnmatched:                  "error",
+		IstioGatewayCredentialHosts:            true,
+		IstioGatewayResolveLBHostname:          true,
+		IstioGatewayHostConflict:               "merge",
 		IgnoreHostnameAnnotation:               true,
 		IgnoreNonHostNetworkPods:               true,
 		IgnoreIngressTLSSpec:                   true,
@@ -209,6 +221,7 @@ var (
 		TLSClientCertKey:                              "/path/to/key.pem",
 		PodSourceDomain:                               "example.org",
 		Policy:                                        "upsert-only",
+		MaxDeletionRatio:                              0.2,
 		Registry:                                      "noop",
 		TXTOwnerID:                                    "owner-1",
 		TXTPrefix:                                     "associated-txt-record",
@@ -216,6 +229,7 @@ var (
 		Interval:                                      10 * time.Minute,
 		MinEventSyncInterval:                          50 * time.Second,
 		Once:                                          true,
+		DeleteAllManaged:                              true,
 		DryRun:                                        true,
 		UpdateEvents:                                  true,
 		LogFormat:                                     "json",
@@ -238,6 +252,18 @@ var (
 		RFC2136Host:                                   []string{"rfc2136-host1", "rfc2136-host2"},
 		RFC2136LoadBalancingStrategy:                  "round-robin",
 		PiholeApiVersion:                              "6",
+		PiholeUserAgent:                               "my-gateway/1.0",
+		PiholeExtraHeaders:                            map[string]string{"X-Auth-Token": "proxy-token"},
+		PiholeOwnershipMode:                           "comment",
+		PiholeCNAMETargetConflict:                     "reject",
+		PiholeCACertFile:                              "/path/to/ca.crt",
+		PiholeRequestTimeout:                          10 * time.Second,
+		PiholeAuthPath:                                "/admin/api/auth",
+		PiholeBatchWrites:                             true,
+		PiholeOrderCreates:                            true,
+		PiholeStrictDecoding:                          true,
+		PiholePreserveNameCase:                        true,
+		PiholeRecordsCacheTTL:                         time.Minute,
 		WebhookProviderURL:                            "http://localhost:8888",
 		WebhookProviderReadTimeout:                    5 * time.Second,
 		WebhookProviderWriteTimeout:                   10 * time.Second,
@@ -275,6 +301,10 @@ func TestParseFlags(t *testing.T) {
 				"--source=ingress",
 				"--source=connector",
 				"--namespace=namespace",
+				"--istio-gateway-unmatched-selector=error",
+				"--istio-gateway-credential-hosts",
+				"--istio-gateway-resolve-load-balancer-hostname",
+				"--istio-gateway-host-conflict=merge",
 				"--fqdn-template={{.Name}}.service.example.com",
 				"--ignore-non-host-network-pods",
 				"--ignore-hostname-annotation",
@@ -352,7 +382,20 @@ func TestParseFlags(t *testing.T) {
 				"--aws-sd-create-tag=key2=value2",
 				"--no-aws-evaluate-target-health",
 				"--pihole-api-version=6",
+				"--pihole-user-agent=my-gateway/1.0",
+				"--pihole-extra-header=X-Auth-Token=proxy-token",
+				"--pihole-ownership-mode=comment",
+				"--pihole-cname-target-conflict=reject",
+				"--pihole-ca-cert-file=/path/to/ca.crt",
+				"--pihole-request-timeout=10s",
+				"--pihole-auth-path=/admin/api/auth",
+				"--pihole-batch-writes",
+				"--pihole-order-creates",
+				"--pihole-strict-decoding",
+				"--pihole-preserve-name-case",
+				"--pihole-records-cache-ttl=1m",
 				"--policy=upsert-only",
+				"--max-deletion-ratio=0.2",
 				"--registry=noop",
 				"--txt-owner-id=owner-1",
 				"--txt-prefix=associated-txt-record",
@@ -361,6 +404,7 @@ func TestParseFlags(t *testing.T) {
 				"--interval=10m",
 				"--min-event-sync-interval=50s",
 				"--once",
+				"--delete-all-managed",
 				"--dry-run",
 				"--events",
 				"--log-format=json",
@@ -403,6 +447,10 @@ func TestParseFlags(t *testing.T) {
 				"EXTERNAL_DNS_SKIPPER_ROUTEGROUP_GROUPVERSION":                   "zalando.org/v2",
 				"EXTERNAL_DNS_SOURCE":                                            "service\ningress\nconnector",
 				"EXTERNAL_DNS_NAMESPACE":                                         "namespace",
+				"EXTERNAL_DNS_ISTIO_GATEWAY_UNMATCHED_SELECTOR":                  "error",
+				"EXTERNAL_DNS_ISTIO_GATEWAY_CREDENTIAL_HOSTS":                    "1",
+				"EXTERNAL_DNS_ISTIO_GATEWAY_RESOLVE_LOAD_BALANCER_HOSTNAME":      "1",
+				"EXTERNAL_DNS_ISTIO_GATEWAY_HOST_CONFLICT":                       "merge",
 				"EXTERNAL_DNS_FQDN_TEMPLATE":                                     "{{.Name}}.service.example.com",
 				"EXTERNAL_DNS_IGNORE_NON_HOST_NETWORK_PODS":                      "1",
 				"EXTERNAL_DNS_IGNORE_HOSTNAME_ANNOTATION":                        "1",
@@ -473,7 +521,20 @@ func TestParseFlags(t *testing.T) {
 				"EXTERNAL_DNS_AWS_SD_CREATE_TAG":                                 "key1=value1\nkey2=value2",
 				"EXTERNAL_DNS_DYNAMODB_TABLE":                                    "custom-table",
 				"EXTERNAL_DNS_PIHOLE_API_VERSION":                                "6",
+				"EXTERNAL_DNS_PIHOLE_USER_AGENT":                                 "my-gateway/1.0",
+				"EXTERNAL_DNS_PIHOLE_EXTRA_HEADER":                               "X-Auth-Token=proxy-token",
+				"EXTERNAL_DNS_PIHOLE_OWNERSHIP_MODE":                             "comment",
+				"EXTERNAL_DNS_PIHOLE_CNAME_TARGET_CONFLICT":                      "reject",
+				"EXTERNAL_DNS_PIHOLE_CA_CERT_FILE":                               "/path/to/ca.crt",
+				"EXTERNAL_DNS_PIHOLE_REQUEST_TIMEOUT":                            "10s",
+				"EXTERNAL_DNS_PIHOLE_AUTH_PATH":                                  "/admin/api/auth",
+				"EXTERNAL_DNS_PIHOLE_BATCH_WRITES":                               "1",
+				"EXTERNAL_DNS_PIHOLE_ORDER_CREATES":                              "1",
+				"EXTERNAL_DNS_PIHOLE_STRICT_DECODING":                            "1",
+				"EXTERNAL_DNS_PIHOLE_PRESERVE_NAME_CASE":                         "1",
+				"EXTERNAL_DNS_PIHOLE_RECORDS_CACHE_TTL":                          "1m",
 				"EXTERNAL_DNS_POLICY":                                            "upsert-only",
+				"EXTERNAL_DNS_MAX_DELETION_RATIO":                                "0.2",
 				"EXTERNAL_DNS_REGISTRY":                                          "noop",
 				"EXTERNAL_DNS_TXT_OWNER_ID":                                      "owner-1",
 				"EXTERNAL_DNS_TXT_PREFIX":                                        "associated-txt-record",
@@ -482,6 +543,7 @@ func TestParseFlags(t *testing.T) {
 				"EXTERNAL_DNS_INTERVAL":                                          "10m",
 				"EXTERNAL_DNS_MIN_EVENT_SYNC_INTERVAL":                           "50s",
 				"EXTERNAL_DNS_ONCE":                                              "1",
+				"EXTERNAL_DNS_DELETE_ALL_MANAGED":                                "1",
 				"EXTERNAL_DNS_DRY_RUN":                                           "1",
 				"EXTERNAL_DNS_EVENTS":                                            "1",
 				"EXTERNAL_DNS_LOG_FORMAT":                                        "json",
@@ -540,12 +602,17 @@ func restoreEnv(t *testing.T, originalEnv map[string]string) {
 
 func TestPasswordsNotLogged(t *testing.T) {
 	cfg := Config{
-		PDNSAPIKey:        "pdns-api-key",
-		RFC2136TSIGSecret: "tsig-secret",
+		PDNSAPIKey:         "pdns-api-key",
+		RFC2136TSIGSecret:  "tsig-secret",
+		PiholeExtraHeaders: map[string]string{"X-Auth-Token": "proxy-token"},
 	}
 
 	s := cfg.String()
 
 	assert.NotContains(t, s, "pdns-api-key")
 	assert.NotContains(t, s, "tsig-secret")
+	assert.NotContains(t, s, "proxy-token")
+	assert.Contains(t, s, "X-Auth-Token")
+	// The configuration itself is left untouched.
+	assert.Equal(t, map[string]string{"X-Auth-Token": "proxy-token"}, cfg.PiholeExtraHeaders)
 }
@@ -45,6 +45,10 @@ func ValidateConfig(cfg *externaldns.Config) error {
 		return errors.New("txt-prefix and txt-suffix are mutual exclusive")
 	}
 
+	if cfg.MaxDeletionRatio < 0 || cfg.MaxDeletionRatio > 1 {
+		return errors.New("--max-deletion-ratio must be between 0 and 1")
+	}
+
 	_, err := labels.Parse(cfg.LabelFilter)
 	if err != nil {
 		return errors.New("--label-filter does not specify a valid label selector")
@@ -94,6 +94,19 @@ func TestValidateBadIgnoreHostnameAnnotationsConfig(t *testing.T) {
 	assert.Error(t, ValidateConfig(cfg))
 }
 
+func TestValidateMaxDeletionRatio(t *testing.T) {
+	for _, ratio := range []float64{-0.1, 1.5} {
+		cfg := newValidConfig(t)
+		cfg.MaxDeletionRatio = ratio
+		assert.Error(t, ValidateConfig(cfg), "ratio %v", ratio)
+	}
+	for _, ratio := range []float64{0, 0.2, 1} {
+		cfg := newValidConfig(t)
+		cfg.MaxDeletionRatio = ratio
+		assert.NoError(t, ValidateConfig(cfg), "ratio %v", ratio)
+	}
+}
+
 func TestValidateBadRfc2136Config(t *testing.T) {
 	cfg := externaldns.NewConfig()
 
@@ -67,12 +67,33 @@ func (r *CustomRoundTripper) RoundTrip(req *http.Request) (*http.Response, error
 	return resp, err
 }
 
-func NewInstrumentedClient(next *http.Client) *http.Client {
+// ClientOption configures the clients built by NewInstrumentedClient.
+type ClientOption func(*clientOptions)
+
+type clientOptions struct {
+	userAgent string
+}
+
+// WithUserAgent overrides the User-Agent header of the requests, see NewUserAgentTransport.
+func WithUserAgent(userAgent string) ClientOption {
+	return func(o *clientOptions) {
+		o.userAgent = userAgent
+	}
+}
+
+// NewInstrumentedClient records the latencies of the requests of the given client and sets
+// their User-Agent header, which defaults to externaldns.UserAgent().
+func NewInstrumentedClient(next *http.Client, opts ...ClientOption) *http.Client {
 	if next == nil {
 		next = http.DefaultClient
 	}
 
-	next.Transport = NewInstrumentedTransport(next.Transport)
+	var o clientOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	next.Transport = NewInstrumentedTransport(NewUserAgentTransport(next.Transport, o.userAgent))
 
 	return next
 }
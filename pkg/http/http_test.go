@@ -25,6 +25,8 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+
+	"sigs.k8s.io/external-dns/pkg/apis/externaldns"
 )
 
 type dummyTransport struct{}
@@ -51,8 +53,18 @@ func TestNewInstrumentedClient(t *testing.T) {
 	client := &http.Client{Transport: &dummyTransport{}}
 	result := NewInstrumentedClient(client)
 	require.Equal(t, client, result)
-	_, ok := result.Transport.(*CustomRoundTripper)
+	crt, ok := result.Transport.(*CustomRoundTripper)
+	require.True(t, ok)
+	uart, ok := crt.next.(*UserAgentRoundTripper)
+	require.True(t, ok)
+	require.Equal(t, externaldns.UserAgent(), uart.userAgent)
+
+	result = NewInstrumentedClient(&http.Client{}, WithUserAgent("my-gateway/1.0"))
+	crt, ok = result.Transport.(*CustomRoundTripper)
+	require.True(t, ok)
+	uart, ok = crt.next.(*UserAgentRoundTripper)
 	require.True(t, ok)
+	require.Equal(t, "my-gateway/1.0", uart.userAgent)
 
 	// Should default to http.DefaultClient if nil
 	result2 := NewInstrumentedClient(nil)
@@ -0,0 +1,191 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package http
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	defaultMaxRetries     = 3
+	defaultInitialBackoff = 100 * time.Millisecond
+	defaultMaxBackoff     = 10 * time.Second
+)
+
+// RetryRoundTripper retries the requests its policy selects, by default idempotent requests answered with
+// 429 Too Many Requests or a 5xx status, waiting with an exponential backoff between attempts,
+// or as long as the Retry-After header asks. Requests whose body cannot be read again are never retried.
+type RetryRoundTripper struct {
+	next           http.RoundTripper
+	maxRetries     int
+	initialBackoff time.Duration
+	maxBackoff     time.Duration
+	policy         RetryPolicy
+	now            func() time.Time
+}
+
+// RetryPolicy reports whether a request is worth sending again after the given response.
+type RetryPolicy func(req *http.Request, resp *http.Response) bool
+
+// DefaultRetryPolicy retries idempotent requests answered with 429 Too Many Requests or a 5xx status.
+func DefaultRetryPolicy(req *http.Request, resp *http.Response) bool {
+	return isIdempotent(req) && isRetryableStatus(resp.StatusCode)
+}
+
+// RetryOption configures a RetryRoundTripper.
+type RetryOption func(*RetryRoundTripper)
+
+// WithMaxRetries sets how many times a request is retried after its first attempt.
+func WithMaxRetries(maxRetries int) RetryOption {
+	return func(r *RetryRoundTripper) {
+		r.maxRetries = maxRetries
+	}
+}
+
+// WithBackoff sets the wait before the first retry, doubled on each further retry up to maxBackoff.
+// maxBackoff also bounds the wait asked by a Retry-After header.
+func WithBackoff(initialBackoff, maxBackoff time.Duration) RetryOption {
+	return func(r *RetryRoundTripper) {
+		r.initialBackoff = initialBackoff
+		r.maxBackoff = maxBackoff
+	}
+}
+
+// WithRetryPolicy sets the policy selecting the requests to retry, DefaultRetryPolicy by default.
+func WithRetryPolicy(policy RetryPolicy) RetryOption {
+	return func(r *RetryRoundTripper) {
+		r.policy = policy
+	}
+}
+
+// NewRetryTransport wraps the given transport so that failed idempotent requests are retried,
+// see RetryRoundTripper. It defaults to http.DefaultTransport if next is nil.
+func NewRetryTransport(next http.RoundTripper, opts ...RetryOption) http.RoundTripper {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+
+	r := &RetryRoundTripper{
+		next:           next,
+		maxRetries:     defaultMaxRetries,
+		initialBackoff: defaultInitialBackoff,
+		maxBackoff:     defaultMaxBackoff,
+		policy:         DefaultRetryPolicy,
+		now:            time.Now,
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+// CancelRequest is a no-op to satisfy interfaces that require it, see CustomRoundTripper.CancelRequest.
+func (r *RetryRoundTripper) CancelRequest(_ *http.Request) {
+}
+
+func (r *RetryRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	replayable := req.Body == nil || req.Body == http.NoBody || req.GetBody != nil
+	for attempt := 0; ; attempt++ {
+		// Retries send a copy of the request with a fresh body, a RoundTripper must not modify the request it is given.
+		attemptReq := req
+		if attempt > 0 {
+			attemptReq = req.Clone(req.Context())
+			if req.GetBody != nil {
+				body, err := req.GetBody()
+				if err != nil {
+					return nil, err
+				}
+				attemptReq.Body = body
+			}
+		}
+
+		resp, err := r.next.RoundTrip(attemptReq)
+		if err != nil || !replayable || attempt >= r.maxRetries || !r.policy(req, resp) {
+			return resp, err
+		}
+
+		wait := r.backoff(attempt, resp.Header.Get("Retry-After"))
+		log.Debugf("Request %s %s failed with status %d, retrying in %s (%d/%d)", req.Method, req.URL.Redacted(), resp.StatusCode, wait, attempt+1, r.maxRetries)
+		// The body must be read to the end for the connection to be reused.
+		_, _ = io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+
+		if err := sleep(req.Context(), wait); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// backoff returns the wait before the given retry: the delay asked by the Retry-After header if any,
+// the exponential backoff otherwise, and never more than the maximum backoff.
+func (r *RetryRoundTripper) backoff(attempt int, retryAfter string) time.Duration {
+	wait := r.initialBackoff
+	for i := 0; i < attempt && wait < r.maxBackoff; i++ {
+		wait *= 2
+	}
+	if asked, ok := ParseRetryAfter(retryAfter, r.now()); ok {
+		wait = asked
+	}
+	return min(wait, r.maxBackoff)
+}
+
+// ParseRetryAfter returns the wait asked by a Retry-After header, given in seconds or as an HTTP date.
+// It reports false if the header is missing or invalid.
+func ParseRetryAfter(value string, now time.Time) (time.Duration, bool) {
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		return max(date.Sub(now), 0), true
+	}
+	return 0, false
+}
+
+// isIdempotent reports whether the request can be sent again without side effects, following the rules
+// of the net/http transport.
+func isIdempotent(req *http.Request) bool {
+	switch req.Method {
+	case "", http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	_, hasKey := req.Header["Idempotency-Key"]
+	_, hasXKey := req.Header["X-Idempotency-Key"]
+	return hasKey || hasXKey
+}
+
+// isRetryableStatus reports whether a response status is worth retrying the request for.
+func isRetryableStatus(status int) bool {
+	return status == http.StatusTooManyRequests || status >= http.StatusInternalServerError
+}
+
+// sleep waits for the given duration, or returns the error of the context if it is done first.
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
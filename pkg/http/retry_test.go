@@ -0,0 +1,286 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package http
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newFlakyServer returns a server answering the given statuses in order, then 200 OK,
+// and the counter of the requests it received.
+func newFlakyServer(t *testing.T, header http.Header, statuses ...int) (*httptest.Server, *atomic.Int32) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		call := int(calls.Add(1))
+		body, _ := io.ReadAll(r.Body)
+		if call <= len(statuses) {
+			for key, values := range header {
+				w.Header()[key] = values
+			}
+			w.WriteHeader(statuses[call-1])
+			return
+		}
+		_, _ = w.Write(append([]byte("ok:"), body...))
+	}))
+	t.Cleanup(server.Close)
+	return server, &calls
+}
+
+func TestRetryTransport(t *testing.T) {
+	server, calls := newFlakyServer(t, nil, http.StatusServiceUnavailable)
+	client := &http.Client{Transport: NewRetryTransport(nil, WithBackoff(time.Millisecond, 10*time.Millisecond))}
+
+	resp, err := client.Get(server.URL)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "ok:", string(body))
+	assert.Equal(t, int32(2), calls.Load())
+}
+
+func TestRetryTransportReplaysBody(t *testing.T) {
+	server, calls := newFlakyServer(t, nil, http.StatusTooManyRequests, http.StatusBadGateway)
+	client := &http.Client{Transport: NewRetryTransport(nil, WithBackoff(time.Millisecond, 10*time.Millisecond))}
+
+	req, err := http.NewRequest(http.MethodPut, server.URL, strings.NewReader("payload"))
+	require.NoError(t, err)
+	resp, err := client.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+
+	assert.Equal(t, "ok:payload", string(body))
+	assert.Equal(t, int32(3), calls.Load())
+}
+
+// roundTripperFunc adapts a function to the http.RoundTripper interface.
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func TestRetryTransportDoesNotModifyRequest(t *testing.T) {
+	var attempts []*http.Request
+	var bodies []string
+	next := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		attempts = append(attempts, req)
+		body, err := io.ReadAll(req.Body)
+		require.NoError(t, err)
+		bodies = append(bodies, string(body))
+		status := http.StatusServiceUnavailable
+		if len(attempts) == 3 {
+			status = http.StatusOK
+		}
+		return &http.Response{StatusCode: status, Header: http.Header{}, Body: http.NoBody}, nil
+	})
+	rt := NewRetryTransport(next, WithBackoff(time.Millisecond, 10*time.Millisecond))
+
+	req, err := http.NewRequest(http.MethodPut, "http://example.com", strings.NewReader("payload"))
+	require.NoError(t, err)
+	body := req.Body
+	resp, err := rt.RoundTrip(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, []string{"payload", "payload", "payload"}, bodies)
+	assert.True(t, req.Body == body, "the body of the caller's request must not be replaced")
+	require.Len(t, attempts, 3)
+	assert.NotSame(t, attempts[0], attempts[1])
+	assert.NotSame(t, attempts[1], attempts[2])
+}
+
+func TestRetryTransportGivesUp(t *testing.T) {
+	for _, tt := range []struct {
+		title         string
+		method        string
+		header        http.Header
+		status        int
+		expectedCalls int32
+	}{
+		{
+			title:         "retries are exhausted",
+			method:        http.MethodGet,
+			status:        http.StatusServiceUnavailable,
+			expectedCalls: 3,
+		},
+		{
+			title:         "non-idempotent request",
+			method:        http.MethodPost,
+			status:        http.StatusServiceUnavailable,
+			expectedCalls: 1,
+		},
+		{
+			title:         "non-idempotent request with an idempotency key",
+			method:        http.MethodPost,
+			header:        http.Header{"Idempotency-Key": {"abc"}},
+			status:        http.StatusServiceUnavailable,
+			expectedCalls: 3,
+		},
+		{
+			title:         "client error",
+			method:        http.MethodGet,
+			status:        http.StatusNotFound,
+			expectedCalls: 1,
+		},
+	} {
+		t.Run(tt.title, func(t *testing.T) {
+			server, calls := newFlakyServer(t, nil, tt.status, tt.status, tt.status, tt.status)
+			client := &http.Client{Transport: NewRetryTransport(nil, WithMaxRetries(2), WithBackoff(time.Millisecond, 10*time.Millisecond))}
+
+			req, err := http.NewRequest(tt.method, server.URL, strings.NewReader("payload"))
+			require.NoError(t, err)
+			req.Header = tt.header.Clone()
+			if req.Header == nil {
+				req.Header = http.Header{}
+			}
+			resp, err := client.Do(req)
+			require.NoError(t, err)
+			resp.Body.Close()
+
+			assert.Equal(t, tt.status, resp.StatusCode)
+			assert.Equal(t, tt.expectedCalls, calls.Load())
+		})
+	}
+}
+
+func TestRetryTransportRetryAfter(t *testing.T) {
+	server, calls := newFlakyServer(t, http.Header{"Retry-After": {"1"}}, http.StatusServiceUnavailable)
+	client := &http.Client{Transport: NewRetryTransport(nil, WithBackoff(time.Millisecond, 50*time.Millisecond))}
+
+	start := time.Now()
+	resp, err := client.Get(server.URL)
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, int32(2), calls.Load())
+	// Retry-After asks for a second, which the maximum backoff bounds.
+	assert.GreaterOrEqual(t, time.Since(start), 50*time.Millisecond)
+	assert.Less(t, time.Since(start), time.Second)
+}
+
+func TestRetryTransportContextCanceled(t *testing.T) {
+	server, calls := newFlakyServer(t, nil, http.StatusServiceUnavailable)
+	client := &http.Client{Transport: NewRetryTransport(nil, WithBackoff(time.Minute, time.Minute))}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+	require.NoError(t, err)
+
+	_, err = client.Do(req)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Equal(t, int32(1), calls.Load())
+}
+
+func TestRetryBackoff(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	rt := NewRetryTransport(nil, WithBackoff(100*time.Millisecond, 2*time.Second)).(*RetryRoundTripper)
+	rt.now = func() time.Time { return now }
+
+	for _, tt := range []struct {
+		title      string
+		attempt    int
+		retryAfter string
+		expected   time.Duration
+	}{
+		{title: "first retry", attempt: 0, expected: 100 * time.Millisecond},
+		{title: "third retry", attempt: 2, expected: 400 * time.Millisecond},
+		{title: "capped backoff", attempt: 10, expected: 2 * time.Second},
+		{title: "overflowing backoff", attempt: 70, expected: 2 * time.Second},
+		{title: "Retry-After seconds", attempt: 0, retryAfter: "1", expected: time.Second},
+		{title: "capped Retry-After seconds", attempt: 0, retryAfter: "120", expected: 2 * time.Second},
+		{title: "Retry-After date", attempt: 0, retryAfter: now.Add(time.Second).Format(http.TimeFormat), expected: time.Second},
+		{title: "past Retry-After date", attempt: 0, retryAfter: now.Add(-time.Minute).Format(http.TimeFormat), expected: 0},
+		{title: "invalid Retry-After", attempt: 1, retryAfter: "soon", expected: 200 * time.Millisecond},
+	} {
+		t.Run(tt.title, func(t *testing.T) {
+			assert.Equal(t, tt.expected, rt.backoff(tt.attempt, tt.retryAfter))
+		})
+	}
+}
+
+func TestRetryTransportPolicy(t *testing.T) {
+	server, calls := newFlakyServer(t, nil, http.StatusTooManyRequests, http.StatusTooManyRequests)
+	policy := func(req *http.Request, resp *http.Response) bool {
+		return resp.StatusCode == http.StatusTooManyRequests
+	}
+	client := &http.Client{Transport: NewRetryTransport(nil, WithBackoff(time.Millisecond, 10*time.Millisecond), WithRetryPolicy(policy))}
+
+	// The policy retries requests the default one would not.
+	resp, err := client.Post(server.URL, "text/plain", strings.NewReader("payload"))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+
+	assert.Equal(t, "ok:payload", string(body))
+	assert.Equal(t, int32(3), calls.Load())
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	for _, tt := range []struct {
+		value    string
+		expected time.Duration
+		ok       bool
+	}{
+		{value: ""},
+		{value: "soon"},
+		{value: "-1"},
+		{value: "0", expected: 0, ok: true},
+		{value: "5", expected: 5 * time.Second, ok: true},
+		{value: now.Add(2 * time.Second).Format(http.TimeFormat), expected: 2 * time.Second, ok: true},
+		{value: now.Add(-time.Minute).Format(http.TimeFormat), expected: 0, ok: true},
+	} {
+		t.Run(tt.value, func(t *testing.T) {
+			wait, ok := ParseRetryAfter(tt.value, now)
+			assert.Equal(t, tt.ok, ok)
+			assert.Equal(t, tt.expected, wait)
+		})
+	}
+}
+
+func TestNewRetryTransport(t *testing.T) {
+	dt := &dummyTransport{}
+	rt, ok := NewRetryTransport(dt, WithMaxRetries(5)).(*RetryRoundTripper)
+	require.True(t, ok)
+	assert.Equal(t, dt, rt.next)
+	assert.Equal(t, 5, rt.maxRetries)
+	assert.Equal(t, defaultInitialBackoff, rt.initialBackoff)
+
+	// Should default to http.DefaultTransport if nil
+	rt, ok = NewRetryTransport(nil).(*RetryRoundTripper)
+	require.True(t, ok)
+	assert.Equal(t, http.DefaultTransport, rt.next)
+}
@@ -0,0 +1,59 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package http
+
+import (
+	"net/http"
+
+	"sigs.k8s.io/external-dns/pkg/apis/externaldns"
+)
+
+// UserAgentRoundTripper sets the User-Agent header of the requests that do not have one,
+// so that the traffic of external-dns can be told apart in the logs of DNS servers.
+type UserAgentRoundTripper struct {
+	next      http.RoundTripper
+	userAgent string
+}
+
+// NewUserAgentTransport wraps the given transport so that requests carry the given user agent,
+// see UserAgentRoundTripper. It defaults to externaldns.UserAgent() if userAgent is empty,
+// and to http.DefaultTransport if next is nil.
+func NewUserAgentTransport(next http.RoundTripper, userAgent string) http.RoundTripper {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	if userAgent == "" {
+		userAgent = externaldns.UserAgent()
+	}
+
+	return &UserAgentRoundTripper{next: next, userAgent: userAgent}
+}
+
+// CancelRequest is a no-op to satisfy interfaces that require it, see CustomRoundTripper.CancelRequest.
+func (r *UserAgentRoundTripper) CancelRequest(_ *http.Request) {
+}
+
+func (r *UserAgentRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Header.Get("User-Agent") != "" {
+		return r.next.RoundTrip(req)
+	}
+
+	// A RoundTripper must not modify the request, set the header on a copy.
+	req = req.Clone(req.Context())
+	req.Header.Set("User-Agent", r.userAgent)
+	return r.next.RoundTrip(req)
+}
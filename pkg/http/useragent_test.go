@@ -0,0 +1,89 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"sigs.k8s.io/external-dns/pkg/apis/externaldns"
+)
+
+func TestUserAgentTransport(t *testing.T) {
+	for _, tt := range []struct {
+		title     string
+		userAgent string
+		header    string
+		expected  string
+	}{
+		{
+			title:    "defaults to the external-dns user agent",
+			expected: externaldns.UserAgent(),
+		},
+		{
+			title:     "configured user agent",
+			userAgent: "my-gateway/1.0",
+			expected:  "my-gateway/1.0",
+		},
+		{
+			title:     "user agent set on the request is kept",
+			userAgent: "my-gateway/1.0",
+			header:    "sdk/2.0",
+			expected:  "sdk/2.0",
+		},
+	} {
+		t.Run(tt.title, func(t *testing.T) {
+			var received string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				received = r.UserAgent()
+			}))
+			defer server.Close()
+
+			client := &http.Client{Transport: NewUserAgentTransport(nil, tt.userAgent)}
+			req, err := http.NewRequest(http.MethodGet, server.URL, nil)
+			require.NoError(t, err)
+			if tt.header != "" {
+				req.Header.Set("User-Agent", tt.header)
+			}
+			resp, err := client.Do(req)
+			require.NoError(t, err)
+			resp.Body.Close()
+
+			assert.Equal(t, tt.expected, received)
+			// The request of the caller is left untouched.
+			assert.Equal(t, tt.header, req.Header.Get("User-Agent"))
+		})
+	}
+}
+
+func TestNewUserAgentTransport(t *testing.T) {
+	dt := &dummyTransport{}
+	rt, ok := NewUserAgentTransport(dt, "agent").(*UserAgentRoundTripper)
+	require.True(t, ok)
+	assert.Equal(t, dt, rt.next)
+	assert.Equal(t, "agent", rt.userAgent)
+
+	// Should default to http.DefaultTransport if nil
+	rt, ok = NewUserAgentTransport(nil, "").(*UserAgentRoundTripper)
+	require.True(t, ok)
+	assert.Equal(t, http.DefaultTransport, rt.next)
+	assert.Equal(t, externaldns.UserAgent(), rt.userAgent)
+}
@@ -211,6 +211,40 @@ func NewSummaryVecWithOpts(opts prometheus.SummaryOpts, labelNames []string) Sum
 	}
 }
 
+type HistogramVecMetric struct {
+	Metric
+	HistogramVec prometheus.HistogramVec
+}
+
+func (h HistogramVecMetric) Get() *Metric {
+	return &h.Metric
+}
+
+// SetWithLabels observes the value in the HistogramVec metric for the specified label values.
+// All label values are converted to lowercase before being applied.
+func (h HistogramVecMetric) SetWithLabels(value float64, lvs ...string) {
+	for i, v := range lvs {
+		lvs[i] = strings.ToLower(v)
+	}
+
+	h.HistogramVec.WithLabelValues(lvs...).Observe(value)
+}
+
+func NewHistogramVecWithOpts(opts prometheus.HistogramOpts, labelNames []string) HistogramVecMetric {
+	opts.Namespace = Namespace
+	return HistogramVecMetric{
+		Metric: Metric{
+			Type:      "histogramVec",
+			Name:      opts.Name,
+			FQDN:      fmt.Sprintf("%s_%s", opts.Subsystem, opts.Name),
+			Namespace: opts.Namespace,
+			Subsystem: opts.Subsystem,
+			Help:      opts.Help,
+		},
+		HistogramVec: *prometheus.NewHistogramVec(opts, labelNames),
+	}
+}
+
 func PathProcessor(path string) string {
 	parts := strings.Split(path, "/")
 	return parts[len(parts)-1]
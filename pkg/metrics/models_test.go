@@ -214,6 +214,35 @@ func TestSummaryV_SetWithLabels(t *testing.T) {
 	assert.Len(t, metricsFamilies[0].Metric[0].Label, 2)
 }
 
+func TestHistogramV_SetWithLabels(t *testing.T) {
+	opts := prometheus.HistogramOpts{
+		Name:      "test_histogramVec",
+		Namespace: "test_ns",
+		Subsystem: "test_sub",
+		Help:      "help text",
+		Buckets:   []float64{1, 10},
+	}
+	hv := NewHistogramVecWithOpts(opts, []string{"label1", "label2"})
+
+	hv.SetWithLabels(5.01, "Alpha", "BETA")
+	hv.SetWithLabels(0.5, "alpha", "beta")
+
+	reg := prometheus.NewRegistry()
+	reg.MustRegister(hv.HistogramVec)
+
+	metricsFamilies, err := reg.Gather()
+	assert.NoError(t, err)
+	assert.Len(t, metricsFamilies, 1)
+	assert.Equal(t, "histogramVec", hv.Get().Type)
+	assert.Equal(t, "test_sub_test_histogramVec", hv.Get().FQDN)
+
+	histogram := metricsFamilies[0].Metric[0].Histogram
+	assert.Equal(t, uint64(2), histogram.GetSampleCount())
+	assert.InDelta(t, 5.51, histogram.GetSampleSum(), 0.01)
+	assert.Equal(t, uint64(1), histogram.Bucket[0].GetCumulativeCount())
+	assert.Len(t, metricsFamilies[0].Metric[0].Label, 2)
+}
+
 func TestPathProcessor(t *testing.T) {
 	tests := []struct {
 		input    string
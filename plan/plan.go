@@ -17,6 +17,7 @@ limitations under the License.
 package plan
 
 import (
+	"errors"
 	"fmt"
 	"slices"
 	"strings"
@@ -51,8 +52,17 @@ type Plan struct {
 	ExcludeRecords []string
 	// OwnerID of records to manage
 	OwnerID string
+	// MaxDeletionRatio is the largest fraction of the currently managed records the plan may delete.
+	// Zero disables the check, see CheckDeletionRatio.
+	MaxDeletionRatio float64
+
+	// managedCount is the number of current records in scope of the plan, populated after calling Calculate()
+	managedCount int
 }
 
+// ErrDeletionRatioExceeded is returned when a plan would delete more records than its MaxDeletionRatio allows.
+var ErrDeletionRatioExceeded = errors.New("planned deletions exceed the maximum deletion ratio")
+
 // Changes holds lists of actions to be executed by dns providers
 type Changes struct {
 	// Records that need to be created
@@ -173,8 +183,12 @@ func (p *Plan) Calculate() *Plan {
 		p.DomainFilter = endpoint.MatchAllDomainFilters(nil)
 	}
 
+	managedCount := 0
 	for _, current := range filterRecordsForPlan(p.Current, p.DomainFilter, p.ManagedRecords, p.ExcludeRecords) {
 		t.addCurrent(current)
+		if p.OwnerID == "" || current.IsOwnedBy(p.OwnerID) {
+			managedCount++
+		}
 	}
 	for _, desired := range filterRecordsForPlan(p.Desired, p.DomainFilter, p.ManagedRecords, p.ExcludeRecords) {
 		t.addCandidate(desired)
@@ -269,12 +283,28 @@ func (p *Plan) Calculate() *Plan {
 		Changes: changes,
 		// The default for ExternalDNS is to always only consider A/AAAA and CNAMEs.
 		// Everything else is an add on or something to be considered.
-		ManagedRecords: []string{endpoint.RecordTypeA, endpoint.RecordTypeAAAA, endpoint.RecordTypeCNAME},
+		ManagedRecords:   []string{endpoint.RecordTypeA, endpoint.RecordTypeAAAA, endpoint.RecordTypeCNAME},
+		MaxDeletionRatio: p.MaxDeletionRatio,
+		managedCount:     managedCount,
 	}
 
 	return plan
 }
 
+// CheckDeletionRatio returns ErrDeletionRatioExceeded when the calculated changes would delete more than
+// MaxDeletionRatio of the records currently managed by this plan. This guards against wiping a zone
+// because of a misbehaving source, so the changes must not be applied when it returns an error.
+func (p *Plan) CheckDeletionRatio() error {
+	if p.MaxDeletionRatio <= 0 || p.Changes == nil || len(p.Changes.Delete) == 0 {
+		return nil
+	}
+	if float64(len(p.Changes.Delete)) > p.MaxDeletionRatio*float64(p.managedCount) {
+		return fmt.Errorf("%w: %d of %d managed records would be deleted, maximum ratio is %g",
+			ErrDeletionRatioExceeded, len(p.Changes.Delete), p.managedCount, p.MaxDeletionRatio)
+	}
+	return nil
+}
+
 func inheritOwner(from, to *endpoint.Endpoint) {
 	if to.Labels == nil {
 		to.Labels = map[string]string{}
@@ -328,7 +358,7 @@ func filterRecordsForPlan(records []*endpoint.Endpoint, domainFilter endpoint.Ma
 
 	for _, record := range records {
 		// Ignore records that do not match the domain filter provided
-		if !domainFilter.Match(record.DNSName) {
+		if !domainFilter.MatchWithType(record.DNSName, record.RecordType) {
 			log.Debugf("ignoring record %s that does not match domain filter", record.DNSName)
 			continue
 		}
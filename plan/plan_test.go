@@ -937,6 +937,36 @@ func (suite *PlanTestSuite) TestDomainFiltersUpdate() {
 	validateEntries(suite.T(), changes.Delete, expectedDelete)
 }
 
+func (suite *PlanTestSuite) TestDomainFiltersRecordTypes() {
+	cname := &endpoint.Endpoint{
+		DNSName:    "www.domain.tld",
+		Targets:    endpoint.Targets{"foo.domain.tld"},
+		RecordType: endpoint.RecordTypeCNAME,
+	}
+	current := []*endpoint.Endpoint{cname}
+	desired := []*endpoint.Endpoint{suite.domainFilterFiltered1}
+	expectedCreate := []*endpoint.Endpoint{suite.domainFilterFiltered1}
+	expectedUpdateOld := []*endpoint.Endpoint{}
+	expectedUpdateNew := []*endpoint.Endpoint{}
+	expectedDelete := []*endpoint.Endpoint{}
+
+	// CNAME records of the domain are left alone, even if they are no longer desired
+	domainFilter := endpoint.NewDomainFilterWithOptions([]string{"domain.tld"}, nil, endpoint.DomainFilterOptions{ExcludeTypes: []string{endpoint.RecordTypeCNAME}})
+	p := &Plan{
+		Policies:       []Policy{&SyncPolicy{}},
+		Current:        current,
+		Desired:        desired,
+		DomainFilter:   endpoint.MatchAllDomainFilters{domainFilter},
+		ManagedRecords: []string{endpoint.RecordTypeA, endpoint.RecordTypeCNAME},
+	}
+
+	changes := p.Calculate().Changes
+	validateEntries(suite.T(), changes.Create, expectedCreate)
+	validateEntries(suite.T(), changes.UpdateNew, expectedUpdateNew)
+	validateEntries(suite.T(), changes.UpdateOld, expectedUpdateOld)
+	validateEntries(suite.T(), changes.Delete, expectedDelete)
+}
+
 func (suite *PlanTestSuite) TestAAAARecords() {
 	current := []*endpoint.Endpoint{}
 	desired := []*endpoint.Endpoint{suite.fooAAAA}
@@ -1203,3 +1233,70 @@ func TestShouldUpdateProviderSpecific(tt *testing.T) {
 		})
 	}
 }
+
+func TestCheckDeletionRatio(t *testing.T) {
+	current := []*endpoint.Endpoint{
+		endpoint.NewEndpoint("a.example.com", endpoint.RecordTypeA, "1.1.1.1").WithLabel(endpoint.OwnerLabelKey, "owner"),
+		endpoint.NewEndpoint("b.example.com", endpoint.RecordTypeA, "1.1.1.2").WithLabel(endpoint.OwnerLabelKey, "owner"),
+		endpoint.NewEndpoint("c.example.com", endpoint.RecordTypeA, "1.1.1.3").WithLabel(endpoint.OwnerLabelKey, "owner"),
+		endpoint.NewEndpoint("d.example.com", endpoint.RecordTypeA, "1.1.1.4").WithLabel(endpoint.OwnerLabelKey, "owner"),
+		endpoint.NewEndpoint("e.example.com", endpoint.RecordTypeA, "1.1.1.5").WithLabel(endpoint.OwnerLabelKey, "other"),
+	}
+
+	for _, tt := range []struct {
+		name             string
+		desired          []*endpoint.Endpoint
+		policies         []Policy
+		maxDeletionRatio float64
+		expectedDeletes  int
+		expectErr        bool
+	}{
+		{
+			name:             "deletions below the ratio",
+			desired:          current[1:4],
+			maxDeletionRatio: 0.25,
+			expectedDeletes:  1,
+		},
+		{
+			name:             "deletions above the ratio",
+			desired:          current[2:4],
+			maxDeletionRatio: 0.25,
+			expectedDeletes:  2,
+			expectErr:        true,
+		},
+		{
+			name:             "all records deleted",
+			maxDeletionRatio: 0.5,
+			expectedDeletes:  4,
+			expectErr:        true,
+		},
+		{
+			name:            "disabled check",
+			expectedDeletes: 4,
+		},
+		{
+			name:             "deletions removed by policy",
+			policies:         []Policy{&UpsertOnlyPolicy{}},
+			maxDeletionRatio: 0.25,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			p := (&Plan{
+				Current:          current,
+				Desired:          tt.desired,
+				Policies:         tt.policies,
+				ManagedRecords:   []string{endpoint.RecordTypeA},
+				OwnerID:          "owner",
+				MaxDeletionRatio: tt.maxDeletionRatio,
+			}).Calculate()
+
+			assert.Len(t, p.Changes.Delete, tt.expectedDeletes)
+			err := p.CheckDeletionRatio()
+			if tt.expectErr {
+				require.ErrorIs(t, err, ErrDeletionRatioExceeded)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
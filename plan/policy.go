@@ -36,7 +36,8 @@ func (p *SyncPolicy) Apply(changes *Changes) *Changes {
 	return changes
 }
 
-// UpsertOnlyPolicy allows everything but deleting DNS records.
+// UpsertOnlyPolicy allows everything but deleting DNS records: records are created and updated,
+// but never deleted, even when a source briefly returns no endpoints at all.
 type UpsertOnlyPolicy struct{}
 
 // Apply applies the upsert-only policy which strips out any deletions.
@@ -70,6 +70,59 @@ func TestApply(t *testing.T) {
 	}
 }
 
+// TestUpsertOnlyPolicyPlan tests that planning with the upsert-only policy creates and updates records but never deletes them.
+func TestUpsertOnlyPolicyPlan(t *testing.T) {
+	foo := endpoint.NewEndpoint("foo.example.org", endpoint.RecordTypeA, "1.1.1.1")
+	fooV2 := endpoint.NewEndpoint("foo.example.org", endpoint.RecordTypeA, "2.2.2.2")
+	bar := endpoint.NewEndpoint("bar.example.org", endpoint.RecordTypeA, "3.3.3.3")
+
+	for _, tc := range []struct {
+		name     string
+		current  []*endpoint.Endpoint
+		desired  []*endpoint.Endpoint
+		expected *Changes
+	}{
+		{
+			name:     "source returning no endpoints deletes nothing",
+			current:  []*endpoint.Endpoint{foo, bar},
+			desired:  []*endpoint.Endpoint{},
+			expected: &Changes{},
+		},
+		{
+			name:     "removed endpoint is kept",
+			current:  []*endpoint.Endpoint{foo, bar},
+			desired:  []*endpoint.Endpoint{foo},
+			expected: &Changes{},
+		},
+		{
+			name:     "new endpoint is created",
+			current:  []*endpoint.Endpoint{foo},
+			desired:  []*endpoint.Endpoint{foo, bar},
+			expected: &Changes{Create: []*endpoint.Endpoint{bar}},
+		},
+		{
+			name:     "changed endpoint is updated",
+			current:  []*endpoint.Endpoint{foo, bar},
+			desired:  []*endpoint.Endpoint{fooV2},
+			expected: &Changes{UpdateOld: []*endpoint.Endpoint{foo}, UpdateNew: []*endpoint.Endpoint{fooV2}},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			changes := (&Plan{
+				Current:        tc.current,
+				Desired:        tc.desired,
+				Policies:       []Policy{Policies["upsert-only"]},
+				ManagedRecords: []string{endpoint.RecordTypeA},
+			}).Calculate().Changes
+
+			validateEntries(t, changes.Create, tc.expected.Create)
+			validateEntries(t, changes.UpdateOld, tc.expected.UpdateOld)
+			validateEntries(t, changes.UpdateNew, tc.expected.UpdateNew)
+			validateEntries(t, changes.Delete, tc.expected.Delete)
+		})
+	}
+}
+
 // TestPolicies tests that policies are correctly registered.
 func TestPolicies(t *testing.T) {
 	validatePolicy(t, Policies["sync"], &SyncPolicy{})
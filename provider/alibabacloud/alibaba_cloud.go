@@ -18,13 +18,20 @@ package alibabacloud
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"maps"
+	"net/netip"
 	"os"
+	"slices"
 	"sort"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
+	"unicode/utf8"
 
+	aliyunerrors "github.com/aliyun/alibaba-cloud-sdk-go/sdk/errors"
 	"github.com/aliyun/alibaba-cloud-sdk-go/sdk/requests"
 	"github.com/aliyun/alibaba-cloud-sdk-go/services/alidns"
 	"github.com/aliyun/alibaba-cloud-sdk-go/services/pvtz"
@@ -44,6 +51,36 @@ const (
 	nullHostAlibabaCloud                    = "@"
 	pVTZDoamin                              = "pvtz.aliyuncs.com"
 	defaultAlibabaCloudRequestScheme        = "https"
+	defaultAlibabaCloudRetryMaxAttempts     = 5
+	defaultAlibabaCloudRetryBaseDelay       = 500 * time.Millisecond
+	defaultAlibabaCloudLine                 = "default"
+	defaultAlibabaCloudBatchSize            = 1000
+	defaultAlibabaCloudBatchPollInterval    = time.Second
+	alibabaCloudBatchAddRecords             = "RR_ADD"
+	alibabaCloudBatchDeleteRecords          = "RR_DEL"
+	alibabaCloudBatchRunning                = 0
+	alibabaCloudBatchFinished               = 1
+	minAlibabaCloudWeight                   = 1
+	alibabaCloudZoneTypePublic              = "public"
+	alibabaCloudZoneTypePrivate             = "private"
+	maxAlibabaCloudWeight                   = 100
+	// txtHeritagePrefix starts the TXT records written by the registry.
+	txtHeritagePrefix = "heritage="
+	// registryTXTSeparator separates the key/value pairs of heritage strings in endpoint targets.
+	registryTXTSeparator = ","
+	// legacyAlibabaCloudTXTSeparator separates the key/value pairs of heritage strings stored by older releases.
+	legacyAlibabaCloudTXTSeparator = ";"
+	// maxTXTStringLength is the maximum length in bytes of a single character-string of a TXT record.
+	maxTXTStringLength = 255
+	// providerSpecificLine is the provider specific property selecting the resolution line (ISP routing) of a record.
+	providerSpecificLine = "alibabacloud.com/line"
+	// providerSpecificWeight is the provider specific property holding the weight of records with a set identifier.
+	providerSpecificWeight = "alibabacloud.com/weight"
+	// providerSpecificZoneType is the provider specific property selecting the zone type of a record when
+	// public zones and Private Zones are managed together.
+	providerSpecificZoneType = "alibabacloud.com/zone-type"
+	// setIdentifierRemarkPrefix starts the remark of weighted records, followed by the set identifier of their endpoint.
+	setIdentifierRemarkPrefix = "external-dns/set-identifier="
 )
 
 // AlibabaCloudDNSAPI is a minimal implementation of DNS API that we actually use, used primarily for unit testing.
@@ -56,6 +93,28 @@ type AlibabaCloudDNSAPI interface {
 	DescribeDomains(request *alidns.DescribeDomainsRequest) (*alidns.DescribeDomainsResponse, error)
 }
 
+// alibabaCloudDNSBatchAPI is implemented by Alibaba Cloud DNS clients able to run batch tasks.
+// See https://help.aliyun.com/document_detail/29754.html for the OperateBatchDomain API.
+type alibabaCloudDNSBatchAPI interface {
+	OperateBatchDomain(request *alidns.OperateBatchDomainRequest) (*alidns.OperateBatchDomainResponse, error)
+	DescribeBatchResultCount(request *alidns.DescribeBatchResultCountRequest) (*alidns.DescribeBatchResultCountResponse, error)
+	DescribeBatchResultDetail(request *alidns.DescribeBatchResultDetailRequest) (*alidns.DescribeBatchResultDetailResponse, error)
+}
+
+// alibabaCloudDNSWeightAPI is implemented by Alibaba Cloud DNS clients able to weight records
+// sharing a name, type and line (weighted round robin).
+type alibabaCloudDNSWeightAPI interface {
+	SetDNSSLBStatus(request *alidns.SetDNSSLBStatusRequest) (*alidns.SetDNSSLBStatusResponse, error)
+	UpdateDNSSLBWeight(request *alidns.UpdateDNSSLBWeightRequest) (*alidns.UpdateDNSSLBWeightResponse, error)
+	UpdateDomainRecordRemark(request *alidns.UpdateDomainRecordRemarkRequest) (*alidns.UpdateDomainRecordRemarkResponse, error)
+}
+
+// alibabaCloudDNSDomainInfoAPI is implemented by Alibaba Cloud DNS clients able to describe a zone,
+// including the minimum TTL allowed by its plan.
+type alibabaCloudDNSDomainInfoAPI interface {
+	DescribeDomainInfo(request *alidns.DescribeDomainInfoRequest) (*alidns.DescribeDomainInfoResponse, error)
+}
+
 // AlibabaCloudPrivateZoneAPI is a minimal implementation of Private Zone API that we actually use, used primarily for unit testing.
 // See https://help.aliyun.com/document_detail/66234.html for descriptions of all of its methods.
 type AlibabaCloudPrivateZoneAPI interface {
@@ -80,18 +139,41 @@ type AlibabaCloudProvider struct {
 	dnsClient            AlibabaCloudDNSAPI
 	pvtzClient           AlibabaCloudPrivateZoneAPI
 	privateZone          bool
+	mixedZoneTypes       bool
+	retry                alibabaCloudRetry
+	txtSeparator         string
+	strictZoneMatching   bool
+	batchChanges         bool
+	batchPollInterval    time.Duration
+	defaultRecordTTL     int64
 	clientLock           sync.RWMutex
 	nextExpire           time.Time
+	zoneNamesLock        sync.RWMutex
+	publicZoneNames      []string // Mixed zone types only, found by the last call to Records
+	privateZoneNames     []string // Mixed zone types only, found by the last call to Records
+}
+
+// alibabaCloudRetry configures how API calls are retried when Alibaba Cloud throttles them.
+type alibabaCloudRetry struct {
+	maxAttempts int
+	baseDelay   time.Duration
 }
 
 type alibabaCloudConfig struct {
-	RegionID        string    `json:"regionId"        yaml:"regionId"`
-	AccessKeyID     string    `json:"accessKeyId"     yaml:"accessKeyId"`
-	AccessKeySecret string    `json:"accessKeySecret" yaml:"accessKeySecret"`
-	VPCID           string    `json:"vpcId"           yaml:"vpcId"`
-	RoleName        string    `json:"-"               yaml:"-"` // For ECS RAM role only
-	StsToken        string    `json:"-"               yaml:"-"`
-	ExpireTime      time.Time `json:"-"               yaml:"-"`
+	RegionID           string        `json:"regionId"           yaml:"regionId"`
+	AccessKeyID        string        `json:"accessKeyId"        yaml:"accessKeyId"`
+	AccessKeySecret    string        `json:"accessKeySecret"    yaml:"accessKeySecret"`
+	VPCID              string        `json:"vpcId"              yaml:"vpcId"`
+	RoleName           string        `json:"-"                  yaml:"-"` // For ECS RAM role only
+	StsToken           string        `json:"-"                  yaml:"-"`
+	ExpireTime         time.Time     `json:"-"                  yaml:"-"`
+	RetryMaxAttempts   int           `json:"retryMaxAttempts"   yaml:"retryMaxAttempts"`   // Attempts for throttled API calls
+	RetryBaseDelay     time.Duration `json:"retryBaseDelay"     yaml:"retryBaseDelay"`     // Delay before the first retry, doubled afterwards
+	TXTSeparator       string        `json:"txtSeparator"       yaml:"txtSeparator"`       // Separator of heritage TXT record pairs as stored, "," or ";"
+	StrictZoneMatching bool          `json:"strictZoneMatching" yaml:"strictZoneMatching"` // Report endpoints outside of every zone instead of skipping them
+	BatchChanges       bool          `json:"batchChanges"       yaml:"batchChanges"`       // Create and delete records of public zones in batch tasks
+	DefaultTTL         int64         `json:"defaultTTL"         yaml:"defaultTTL"`         // TTL of public zone records without one, 600 when unset
+	MixedZoneTypes     bool          `json:"mixedZoneTypes"     yaml:"mixedZoneTypes"`     // Manage public zones and Private Zones together, selected per endpoint
 }
 
 // NewAlibabaCloudProvider creates a new Alibaba Cloud provider.
@@ -116,6 +198,18 @@ func NewAlibabaCloudProvider(configFile string, domainFilter *endpoint.DomainFil
 		}
 	}
 
+	switch cfg.TXTSeparator {
+	case "":
+		cfg.TXTSeparator = registryTXTSeparator
+	case registryTXTSeparator, legacyAlibabaCloudTXTSeparator:
+	default:
+		return nil, fmt.Errorf("invalid Alibaba Cloud TXT separator %q, must be %q or %q", cfg.TXTSeparator, registryTXTSeparator, legacyAlibabaCloudTXTSeparator)
+	}
+
+	if cfg.DefaultTTL < 0 {
+		return nil, fmt.Errorf("invalid Alibaba Cloud default TTL %d, must be positive", cfg.DefaultTTL)
+	}
+
 	// Public DNS service
 	var dnsClient AlibabaCloudDNSAPI
 	var err error
@@ -161,13 +255,20 @@ func NewAlibabaCloudProvider(configFile string, domainFilter *endpoint.DomainFil
 	}
 
 	provider := &AlibabaCloudProvider{
-		domainFilter: domainFilter,
-		zoneIDFilter: zoneIDFileter,
-		vpcID:        cfg.VPCID,
-		dryRun:       dryRun,
-		dnsClient:    dnsClient,
-		pvtzClient:   pvtzClient,
-		privateZone:  zoneType == "private",
+		domainFilter:       domainFilter,
+		zoneIDFilter:       zoneIDFileter,
+		vpcID:              cfg.VPCID,
+		dryRun:             dryRun,
+		dnsClient:          dnsClient,
+		pvtzClient:         pvtzClient,
+		privateZone:        zoneType == alibabaCloudZoneTypePrivate,
+		mixedZoneTypes:     cfg.MixedZoneTypes,
+		retry:              newAlibabaCloudRetry(cfg),
+		txtSeparator:       cfg.TXTSeparator,
+		strictZoneMatching: cfg.StrictZoneMatching,
+		batchChanges:       cfg.BatchChanges,
+		batchPollInterval:  defaultAlibabaCloudBatchPollInterval,
+		defaultRecordTTL:   cfg.DefaultTTL,
 	}
 
 	if cfg.RoleName != "" {
@@ -177,6 +278,49 @@ func NewAlibabaCloudProvider(configFile string, domainFilter *endpoint.DomainFil
 	return provider, nil
 }
 
+func newAlibabaCloudRetry(cfg alibabaCloudConfig) alibabaCloudRetry {
+	retry := alibabaCloudRetry{
+		maxAttempts: cfg.RetryMaxAttempts,
+		baseDelay:   cfg.RetryBaseDelay,
+	}
+	if retry.maxAttempts <= 0 {
+		retry.maxAttempts = defaultAlibabaCloudRetryMaxAttempts
+	}
+	if retry.baseDelay <= 0 {
+		retry.baseDelay = defaultAlibabaCloudRetryBaseDelay
+	}
+	return retry
+}
+
+// isThrottlingError reports whether err is one of the errors Alibaba Cloud returns when it is overloaded.
+func isThrottlingError(err error) bool {
+	var serverErr *aliyunerrors.ServerError
+	if !errors.As(err, &serverErr) {
+		return false
+	}
+	code := serverErr.ErrorCode()
+	return strings.HasPrefix(code, "Throttling") || code == "ServiceUnavailable"
+}
+
+// withRetry calls fn until it returns something else than a throttling error or the attempts
+// are exhausted, backing off exponentially in between. It stops early if ctx is done.
+func withRetry[T any](ctx context.Context, retry alibabaCloudRetry, fn func() (T, error)) (T, error) {
+	delay := retry.baseDelay
+	for attempt := 1; ; attempt++ {
+		result, err := fn()
+		if err == nil || attempt >= retry.maxAttempts || !isThrottlingError(err) {
+			return result, err
+		}
+		log.Debugf("Alibaba Cloud API call throttled, retrying in %s (attempt %d/%d): %v", delay, attempt, retry.maxAttempts, err)
+		select {
+		case <-ctx.Done():
+			return result, ctx.Err()
+		case <-time.After(delay):
+		}
+		delay *= 2
+	}
+}
+
 func getCloudConfigFromStsToken() (alibabaCloudConfig, error) {
 	cfg := alibabaCloudConfig{}
 	// Load config from Metadata Service
@@ -285,26 +429,246 @@ func (p *AlibabaCloudProvider) refreshStsToken(sleepTime time.Duration) {
 //
 // Returns the current records or an error if the operation failed.
 func (p *AlibabaCloudProvider) Records(ctx context.Context) ([]*endpoint.Endpoint, error) {
+	if p.mixedZoneTypes {
+		return p.mixedZoneTypeRecords(ctx)
+	}
 	if p.privateZone {
-		return p.privateZoneRecords()
+		return p.privateZoneRecords(ctx)
 	} else {
-		return p.recordsForDNS()
+		return p.recordsForDNS(ctx)
+	}
+}
+
+// mixedZoneTypeRecords gets the current records of both public zones and Private Zones.
+// Private Zone records are told apart by their zone type, which is also used as their set identifier.
+// The names of the zones are kept to select the zone type of endpoints in AdjustEndpoints.
+func (p *AlibabaCloudProvider) mixedZoneTypeRecords(ctx context.Context) ([]*endpoint.Endpoint, error) {
+	hostedZoneDomains, err := p.getDomainList(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("getting domain list: %w", err)
+	}
+	endpoints, err := p.dnsEndpoints(ctx, hostedZoneDomains)
+	if err != nil {
+		return nil, err
+	}
+	zones, err := p.getPrivateZones(ctx)
+	if err != nil {
+		return nil, err
+	}
+	privateEndpoints := p.privateZoneEndpoints(zones)
+	for _, ep := range privateEndpoints {
+		ep.WithSetIdentifier(alibabaCloudZoneTypePrivate).WithProviderSpecific(providerSpecificZoneType, alibabaCloudZoneTypePrivate)
+	}
+
+	p.zoneNamesLock.Lock()
+	p.publicZoneNames = hostedZoneDomains
+	p.privateZoneNames = keys(zones)
+	p.zoneNamesLock.Unlock()
+
+	return append(endpoints, privateEndpoints...), nil
+}
+
+// zoneTypeOfName returns the type of the most specific zone containing name among the zones found
+// by the last call to Records, or "" when there is none or a public zone and a Private Zone share its name.
+func (p *AlibabaCloudProvider) zoneTypeOfName(name string) string {
+	p.zoneNamesLock.RLock()
+	defer p.zoneNamesLock.RUnlock()
+
+	_, publicZone := p.splitDNSName(name, slices.Clone(p.publicZoneNames))
+	_, privateZone := p.splitDNSName(name, slices.Clone(p.privateZoneNames))
+	switch {
+	case publicZone == privateZone:
+		return ""
+	case len(privateZone) > len(publicZone):
+		return alibabaCloudZoneTypePrivate
+	default:
+		return alibabaCloudZoneTypePublic
+	}
+}
+
+// Healthcheck checks that the services of the zone types in use can be reached with the
+// configured credentials, by listing a single zone of each.
+func (p *AlibabaCloudProvider) Healthcheck(ctx context.Context) error {
+	if p.mixedZoneTypes || !p.privateZone {
+		request := alidns.CreateDescribeDomainsRequest()
+		request.PageSize = requests.NewInteger(1)
+		request.Scheme = defaultAlibabaCloudRequestScheme
+		if _, err := p.getDNSClient().DescribeDomains(request); err != nil {
+			return fmt.Errorf("describing Alibaba Cloud DNS domains: %w", err)
+		}
+	}
+	if p.mixedZoneTypes || p.privateZone {
+		request := pvtz.CreateDescribeZonesRequest()
+		request.PageSize = requests.NewInteger(1)
+		request.Domain = pVTZDoamin
+		request.Scheme = defaultAlibabaCloudRequestScheme
+		if _, err := p.getPvtzClient().DescribeZones(request); err != nil {
+			return fmt.Errorf("describing Alibaba Cloud Private Zones: %w", err)
+		}
+	}
+	return nil
+}
+
+// AdjustEndpoints uses the resolution line of each endpoint as its set identifier,
+// so that records of the same name differing only by line are planned separately.
+// Weighted endpoints keep their own set identifier instead.
+// When zone types are mixed, endpoints of Private Zones use their zone type as set identifier.
+func (p *AlibabaCloudProvider) AdjustEndpoints(endpoints []*endpoint.Endpoint) ([]*endpoint.Endpoint, error) {
+	if !p.mixedZoneTypes {
+		for _, ep := range endpoints {
+			ep.DeleteProviderSpecificProperty(providerSpecificZoneType)
+		}
+		if p.privateZone {
+			return endpoints, nil
+		}
+		p.adjustPublicEndpoints(endpoints)
+		return endpoints, nil
+	}
+
+	var public []*endpoint.Endpoint
+	for _, ep := range endpoints {
+		if p.endpointZoneType(ep) == alibabaCloudZoneTypePrivate {
+			ep.WithSetIdentifier(alibabaCloudZoneTypePrivate)
+			ep.SetProviderSpecificProperty(providerSpecificZoneType, alibabaCloudZoneTypePrivate)
+			continue
+		}
+		ep.DeleteProviderSpecificProperty(providerSpecificZoneType)
+		public = append(public, ep)
+	}
+	p.adjustPublicEndpoints(public)
+	return endpoints, nil
+}
+
+// endpointZoneType returns the zone type selected by an endpoint. When unset, it is the type of
+// the zone its name falls under, or the zone type of the provider if that is ambiguous.
+func (p *AlibabaCloudProvider) endpointZoneType(ep *endpoint.Endpoint) string {
+	zoneType, ok := ep.GetProviderSpecificProperty(providerSpecificZoneType)
+	switch {
+	case zoneType == alibabaCloudZoneTypePublic || zoneType == alibabaCloudZoneTypePrivate:
+		return zoneType
+	case ok:
+		log.Warnf("Ignoring invalid zone type %q of %s record named '%s', must be %q or %q", zoneType, ep.RecordType, ep.DNSName, alibabaCloudZoneTypePublic, alibabaCloudZoneTypePrivate)
+	}
+	if zoneType := p.zoneTypeOfName(ep.DNSName); zoneType != "" {
+		return zoneType
+	}
+	if p.privateZone {
+		return alibabaCloudZoneTypePrivate
+	}
+	return alibabaCloudZoneTypePublic
+}
+
+// adjustPublicEndpoints sets the set identifier of endpoints of public zones from their resolution line.
+func (p *AlibabaCloudProvider) adjustPublicEndpoints(endpoints []*endpoint.Endpoint) {
+	for _, ep := range endpoints {
+		line := endpointLine(ep)
+		if value, ok := ep.GetProviderSpecificProperty(providerSpecificWeight); ok && ep.SetIdentifier != "" {
+			// Normalize the weight so that it compares equal to the one read back from Alibaba Cloud.
+			if weight, err := strconv.Atoi(value); err == nil {
+				ep.SetProviderSpecificProperty(providerSpecificWeight, strconv.Itoa(weight))
+			}
+			if line == defaultAlibabaCloudLine {
+				ep.DeleteProviderSpecificProperty(providerSpecificLine)
+			}
+			continue
+		}
+		ep.DeleteProviderSpecificProperty(providerSpecificWeight)
+		if line == defaultAlibabaCloudLine {
+			ep.DeleteProviderSpecificProperty(providerSpecificLine)
+			continue
+		}
+		ep.SetIdentifier = line
+		ep.SetProviderSpecificProperty(providerSpecificLine, line)
 	}
 }
 
 // ApplyChanges applies the given changes.
 //
 // Returns nil if the operation was successful or an error if the operation failed.
-func (p *AlibabaCloudProvider) ApplyChanges(_ context.Context, changes *plan.Changes) error {
+func (p *AlibabaCloudProvider) ApplyChanges(ctx context.Context, changes *plan.Changes) error {
 	if changes == nil || len(changes.Create)+len(changes.Delete)+len(changes.UpdateNew) == 0 {
 		// No op
 		return nil
 	}
 
-	if p.privateZone {
-		return p.applyChangesForPrivateZone(changes)
+	// Invalid MX and SRV records are skipped so that the remaining changes can still be applied.
+	creates, createErrs := validateEndpoints(changes.Create)
+	updates, updateErrs := validateEndpoints(changes.UpdateNew)
+	changes = &plan.Changes{
+		Create:    creates,
+		UpdateOld: changes.UpdateOld,
+		UpdateNew: updates,
+		Delete:    changes.Delete,
+	}
+
+	var err error
+	switch {
+	case p.mixedZoneTypes:
+		err = p.applyChangesForZoneTypes(ctx, changes)
+	case p.privateZone:
+		err = p.applyChangesForPrivateZone(ctx, changes)
+	default:
+		err = p.applyChangesForDNS(ctx, changes)
+	}
+	if err != nil && !errors.Is(err, provider.SoftError) {
+		return err
+	}
+
+	if errs := append(createErrs, updateErrs...); len(errs) > 0 {
+		return errors.Join(err, provider.NewSoftErrorf("invalid records: %w", errors.Join(errs...)))
+	}
+	return err
+}
+
+// applyChangesForZoneTypes applies the changes of public zones and Private Zones separately,
+// telling them apart by the zone type set by AdjustEndpoints and Records.
+func (p *AlibabaCloudProvider) applyChangesForZoneTypes(ctx context.Context, changes *plan.Changes) error {
+	public, private := &plan.Changes{}, &plan.Changes{}
+	split := func(endpoints []*endpoint.Endpoint, publicEndpoints, privateEndpoints *[]*endpoint.Endpoint) {
+		for _, ep := range endpoints {
+			if zoneType, _ := ep.GetProviderSpecificProperty(providerSpecificZoneType); zoneType == alibabaCloudZoneTypePrivate {
+				*privateEndpoints = append(*privateEndpoints, ep)
+			} else {
+				*publicEndpoints = append(*publicEndpoints, ep)
+			}
+		}
+	}
+	split(changes.Create, &public.Create, &private.Create)
+	split(changes.UpdateOld, &public.UpdateOld, &private.UpdateOld)
+	split(changes.UpdateNew, &public.UpdateNew, &private.UpdateNew)
+	split(changes.Delete, &public.Delete, &private.Delete)
+
+	var errs []error
+	if public.HasChanges() {
+		errs = append(errs, p.applyChangesForDNS(ctx, public))
+	}
+	if private.HasChanges() {
+		errs = append(errs, p.applyChangesForPrivateZone(ctx, private))
+	}
+	for _, err := range errs {
+		if err != nil && !errors.Is(err, provider.SoftError) {
+			return err
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// checkZoneMatching returns a soft error listing the created or updated endpoints outside of
+// the given zones when strict zone matching is enabled. Otherwise these are only logged.
+func (p *AlibabaCloudProvider) checkZoneMatching(changes *plan.Changes, zones []string) error {
+	if !p.strictZoneMatching {
+		return nil
 	}
-	return p.applyChangesForDNS(changes)
+	var unmatched []string
+	for _, ep := range slices.Concat(changes.Create, changes.UpdateNew) {
+		if _, domain := p.splitDNSName(ep.DNSName, zones); domain == "" {
+			unmatched = append(unmatched, ep.DNSName)
+		}
+	}
+	if len(unmatched) > 0 {
+		return provider.NewSoftErrorf("no Alibaba Cloud zone found for: %s", strings.Join(unmatched, ", "))
+	}
+	return nil
 }
 
 func (p *AlibabaCloudProvider) getDNSName(rr, domain string) string {
@@ -317,8 +681,17 @@ func (p *AlibabaCloudProvider) getDNSName(rr, domain string) string {
 // recordsForDNS gets the current records.
 //
 // Returns the current records or an error if the operation failed.
-func (p *AlibabaCloudProvider) recordsForDNS() ([]*endpoint.Endpoint, error) {
-	records, err := p.records()
+func (p *AlibabaCloudProvider) recordsForDNS(ctx context.Context) ([]*endpoint.Endpoint, error) {
+	hostedZoneDomains, err := p.getDomainList(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("getting domain list: %w", err)
+	}
+	return p.dnsEndpoints(ctx, hostedZoneDomains)
+}
+
+// dnsEndpoints gets the current records of the given public zones as endpoints.
+func (p *AlibabaCloudProvider) dnsEndpoints(ctx context.Context, hostedZoneDomains []string) ([]*endpoint.Endpoint, error) {
+	records, err := p.records(ctx, hostedZoneDomains)
 	if err != nil {
 		return nil, err
 	}
@@ -330,13 +703,19 @@ func (p *AlibabaCloudProvider) recordsForDNS() ([]*endpoint.Endpoint, error) {
 
 		var targets []string
 		for _, record := range recordList {
-			target := record.Value
-			if recordType == "TXT" {
-				target = p.unescapeTXTRecordValue(target)
-			}
+			target := p.dnsRecordTarget(record)
 			targets = append(targets, target)
 		}
 		ep := endpoint.NewEndpointWithTTL(name, recordType, endpoint.TTL(ttl), targets...)
+		line := recordLine(recordList[0])
+		if setIdentifier, ok := recordSetIdentifier(recordList[0]); ok {
+			ep.WithSetIdentifier(setIdentifier).WithProviderSpecific(providerSpecificWeight, strconv.Itoa(recordList[0].Weight))
+			if line != defaultAlibabaCloudLine {
+				ep.WithProviderSpecific(providerSpecificLine, line)
+			}
+		} else if line != defaultAlibabaCloudLine {
+			ep.WithSetIdentifier(line).WithProviderSpecific(providerSpecificLine, line)
+		}
 		endpoints = append(endpoints, ep)
 	}
 	return endpoints, nil
@@ -350,14 +729,51 @@ func getNextPageNumber(pageNumber, pageSize, totalCount int64) int64 {
 }
 
 func (p *AlibabaCloudProvider) getRecordKey(record alidns.Record) string {
-	if record.RR == nullHostAlibabaCloud {
-		return record.Type + ":" + record.DomainName
+	key := record.Type + ":" + p.getDNSName(record.RR, record.DomainName) + ":" + recordLine(record)
+	if setIdentifier, ok := recordSetIdentifier(record); ok {
+		key += ":" + setIdentifier
 	}
-	return record.Type + ":" + record.RR + "." + record.DomainName
+	return key
 }
 
 func (p *AlibabaCloudProvider) getRecordKeyByEndpoint(endpoint *endpoint.Endpoint) string {
-	return endpoint.RecordType + ":" + endpoint.DNSName
+	key := endpoint.RecordType + ":" + endpoint.DNSName + ":" + endpointLine(endpoint)
+	if _, ok := endpointWeight(endpoint); ok {
+		key += ":" + endpoint.SetIdentifier
+	}
+	return key
+}
+
+// recordLine returns the resolution line of a record, which is the default line when unset.
+func recordLine(record alidns.Record) string {
+	if record.Line == "" {
+		return defaultAlibabaCloudLine
+	}
+	return record.Line
+}
+
+// endpointLine returns the resolution line requested by an endpoint, which is the default line when unset.
+func endpointLine(ep *endpoint.Endpoint) string {
+	if line, ok := ep.GetProviderSpecificProperty(providerSpecificLine); ok && line != "" {
+		return line
+	}
+	return defaultAlibabaCloudLine
+}
+
+// recordSetIdentifier returns the set identifier of a weighted record, stored in its remark.
+func recordSetIdentifier(record alidns.Record) (string, bool) {
+	return strings.CutPrefix(record.Remark, setIdentifierRemarkPrefix)
+}
+
+// endpointWeight returns the weight of an endpoint and whether it is weighted,
+// which takes a set identifier along with the weight property.
+func endpointWeight(ep *endpoint.Endpoint) (int, bool) {
+	value, ok := ep.GetProviderSpecificProperty(providerSpecificWeight)
+	if !ok || ep.SetIdentifier == "" {
+		return 0, false
+	}
+	weight, err := strconv.Atoi(value)
+	return weight, err == nil
 }
 
 func (p *AlibabaCloudProvider) groupRecords(records []alidns.Record) map[string][]alidns.Record {
@@ -371,16 +787,13 @@ func (p *AlibabaCloudProvider) groupRecords(records []alidns.Record) map[string]
 	return endpointMap
 }
 
-func (p *AlibabaCloudProvider) records() ([]alidns.Record, error) {
+// records gets the current records of the given hosted zones.
+func (p *AlibabaCloudProvider) records(ctx context.Context, hostedZoneDomains []string) ([]alidns.Record, error) {
 	log.Infof("Retrieving Alibaba Cloud DNS Domain Records")
 	var results []alidns.Record
-	hostedZoneDomains, err := p.getDomainList()
-	if err != nil {
-		return results, fmt.Errorf("getting domain list: %w", err)
-	}
 	if !p.domainFilter.IsConfigured() {
 		for _, zoneDomain := range hostedZoneDomains {
-			domainRecords, err := p.getDomainRecords(zoneDomain)
+			domainRecords, err := p.getDomainRecords(ctx, zoneDomain)
 			if err != nil {
 				return nil, fmt.Errorf("getDomainRecords %q: %w", zoneDomain, err)
 			}
@@ -389,7 +802,7 @@ func (p *AlibabaCloudProvider) records() ([]alidns.Record, error) {
 	} else {
 		for _, domainName := range p.domainFilter.Filters {
 			_, domainName = p.splitDNSName(domainName, hostedZoneDomains)
-			tmpResults, err := p.getDomainRecords(domainName)
+			tmpResults, err := p.getDomainRecords(ctx, domainName)
 			if err != nil {
 				log.Errorf("getDomainRecords %s error %v", domainName, err)
 				continue
@@ -401,14 +814,16 @@ func (p *AlibabaCloudProvider) records() ([]alidns.Record, error) {
 	return results, nil
 }
 
-func (p *AlibabaCloudProvider) getDomainList() ([]string, error) {
+func (p *AlibabaCloudProvider) getDomainList(ctx context.Context) ([]string, error) {
 	var domainNames []string
 	request := alidns.CreateDescribeDomainsRequest()
 	request.PageSize = requests.NewInteger(defaultAlibabaCloudPageSize)
 	request.PageNumber = "1"
 	request.Scheme = defaultAlibabaCloudRequestScheme
 	for {
-		resp, err := p.dnsClient.DescribeDomains(request)
+		resp, err := withRetry(ctx, p.retry, func() (*alidns.DescribeDomainsResponse, error) {
+			return p.getDNSClient().DescribeDomains(request)
+		})
 		if err != nil {
 			log.Errorf("Failed to describe domains for Alibaba Cloud DNS: %v", err)
 			return nil, err
@@ -426,7 +841,7 @@ func (p *AlibabaCloudProvider) getDomainList() ([]string, error) {
 	return domainNames, nil
 }
 
-func (p *AlibabaCloudProvider) getDomainRecords(domainName string) ([]alidns.Record, error) {
+func (p *AlibabaCloudProvider) getDomainRecords(ctx context.Context, domainName string) ([]alidns.Record, error) {
 	var results []alidns.Record
 	request := alidns.CreateDescribeDomainRecordsRequest()
 	request.DomainName = domainName
@@ -434,26 +849,33 @@ func (p *AlibabaCloudProvider) getDomainRecords(domainName string) ([]alidns.Rec
 	request.PageNumber = "1"
 	request.Scheme = defaultAlibabaCloudRequestScheme
 	for {
-		response, err := p.getDNSClient().DescribeDomainRecords(request)
+		response, err := withRetry(ctx, p.retry, func() (*alidns.DescribeDomainRecordsResponse, error) {
+			return p.getDNSClient().DescribeDomainRecords(request)
+		})
 		if err != nil {
 			log.Errorf("Failed to describe domain records for Alibaba Cloud DNS: %v", err)
 			return nil, err
 		}
 
 		for _, record := range response.DomainRecords.Record {
-			domainName := record.RR + "." + record.DomainName
+			domainName := p.getDNSName(record.RR, record.DomainName)
 			recordType := record.Type
 
 			if !p.domainFilter.Match(domainName) {
 				continue
 			}
-			if !provider.SupportedRecordType(recordType) {
+			if !supportedRecordType(recordType) {
 				continue
 			}
 			// TODO filter Locked record
 			results = append(results, record)
 		}
-		nextPage := getNextPageNumber(response.PageNumber, defaultAlibabaCloudPageSize, response.TotalCount)
+		// The service may cap the page size below the requested one, so page using the size it reports.
+		pageSize := response.PageSize
+		if pageSize <= 0 {
+			pageSize = defaultAlibabaCloudPageSize
+		}
+		nextPage := getNextPageNumber(response.PageNumber, pageSize, response.TotalCount)
 		if nextPage == 0 {
 			break
 		} else {
@@ -464,44 +886,311 @@ func (p *AlibabaCloudProvider) getDomainRecords(domainName string) ([]alidns.Rec
 	return results, nil
 }
 
-func (p *AlibabaCloudProvider) applyChangesForDNS(changes *plan.Changes) error {
+func (p *AlibabaCloudProvider) applyChangesForDNS(ctx context.Context, changes *plan.Changes) error {
 	log.Infof("ApplyChanges to Alibaba Cloud DNS: %++v", *changes)
 
-	records, err := p.records()
+	// The hosted zones are listed once per call, so that zones created in the meantime are picked up by the next one.
+	hostedZoneDomains, err := p.getDomainList(ctx)
 	if err != nil {
+		return fmt.Errorf("getting domain list: %w", err)
+	}
+
+	records, err := p.records(ctx, hostedZoneDomains)
+	if err != nil {
+		return err
+	}
+
+	if err := p.setDefaultTTL(ctx, changes, hostedZoneDomains); err != nil {
 		return err
 	}
 
 	recordMap := p.groupRecords(records)
 
-	hostedZoneDomains, err := p.getDomainList()
-	if err != nil {
-		return fmt.Errorf("getting domain list: %w", err)
+	if batcher, ok := p.getDNSClient().(alibabaCloudDNSBatchAPI); ok && p.batchChanges && !p.dryRun {
+		p.createRecordsInBatches(ctx, batcher, changes.Create, hostedZoneDomains)
+		p.deleteRecordsInBatches(ctx, batcher, p.matchDeletedRecords(recordMap, changes.Delete))
+	} else {
+		p.createRecords(ctx, changes.Create, hostedZoneDomains)
+		p.deleteRecords(ctx, recordMap, changes.Delete)
 	}
+	// Alibaba Cloud DNS has no batch operation updating records in place.
+	p.updateRecords(ctx, recordMap, changes.UpdateNew, hostedZoneDomains)
+	return p.checkZoneMatching(changes, hostedZoneDomains)
+}
 
-	p.createRecords(changes.Create, hostedZoneDomains)
-	p.deleteRecords(recordMap, changes.Delete)
-	p.updateRecords(recordMap, changes.UpdateNew, hostedZoneDomains)
+// getDefaultTTL returns the TTL of public zone records whose endpoint has none.
+func (p *AlibabaCloudProvider) getDefaultTTL() int64 {
+	if p.defaultRecordTTL == 0 {
+		return defaultTTL
+	}
+	return p.defaultRecordTTL
+}
+
+// setDefaultTTL sets the default TTL on the created and updated endpoints without one,
+// after checking it against the minimum TTL of the plan of their zones.
+func (p *AlibabaCloudProvider) setDefaultTTL(ctx context.Context, changes *plan.Changes, hostedZoneDomains []string) error {
+	ttl := p.getDefaultTTL()
+	minTTLs := make(map[string]int64)
+	for _, ep := range slices.Concat(changes.Create, changes.UpdateNew) {
+		if ep.RecordTTL.IsConfigured() {
+			continue
+		}
+		_, domain := p.splitDNSName(ep.DNSName, hostedZoneDomains)
+		// The built-in default is allowed by every plan, so zones are only described for lower TTLs.
+		if domain != "" && ttl < defaultTTL {
+			minTTL, ok := minTTLs[domain]
+			if !ok {
+				var err error
+				if minTTL, err = p.getMinTTL(ctx, domain); err != nil {
+					return fmt.Errorf("getting minimum TTL of zone %s: %w", domain, err)
+				}
+				minTTLs[domain] = minTTL
+			}
+			if ttl < minTTL {
+				return fmt.Errorf("default TTL %d is below the minimum TTL %d allowed by the Alibaba Cloud DNS plan of zone %s", ttl, minTTL, domain)
+			}
+		}
+		ep.RecordTTL = endpoint.TTL(ttl)
+	}
 	return nil
 }
 
+// getMinTTL returns the minimum TTL allowed by the plan of a zone, or 0 if the client cannot tell.
+func (p *AlibabaCloudProvider) getMinTTL(ctx context.Context, domain string) (int64, error) {
+	describer, ok := p.getDNSClient().(alibabaCloudDNSDomainInfoAPI)
+	if !ok {
+		return 0, nil
+	}
+	request := alidns.CreateDescribeDomainInfoRequest()
+	request.DomainName = domain
+	request.Scheme = defaultAlibabaCloudRequestScheme
+	response, err := withRetry(ctx, p.retry, func() (*alidns.DescribeDomainInfoResponse, error) {
+		return describer.DescribeDomainInfo(request)
+	})
+	if err != nil {
+		return 0, err
+	}
+	return response.MinTtl, nil
+}
+
+// escapeTXTRecordValue converts an endpoint TXT target into the value stored in Alibaba Cloud.
+// Heritage strings are stored unquoted with the configured separator, unless it is the registry's own.
+// Values longer than a single character-string are split, see splitTXTValue.
 func (p *AlibabaCloudProvider) escapeTXTRecordValue(value string) string {
-	// For unsupported chars
-	return value
+	return splitTXTValue(p.escapeTXTHeritage(value))
 }
 
+// escapeTXTHeritage stores heritage strings with the configured separator, see escapeTXTRecordValue.
+func (p *AlibabaCloudProvider) escapeTXTHeritage(value string) string {
+	if p.txtSeparator == "" || p.txtSeparator == registryTXTSeparator {
+		return value
+	}
+	heritage, ok := strings.CutPrefix(value, "\"")
+	if !ok {
+		return value
+	}
+	heritage, ok = strings.CutSuffix(heritage, "\"")
+	if !ok || !strings.HasPrefix(heritage, txtHeritagePrefix) {
+		return value
+	}
+	return strings.ReplaceAll(heritage, registryTXTSeparator, p.txtSeparator)
+}
+
+// unescapeTXTRecordValue converts a TXT value stored in Alibaba Cloud into an endpoint target.
+// Values split into several character-strings are joined back together first.
+// Unquoted heritage strings are quoted and their pairs separated by the registry's separator,
+// regardless of the configured separator so that records written with either one are recognized.
 func (p *AlibabaCloudProvider) unescapeTXTRecordValue(value string) string {
-	if strings.HasPrefix(value, "heritage=") {
-		return fmt.Sprintf("\"%s\"", strings.ReplaceAll(value, ";", ","))
+	if joined, ok := joinTXTValue(value); ok {
+		value = joined
+	}
+	if !strings.HasPrefix(value, txtHeritagePrefix) {
+		return value
+	}
+	return fmt.Sprintf("\"%s\"", strings.ReplaceAll(value, legacyAlibabaCloudTXTSeparator, registryTXTSeparator))
+}
+
+// splitTXTValue splits a TXT value longer than maxTXTStringLength bytes into quoted character-strings
+// separated by spaces, e.g. `"v=DKIM1; p=MIIB..." "...IDAQAB"`, escaping quotes and backslashes.
+// Quoted heritage strings are split without their quotes, which unescapeTXTRecordValue adds back.
+// Chunks never end in the middle of a UTF-8 character. Shorter values are returned as-is.
+func splitTXTValue(value string) string {
+	if len(value) <= maxTXTStringLength {
+		return value
+	}
+	if heritage, ok := strings.CutPrefix(value, "\""+txtHeritagePrefix); ok && strings.HasSuffix(heritage, "\"") {
+		value = strings.TrimSuffix(strings.TrimPrefix(value, "\""), "\"")
+	}
+
+	var chunks []string
+	for len(value) > 0 {
+		end := min(len(value), maxTXTStringLength)
+		for end < len(value) && end > 1 && !utf8.RuneStart(value[end]) {
+			end--
+		}
+		chunk := strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(value[:end])
+		chunks = append(chunks, `"`+chunk+`"`)
+		value = value[end:]
+	}
+	return strings.Join(chunks, " ")
+}
+
+// joinTXTValue joins a TXT value made of several quoted character-strings, as written by splitTXTValue.
+// It reports false for values which are not made of at least two quoted character-strings.
+func joinTXTValue(value string) (string, bool) {
+	var joined strings.Builder
+	count := 0
+	for rest := strings.TrimSpace(value); rest != ""; rest = strings.TrimLeft(rest, " ") {
+		if rest[0] != '"' {
+			return "", false
+		}
+		closed := false
+		i := 1
+		for ; i < len(rest); i++ {
+			switch rest[i] {
+			case '\\':
+				i++
+				if i < len(rest) {
+					joined.WriteByte(rest[i])
+				}
+				continue
+			case '"':
+				closed = true
+			default:
+				joined.WriteByte(rest[i])
+				continue
+			}
+			break
+		}
+		if !closed {
+			return "", false
+		}
+		count++
+		rest = rest[i+1:]
+		if rest != "" && rest[0] != ' ' {
+			return "", false
+		}
+	}
+	return joined.String(), count > 1
+}
+
+// recordTarget converts the value of an Alibaba Cloud record into an endpoint target.
+func (p *AlibabaCloudProvider) recordTarget(recordType, value string, priority int64) string {
+	switch recordType {
+	case "TXT":
+		return p.unescapeTXTRecordValue(value)
+	case "MX":
+		return fmt.Sprintf("%d %s", priority, value)
+	case "CAA":
+		if target, err := formatCAATarget(value); err == nil {
+			return target
+		}
 	}
 	return value
 }
 
-func (p *AlibabaCloudProvider) createRecord(endpoint *endpoint.Endpoint, target string, hostedZoneDomains []string) error {
+// formatCAATarget parses a CAA target like `0 issue "letsencrypt.org"` and returns it in the
+// canonical form stored by Alibaba Cloud DNS, with a lower case tag and a quoted value.
+func formatCAATarget(target string) (string, error) {
+	caa, err := endpoint.NewCAARecord(target)
+	if err != nil {
+		return "", err
+	}
+	return caa.String(), nil
+}
+
+// splitMXTarget splits an MX target like "10 mail.example.com" into its priority and host,
+// which Alibaba Cloud stores in separate fields.
+func splitMXTarget(target string) (int, string, error) {
+	mx, err := endpoint.NewMXRecord(target)
+	if err != nil {
+		return 0, "", err
+	}
+	return int(*mx.GetPriority()), *mx.GetHost(), nil
+}
+
+// dnsRecordTarget converts an Alibaba Cloud DNS record into an endpoint target.
+// Unlike Private Zone, Alibaba Cloud DNS stores the priority of SRV records in a separate field.
+func (p *AlibabaCloudProvider) dnsRecordTarget(record alidns.Record) string {
+	if record.Type == endpoint.RecordTypeSRV {
+		return fmt.Sprintf("%d %s", record.Priority, record.Value)
+	}
+	return p.recordTarget(record.Type, record.Value, record.Priority)
+}
+
+// hasDNSPriority reports whether Alibaba Cloud DNS stores the priority of records of the given type in a separate field.
+func hasDNSPriority(recordType string) bool {
+	return recordType == endpoint.RecordTypeMX || recordType == endpoint.RecordTypeSRV
+}
+
+// splitDNSPriorityTarget splits an MX or SRV target into the priority and value stored by Alibaba Cloud DNS.
+func splitDNSPriorityTarget(recordType, target string) (int, string, error) {
+	if recordType == endpoint.RecordTypeSRV {
+		return splitSRVTarget(target)
+	}
+	return splitMXTarget(target)
+}
+
+// splitSRVTarget splits an SRV target like "10 5 5060 sip.example.com" into its priority
+// and the remaining "weight port target" value.
+func splitSRVTarget(target string) (int, string, error) {
+	if !endpoint.NewTargets(target).ValidateSRVRecord() {
+		return 0, "", fmt.Errorf("invalid SRV record target: %s. SRV records must have a priority, weight, and port value, e.g. '10 5 5060 example.com'", target)
+	}
+	parts := strings.Fields(target)
+	priority, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return 0, "", err
+	}
+	return priority, strings.Join(parts[1:], " "), nil
+}
+
+// supportedRecordType reports whether records of the given type are managed by the provider.
+func supportedRecordType(recordType string) bool {
+	return recordType == endpoint.RecordTypeMX || recordType == endpoint.RecordTypeCAA || provider.SupportedRecordType(recordType)
+}
+
+// validateEndpoints splits off MX, SRV and CAA endpoints with malformed targets, and weighted endpoints with invalid weights.
+func validateEndpoints(endpoints []*endpoint.Endpoint) ([]*endpoint.Endpoint, []error) {
+	valid := make([]*endpoint.Endpoint, 0, len(endpoints))
+	var errs []error
+	for _, ep := range endpoints {
+		if !ep.CheckEndpoint() {
+			errs = append(errs, fmt.Errorf("%s record %s has malformed targets: %v", ep.RecordType, ep.DNSName, ep.Targets))
+			continue
+		}
+		if value, ok := ep.GetProviderSpecificProperty(providerSpecificWeight); ok && ep.SetIdentifier != "" {
+			if weight, err := strconv.Atoi(value); err != nil || weight < minAlibabaCloudWeight || weight > maxAlibabaCloudWeight {
+				errs = append(errs, fmt.Errorf("%s record %s has an invalid weight %q, expected %d to %d",
+					ep.RecordType, ep.DNSName, value, minAlibabaCloudWeight, maxAlibabaCloudWeight))
+				continue
+			}
+		}
+		valid = append(valid, ep)
+	}
+	return valid, errs
+}
+
+func (p *AlibabaCloudProvider) createRecord(ctx context.Context, endpoint *endpoint.Endpoint, target string, hostedZoneDomains []string) error {
+	request, err := p.newAddDomainRecordRequest(endpoint, target, hostedZoneDomains)
+	if err != nil {
+		return err
+	}
+
+	if p.dryRun {
+		log.Infof("Dry run: Create %s record named '%s' to '%s' with ttl %d for Alibaba Cloud DNS", endpoint.RecordType, endpoint.DNSName, request.Value, endpoint.RecordTTL)
+		return nil
+	}
+
+	return p.addDomainRecord(ctx, endpoint, request)
+}
+
+// newAddDomainRecordRequest builds the request creating the record of the given endpoint target.
+func (p *AlibabaCloudProvider) newAddDomainRecordRequest(endpoint *endpoint.Endpoint, target string, hostedZoneDomains []string) (*alidns.AddDomainRecordRequest, error) {
 	if len(hostedZoneDomains) == 0 {
 		log.Errorf("Failed to create %s record named '%s' to '%s' for Alibaba Cloud DNS: zone not found",
 			endpoint.RecordType, endpoint.DNSName, target)
-		return fmt.Errorf("zone not found")
+		return nil, fmt.Errorf("zone not found")
 	}
 
 	rr, domain := p.splitDNSName(endpoint.DNSName, hostedZoneDomains)
@@ -509,7 +1198,7 @@ func (p *AlibabaCloudProvider) createRecord(endpoint *endpoint.Endpoint, target
 	if domain == "" {
 		log.Errorf("Failed to create %s record named '%s' to '%s' for Alibaba Cloud DNS: no corresponding DNS zone found for this domain '%s'",
 			endpoint.RecordType, endpoint.DNSName, target, endpoint.DNSName)
-		return fmt.Errorf("no corresponding DNS zone found for this domain")
+		return nil, fmt.Errorf("no corresponding DNS zone found for this domain")
 	}
 
 	request := alidns.CreateAddDomainRecordRequest()
@@ -523,36 +1212,138 @@ func (p *AlibabaCloudProvider) createRecord(endpoint *endpoint.Endpoint, target
 		request.TTL = requests.NewInteger(ttl)
 	}
 
+	if line := endpointLine(endpoint); line != defaultAlibabaCloudLine {
+		request.Line = line
+	}
+
 	if endpoint.RecordType == "TXT" {
 		target = p.escapeTXTRecordValue(target)
 	}
 
+	if hasDNSPriority(endpoint.RecordType) {
+		priority, value, err := splitDNSPriorityTarget(endpoint.RecordType, target)
+		if err != nil {
+			log.Errorf("Failed to create %s record named '%s' to '%s' for Alibaba Cloud DNS: %v", endpoint.RecordType, endpoint.DNSName, target, err)
+			return nil, err
+		}
+		request.Priority = requests.NewInteger(priority)
+		target = value
+	}
+
+	if endpoint.RecordType == "CAA" {
+		value, err := formatCAATarget(target)
+		if err != nil {
+			log.Errorf("Failed to create %s record named '%s' to '%s' for Alibaba Cloud DNS: %v", endpoint.RecordType, endpoint.DNSName, target, err)
+			return nil, err
+		}
+		target = value
+	}
+
 	request.Value = target
+	return request, nil
+}
+
+func (p *AlibabaCloudProvider) addDomainRecord(ctx context.Context, endpoint *endpoint.Endpoint, request *alidns.AddDomainRecordRequest) error {
+	response, err := withRetry(ctx, p.retry, func() (*alidns.AddDomainRecordResponse, error) {
+		return p.getDNSClient().AddDomainRecord(request)
+	})
+	if err != nil {
+		log.Errorf("Failed to create %s record named '%s' to '%s' with ttl %d for Alibaba Cloud DNS: %v", endpoint.RecordType, endpoint.DNSName, request.Value, endpoint.RecordTTL, err)
+		return err
+	}
+	log.Infof("Create %s record named '%s' to '%s' with ttl %d for Alibaba Cloud DNS: Record ID=%s", endpoint.RecordType, endpoint.DNSName, request.Value, endpoint.RecordTTL, response.RecordId)
+
+	if weight, ok := endpointWeight(endpoint); ok {
+		record := alidns.Record{
+			RecordId:   response.RecordId,
+			DomainName: request.DomainName,
+			RR:         request.RR,
+			Type:       request.Type,
+			Line:       request.Line,
+		}
+		if err := p.setRecordSetIdentifier(ctx, record, endpoint.SetIdentifier); err != nil {
+			return err
+		}
+		return p.weightRecord(ctx, record, weight)
+	}
+	return nil
+}
+
+// setRecordSetIdentifier stores the set identifier of a weighted record in its remark.
+func (p *AlibabaCloudProvider) setRecordSetIdentifier(ctx context.Context, record alidns.Record, setIdentifier string) error {
+	weighter, ok := p.getDNSClient().(alibabaCloudDNSWeightAPI)
+	if !ok {
+		log.Errorf("Failed to set the set identifier of record '%s' in Alibaba Cloud DNS: weighted records are not supported by the client", record.RecordId)
+		return errors.New("weighted records are not supported by the client")
+	}
 
+	request := alidns.CreateUpdateDomainRecordRemarkRequest()
+	request.RecordId = record.RecordId
+	request.Remark = setIdentifierRemarkPrefix + setIdentifier
+	request.Scheme = defaultAlibabaCloudRequestScheme
+	_, err := withRetry(ctx, p.retry, func() (*alidns.UpdateDomainRecordRemarkResponse, error) {
+		return weighter.UpdateDomainRecordRemark(request)
+	})
+	if err != nil {
+		log.Errorf("Failed to set the set identifier of record '%s' to '%s' in Alibaba Cloud DNS: %v", record.RecordId, setIdentifier, err)
+	}
+	return err
+}
+
+// weightRecord turns on weighted round robin for the records sharing the name, type and line
+// of the given record, and sets its weight.
+func (p *AlibabaCloudProvider) weightRecord(ctx context.Context, record alidns.Record, weight int) error {
 	if p.dryRun {
-		log.Infof("Dry run: Create %s record named '%s' to '%s' with ttl %d for Alibaba Cloud DNS", endpoint.RecordType, endpoint.DNSName, target, ttl)
+		log.Infof("Dry run: Set weight of record id '%s' to %d in Alibaba Cloud DNS", record.RecordId, weight)
 		return nil
 	}
 
-	response, err := p.getDNSClient().AddDomainRecord(request)
+	weighter, ok := p.getDNSClient().(alibabaCloudDNSWeightAPI)
+	if !ok {
+		log.Errorf("Failed to set weight of record '%s' in Alibaba Cloud DNS: weighted records are not supported by the client", record.RecordId)
+		return errors.New("weighted records are not supported by the client")
+	}
+
+	statusRequest := alidns.CreateSetDNSSLBStatusRequest()
+	statusRequest.DomainName = record.DomainName
+	statusRequest.SubDomain = p.getDNSName(record.RR, record.DomainName)
+	statusRequest.Type = record.Type
+	statusRequest.Line = record.Line
+	statusRequest.Open = requests.NewBoolean(true)
+	statusRequest.Scheme = defaultAlibabaCloudRequestScheme
+	_, err := withRetry(ctx, p.retry, func() (*alidns.SetDNSSLBStatusResponse, error) {
+		return weighter.SetDNSSLBStatus(statusRequest)
+	})
+	if err != nil {
+		log.Errorf("Failed to enable weighted records named '%s' in Alibaba Cloud DNS: %v", statusRequest.SubDomain, err)
+		return err
+	}
+
+	weightRequest := alidns.CreateUpdateDNSSLBWeightRequest()
+	weightRequest.RecordId = record.RecordId
+	weightRequest.Weight = requests.NewInteger(weight)
+	weightRequest.Scheme = defaultAlibabaCloudRequestScheme
+	_, err = withRetry(ctx, p.retry, func() (*alidns.UpdateDNSSLBWeightResponse, error) {
+		return weighter.UpdateDNSSLBWeight(weightRequest)
+	})
 	if err == nil {
-		log.Infof("Create %s record named '%s' to '%s' with ttl %d for Alibaba Cloud DNS: Record ID=%s", endpoint.RecordType, endpoint.DNSName, target, ttl, response.RecordId)
+		log.Infof("Set weight of record id '%s' to %d in Alibaba Cloud DNS", record.RecordId, weight)
 	} else {
-		log.Errorf("Failed to create %s record named '%s' to '%s' with ttl %d for Alibaba Cloud DNS: %v", endpoint.RecordType, endpoint.DNSName, target, ttl, err)
+		log.Errorf("Failed to set weight of record '%s' to %d in Alibaba Cloud DNS: %v", record.RecordId, weight, err)
 	}
 	return err
 }
 
-func (p *AlibabaCloudProvider) createRecords(endpoints []*endpoint.Endpoint, hostedZoneDomains []string) error {
+func (p *AlibabaCloudProvider) createRecords(ctx context.Context, endpoints []*endpoint.Endpoint, hostedZoneDomains []string) error {
 	for _, endpoint := range endpoints {
 		for _, target := range endpoint.Targets {
-			p.createRecord(endpoint, target, hostedZoneDomains)
+			p.createRecord(ctx, endpoint, target, hostedZoneDomains)
 		}
 	}
 	return nil
 }
 
-func (p *AlibabaCloudProvider) deleteRecord(recordID string) error {
+func (p *AlibabaCloudProvider) deleteRecord(ctx context.Context, recordID string) error {
 	if p.dryRun {
 		log.Infof("Dry run: Delete record id '%s' in Alibaba Cloud DNS", recordID)
 		return nil
@@ -561,7 +1352,9 @@ func (p *AlibabaCloudProvider) deleteRecord(recordID string) error {
 	request := alidns.CreateDeleteDomainRecordRequest()
 	request.RecordId = recordID
 	request.Scheme = defaultAlibabaCloudRequestScheme
-	response, err := p.getDNSClient().DeleteDomainRecord(request)
+	response, err := withRetry(ctx, p.retry, func() (*alidns.DeleteDomainRecordResponse, error) {
+		return p.getDNSClient().DeleteDomainRecord(request)
+	})
 	if err == nil {
 		log.Infof("Delete record id %s in Alibaba Cloud DNS", response.RecordId)
 	} else {
@@ -570,41 +1363,59 @@ func (p *AlibabaCloudProvider) deleteRecord(recordID string) error {
 	return err
 }
 
-func (p *AlibabaCloudProvider) updateRecord(record alidns.Record, endpoint *endpoint.Endpoint) error {
+func (p *AlibabaCloudProvider) updateRecord(ctx context.Context, record alidns.Record, endpoint *endpoint.Endpoint) error {
 	request := alidns.CreateUpdateDomainRecordRequest()
 	request.RecordId = record.RecordId
 	request.RR = record.RR
 	request.Type = record.Type
 	request.Value = record.Value
+	request.Line = record.Line
+	if hasDNSPriority(record.Type) {
+		request.Priority = requests.NewInteger64(record.Priority)
+	}
 	request.Scheme = defaultAlibabaCloudRequestScheme
 	ttl := int(endpoint.RecordTTL)
 	if ttl != 0 {
 		request.TTL = requests.NewInteger(ttl)
 	}
-	response, err := p.getDNSClient().UpdateDomainRecord(request)
+
+	if p.dryRun {
+		log.Infof("Dry run: Update record id '%s' to '%s' with ttl %d in Alibaba Cloud DNS", record.RecordId, record.Value, ttl)
+		return nil
+	}
+
+	response, err := withRetry(ctx, p.retry, func() (*alidns.UpdateDomainRecordResponse, error) {
+		return p.getDNSClient().UpdateDomainRecord(request)
+	})
 	if err == nil {
 		log.Infof("Update record id '%s' in Alibaba Cloud DNS", response.RecordId)
 	} else {
-		log.Errorf("Failed to update record '%s' in Alibaba Cloud DNS: %v", response.RecordId, err)
+		log.Errorf("Failed to update record '%s' in Alibaba Cloud DNS: %v", record.RecordId, err)
 	}
 	return err
 }
 
-func (p *AlibabaCloudProvider) deleteRecords(recordMap map[string][]alidns.Record, endpoints []*endpoint.Endpoint) error {
+func (p *AlibabaCloudProvider) deleteRecords(ctx context.Context, recordMap map[string][]alidns.Record, endpoints []*endpoint.Endpoint) error {
+	for _, record := range p.matchDeletedRecords(recordMap, endpoints) {
+		p.deleteRecord(ctx, record.RecordId)
+	}
+	return nil
+}
+
+// matchDeletedRecords returns the records matching the targets of the deleted endpoints.
+func (p *AlibabaCloudProvider) matchDeletedRecords(recordMap map[string][]alidns.Record, endpoints []*endpoint.Endpoint) []alidns.Record {
+	var matched []alidns.Record
 	for _, endpoint := range endpoints {
 		key := p.getRecordKeyByEndpoint(endpoint)
 		records := recordMap[key]
 		found := false
 		for _, record := range records {
-			value := record.Value
-			if record.Type == "TXT" {
-				value = p.unescapeTXTRecordValue(value)
-			}
+			value := p.dnsRecordTarget(record)
 
 			for _, target := range endpoint.Targets {
 				// Find matched record to delete
-				if value == target {
-					p.deleteRecord(record.RecordId)
+				if sameRecordValue(endpoint.RecordType, value, target) {
+					matched = append(matched, record)
 					found = true
 					break
 				}
@@ -614,67 +1425,260 @@ func (p *AlibabaCloudProvider) deleteRecords(recordMap map[string][]alidns.Recor
 			log.Errorf("Failed to find %s record named '%s' to delete for Alibaba Cloud DNS", endpoint.RecordType, endpoint.DNSName)
 		}
 	}
-	return nil
+	return matched
+}
+
+// alibabaCloudBatchOperation is a record operation of a batch task, along with the
+// per-record call applying it when the batch task fails.
+type alibabaCloudBatchOperation struct {
+	info     alidns.OperateBatchDomainDomainRecordInfo
+	fallback func(ctx context.Context) error
 }
 
+func (p *AlibabaCloudProvider) createRecordsInBatches(ctx context.Context, batcher alibabaCloudDNSBatchAPI, endpoints []*endpoint.Endpoint, hostedZoneDomains []string) {
+	operations := make(map[string][]alibabaCloudBatchOperation)
+	for _, endpoint := range endpoints {
+		if _, ok := endpointWeight(endpoint); ok {
+			// Batch tasks can neither set remarks nor weights.
+			for _, target := range endpoint.Targets {
+				p.createRecord(ctx, endpoint, target, hostedZoneDomains)
+			}
+			continue
+		}
+		for _, target := range endpoint.Targets {
+			request, err := p.newAddDomainRecordRequest(endpoint, target, hostedZoneDomains)
+			if err != nil {
+				continue
+			}
+			operations[request.DomainName] = append(operations[request.DomainName], alibabaCloudBatchOperation{
+				info: alidns.OperateBatchDomainDomainRecordInfo{
+					Domain:   request.DomainName,
+					Rr:       request.RR,
+					Type:     request.Type,
+					Value:    request.Value,
+					Ttl:      string(request.TTL),
+					Priority: string(request.Priority),
+					Line:     request.Line,
+				},
+				fallback: func(ctx context.Context) error {
+					return p.addDomainRecord(ctx, endpoint, request)
+				},
+			})
+		}
+	}
+	p.applyBatches(ctx, batcher, alibabaCloudBatchAddRecords, operations)
+}
+
+func (p *AlibabaCloudProvider) deleteRecordsInBatches(ctx context.Context, batcher alibabaCloudDNSBatchAPI, records []alidns.Record) {
+	operations := make(map[string][]alibabaCloudBatchOperation)
+	for _, record := range records {
+		operations[record.DomainName] = append(operations[record.DomainName], alibabaCloudBatchOperation{
+			info: alidns.OperateBatchDomainDomainRecordInfo{
+				Domain: record.DomainName,
+				Rr:     record.RR,
+				Type:   record.Type,
+				Value:  record.Value,
+				Line:   record.Line,
+			},
+			fallback: func(ctx context.Context) error {
+				return p.deleteRecord(ctx, record.RecordId)
+			},
+		})
+	}
+	p.applyBatches(ctx, batcher, alibabaCloudBatchDeleteRecords, operations)
+}
+
+// applyBatches runs one batch task per zone and chunk of operations. Operations which failed are
+// applied again one by one, as are all operations of a batch task which could not be run.
+func (p *AlibabaCloudProvider) applyBatches(ctx context.Context, batcher alibabaCloudDNSBatchAPI, batchType string, operations map[string][]alibabaCloudBatchOperation) {
+	for _, domain := range slices.Sorted(maps.Keys(operations)) {
+		for chunk := range slices.Chunk(operations[domain], defaultAlibabaCloudBatchSize) {
+			failed, err := p.runBatch(ctx, batcher, batchType, chunk)
+			if err != nil {
+				log.Warnf("Falling back to per-record changes for %d %s operation(s) in Alibaba Cloud DNS zone '%s': %v", len(chunk), batchType, domain, err)
+				failed = chunk
+			}
+			for _, operation := range failed {
+				operation.fallback(ctx)
+			}
+		}
+	}
+}
+
+// runBatch runs a batch task and waits for it to finish, returning the operations which failed.
+func (p *AlibabaCloudProvider) runBatch(ctx context.Context, batcher alibabaCloudDNSBatchAPI, batchType string, operations []alibabaCloudBatchOperation) ([]alibabaCloudBatchOperation, error) {
+	infos := make([]alidns.OperateBatchDomainDomainRecordInfo, 0, len(operations))
+	for _, operation := range operations {
+		infos = append(infos, operation.info)
+	}
+	request := alidns.CreateOperateBatchDomainRequest()
+	request.Type = batchType
+	request.DomainRecordInfo = &infos
+	request.Scheme = defaultAlibabaCloudRequestScheme
+	response, err := withRetry(ctx, p.retry, func() (*alidns.OperateBatchDomainResponse, error) {
+		return batcher.OperateBatchDomain(request)
+	})
+	if err != nil {
+		return nil, err
+	}
+	log.Infof("Submitted %d %s operation(s) to Alibaba Cloud DNS: Task ID=%d", len(operations), batchType, response.TaskId)
+
+	result, err := p.waitForBatch(ctx, batcher, batchType, response.TaskId)
+	if err != nil {
+		return nil, err
+	}
+	if result.FailedCount == 0 {
+		return nil, nil
+	}
+
+	details, err := p.batchResultDetails(ctx, batcher, batchType, response.TaskId)
+	if err != nil {
+		return nil, err
+	}
+	var failed []alibabaCloudBatchOperation
+	for _, operation := range operations {
+		if slices.ContainsFunc(details, func(detail alidns.BatchResultDetail) bool {
+			return !detail.Status && detail.Domain == operation.info.Domain && detail.Rr == operation.info.Rr && detail.Value == operation.info.Value
+		}) {
+			failed = append(failed, operation)
+		}
+	}
+	if len(failed) < result.FailedCount {
+		return nil, fmt.Errorf("could not identify the %d failed operation(s) of task %d", result.FailedCount, response.TaskId)
+	}
+	return failed, nil
+}
+
+// waitForBatch polls the result of a batch task until it is finished.
+func (p *AlibabaCloudProvider) waitForBatch(ctx context.Context, batcher alibabaCloudDNSBatchAPI, batchType string, taskID int64) (*alidns.DescribeBatchResultCountResponse, error) {
+	request := alidns.CreateDescribeBatchResultCountRequest()
+	request.BatchType = batchType
+	request.TaskId = requests.NewInteger64(taskID)
+	request.Scheme = defaultAlibabaCloudRequestScheme
+	for {
+		response, err := withRetry(ctx, p.retry, func() (*alidns.DescribeBatchResultCountResponse, error) {
+			return batcher.DescribeBatchResultCount(request)
+		})
+		if err != nil {
+			return nil, err
+		}
+		switch response.Status {
+		case alibabaCloudBatchFinished:
+			return response, nil
+		case alibabaCloudBatchRunning:
+		default:
+			return nil, fmt.Errorf("task %d is in unexpected status %d: %s", taskID, response.Status, response.Reason)
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(p.batchPollInterval):
+		}
+	}
+}
+
+// batchResultDetails gets the results of the operations of a batch task.
+func (p *AlibabaCloudProvider) batchResultDetails(ctx context.Context, batcher alibabaCloudDNSBatchAPI, batchType string, taskID int64) ([]alidns.BatchResultDetail, error) {
+	var details []alidns.BatchResultDetail
+	request := alidns.CreateDescribeBatchResultDetailRequest()
+	request.BatchType = batchType
+	request.TaskId = requests.NewInteger64(taskID)
+	request.PageSize = requests.NewInteger(defaultAlibabaCloudPageSize)
+	request.PageNumber = "1"
+	request.Scheme = defaultAlibabaCloudRequestScheme
+	for {
+		response, err := withRetry(ctx, p.retry, func() (*alidns.DescribeBatchResultDetailResponse, error) {
+			return batcher.DescribeBatchResultDetail(request)
+		})
+		if err != nil {
+			return nil, err
+		}
+		details = append(details, response.BatchResultDetails.BatchResultDetail...)
+		pageSize := response.PageSize
+		if pageSize <= 0 {
+			pageSize = defaultAlibabaCloudPageSize
+		}
+		nextPage := getNextPageNumber(response.PageNumber, pageSize, response.TotalCount)
+		if nextPage == 0 {
+			return details, nil
+		}
+		request.PageNumber = requests.NewInteger64(nextPage)
+	}
+}
+
+// sameRecordValue reports whether a record value matches an endpoint target.
+// AAAA values are compared as addresses since Alibaba Cloud may return them in a different notation,
+// and CAA values in their canonical form since targets may leave the value unquoted.
+func sameRecordValue(recordType, value, target string) bool {
+	switch recordType {
+	case endpoint.RecordTypeAAAA:
+		a, errA := netip.ParseAddr(value)
+		b, errB := netip.ParseAddr(target)
+		if errA == nil && errB == nil {
+			return a == b
+		}
+	case endpoint.RecordTypeCAA:
+		a, errA := formatCAATarget(value)
+		b, errB := formatCAATarget(target)
+		if errA == nil && errB == nil {
+			return a == b
+		}
+	}
+	return value == target
+}
+
+// equals reports whether the record has the TTL of the endpoint, the default one standing for an unset TTL.
 func (p *AlibabaCloudProvider) equals(record alidns.Record, endpoint *endpoint.Endpoint) bool {
 	ttl1 := record.TTL
-	if ttl1 == defaultTTL {
-		ttl1 = 0
+	if ttl1 == 0 {
+		ttl1 = p.getDefaultTTL()
 	}
 
 	ttl2 := int64(endpoint.RecordTTL)
-	if ttl2 == defaultTTL {
-		ttl2 = 0
+	if ttl2 == 0 {
+		ttl2 = p.getDefaultTTL()
 	}
 
 	return ttl1 == ttl2
 }
 
-func (p *AlibabaCloudProvider) updateRecords(recordMap map[string][]alidns.Record, endpoints []*endpoint.Endpoint, hostedZoneDomains []string) error {
+// updateRecords reconciles the existing records of the given endpoints with their targets.
+// Records whose value is still a target are kept, and only updated in place when their TTL changed,
+// so that a TTL-only change neither deletes nor recreates them. Records of removed targets are deleted
+// and new targets are created.
+func (p *AlibabaCloudProvider) updateRecords(ctx context.Context, recordMap map[string][]alidns.Record, endpoints []*endpoint.Endpoint, hostedZoneDomains []string) error {
 	for _, endpoint := range endpoints {
 		key := p.getRecordKeyByEndpoint(endpoint)
-		records := recordMap[key]
-		for _, record := range records {
-			value := record.Value
-			if record.Type == "TXT" {
-				value = p.unescapeTXTRecordValue(value)
+		matched := make(map[string]bool, len(endpoint.Targets))
+		for _, record := range recordMap[key] {
+			value := p.dnsRecordTarget(record)
+			index := slices.IndexFunc(endpoint.Targets, func(target string) bool {
+				return sameRecordValue(endpoint.RecordType, value, target)
+			})
+			if index < 0 {
+				p.deleteRecord(ctx, record.RecordId)
+				continue
 			}
-			found := false
-			for _, target := range endpoint.Targets {
-				// Find matched record to delete
-				if value == target {
-					found = true
-				}
+			matched[endpoint.Targets[index]] = true
+			if !p.equals(record, endpoint) {
+				// Only the TTL differs, the record keeps its value.
+				p.updateRecord(ctx, record, endpoint)
 			}
-			if found {
-				if !p.equals(record, endpoint) {
-					// Update record
-					p.updateRecord(record, endpoint)
-				}
-			} else {
-				p.deleteRecord(record.RecordId)
+			if weight, ok := endpointWeight(endpoint); ok && record.Weight != weight {
+				p.weightRecord(ctx, record, weight)
 			}
 		}
 		for _, target := range endpoint.Targets {
-			if endpoint.RecordType == "TXT" {
-				target = p.escapeTXTRecordValue(target)
-			}
-			found := false
-			for _, record := range records {
-				// Find matched record to delete
-				if record.Value == target {
-					found = true
-				}
-			}
-			if !found {
-				p.createRecord(endpoint, target, hostedZoneDomains)
+			if !matched[target] {
+				p.createRecord(ctx, endpoint, target, hostedZoneDomains)
 			}
 		}
 	}
 	return nil
 }
 
+// splitDNSName splits dnsName into its RR and the most specific of the given zones containing it.
+// The RR of the zone apex is "@", wildcard names such as "*.example.org" keep the "*" label as RR.
 func (p *AlibabaCloudProvider) splitDNSName(dnsName string, hostedZoneDomains []string) (string, string) {
 	name := strings.TrimSuffix(dnsName, ".")
 
@@ -702,12 +1706,14 @@ func (p *AlibabaCloudProvider) splitDNSName(dnsName string, hostedZoneDomains []
 	return rr, domain
 }
 
-func (p *AlibabaCloudProvider) matchVPC(zoneID string) bool {
+func (p *AlibabaCloudProvider) matchVPC(ctx context.Context, zoneID string) bool {
 	request := pvtz.CreateDescribeZoneInfoRequest()
 	request.ZoneId = zoneID
 	request.Domain = pVTZDoamin
 	request.Scheme = defaultAlibabaCloudRequestScheme
-	response, err := p.getPvtzClient().DescribeZoneInfo(request)
+	response, err := withRetry(ctx, p.retry, func() (*pvtz.DescribeZoneInfoResponse, error) {
+		return p.getPvtzClient().DescribeZoneInfo(request)
+	})
 	if err != nil {
 		log.Errorf("Failed to describe zone info %s in Alibaba Cloud DNS: %v", zoneID, err)
 		return false
@@ -722,7 +1728,7 @@ func (p *AlibabaCloudProvider) matchVPC(zoneID string) bool {
 	return foundVPC
 }
 
-func (p *AlibabaCloudProvider) privateZones() ([]pvtz.Zone, error) {
+func (p *AlibabaCloudProvider) privateZones(ctx context.Context) ([]pvtz.Zone, error) {
 	var zones []pvtz.Zone
 
 	request := pvtz.CreateDescribeZonesRequest()
@@ -731,7 +1737,9 @@ func (p *AlibabaCloudProvider) privateZones() ([]pvtz.Zone, error) {
 	request.Domain = pVTZDoamin
 	request.Scheme = defaultAlibabaCloudRequestScheme
 	for {
-		response, err := p.getPvtzClient().DescribeZones(request)
+		response, err := withRetry(ctx, p.retry, func() (*pvtz.DescribeZonesResponse, error) {
+			return p.getPvtzClient().DescribeZones(request)
+		})
 		if err != nil {
 			log.Errorf("Failed to describe zones in Alibaba Cloud DNS: %v", err)
 			return nil, err
@@ -745,7 +1753,7 @@ func (p *AlibabaCloudProvider) privateZones() ([]pvtz.Zone, error) {
 			if !p.domainFilter.Match(zone.ZoneName) {
 				continue
 			}
-			if !p.matchVPC(zone.ZoneId) {
+			if !p.matchVPC(ctx, zone.ZoneId) {
 				continue
 			}
 			zones = append(zones, zone)
@@ -765,13 +1773,13 @@ type alibabaPrivateZone struct {
 	records []pvtz.Record
 }
 
-func (p *AlibabaCloudProvider) getPrivateZones() (map[string]*alibabaPrivateZone, error) {
+func (p *AlibabaCloudProvider) getPrivateZones(ctx context.Context) (map[string]*alibabaPrivateZone, error) {
 	log.Infof("Retrieving Alibaba Cloud Private Zone records")
 
 	result := make(map[string]*alibabaPrivateZone)
 	recordsCount := 0
 
-	zones, err := p.privateZones()
+	zones, err := p.privateZones(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -786,7 +1794,9 @@ func (p *AlibabaCloudProvider) getPrivateZones() (map[string]*alibabaPrivateZone
 		var records []pvtz.Record
 
 		for {
-			response, err := p.getPvtzClient().DescribeZoneRecords(request)
+			response, err := withRetry(ctx, p.retry, func() (*pvtz.DescribeZoneRecordsResponse, error) {
+				return p.getPvtzClient().DescribeZoneRecords(request)
+			})
 			if err != nil {
 				log.Errorf("Failed to describe zone record '%s' in Alibaba Cloud DNS: %v", zone.ZoneId, err)
 				return nil, err
@@ -795,7 +1805,7 @@ func (p *AlibabaCloudProvider) getPrivateZones() (map[string]*alibabaPrivateZone
 			for _, record := range response.Records.Record {
 				recordType := record.Type
 
-				if !provider.SupportedRecordType(recordType) {
+				if !supportedRecordType(recordType) {
 					continue
 				}
 
@@ -836,12 +1846,16 @@ func (p *AlibabaCloudProvider) groupPrivateZoneRecords(zone *alibabaPrivateZone)
 // recordsForPrivateZone gets the current records.
 //
 // Returns the current records or an error if the operation failed.
-func (p *AlibabaCloudProvider) privateZoneRecords() ([]*endpoint.Endpoint, error) {
-	zones, err := p.getPrivateZones()
+func (p *AlibabaCloudProvider) privateZoneRecords(ctx context.Context) ([]*endpoint.Endpoint, error) {
+	zones, err := p.getPrivateZones(ctx)
 	if err != nil {
 		return nil, err
 	}
+	return p.privateZoneEndpoints(zones), nil
+}
 
+// privateZoneEndpoints converts the records of the given Private Zones to endpoints.
+func (p *AlibabaCloudProvider) privateZoneEndpoints(zones map[string]*alibabaPrivateZone) []*endpoint.Endpoint {
 	endpoints := make([]*endpoint.Endpoint, 0)
 
 	for _, zone := range zones {
@@ -855,20 +1869,17 @@ func (p *AlibabaCloudProvider) privateZoneRecords() ([]*endpoint.Endpoint, error
 			}
 			var targets []string
 			for _, record := range recordList {
-				target := record.Value
-				if recordType == "TXT" {
-					target = p.unescapeTXTRecordValue(target)
-				}
+				target := p.recordTarget(recordType, record.Value, int64(record.Priority))
 				targets = append(targets, target)
 			}
 			ep := endpoint.NewEndpointWithTTL(name, recordType, endpoint.TTL(ttl), targets...)
 			endpoints = append(endpoints, ep)
 		}
 	}
-	return endpoints, nil
+	return endpoints
 }
 
-func (p *AlibabaCloudProvider) createPrivateZoneRecord(zones map[string]*alibabaPrivateZone, endpoint *endpoint.Endpoint, target string) error {
+func (p *AlibabaCloudProvider) createPrivateZoneRecord(ctx context.Context, zones map[string]*alibabaPrivateZone, endpoint *endpoint.Endpoint, target string) error {
 	rr, domain := p.splitDNSName(endpoint.DNSName, keys(zones))
 	zone := zones[domain]
 	if zone == nil {
@@ -893,6 +1904,16 @@ func (p *AlibabaCloudProvider) createPrivateZoneRecord(zones map[string]*alibaba
 		target = p.escapeTXTRecordValue(target)
 	}
 
+	if endpoint.RecordType == "MX" {
+		priority, host, err := splitMXTarget(target)
+		if err != nil {
+			log.Errorf("Failed to create %s record named '%s' to '%s' for Alibaba Cloud Private Zone: %v", endpoint.RecordType, endpoint.DNSName, target, err)
+			return err
+		}
+		request.Priority = requests.NewInteger(priority)
+		target = host
+	}
+
 	request.Value = target
 
 	if p.dryRun {
@@ -900,7 +1921,9 @@ func (p *AlibabaCloudProvider) createPrivateZoneRecord(zones map[string]*alibaba
 		return nil
 	}
 
-	response, err := p.getPvtzClient().AddZoneRecord(request)
+	response, err := withRetry(ctx, p.retry, func() (*pvtz.AddZoneRecordResponse, error) {
+		return p.getPvtzClient().AddZoneRecord(request)
+	})
 	if err == nil {
 		log.Infof("Create %s record named '%s' to '%s' with ttl %d for Alibaba Cloud Private Zone: Record ID=%d", endpoint.RecordType, endpoint.DNSName, target, ttl, response.RecordId)
 	} else {
@@ -909,18 +1932,19 @@ func (p *AlibabaCloudProvider) createPrivateZoneRecord(zones map[string]*alibaba
 	return err
 }
 
-func (p *AlibabaCloudProvider) createPrivateZoneRecords(zones map[string]*alibabaPrivateZone, endpoints []*endpoint.Endpoint) error {
+func (p *AlibabaCloudProvider) createPrivateZoneRecords(ctx context.Context, zones map[string]*alibabaPrivateZone, endpoints []*endpoint.Endpoint) error {
 	for _, endpoint := range endpoints {
 		for _, target := range endpoint.Targets {
-			_ = p.createPrivateZoneRecord(zones, endpoint, target)
+			_ = p.createPrivateZoneRecord(ctx, zones, endpoint, target)
 		}
 	}
 	return nil
 }
 
-func (p *AlibabaCloudProvider) deletePrivateZoneRecord(recordID int64) error {
+func (p *AlibabaCloudProvider) deletePrivateZoneRecord(ctx context.Context, recordID int64) error {
 	if p.dryRun {
 		log.Infof("Dry run: Delete record id '%d' in Alibaba Cloud Private Zone", recordID)
+		return nil
 	}
 
 	request := pvtz.CreateDeleteZoneRecordRequest()
@@ -928,7 +1952,9 @@ func (p *AlibabaCloudProvider) deletePrivateZoneRecord(recordID int64) error {
 	request.Domain = pVTZDoamin
 	request.Scheme = defaultAlibabaCloudRequestScheme
 
-	response, err := p.getPvtzClient().DeleteZoneRecord(request)
+	response, err := withRetry(ctx, p.retry, func() (*pvtz.DeleteZoneRecordResponse, error) {
+		return p.getPvtzClient().DeleteZoneRecord(request)
+	})
 	if err == nil {
 		log.Infof("Delete record id '%d' in Alibaba Cloud Private Zone", response.RecordId)
 	} else {
@@ -937,7 +1963,7 @@ func (p *AlibabaCloudProvider) deletePrivateZoneRecord(recordID int64) error {
 	return err
 }
 
-func (p *AlibabaCloudProvider) deletePrivateZoneRecords(zones map[string]*alibabaPrivateZone, endpoints []*endpoint.Endpoint) error {
+func (p *AlibabaCloudProvider) deletePrivateZoneRecords(ctx context.Context, zones map[string]*alibabaPrivateZone, endpoints []*endpoint.Endpoint) error {
 	zoneNames := keys(zones)
 	for _, endpoint := range endpoints {
 		rr, domain := p.splitDNSName(endpoint.DNSName, zoneNames)
@@ -951,14 +1977,11 @@ func (p *AlibabaCloudProvider) deletePrivateZoneRecords(zones map[string]*alibab
 		found := false
 		for _, record := range zone.records {
 			if rr == record.Rr && endpoint.RecordType == record.Type {
-				value := record.Value
-				if record.Type == "TXT" {
-					value = p.unescapeTXTRecordValue(value)
-				}
+				value := p.recordTarget(record.Type, record.Value, int64(record.Priority))
 				for _, target := range endpoint.Targets {
 					// Find matched record to delete
-					if value == target {
-						p.deletePrivateZoneRecord(record.RecordId)
+					if sameRecordValue(endpoint.RecordType, value, target) {
+						p.deletePrivateZoneRecord(ctx, record.RecordId)
 						found = true
 						break
 					}
@@ -975,10 +1998,10 @@ func (p *AlibabaCloudProvider) deletePrivateZoneRecords(zones map[string]*alibab
 // ApplyChanges applies the given changes.
 //
 // Returns nil if the operation was successful or an error if the operation failed.
-func (p *AlibabaCloudProvider) applyChangesForPrivateZone(changes *plan.Changes) error {
+func (p *AlibabaCloudProvider) applyChangesForPrivateZone(ctx context.Context, changes *plan.Changes) error {
 	log.Infof("ApplyChanges to Alibaba Cloud Private Zone: %++v", *changes)
 
-	zones, err := p.getPrivateZones()
+	zones, err := p.getPrivateZones(ctx)
 	if err != nil {
 		return err
 	}
@@ -987,25 +2010,36 @@ func (p *AlibabaCloudProvider) applyChangesForPrivateZone(changes *plan.Changes)
 		log.Debugf("%s: %++v", zoneName, zone)
 	}
 
-	p.createPrivateZoneRecords(zones, changes.Create)
-	p.deletePrivateZoneRecords(zones, changes.Delete)
-	p.updatePrivateZoneRecords(zones, changes.UpdateNew)
-	return nil
+	p.createPrivateZoneRecords(ctx, zones, changes.Create)
+	p.deletePrivateZoneRecords(ctx, zones, changes.Delete)
+	p.updatePrivateZoneRecords(ctx, zones, changes.UpdateNew)
+	return p.checkZoneMatching(changes, keys(zones))
 }
 
-func (p *AlibabaCloudProvider) updatePrivateZoneRecord(record pvtz.Record, endpoint *endpoint.Endpoint) error {
+func (p *AlibabaCloudProvider) updatePrivateZoneRecord(ctx context.Context, record pvtz.Record, endpoint *endpoint.Endpoint) error {
 	request := pvtz.CreateUpdateZoneRecordRequest()
 	request.RecordId = requests.NewInteger64(record.RecordId)
 	request.Rr = record.Rr
 	request.Type = record.Type
 	request.Value = record.Value
+	if record.Type == "MX" {
+		request.Priority = requests.NewInteger(record.Priority)
+	}
 	request.Domain = pVTZDoamin
 	request.Scheme = defaultAlibabaCloudRequestScheme
 	ttl := int(endpoint.RecordTTL)
 	if ttl != 0 {
 		request.Ttl = requests.NewInteger(ttl)
 	}
-	response, err := p.getPvtzClient().UpdateZoneRecord(request)
+
+	if p.dryRun {
+		log.Infof("Dry run: Update record id '%d' to '%s' with ttl %d in Alibaba Cloud Private Zone", record.RecordId, record.Value, ttl)
+		return nil
+	}
+
+	response, err := withRetry(ctx, p.retry, func() (*pvtz.UpdateZoneRecordResponse, error) {
+		return p.getPvtzClient().UpdateZoneRecord(request)
+	})
 	if err == nil {
 		log.Infof("Update record id '%d' in Alibaba Cloud Private Zone", response.RecordId)
 	} else {
@@ -1028,7 +2062,7 @@ func (p *AlibabaCloudProvider) equalsPrivateZone(record pvtz.Record, endpoint *e
 	return ttl1 == ttl2
 }
 
-func (p *AlibabaCloudProvider) updatePrivateZoneRecords(zones map[string]*alibabaPrivateZone, endpoints []*endpoint.Endpoint) error {
+func (p *AlibabaCloudProvider) updatePrivateZoneRecords(ctx context.Context, zones map[string]*alibabaPrivateZone, endpoints []*endpoint.Endpoint) error {
 	zoneNames := keys(zones)
 	for _, endpoint := range endpoints {
 		rr, domain := p.splitDNSName(endpoint.DNSName, zoneNames)
@@ -1043,14 +2077,11 @@ func (p *AlibabaCloudProvider) updatePrivateZoneRecords(zones map[string]*alibab
 			if record.Rr != rr || record.Type != endpoint.RecordType {
 				continue
 			}
-			value := record.Value
-			if record.Type == "TXT" {
-				value = p.unescapeTXTRecordValue(value)
-			}
+			value := p.recordTarget(record.Type, record.Value, int64(record.Priority))
 			found := false
 			for _, target := range endpoint.Targets {
 				// Find matched record to delete
-				if value == target {
+				if sameRecordValue(endpoint.RecordType, value, target) {
 					found = true
 					break
 				}
@@ -1058,10 +2089,10 @@ func (p *AlibabaCloudProvider) updatePrivateZoneRecords(zones map[string]*alibab
 			if found {
 				if !p.equalsPrivateZone(record, endpoint) {
 					// Update record
-					p.updatePrivateZoneRecord(record, endpoint)
+					p.updatePrivateZoneRecord(ctx, record, endpoint)
 				}
 			} else {
-				p.deletePrivateZoneRecord(record.RecordId)
+				p.deletePrivateZoneRecord(ctx, record.RecordId)
 			}
 		}
 		for _, target := range endpoint.Targets {
@@ -1074,13 +2105,17 @@ func (p *AlibabaCloudProvider) updatePrivateZoneRecords(zones map[string]*alibab
 					continue
 				}
 				// Find matched record to delete
-				if record.Value == target {
+				value := record.Value
+				if record.Type == "MX" {
+					value = p.recordTarget(record.Type, record.Value, int64(record.Priority))
+				}
+				if sameRecordValue(endpoint.RecordType, value, target) {
 					found = true
 					break
 				}
 			}
 			if !found {
-				p.createPrivateZoneRecord(zones, endpoint, target)
+				p.createPrivateZoneRecord(ctx, zones, endpoint, target)
 			}
 		}
 	}
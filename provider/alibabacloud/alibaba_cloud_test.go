@@ -18,18 +18,51 @@ package alibabacloud
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
 	"testing"
+	"time"
 
+	aliyunerrors "github.com/aliyun/alibaba-cloud-sdk-go/sdk/errors"
 	"github.com/aliyun/alibaba-cloud-sdk-go/services/alidns"
 	"github.com/aliyun/alibaba-cloud-sdk-go/services/pvtz"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"sigs.k8s.io/external-dns/endpoint"
 	"sigs.k8s.io/external-dns/plan"
+	"sigs.k8s.io/external-dns/provider"
 )
 
 type MockAlibabaCloudDNSAPI struct {
 	records []alidns.Record
+	// maxPageSize caps the page size of DescribeDomainRecords like the real service does.
+	maxPageSize int
+	// throttled is the number of AddDomainRecord calls to reject with a throttling error.
+	throttled int
+	// describeDomainsCalls counts the DescribeDomains calls.
+	describeDomainsCalls int
+	// describeDomainsErr is returned by DescribeDomains when set.
+	describeDomainsErr error
+	// added counts the records added, to give them distinct IDs.
+	added int
+	// slbSubDomains are the names with weighted round robin turned on.
+	slbSubDomains []string
+	// minTTL is the minimum TTL allowed by the plan of every zone.
+	minTTL int64
+	// describeDomainInfoCalls counts the DescribeDomainInfo calls.
+	describeDomainInfoCalls int
+	// updated and deleted are the IDs of the records updated and deleted, in call order.
+	updated []string
+	deleted []string
+	// updateRequests are the UpdateDomainRecord requests received.
+	updateRequests []*alidns.UpdateDomainRecordRequest
 }
 
 func NewMockAlibabaCloudDNSAPI() *MockAlibabaCloudDNSAPI {
@@ -56,19 +89,31 @@ func NewMockAlibabaCloudDNSAPI() *MockAlibabaCloudDNSAPI {
 }
 
 func (m *MockAlibabaCloudDNSAPI) AddDomainRecord(request *alidns.AddDomainRecordRequest) (*alidns.AddDomainRecordResponse, error) {
+	if m.throttled > 0 {
+		m.throttled--
+		return nil, newThrottlingError("Throttling.User")
+	}
 	ttl, _ := request.TTL.GetValue()
+	priority, _ := request.Priority.GetValue64()
+	m.added++
+	recordID := fmt.Sprintf("added-%d", m.added)
 	m.records = append(m.records, alidns.Record{
-		RecordId:   "3",
+		RecordId:   recordID,
 		DomainName: request.DomainName,
 		Type:       request.Type,
 		TTL:        int64(ttl),
 		RR:         request.RR,
 		Value:      request.Value,
+		Priority:   priority,
+		Line:       request.Line,
 	})
-	return alidns.CreateAddDomainRecordResponse(), nil
+	response := alidns.CreateAddDomainRecordResponse()
+	response.RecordId = recordID
+	return response, nil
 }
 
 func (m *MockAlibabaCloudDNSAPI) DeleteDomainRecord(request *alidns.DeleteDomainRecordRequest) (*alidns.DeleteDomainRecordResponse, error) {
+	m.deleted = append(m.deleted, request.RecordId)
 	var result []alidns.Record
 	for _, record := range m.records {
 		if record.RecordId != request.RecordId {
@@ -82,6 +127,8 @@ func (m *MockAlibabaCloudDNSAPI) DeleteDomainRecord(request *alidns.DeleteDomain
 }
 
 func (m *MockAlibabaCloudDNSAPI) UpdateDomainRecord(request *alidns.UpdateDomainRecordRequest) (*alidns.UpdateDomainRecordResponse, error) {
+	m.updated = append(m.updated, request.RecordId)
+	m.updateRequests = append(m.updateRequests, request)
 	ttl, _ := request.TTL.GetValue64()
 	for i := range m.records {
 		if m.records[i].RecordId == request.RecordId {
@@ -94,6 +141,10 @@ func (m *MockAlibabaCloudDNSAPI) UpdateDomainRecord(request *alidns.UpdateDomain
 }
 
 func (m *MockAlibabaCloudDNSAPI) DescribeDomains(request *alidns.DescribeDomainsRequest) (*alidns.DescribeDomainsResponse, error) {
+	m.describeDomainsCalls++
+	if m.describeDomainsErr != nil {
+		return nil, m.describeDomainsErr
+	}
 	var result alidns.DomainsInDescribeDomains
 	for _, record := range m.records {
 		domain := alidns.Domain{}
@@ -114,14 +165,158 @@ func (m *MockAlibabaCloudDNSAPI) DescribeDomainRecords(request *alidns.DescribeD
 			result = append(result, record)
 		}
 	}
+
+	pageNumber, _ := request.PageNumber.GetValue()
+	pageSize, _ := request.PageSize.GetValue()
+	if m.maxPageSize > 0 && pageSize > m.maxPageSize {
+		pageSize = m.maxPageSize
+	}
+	pageNumber = max(pageNumber, 1)
+
 	response := alidns.CreateDescribeDomainRecordsResponse()
-	response.DomainRecords.Record = result
+	response.TotalCount = int64(len(result))
+	response.PageNumber = int64(pageNumber)
+	response.PageSize = int64(pageSize)
+	start := min((pageNumber-1)*pageSize, len(result))
+	end := min(start+pageSize, len(result))
+	response.DomainRecords.Record = result[start:end]
+	return response, nil
+}
+
+func (m *MockAlibabaCloudDNSAPI) DescribeDomainInfo(request *alidns.DescribeDomainInfoRequest) (*alidns.DescribeDomainInfoResponse, error) {
+	m.describeDomainInfoCalls++
+	response := alidns.CreateDescribeDomainInfoResponse()
+	response.DomainName = request.DomainName
+	response.MinTtl = m.minTTL
+	return response, nil
+}
+
+func (m *MockAlibabaCloudDNSAPI) UpdateDomainRecordRemark(request *alidns.UpdateDomainRecordRemarkRequest) (*alidns.UpdateDomainRecordRemarkResponse, error) {
+	for i := range m.records {
+		if m.records[i].RecordId == request.RecordId {
+			m.records[i].Remark = request.Remark
+		}
+	}
+	return alidns.CreateUpdateDomainRecordRemarkResponse(), nil
+}
+
+func (m *MockAlibabaCloudDNSAPI) SetDNSSLBStatus(request *alidns.SetDNSSLBStatusRequest) (*alidns.SetDNSSLBStatusResponse, error) {
+	if open, _ := request.Open.GetValue(); open && !slices.Contains(m.slbSubDomains, request.SubDomain) {
+		m.slbSubDomains = append(m.slbSubDomains, request.SubDomain)
+	}
+	return alidns.CreateSetDNSSLBStatusResponse(), nil
+}
+
+func (m *MockAlibabaCloudDNSAPI) UpdateDNSSLBWeight(request *alidns.UpdateDNSSLBWeightRequest) (*alidns.UpdateDNSSLBWeightResponse, error) {
+	weight, _ := request.Weight.GetValue()
+	for i := range m.records {
+		if m.records[i].RecordId == request.RecordId {
+			m.records[i].Weight = weight
+		}
+	}
+	return alidns.CreateUpdateDNSSLBWeightResponse(), nil
+}
+
+// MockAlibabaCloudDNSBatchAPI adds batch tasks to MockAlibabaCloudDNSAPI.
+type MockAlibabaCloudDNSBatchAPI struct {
+	*MockAlibabaCloudDNSAPI
+	// failValues are the record values whose batch operations fail.
+	failValues map[string]bool
+	// unavailable makes every batch task submission fail.
+	unavailable bool
+	// running is the number of polls reporting a task as running before it finishes.
+	running int
+
+	tasks                    [][]alidns.BatchResultDetail
+	addDomainRecordCalls     int
+	deleteDomainRecordCalls  int
+	describeBatchResultCalls int
+}
+
+func (m *MockAlibabaCloudDNSBatchAPI) AddDomainRecord(request *alidns.AddDomainRecordRequest) (*alidns.AddDomainRecordResponse, error) {
+	m.addDomainRecordCalls++
+	return m.MockAlibabaCloudDNSAPI.AddDomainRecord(request)
+}
+
+func (m *MockAlibabaCloudDNSBatchAPI) DeleteDomainRecord(request *alidns.DeleteDomainRecordRequest) (*alidns.DeleteDomainRecordResponse, error) {
+	m.deleteDomainRecordCalls++
+	return m.MockAlibabaCloudDNSAPI.DeleteDomainRecord(request)
+}
+
+func (m *MockAlibabaCloudDNSBatchAPI) OperateBatchDomain(request *alidns.OperateBatchDomainRequest) (*alidns.OperateBatchDomainResponse, error) {
+	if m.unavailable {
+		return nil, errors.New("batch tasks unavailable")
+	}
+	var details []alidns.BatchResultDetail
+	for _, info := range *request.DomainRecordInfo {
+		detail := alidns.BatchResultDetail{Domain: info.Domain, Rr: info.Rr, Value: info.Value, Status: !m.failValues[info.Value]}
+		details = append(details, detail)
+		if !detail.Status {
+			continue
+		}
+		switch request.Type {
+		case "RR_ADD":
+			ttl, _ := strconv.ParseInt(info.Ttl, 10, 64)
+			priority, _ := strconv.ParseInt(info.Priority, 10, 64)
+			m.records = append(m.records, alidns.Record{
+				RecordId:   fmt.Sprintf("batch-%d-%d", len(m.tasks), len(details)),
+				DomainName: info.Domain,
+				Type:       info.Type,
+				TTL:        ttl,
+				RR:         info.Rr,
+				Value:      info.Value,
+				Priority:   priority,
+				Line:       info.Line,
+			})
+		case "RR_DEL":
+			m.records = slices.DeleteFunc(m.records, func(record alidns.Record) bool {
+				return record.DomainName == info.Domain && record.RR == info.Rr && record.Type == info.Type && record.Value == info.Value
+			})
+		}
+	}
+	m.tasks = append(m.tasks, details)
+
+	response := alidns.CreateOperateBatchDomainResponse()
+	response.TaskId = int64(len(m.tasks))
+	return response, nil
+}
+
+func (m *MockAlibabaCloudDNSBatchAPI) DescribeBatchResultCount(request *alidns.DescribeBatchResultCountRequest) (*alidns.DescribeBatchResultCountResponse, error) {
+	m.describeBatchResultCalls++
+	taskID, _ := request.TaskId.GetValue64()
+	response := alidns.CreateDescribeBatchResultCountResponse()
+	response.TaskId = taskID
+	if m.running > 0 {
+		m.running--
+		return response, nil
+	}
+	response.Status = 1
+	for _, detail := range m.tasks[taskID-1] {
+		response.TotalCount++
+		if detail.Status {
+			response.SuccessCount++
+		} else {
+			response.FailedCount++
+		}
+	}
+	return response, nil
+}
+
+func (m *MockAlibabaCloudDNSBatchAPI) DescribeBatchResultDetail(request *alidns.DescribeBatchResultDetailRequest) (*alidns.DescribeBatchResultDetailResponse, error) {
+	taskID, _ := request.TaskId.GetValue64()
+	response := alidns.CreateDescribeBatchResultDetailResponse()
+	response.PageNumber = 1
+	response.PageSize = int64(len(m.tasks[taskID-1]))
+	response.TotalCount = int64(len(m.tasks[taskID-1]))
+	response.BatchResultDetails.BatchResultDetail = m.tasks[taskID-1]
 	return response, nil
 }
 
 type MockAlibabaCloudPrivateZoneAPI struct {
 	zone    pvtz.Zone
 	records []pvtz.Record
+	// describeZonesErr is returned by DescribeZones when set.
+	describeZonesErr error
 }
 
 func NewMockAlibabaCloudPrivateZoneAPI() *MockAlibabaCloudPrivateZoneAPI {
@@ -159,12 +354,14 @@ func NewMockAlibabaCloudPrivateZoneAPI() *MockAlibabaCloudPrivateZoneAPI {
 
 func (m *MockAlibabaCloudPrivateZoneAPI) AddZoneRecord(request *pvtz.AddZoneRecordRequest) (*pvtz.AddZoneRecordResponse, error) {
 	ttl, _ := request.Ttl.GetValue()
+	priority, _ := request.Priority.GetValue()
 	m.records = append(m.records, pvtz.Record{
 		RecordId: 3,
 		Type:     request.Type,
 		Ttl:      ttl,
 		Rr:       request.Rr,
 		Value:    request.Value,
+		Priority: priority,
 	})
 	return pvtz.CreateAddZoneRecordResponse(), nil
 }
@@ -200,6 +397,9 @@ func (m *MockAlibabaCloudPrivateZoneAPI) DescribeZoneRecords(request *pvtz.Descr
 }
 
 func (m *MockAlibabaCloudPrivateZoneAPI) DescribeZones(_ *pvtz.DescribeZonesRequest) (*pvtz.DescribeZonesResponse, error) {
+	if m.describeZonesErr != nil {
+		return nil, m.describeZonesErr
+	}
 	response := pvtz.CreateDescribeZonesResponse()
 	response.Zones.Zone = append(response.Zones.Zone, m.zone)
 	return response, nil
@@ -263,6 +463,28 @@ func TestAlibabaCloudProvider_Records(t *testing.T) {
 	}
 }
 
+func TestAlibabaCloudProvider_Records_Paginated(t *testing.T) {
+	for _, maxPageSize := range []int{0, 20} {
+		p := newTestAlibabaCloudProvider(false)
+		api := p.dnsClient.(*MockAlibabaCloudDNSAPI)
+		api.maxPageSize = maxPageSize
+		for i := range 120 {
+			api.records = append(api.records, alidns.Record{
+				RecordId:   fmt.Sprintf("page-%d", i),
+				DomainName: "container-service.top",
+				Type:       "A",
+				TTL:        300,
+				RR:         fmt.Sprintf("host%d", i),
+				Value:      "1.2.3.4",
+			})
+		}
+
+		endpoints, err := p.Records(context.Background())
+		assert.NoError(t, err)
+		assert.Len(t, endpoints, 122, "max page size: %d", maxPageSize)
+	}
+}
+
 func TestAlibabaCloudProvider_ApplyChanges(t *testing.T) {
 	p := newTestAlibabaCloudProvider(false)
 	defaultTtlPlan := &endpoint.Endpoint{
@@ -321,6 +543,120 @@ func TestAlibabaCloudProvider_ApplyChanges(t *testing.T) {
 	}
 }
 
+func TestAlibabaCloudProvider_ApplyChanges_DryRun(t *testing.T) {
+	for _, private := range []bool{false, true} {
+		t.Run(fmt.Sprintf("private=%t", private), func(t *testing.T) {
+			p := newTestAlibabaCloudProvider(private)
+			p.dryRun = true
+			ctx := context.Background()
+			before, err := p.Records(ctx)
+			require.NoError(t, err)
+
+			err = p.ApplyChanges(ctx, &plan.Changes{
+				Create: []*endpoint.Endpoint{
+					endpoint.NewEndpointWithTTL("xyz.container-service.top", endpoint.RecordTypeA, 300, "4.3.2.1"),
+				},
+				UpdateNew: []*endpoint.Endpoint{
+					endpoint.NewEndpointWithTTL("abc.container-service.top", endpoint.RecordTypeA, 500, "1.2.3.4", "5.6.7.8"),
+				},
+				Delete: []*endpoint.Endpoint{
+					endpoint.NewEndpointWithTTL("abc.container-service.top", endpoint.RecordTypeTXT, 300, "\"heritage=external-dns,external-dns/owner=default\""),
+				},
+			})
+			require.NoError(t, err)
+
+			after, err := p.Records(ctx)
+			require.NoError(t, err)
+			assert.ElementsMatch(t, before, after)
+		})
+	}
+}
+
+func TestAlibabaCloudProvider_Healthcheck(t *testing.T) {
+	unreachable := errors.New("connection refused")
+	for _, tt := range []struct {
+		name           string
+		private        bool
+		mixed          bool
+		dnsErr         error
+		pvtzErr        error
+		wantErr        bool
+		wantDNSChecked bool
+	}{
+		{name: "public zones", wantDNSChecked: true},
+		{name: "public zones unreachable", dnsErr: unreachable, wantErr: true, wantDNSChecked: true},
+		{name: "public zones ignore Private Zones", pvtzErr: unreachable, wantDNSChecked: true},
+		{name: "Private Zones", private: true},
+		{name: "Private Zones unreachable", private: true, pvtzErr: unreachable, wantErr: true},
+		{name: "Private Zones ignore public zones", private: true, dnsErr: unreachable},
+		{name: "mixed zone types", mixed: true, wantDNSChecked: true},
+		{name: "mixed zone types with Private Zones unreachable", mixed: true, pvtzErr: unreachable, wantErr: true, wantDNSChecked: true},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			p := newTestAlibabaCloudProvider(tt.private)
+			p.mixedZoneTypes = tt.mixed
+			dnsAPI := p.dnsClient.(*MockAlibabaCloudDNSAPI)
+			dnsAPI.describeDomainsErr = tt.dnsErr
+			p.pvtzClient.(*MockAlibabaCloudPrivateZoneAPI).describeZonesErr = tt.pvtzErr
+
+			err := p.Healthcheck(context.Background())
+			if tt.wantErr {
+				assert.ErrorIs(t, err, unreachable)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tt.wantDNSChecked, dnsAPI.describeDomainsCalls == 1)
+		})
+	}
+}
+
+func TestAlibabaCloudProvider_DescribeDomainsOncePerCall(t *testing.T) {
+	p := newTestAlibabaCloudProvider(false)
+	api := p.dnsClient.(*MockAlibabaCloudDNSAPI)
+	ctx := context.Background()
+
+	_, err := p.Records(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, api.describeDomainsCalls)
+
+	changes := plan.Changes{
+		Create: []*endpoint.Endpoint{
+			endpoint.NewEndpointWithTTL("xyz.container-service.top", "A", 300, "4.3.2.1"),
+			endpoint.NewEndpointWithTTL("xyz.container-service.top", "TXT", 300, "\"heritage=external-dns,external-dns/owner=default\""),
+		},
+		UpdateNew: []*endpoint.Endpoint{
+			endpoint.NewEndpointWithTTL("abc.container-service.top", "A", 600, "1.2.3.4"),
+		},
+	}
+	assert.NoError(t, p.ApplyChanges(ctx, &changes))
+	assert.Equal(t, 2, api.describeDomainsCalls)
+
+	// A zone created between two calls is picked up by the next one.
+	api.records = append(api.records, alidns.Record{
+		RecordId:   "20",
+		DomainName: "example.org",
+		Type:       "A",
+		TTL:        300,
+		RR:         "www",
+		Value:      "5.6.7.8",
+	})
+	changes = plan.Changes{
+		Create: []*endpoint.Endpoint{
+			endpoint.NewEndpointWithTTL("new.example.org", "A", 300, "8.7.6.5"),
+		},
+	}
+	assert.NoError(t, p.ApplyChanges(ctx, &changes))
+	assert.Equal(t, 3, api.describeDomainsCalls)
+
+	var created bool
+	for _, record := range api.records {
+		if record.DomainName == "example.org" && record.RR == "new" {
+			created = true
+		}
+	}
+	assert.True(t, created)
+}
+
 func TestAlibabaCloudProvider_ApplyChanges_HaveNoDefinedZoneDomain(t *testing.T) {
 	p := newTestAlibabaCloudProvider(false)
 	defaultTtlPlan := &endpoint.Endpoint{
@@ -379,6 +715,46 @@ func TestAlibabaCloudProvider_ApplyChanges_HaveNoDefinedZoneDomain(t *testing.T)
 	}
 }
 
+func TestAlibabaCloudProvider_ApplyChanges_StrictZoneMatching(t *testing.T) {
+	for _, private := range []bool{false, true} {
+		p := newTestAlibabaCloudProvider(private)
+		p.strictZoneMatching = true
+		changes := plan.Changes{
+			Create: []*endpoint.Endpoint{
+				endpoint.NewEndpointWithTTL("www.example.com", "A", 300, "9.9.9.9"),
+				endpoint.NewEndpointWithTTL("xyz.container-service.top", "A", 300, "4.3.2.1"),
+			},
+			UpdateNew: []*endpoint.Endpoint{
+				endpoint.NewEndpointWithTTL("api.example.net", "A", 300, "8.8.8.8"),
+			},
+		}
+		ctx := context.Background()
+		err := p.ApplyChanges(ctx, &changes)
+		assert.ErrorIs(t, err, provider.SoftError, "private zone: %t", private)
+		assert.ErrorContains(t, err, "www.example.com, api.example.net", "private zone: %t", private)
+
+		// Endpoints within a zone are still applied.
+		endpoints, err := p.Records(ctx)
+		assert.NoError(t, err)
+		var names []string
+		for _, ep := range endpoints {
+			names = append(names, ep.DNSName)
+		}
+		assert.Contains(t, names, "xyz.container-service.top", "private zone: %t", private)
+	}
+}
+
+func TestAlibabaCloudProvider_ApplyChanges_StrictZoneMatchingAllMatched(t *testing.T) {
+	p := newTestAlibabaCloudProvider(false)
+	p.strictZoneMatching = true
+	changes := plan.Changes{
+		Create: []*endpoint.Endpoint{
+			endpoint.NewEndpointWithTTL("xyz.container-service.top", "A", 300, "4.3.2.1"),
+		},
+	}
+	assert.NoError(t, p.ApplyChanges(context.Background(), &changes))
+}
+
 func TestAlibabaCloudProvider_Records_PrivateZone(t *testing.T) {
 	p := newTestAlibabaCloudProvider(true)
 	endpoints, err := p.Records(context.Background())
@@ -394,121 +770,1352 @@ func TestAlibabaCloudProvider_Records_PrivateZone(t *testing.T) {
 	}
 }
 
-func TestAlibabaCloudProvider_ApplyChanges_PrivateZone(t *testing.T) {
-	p := newTestAlibabaCloudProvider(true)
+func TestAlibabaCloudProvider_Records_Apex(t *testing.T) {
+	p := newTestAlibabaCloudProvider(false)
+	p.dnsClient.(*MockAlibabaCloudDNSAPI).records = append(p.dnsClient.(*MockAlibabaCloudDNSAPI).records, alidns.Record{
+		RecordId:   "4",
+		DomainName: "container-service.top",
+		Type:       "A",
+		TTL:        300,
+		RR:         "@",
+		Value:      "5.6.7.8",
+	})
+
+	endpoints, err := p.Records(context.Background())
+	assert.NoError(t, err)
+	assert.Len(t, endpoints, 3)
+
+	var apex *endpoint.Endpoint
+	for _, ep := range endpoints {
+		assert.NotContains(t, ep.DNSName, "@")
+		if ep.DNSName == "container-service.top" {
+			apex = ep
+		}
+	}
+	if assert.NotNil(t, apex) {
+		assert.Equal(t, endpoint.RecordTypeA, apex.RecordType)
+		assert.Equal(t, endpoint.NewTargets("5.6.7.8"), apex.Targets)
+	}
+}
+
+func TestAlibabaCloudProvider_ApplyChanges_Apex(t *testing.T) {
+	p := newTestAlibabaCloudProvider(false)
 	changes := plan.Changes{
 		Create: []*endpoint.Endpoint{
 			{
-				DNSName:    "xyz.container-service.top",
-				RecordType: "A",
-				RecordTTL:  300,
-				Targets:    endpoint.NewTargets("4.3.2.1"),
-			},
-		},
-		UpdateNew: []*endpoint.Endpoint{
-			{
-				DNSName:    "abc.container-service.top",
+				DNSName:    "container-service.top",
 				RecordType: "A",
-				RecordTTL:  500,
-				Targets:    endpoint.NewTargets("1.2.3.4", "5.6.7.8"),
-			},
-		},
-		Delete: []*endpoint.Endpoint{
-			{
-				DNSName:    "abc.container-service.top",
-				RecordType: "TXT",
 				RecordTTL:  300,
-				Targets:    endpoint.NewTargets("\"heritage=external-dns,external-dns/owner=default\""),
+				Targets:    endpoint.NewTargets("5.6.7.8"),
 			},
 		},
 	}
 	ctx := context.Background()
-	err := p.ApplyChanges(ctx, &changes)
-	assert.NoError(t, err)
+	assert.NoError(t, p.ApplyChanges(ctx, &changes))
+
+	records := p.dnsClient.(*MockAlibabaCloudDNSAPI).records
+	assert.Equal(t, "@", records[len(records)-1].RR)
+
 	endpoints, err := p.Records(ctx)
-	if err != nil {
-		t.Errorf("Failed to get records: %v", err)
-	} else {
-		if len(endpoints) != 2 {
-			t.Errorf("Incorrect number of records: %d", len(endpoints))
+	assert.NoError(t, err)
+	var names []string
+	for _, ep := range endpoints {
+		names = append(names, ep.DNSName)
+	}
+	assert.Contains(t, names, "container-service.top")
+	assert.NotContains(t, names, "@.container-service.top")
+}
+
+func TestAlibabaCloudProvider_ApplyChanges_AAAA(t *testing.T) {
+	for _, private := range []bool{false, true} {
+		p := newTestAlibabaCloudProvider(private)
+		changes := plan.Changes{
+			Create: []*endpoint.Endpoint{
+				{
+					DNSName:    "ipv6.container-service.top",
+					RecordType: endpoint.RecordTypeAAAA,
+					RecordTTL:  300,
+					Targets:    endpoint.NewTargets("2001:db8::1"),
+				},
+			},
 		}
+		ctx := context.Background()
+		assert.NoError(t, p.ApplyChanges(ctx, &changes))
+
+		endpoints, err := p.Records(ctx)
+		assert.NoError(t, err)
+		assert.Len(t, endpoints, 3)
+
+		var aaaa *endpoint.Endpoint
 		for _, ep := range endpoints {
-			t.Logf("Endpoint for %++v", *ep)
+			if ep.DNSName == "ipv6.container-service.top" {
+				aaaa = ep
+			}
+		}
+		if assert.NotNil(t, aaaa, "private zone: %t", private) {
+			assert.Equal(t, endpoint.RecordTypeAAAA, aaaa.RecordType)
+			assert.Equal(t, endpoint.TTL(300), aaaa.RecordTTL)
+			assert.Equal(t, endpoint.NewTargets("2001:db8::1"), aaaa.Targets)
 		}
 	}
 }
 
-func TestAlibabaCloudProvider_splitDNSName(t *testing.T) {
+func TestAlibabaCloudProvider_ApplyChanges_UpdateAAAA(t *testing.T) {
 	p := newTestAlibabaCloudProvider(false)
-	endpoint := &endpoint.Endpoint{}
-	hostedZoneDomains := []string{"container-service.top", "example.org"}
-
-	var emptyZoneDomains []string
+	api := p.dnsClient.(*MockAlibabaCloudDNSAPI)
+	api.records = append(api.records, alidns.Record{
+		RecordId:   "4",
+		DomainName: "container-service.top",
+		Type:       "AAAA",
+		TTL:        300,
+		RR:         "ipv6",
+		Value:      "2001:db8:0:0:0:0:0:1",
+	})
 
-	endpoint.DNSName = "www.example.org"
-	rr, domain := p.splitDNSName(endpoint.DNSName, hostedZoneDomains)
-	if rr != "www" || domain != "example.org" {
-		t.Errorf("Failed to splitDNSName for %s: rr=%s, domain=%s", endpoint.DNSName, rr, domain)
-	}
-	endpoint.DNSName = ".example.org"
-	rr, domain = p.splitDNSName(endpoint.DNSName, hostedZoneDomains)
-	if rr != "@" || domain != "example.org" {
-		t.Errorf("Failed to splitDNSName for %s: rr=%s, domain=%s", endpoint.DNSName, rr, domain)
-	}
-	endpoint.DNSName = "www"
-	rr, domain = p.splitDNSName(endpoint.DNSName, hostedZoneDomains)
-	if rr != "@" || domain != "" {
-		t.Errorf("Failed to splitDNSName for %s: rr=%s, domain=%s", endpoint.DNSName, rr, domain)
-	}
-	endpoint.DNSName = ""
-	rr, domain = p.splitDNSName(endpoint.DNSName, hostedZoneDomains)
-	if rr != "@" || domain != "" {
-		t.Errorf("Failed to splitDNSName for %s: rr=%s, domain=%s", endpoint.DNSName, rr, domain)
-	}
-	endpoint.DNSName = "_30000._tcp.container-service.top"
-	rr, domain = p.splitDNSName(endpoint.DNSName, hostedZoneDomains)
-	if rr != "_30000._tcp" || domain != "container-service.top" {
-		t.Errorf("Failed to splitDNSName for %s: rr=%s, domain=%s", endpoint.DNSName, rr, domain)
-	}
-	endpoint.DNSName = "container-service.top"
-	rr, domain = p.splitDNSName(endpoint.DNSName, hostedZoneDomains)
-	if rr != "@" || domain != "container-service.top" {
-		t.Errorf("Failed to splitDNSName for %s: rr=%s, domain=%s", endpoint.DNSName, rr, domain)
-	}
-	endpoint.DNSName = "a.b.container-service.top"
-	rr, domain = p.splitDNSName(endpoint.DNSName, hostedZoneDomains)
-	if rr != "a.b" || domain != "container-service.top" {
-		t.Errorf("Failed to splitDNSName for %s: rr=%s, domain=%s", endpoint.DNSName, rr, domain)
+	changes := plan.Changes{
+		UpdateNew: []*endpoint.Endpoint{
+			{
+				DNSName:    "ipv6.container-service.top",
+				RecordType: endpoint.RecordTypeAAAA,
+				RecordTTL:  600,
+				Targets:    endpoint.NewTargets("2001:db8::1"),
+			},
+		},
 	}
-	endpoint.DNSName = "a.b.c.container-service.top"
-	rr, domain = p.splitDNSName(endpoint.DNSName, hostedZoneDomains)
-	if rr != "a.b.c" || domain != "container-service.top" {
-		t.Errorf("Failed to splitDNSName for %s: rr=%s, domain=%s", endpoint.DNSName, rr, domain)
+	assert.NoError(t, p.ApplyChanges(context.Background(), &changes))
+
+	// The record is updated in place rather than recreated with the compressed notation.
+	var aaaa []alidns.Record
+	for _, record := range api.records {
+		if record.Type == "AAAA" {
+			aaaa = append(aaaa, record)
+		}
 	}
-	endpoint.DNSName = "a.b.c.container-service.top"
-	rr, domain = p.splitDNSName(endpoint.DNSName, []string{"c.container-service.top"})
-	if rr != "a.b" || domain != "c.container-service.top" {
-		t.Errorf("Failed to splitDNSName for %s: rr=%s, domain=%s", endpoint.DNSName, rr, domain)
+	if assert.Len(t, aaaa, 1) {
+		assert.Equal(t, "4", aaaa[0].RecordId)
+		assert.Equal(t, int64(600), aaaa[0].TTL)
 	}
+}
 
-	endpoint.DNSName = "a.b.c.container-service.top"
-	rr, domain = p.splitDNSName(endpoint.DNSName, []string{"container-service.top", "c.container-service.top"})
-	if rr != "a.b" || domain != "c.container-service.top" {
-		t.Errorf("Failed to splitDNSName for %s: rr=%s, domain=%s", endpoint.DNSName, rr, domain)
+func TestAlibabaCloudProvider_ApplyChanges_TTLOnly(t *testing.T) {
+	for _, tt := range []struct {
+		name     string
+		endpoint *endpoint.Endpoint
+		recordID string
+		value    string
+	}{
+		{
+			name:     "A record",
+			endpoint: endpoint.NewEndpointWithTTL("abc.container-service.top", endpoint.RecordTypeA, 600, "1.2.3.4"),
+			recordID: "1",
+			value:    "1.2.3.4",
+		},
+		{
+			name:     "TXT record",
+			endpoint: endpoint.NewEndpointWithTTL("abc.container-service.top", endpoint.RecordTypeTXT, 600, "\"heritage=external-dns,external-dns/owner=default\""),
+			recordID: "2",
+			value:    "heritage=external-dns;external-dns/owner=default",
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			p := newTestAlibabaCloudProvider(false)
+			api := p.dnsClient.(*MockAlibabaCloudDNSAPI)
+
+			changes := plan.Changes{UpdateNew: []*endpoint.Endpoint{tt.endpoint}}
+			require.NoError(t, p.ApplyChanges(context.Background(), &changes))
+
+			assert.Equal(t, []string{tt.recordID}, api.updated)
+			assert.Empty(t, api.deleted)
+			assert.Zero(t, api.added)
+			if assert.Len(t, api.updateRequests, 1) {
+				assert.Equal(t, tt.value, api.updateRequests[0].Value)
+				assert.Equal(t, "600", string(api.updateRequests[0].TTL))
+			}
+			for _, record := range api.records {
+				if record.RecordId == tt.recordID {
+					assert.Equal(t, int64(600), record.TTL)
+					assert.Equal(t, tt.value, record.Value)
+				}
+			}
+		})
 	}
-	rr, domain = p.splitDNSName(endpoint.DNSName, emptyZoneDomains)
-	if rr != "@" || domain != "" {
-		t.Errorf("Failed to splitDNSName with emptyZoneDomains for %s: rr=%s, domain=%s", endpoint.DNSName, rr, domain)
+}
+
+func TestAlibabaCloudProvider_ApplyChanges_MX(t *testing.T) {
+	for _, private := range []bool{false, true} {
+		p := newTestAlibabaCloudProvider(private)
+		changes := plan.Changes{
+			Create: []*endpoint.Endpoint{
+				{
+					DNSName:    "mail.container-service.top",
+					RecordType: endpoint.RecordTypeMX,
+					RecordTTL:  300,
+					Targets:    endpoint.NewTargets("10 mx.container-service.top"),
+				},
+			},
+		}
+		ctx := context.Background()
+		assert.NoError(t, p.ApplyChanges(ctx, &changes))
+
+		if private {
+			records := p.pvtzClient.(*MockAlibabaCloudPrivateZoneAPI).records
+			assert.Equal(t, 10, records[len(records)-1].Priority)
+			assert.Equal(t, "mx.container-service.top", records[len(records)-1].Value)
+		} else {
+			records := p.dnsClient.(*MockAlibabaCloudDNSAPI).records
+			assert.Equal(t, int64(10), records[len(records)-1].Priority)
+			assert.Equal(t, "mx.container-service.top", records[len(records)-1].Value)
+		}
+
+		endpoints, err := p.Records(ctx)
+		assert.NoError(t, err)
+
+		var mx *endpoint.Endpoint
+		for _, ep := range endpoints {
+			if ep.RecordType == endpoint.RecordTypeMX {
+				mx = ep
+			}
+		}
+		if assert.NotNil(t, mx, "private zone: %t", private) {
+			assert.Equal(t, "mail.container-service.top", mx.DNSName)
+			assert.Equal(t, endpoint.NewTargets("10 mx.container-service.top"), mx.Targets)
+		}
 	}
-	rr, domain = p.splitDNSName(endpoint.DNSName, []string{"example.com"})
-	if rr != "@" || domain != "" {
-		t.Errorf("Failed to splitDNSName for %s: rr=%s, domain=%s", endpoint.DNSName, rr, domain)
+}
+
+func TestAlibabaCloudProvider_ApplyChanges_CAA(t *testing.T) {
+	for _, target := range []string{`0 issue "letsencrypt.org"`, "0 ISSUE letsencrypt.org"} {
+		t.Run(target, func(t *testing.T) {
+			p := newTestAlibabaCloudProvider(false)
+			changes := plan.Changes{
+				Create: []*endpoint.Endpoint{
+					endpoint.NewEndpointWithTTL("container-service.top", endpoint.RecordTypeCAA, 300, target),
+				},
+			}
+			ctx := context.Background()
+			require.NoError(t, p.ApplyChanges(ctx, &changes))
+
+			records := p.dnsClient.(*MockAlibabaCloudDNSAPI).records
+			assert.Equal(t, "@", records[len(records)-1].RR)
+			assert.Equal(t, `0 issue "letsencrypt.org"`, records[len(records)-1].Value)
+
+			endpoints, err := p.Records(ctx)
+			require.NoError(t, err)
+
+			var caa *endpoint.Endpoint
+			for _, ep := range endpoints {
+				if ep.RecordType == endpoint.RecordTypeCAA {
+					caa = ep
+				}
+			}
+			require.NotNil(t, caa)
+			assert.Equal(t, "container-service.top", caa.DNSName)
+			assert.Equal(t, endpoint.NewTargets(`0 issue "letsencrypt.org"`), caa.Targets)
+
+			parsed, err := endpoint.NewCAARecord(caa.Targets[0])
+			require.NoError(t, err)
+			assert.Equal(t, uint8(0), parsed.GetFlags())
+			assert.Equal(t, "issue", parsed.GetTag())
+			assert.Equal(t, "letsencrypt.org", parsed.GetValue())
+
+			// Applying the same target again must neither create nor delete records.
+			api := p.dnsClient.(*MockAlibabaCloudDNSAPI)
+			added := api.added
+			changes = plan.Changes{
+				UpdateOld: []*endpoint.Endpoint{caa},
+				UpdateNew: []*endpoint.Endpoint{endpoint.NewEndpointWithTTL("container-service.top", endpoint.RecordTypeCAA, 300, target)},
+			}
+			require.NoError(t, p.ApplyChanges(ctx, &changes))
+			assert.Equal(t, added, api.added)
+			assert.Empty(t, api.deleted)
+		})
 	}
 }
 
-func TestAlibabaCloudProvider_TXTEndpoint(t *testing.T) {
+func TestAlibabaCloudProvider_ApplyChanges_InvalidCAA(t *testing.T) {
 	p := newTestAlibabaCloudProvider(false)
-	const recordValue = "heritage=external-dns,external-dns/owner=default"
+	changes := plan.Changes{
+		Create: []*endpoint.Endpoint{
+			endpoint.NewEndpoint("container-service.top", endpoint.RecordTypeCAA, "issue letsencrypt.org"),
+		},
+	}
+	assert.Error(t, p.ApplyChanges(context.Background(), &changes))
+	for _, record := range p.dnsClient.(*MockAlibabaCloudDNSAPI).records {
+		assert.NotEqual(t, endpoint.RecordTypeCAA, record.Type)
+	}
+}
+
+func TestAlibabaCloudProvider_ApplyChanges_InvalidMX(t *testing.T) {
+	for _, private := range []bool{false, true} {
+		p := newTestAlibabaCloudProvider(private)
+		changes := plan.Changes{
+			Create: []*endpoint.Endpoint{
+				{
+					DNSName:    "mail.container-service.top",
+					RecordType: endpoint.RecordTypeMX,
+					Targets:    endpoint.NewTargets("mx.container-service.top"),
+				},
+				{
+					DNSName:    "xyz.container-service.top",
+					RecordType: endpoint.RecordTypeA,
+					Targets:    endpoint.NewTargets("4.3.2.1"),
+				},
+			},
+		}
+		ctx := context.Background()
+		err := p.ApplyChanges(ctx, &changes)
+		assert.ErrorIs(t, err, provider.SoftError)
+
+		endpoints, err := p.Records(ctx)
+		assert.NoError(t, err)
+		var names []string
+		for _, ep := range endpoints {
+			names = append(names, ep.DNSName)
+		}
+		assert.Contains(t, names, "xyz.container-service.top", "private zone: %t", private)
+		assert.NotContains(t, names, "mail.container-service.top", "private zone: %t", private)
+	}
+}
+
+func TestAlibabaCloudProvider_ApplyChanges_SRV(t *testing.T) {
+	p := newTestAlibabaCloudProvider(false)
+	api := p.dnsClient.(*MockAlibabaCloudDNSAPI)
+	changes := plan.Changes{
+		Create: []*endpoint.Endpoint{
+			{
+				DNSName:    "_sip._tcp.container-service.top",
+				RecordType: endpoint.RecordTypeSRV,
+				RecordTTL:  300,
+				Targets:    endpoint.NewTargets("10 5 5060 sip.container-service.top"),
+			},
+		},
+	}
+	ctx := context.Background()
+	assert.NoError(t, p.ApplyChanges(ctx, &changes))
+
+	created := api.records[len(api.records)-1]
+	assert.Equal(t, "_sip._tcp", created.RR)
+	assert.Equal(t, int64(10), created.Priority)
+	assert.Equal(t, "5 5060 sip.container-service.top", created.Value)
+
+	endpoints, err := p.Records(ctx)
+	assert.NoError(t, err)
+	var srv *endpoint.Endpoint
+	for _, ep := range endpoints {
+		if ep.RecordType == endpoint.RecordTypeSRV {
+			srv = ep
+		}
+	}
+	if assert.NotNil(t, srv) {
+		assert.Equal(t, "_sip._tcp.container-service.top", srv.DNSName)
+		assert.Equal(t, endpoint.NewTargets("10 5 5060 sip.container-service.top"), srv.Targets)
+	}
+
+	// Changing the TTL updates the record in place.
+	changes = plan.Changes{
+		UpdateOld: []*endpoint.Endpoint{srv},
+		UpdateNew: []*endpoint.Endpoint{
+			{
+				DNSName:    "_sip._tcp.container-service.top",
+				RecordType: endpoint.RecordTypeSRV,
+				RecordTTL:  600,
+				Targets:    endpoint.NewTargets("10 5 5060 sip.container-service.top"),
+			},
+		},
+	}
+	assert.NoError(t, p.ApplyChanges(ctx, &changes))
+
+	var srvRecords []alidns.Record
+	for _, record := range api.records {
+		if record.Type == endpoint.RecordTypeSRV {
+			srvRecords = append(srvRecords, record)
+		}
+	}
+	if assert.Len(t, srvRecords, 1) {
+		assert.Equal(t, int64(600), srvRecords[0].TTL)
+		assert.Equal(t, int64(10), srvRecords[0].Priority)
+	}
+}
+
+func TestAlibabaCloudProvider_ApplyChanges_InvalidSRV(t *testing.T) {
+	p := newTestAlibabaCloudProvider(false)
+	changes := plan.Changes{
+		Create: []*endpoint.Endpoint{
+			{
+				DNSName:    "_sip._tcp.container-service.top",
+				RecordType: endpoint.RecordTypeSRV,
+				Targets:    endpoint.NewTargets("5060 sip.container-service.top"),
+			},
+		},
+	}
+	assert.ErrorIs(t, p.ApplyChanges(context.Background(), &changes), provider.SoftError)
+	for _, record := range p.dnsClient.(*MockAlibabaCloudDNSAPI).records {
+		assert.NotEqual(t, endpoint.RecordTypeSRV, record.Type)
+	}
+}
+
+func TestSplitSRVTarget(t *testing.T) {
+	priority, value, err := splitSRVTarget("10 5 5060 sip.container-service.top")
+	assert.NoError(t, err)
+	assert.Equal(t, 10, priority)
+	assert.Equal(t, "5 5060 sip.container-service.top", value)
+
+	for _, target := range []string{"5 5060 sip.container-service.top", "a 5 5060 sip.container-service.top", ""} {
+		_, _, err := splitSRVTarget(target)
+		assert.Error(t, err, target)
+	}
+}
+
+func newTestAlibabaCloudBatchProvider() (*AlibabaCloudProvider, *MockAlibabaCloudDNSBatchAPI) {
+	p := newTestAlibabaCloudProvider(false)
+	api := &MockAlibabaCloudDNSBatchAPI{MockAlibabaCloudDNSAPI: p.dnsClient.(*MockAlibabaCloudDNSAPI)}
+	api.records = append(api.records, alidns.Record{
+		RecordId:   "10",
+		DomainName: "example.org",
+		Type:       "A",
+		TTL:        300,
+		RR:         "www",
+		Value:      "5.6.7.8",
+	})
+	p.dnsClient = api
+	p.batchChanges = true
+	return p, api
+}
+
+func TestAlibabaCloudProvider_ApplyChanges_Batch(t *testing.T) {
+	p, api := newTestAlibabaCloudBatchProvider()
+	api.running = 2
+	changes := plan.Changes{
+		Create: []*endpoint.Endpoint{
+			endpoint.NewEndpointWithTTL("xyz.container-service.top", "A", 300, "4.3.2.1", "4.3.2.2"),
+			endpoint.NewEndpoint("mail.container-service.top", endpoint.RecordTypeMX, "10 mx.container-service.top"),
+			endpoint.NewEndpointWithTTL("api.example.org", "A", 600, "8.8.8.8"),
+			endpoint.NewEndpoint("www.example.com", "A", "9.9.9.9"),
+		},
+		Delete: []*endpoint.Endpoint{
+			endpoint.NewEndpoint("www.example.org", "A", "5.6.7.8"),
+		},
+	}
+	ctx := context.Background()
+	assert.NoError(t, p.ApplyChanges(ctx, &changes))
+
+	// One task per zone and operation type, without per-record calls.
+	assert.Len(t, api.tasks, 3)
+	assert.Equal(t, 0, api.addDomainRecordCalls)
+	assert.Equal(t, 0, api.deleteDomainRecordCalls)
+	assert.Equal(t, 5, api.describeBatchResultCalls)
+
+	endpoints, err := p.Records(ctx)
+	assert.NoError(t, err)
+	records := map[string]*endpoint.Endpoint{}
+	for _, ep := range endpoints {
+		records[ep.DNSName] = ep
+	}
+	assert.NotContains(t, records, "www.example.org")
+	assert.NotContains(t, records, "www.example.com")
+	if assert.Contains(t, records, "xyz.container-service.top") {
+		assert.ElementsMatch(t, endpoint.NewTargets("4.3.2.1", "4.3.2.2"), records["xyz.container-service.top"].Targets)
+		assert.Equal(t, endpoint.TTL(300), records["xyz.container-service.top"].RecordTTL)
+	}
+	if assert.Contains(t, records, "mail.container-service.top") {
+		assert.Equal(t, endpoint.NewTargets("10 mx.container-service.top"), records["mail.container-service.top"].Targets)
+	}
+	if assert.Contains(t, records, "api.example.org") {
+		assert.Equal(t, endpoint.TTL(600), records["api.example.org"].RecordTTL)
+	}
+}
+
+func TestAlibabaCloudProvider_ApplyChanges_BatchPartialFailure(t *testing.T) {
+	p, api := newTestAlibabaCloudBatchProvider()
+	api.failValues = map[string]bool{"4.3.2.2": true, "5.6.7.8": true}
+	changes := plan.Changes{
+		Create: []*endpoint.Endpoint{
+			endpoint.NewEndpointWithTTL("xyz.container-service.top", "A", 300, "4.3.2.1", "4.3.2.2"),
+		},
+		Delete: []*endpoint.Endpoint{
+			endpoint.NewEndpoint("www.example.org", "A", "5.6.7.8"),
+		},
+	}
+	ctx := context.Background()
+	assert.NoError(t, p.ApplyChanges(ctx, &changes))
+
+	// Only the failed operations are applied again one by one.
+	assert.Equal(t, 1, api.addDomainRecordCalls)
+	assert.Equal(t, 1, api.deleteDomainRecordCalls)
+
+	endpoints, err := p.Records(ctx)
+	assert.NoError(t, err)
+	records := map[string]*endpoint.Endpoint{}
+	for _, ep := range endpoints {
+		records[ep.DNSName] = ep
+	}
+	assert.NotContains(t, records, "www.example.org")
+	if assert.Contains(t, records, "xyz.container-service.top") {
+		assert.ElementsMatch(t, endpoint.NewTargets("4.3.2.1", "4.3.2.2"), records["xyz.container-service.top"].Targets)
+	}
+}
+
+func TestAlibabaCloudProvider_ApplyChanges_BatchUnavailable(t *testing.T) {
+	p, api := newTestAlibabaCloudBatchProvider()
+	api.unavailable = true
+	changes := plan.Changes{
+		Create: []*endpoint.Endpoint{
+			endpoint.NewEndpointWithTTL("xyz.container-service.top", "A", 300, "4.3.2.1", "4.3.2.2"),
+		},
+		Delete: []*endpoint.Endpoint{
+			endpoint.NewEndpoint("www.example.org", "A", "5.6.7.8"),
+		},
+	}
+	assert.NoError(t, p.ApplyChanges(context.Background(), &changes))
+
+	assert.Empty(t, api.tasks)
+	assert.Equal(t, 2, api.addDomainRecordCalls)
+	assert.Equal(t, 1, api.deleteDomainRecordCalls)
+}
+
+func TestAlibabaCloudProvider_ApplyChanges_BatchDisabled(t *testing.T) {
+	p, api := newTestAlibabaCloudBatchProvider()
+	p.batchChanges = false
+	changes := plan.Changes{
+		Create: []*endpoint.Endpoint{
+			endpoint.NewEndpointWithTTL("xyz.container-service.top", "A", 300, "4.3.2.1"),
+		},
+	}
+	assert.NoError(t, p.ApplyChanges(context.Background(), &changes))
+
+	assert.Empty(t, api.tasks)
+	assert.Equal(t, 1, api.addDomainRecordCalls)
+}
+
+func TestAlibabaCloudProvider_ApplyChanges_BatchWeights(t *testing.T) {
+	p, api := newTestAlibabaCloudBatchProvider()
+	changes := plan.Changes{
+		Create: []*endpoint.Endpoint{
+			endpoint.NewEndpointWithTTL("xyz.container-service.top", "A", 300, "4.3.2.1"),
+			endpoint.NewEndpointWithTTL("api.container-service.top", "A", 300, "4.3.2.2").
+				WithSetIdentifier("blue").WithProviderSpecific(providerSpecificWeight, "60"),
+		},
+	}
+	assert.NoError(t, p.ApplyChanges(context.Background(), &changes))
+
+	// weighted records are created one by one since batch tasks cannot weight them
+	if assert.Len(t, api.tasks, 1) {
+		assert.Len(t, api.tasks[0], 1)
+	}
+	assert.Equal(t, 1, api.addDomainRecordCalls)
+	weighted := slices.IndexFunc(api.records, func(record alidns.Record) bool { return record.Value == "4.3.2.2" })
+	if assert.NotEqual(t, -1, weighted) {
+		assert.Equal(t, 60, api.records[weighted].Weight)
+		assert.Equal(t, setIdentifierRemarkPrefix+"blue", api.records[weighted].Remark)
+	}
+}
+
+func newThrottlingError(code string) error {
+	return aliyunerrors.NewServerError(http.StatusServiceUnavailable, fmt.Sprintf(`{"Code": %q, "Message": "Request was denied due to flow control."}`, code), "")
+}
+
+func TestIsThrottlingError(t *testing.T) {
+	assert.True(t, isThrottlingError(newThrottlingError("Throttling")))
+	assert.True(t, isThrottlingError(newThrottlingError("Throttling.User")))
+	assert.True(t, isThrottlingError(newThrottlingError("ServiceUnavailable")))
+	assert.True(t, isThrottlingError(fmt.Errorf("wrapped: %w", newThrottlingError("Throttling.Api"))))
+	assert.False(t, isThrottlingError(newThrottlingError("InvalidParameter")))
+	assert.False(t, isThrottlingError(errors.New("Throttling")))
+	assert.False(t, isThrottlingError(nil))
+}
+
+func TestWithRetry(t *testing.T) {
+	retry := alibabaCloudRetry{maxAttempts: 3, baseDelay: time.Millisecond}
+
+	t.Run("retries throttling errors until success", func(t *testing.T) {
+		calls := 0
+		result, err := withRetry(context.Background(), retry, func() (int, error) {
+			calls++
+			if calls < 3 {
+				return 0, newThrottlingError("Throttling")
+			}
+			return 42, nil
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, 42, result)
+		assert.Equal(t, 3, calls)
+	})
+
+	t.Run("gives up after max attempts", func(t *testing.T) {
+		calls := 0
+		_, err := withRetry(context.Background(), retry, func() (int, error) {
+			calls++
+			return 0, newThrottlingError("ServiceUnavailable")
+		})
+		assert.True(t, isThrottlingError(err))
+		assert.Equal(t, 3, calls)
+	})
+
+	t.Run("does not retry other errors", func(t *testing.T) {
+		calls := 0
+		_, err := withRetry(context.Background(), retry, func() (int, error) {
+			calls++
+			return 0, newThrottlingError("InvalidParameter")
+		})
+		assert.Error(t, err)
+		assert.Equal(t, 1, calls)
+	})
+
+	t.Run("stops when the context is done", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		calls := 0
+		_, err := withRetry(ctx, alibabaCloudRetry{maxAttempts: 3, baseDelay: time.Hour}, func() (int, error) {
+			calls++
+			return 0, newThrottlingError("Throttling")
+		})
+		assert.ErrorIs(t, err, context.Canceled)
+		assert.Equal(t, 1, calls)
+	})
+}
+
+func TestNewAlibabaCloudRetry(t *testing.T) {
+	assert.Equal(t, alibabaCloudRetry{maxAttempts: defaultAlibabaCloudRetryMaxAttempts, baseDelay: defaultAlibabaCloudRetryBaseDelay}, newAlibabaCloudRetry(alibabaCloudConfig{}))
+	assert.Equal(t, alibabaCloudRetry{maxAttempts: 2, baseDelay: time.Second}, newAlibabaCloudRetry(alibabaCloudConfig{RetryMaxAttempts: 2, RetryBaseDelay: time.Second}))
+}
+
+func TestAlibabaCloudProvider_ApplyChanges_Throttled(t *testing.T) {
+	p := newTestAlibabaCloudProvider(false)
+	p.retry = alibabaCloudRetry{maxAttempts: 3, baseDelay: time.Millisecond}
+	p.dnsClient.(*MockAlibabaCloudDNSAPI).throttled = 2
+
+	changes := plan.Changes{
+		Create: []*endpoint.Endpoint{
+			{
+				DNSName:    "xyz.container-service.top",
+				RecordType: "A",
+				RecordTTL:  300,
+				Targets:    endpoint.NewTargets("4.3.2.1"),
+			},
+		},
+	}
+	ctx := context.Background()
+	assert.NoError(t, p.ApplyChanges(ctx, &changes))
+
+	endpoints, err := p.Records(ctx)
+	assert.NoError(t, err)
+	assert.Len(t, endpoints, 3)
+}
+
+func TestSameRecordValue(t *testing.T) {
+	assert.True(t, sameRecordValue(endpoint.RecordTypeAAAA, "2001:db8:0:0:0:0:0:1", "2001:db8::1"))
+	assert.True(t, sameRecordValue(endpoint.RecordTypeAAAA, "2001:DB8::1", "2001:db8::1"))
+	assert.False(t, sameRecordValue(endpoint.RecordTypeAAAA, "2001:db8::2", "2001:db8::1"))
+	assert.True(t, sameRecordValue(endpoint.RecordTypeA, "1.2.3.4", "1.2.3.4"))
+	assert.False(t, sameRecordValue(endpoint.RecordTypeCNAME, "a.example.org", "A.example.org"))
+}
+
+func newTestAlibabaCloudProviderWithLines() *AlibabaCloudProvider {
+	p := newTestAlibabaCloudProvider(false)
+	api := p.dnsClient.(*MockAlibabaCloudDNSAPI)
+	api.records = append(api.records,
+		alidns.Record{
+			RecordId:   "10",
+			DomainName: "container-service.top",
+			Type:       "A",
+			TTL:        300,
+			RR:         "www",
+			Value:      "1.1.1.1",
+			Line:       "default",
+		},
+		alidns.Record{
+			RecordId:   "11",
+			DomainName: "container-service.top",
+			Type:       "A",
+			TTL:        300,
+			RR:         "www",
+			Value:      "2.2.2.2",
+			Line:       "telecom",
+		},
+	)
+	return p
+}
+
+func TestAlibabaCloudProvider_Records_Lines(t *testing.T) {
+	p := newTestAlibabaCloudProviderWithLines()
+	endpoints, err := p.Records(context.Background())
+	assert.NoError(t, err)
+	assert.Len(t, endpoints, 4)
+
+	byIdentifier := map[string]*endpoint.Endpoint{}
+	for _, ep := range endpoints {
+		if ep.DNSName == "www.container-service.top" {
+			byIdentifier[ep.SetIdentifier] = ep
+		}
+	}
+	if assert.Len(t, byIdentifier, 2) {
+		assert.Equal(t, endpoint.NewTargets("1.1.1.1"), byIdentifier[""].Targets)
+		assert.Empty(t, byIdentifier[""].ProviderSpecific)
+
+		assert.Equal(t, endpoint.NewTargets("2.2.2.2"), byIdentifier["telecom"].Targets)
+		line, ok := byIdentifier["telecom"].GetProviderSpecificProperty(providerSpecificLine)
+		assert.True(t, ok)
+		assert.Equal(t, "telecom", line)
+	}
+}
+
+func TestAlibabaCloudProvider_AdjustEndpoints_Lines(t *testing.T) {
+	endpoints := []*endpoint.Endpoint{
+		endpoint.NewEndpoint("www.container-service.top", "A", "1.1.1.1"),
+		endpoint.NewEndpoint("www.container-service.top", "A", "1.1.1.1").WithProviderSpecific(providerSpecificLine, "default"),
+		endpoint.NewEndpoint("www.container-service.top", "A", "2.2.2.2").WithProviderSpecific(providerSpecificLine, "unicom"),
+	}
+
+	adjusted, err := newTestAlibabaCloudProvider(false).AdjustEndpoints(endpoints)
+	assert.NoError(t, err)
+	assert.Equal(t, "", adjusted[0].SetIdentifier)
+	assert.Empty(t, adjusted[0].ProviderSpecific)
+	assert.Equal(t, "", adjusted[1].SetIdentifier)
+	assert.Empty(t, adjusted[1].ProviderSpecific)
+	assert.Equal(t, "unicom", adjusted[2].SetIdentifier)
+	assert.Equal(t, endpoint.ProviderSpecific{{Name: providerSpecificLine, Value: "unicom"}}, adjusted[2].ProviderSpecific)
+}
+
+func TestAlibabaCloudProvider_ApplyChanges_Lines(t *testing.T) {
+	p := newTestAlibabaCloudProviderWithLines()
+	api := p.dnsClient.(*MockAlibabaCloudDNSAPI)
+
+	changes := plan.Changes{
+		Create: []*endpoint.Endpoint{
+			endpoint.NewEndpointWithTTL("www.container-service.top", "A", 300, "3.3.3.3").
+				WithSetIdentifier("unicom").WithProviderSpecific(providerSpecificLine, "unicom"),
+		},
+		UpdateOld: []*endpoint.Endpoint{
+			endpoint.NewEndpointWithTTL("www.container-service.top", "A", 300, "2.2.2.2").
+				WithSetIdentifier("telecom").WithProviderSpecific(providerSpecificLine, "telecom"),
+		},
+		UpdateNew: []*endpoint.Endpoint{
+			endpoint.NewEndpointWithTTL("www.container-service.top", "A", 600, "2.2.2.2").
+				WithSetIdentifier("telecom").WithProviderSpecific(providerSpecificLine, "telecom"),
+		},
+	}
+	assert.NoError(t, p.ApplyChanges(context.Background(), &changes))
+
+	lines := map[string]alidns.Record{}
+	for _, record := range api.records {
+		if record.RR == "www" {
+			lines[recordLine(record)] = record
+		}
+	}
+	if assert.Len(t, lines, 3) {
+		assert.Equal(t, "1.1.1.1", lines["default"].Value)
+		assert.Equal(t, int64(300), lines["default"].TTL)
+		assert.Equal(t, "2.2.2.2", lines["telecom"].Value)
+		assert.Equal(t, int64(600), lines["telecom"].TTL)
+		assert.Equal(t, "3.3.3.3", lines["unicom"].Value)
+	}
+
+	changes = plan.Changes{
+		Delete: []*endpoint.Endpoint{
+			endpoint.NewEndpointWithTTL("www.container-service.top", "A", 300, "1.1.1.1"),
+		},
+	}
+	assert.NoError(t, p.ApplyChanges(context.Background(), &changes))
+
+	var remaining []string
+	for _, record := range api.records {
+		if record.RR == "www" {
+			remaining = append(remaining, recordLine(record))
+		}
+	}
+	assert.ElementsMatch(t, []string{"telecom", "unicom"}, remaining)
+}
+
+func newTestAlibabaCloudProviderWithWeights() *AlibabaCloudProvider {
+	p := newTestAlibabaCloudProvider(false)
+	api := p.dnsClient.(*MockAlibabaCloudDNSAPI)
+	api.records = append(api.records,
+		alidns.Record{
+			RecordId:   "20",
+			DomainName: "container-service.top",
+			Type:       "A",
+			TTL:        300,
+			RR:         "www",
+			Value:      "1.1.1.1",
+			Remark:     setIdentifierRemarkPrefix + "blue",
+			Weight:     80,
+		},
+		alidns.Record{
+			RecordId:   "21",
+			DomainName: "container-service.top",
+			Type:       "A",
+			TTL:        300,
+			RR:         "www",
+			Value:      "2.2.2.2",
+			Remark:     setIdentifierRemarkPrefix + "green",
+			Weight:     20,
+		},
+	)
+	api.slbSubDomains = []string{"www.container-service.top"}
+	return p
+}
+
+func TestAlibabaCloudProvider_Records_Weights(t *testing.T) {
+	p := newTestAlibabaCloudProviderWithWeights()
+	endpoints, err := p.Records(context.Background())
+	assert.NoError(t, err)
+	assert.Len(t, endpoints, 4)
+
+	byIdentifier := map[string]*endpoint.Endpoint{}
+	for _, ep := range endpoints {
+		if ep.DNSName == "www.container-service.top" {
+			byIdentifier[ep.SetIdentifier] = ep
+		}
+	}
+	if assert.Len(t, byIdentifier, 2) {
+		assert.Equal(t, endpoint.NewTargets("1.1.1.1"), byIdentifier["blue"].Targets)
+		assert.Equal(t, endpoint.ProviderSpecific{{Name: providerSpecificWeight, Value: "80"}}, byIdentifier["blue"].ProviderSpecific)
+		assert.Equal(t, endpoint.NewTargets("2.2.2.2"), byIdentifier["green"].Targets)
+		assert.Equal(t, endpoint.ProviderSpecific{{Name: providerSpecificWeight, Value: "20"}}, byIdentifier["green"].ProviderSpecific)
+	}
+}
+
+func TestAlibabaCloudProvider_Records_WeightedLines(t *testing.T) {
+	p := newTestAlibabaCloudProviderWithLines()
+	api := p.dnsClient.(*MockAlibabaCloudDNSAPI)
+	api.records = append(api.records, alidns.Record{
+		RecordId:   "30",
+		DomainName: "container-service.top",
+		Type:       "A",
+		TTL:        300,
+		RR:         "api",
+		Value:      "3.3.3.3",
+		Line:       "telecom",
+		Remark:     setIdentifierRemarkPrefix + "blue",
+		Weight:     60,
+	})
+
+	endpoints, err := p.Records(context.Background())
+	assert.NoError(t, err)
+
+	var weighted *endpoint.Endpoint
+	for _, ep := range endpoints {
+		if ep.DNSName == "api.container-service.top" {
+			weighted = ep
+		}
+	}
+	if assert.NotNil(t, weighted) {
+		assert.Equal(t, "blue", weighted.SetIdentifier)
+		assert.ElementsMatch(t, endpoint.ProviderSpecific{
+			{Name: providerSpecificWeight, Value: "60"},
+			{Name: providerSpecificLine, Value: "telecom"},
+		}, weighted.ProviderSpecific)
+	}
+
+	// Desired endpoints carrying the same lines and weights must not cause any change.
+	desired, err := p.AdjustEndpoints([]*endpoint.Endpoint{
+		endpoint.NewEndpointWithTTL("www.container-service.top", "A", 300, "1.1.1.1").
+			WithProviderSpecific(providerSpecificLine, "default"),
+		endpoint.NewEndpointWithTTL("www.container-service.top", "A", 300, "2.2.2.2").
+			WithSetIdentifier("telecom").WithProviderSpecific(providerSpecificLine, "telecom"),
+		endpoint.NewEndpointWithTTL("api.container-service.top", "A", 300, "3.3.3.3").
+			WithSetIdentifier("blue").WithProviderSpecific(providerSpecificWeight, "60").WithProviderSpecific(providerSpecificLine, "telecom"),
+	})
+	assert.NoError(t, err)
+	changes := (&plan.Plan{
+		Current:        endpoints,
+		Desired:        desired,
+		DomainFilter:   endpoint.MatchAllDomainFilters{endpoint.NewDomainFilter([]string{"www.container-service.top", "api.container-service.top"})},
+		ManagedRecords: []string{endpoint.RecordTypeA},
+	}).Calculate().Changes
+	assert.False(t, changes.HasChanges(), "unexpected changes: %+v", changes)
+}
+
+func TestAlibabaCloudProvider_AdjustEndpoints_Weights(t *testing.T) {
+	endpoints := []*endpoint.Endpoint{
+		endpoint.NewEndpoint("www.container-service.top", "A", "1.1.1.1").
+			WithSetIdentifier("blue").WithProviderSpecific(providerSpecificWeight, "080"),
+		endpoint.NewEndpoint("www.container-service.top", "A", "2.2.2.2").
+			WithSetIdentifier("green").WithProviderSpecific(providerSpecificWeight, "20").WithProviderSpecific(providerSpecificLine, "telecom"),
+		endpoint.NewEndpoint("www.container-service.top", "A", "3.3.3.3").WithProviderSpecific(providerSpecificWeight, "20"),
+	}
+
+	adjusted, err := newTestAlibabaCloudProvider(false).AdjustEndpoints(endpoints)
+	assert.NoError(t, err)
+	assert.Equal(t, "blue", adjusted[0].SetIdentifier)
+	assert.Equal(t, endpoint.ProviderSpecific{{Name: providerSpecificWeight, Value: "80"}}, adjusted[0].ProviderSpecific)
+	assert.Equal(t, "green", adjusted[1].SetIdentifier)
+	assert.ElementsMatch(t, endpoint.ProviderSpecific{
+		{Name: providerSpecificWeight, Value: "20"},
+		{Name: providerSpecificLine, Value: "telecom"},
+	}, adjusted[1].ProviderSpecific)
+	// without a set identifier the weight is ignored
+	assert.Equal(t, "", adjusted[2].SetIdentifier)
+	assert.Empty(t, adjusted[2].ProviderSpecific)
+}
+
+func TestAlibabaCloudProvider_ApplyChanges_Weights(t *testing.T) {
+	p := newTestAlibabaCloudProviderWithWeights()
+	api := p.dnsClient.(*MockAlibabaCloudDNSAPI)
+
+	changes := plan.Changes{
+		Create: []*endpoint.Endpoint{
+			endpoint.NewEndpointWithTTL("api.container-service.top", "A", 300, "3.3.3.3").
+				WithSetIdentifier("blue").WithProviderSpecific(providerSpecificWeight, "70"),
+			endpoint.NewEndpointWithTTL("api.container-service.top", "A", 300, "4.4.4.4").
+				WithSetIdentifier("green").WithProviderSpecific(providerSpecificWeight, "30"),
+		},
+		UpdateOld: []*endpoint.Endpoint{
+			endpoint.NewEndpointWithTTL("www.container-service.top", "A", 300, "2.2.2.2").
+				WithSetIdentifier("green").WithProviderSpecific(providerSpecificWeight, "20"),
+		},
+		UpdateNew: []*endpoint.Endpoint{
+			endpoint.NewEndpointWithTTL("www.container-service.top", "A", 300, "2.2.2.2").
+				WithSetIdentifier("green").WithProviderSpecific(providerSpecificWeight, "50"),
+		},
+		Delete: []*endpoint.Endpoint{
+			endpoint.NewEndpointWithTTL("www.container-service.top", "A", 300, "1.1.1.1").
+				WithSetIdentifier("blue").WithProviderSpecific(providerSpecificWeight, "80"),
+		},
+	}
+	assert.NoError(t, p.ApplyChanges(context.Background(), &changes))
+
+	weights := map[string]int{}
+	for _, record := range api.records {
+		if setIdentifier, ok := recordSetIdentifier(record); ok {
+			weights[record.RR+"/"+setIdentifier+"/"+record.Value] = record.Weight
+		}
+	}
+	assert.Equal(t, map[string]int{
+		"api/blue/3.3.3.3":  70,
+		"api/green/4.4.4.4": 30,
+		"www/green/2.2.2.2": 50,
+	}, weights)
+	assert.ElementsMatch(t, []string{"www.container-service.top", "api.container-service.top"}, api.slbSubDomains)
+
+	// the weights read back match the applied endpoints, so that the plan is stable
+	endpoints, err := p.Records(context.Background())
+	assert.NoError(t, err)
+	var created []*endpoint.Endpoint
+	for _, ep := range endpoints {
+		if ep.DNSName == "api.container-service.top" {
+			created = append(created, ep)
+		}
+	}
+	assert.ElementsMatch(t, changes.Create, created)
+}
+
+func TestAlibabaCloudProvider_ApplyChanges_InvalidWeight(t *testing.T) {
+	p := newTestAlibabaCloudProvider(false)
+	api := p.dnsClient.(*MockAlibabaCloudDNSAPI)
+
+	changes := plan.Changes{
+		Create: []*endpoint.Endpoint{
+			endpoint.NewEndpointWithTTL("api.container-service.top", "A", 300, "3.3.3.3").
+				WithSetIdentifier("blue").WithProviderSpecific(providerSpecificWeight, "101"),
+			endpoint.NewEndpointWithTTL("api.container-service.top", "A", 300, "4.4.4.4").
+				WithSetIdentifier("green").WithProviderSpecific(providerSpecificWeight, "heavy"),
+		},
+	}
+	err := p.ApplyChanges(context.Background(), &changes)
+	assert.ErrorIs(t, err, provider.SoftError)
+	assert.Len(t, api.records, 2)
+}
+
+func newTestAlibabaCloudProviderWithMixedZoneTypes() *AlibabaCloudProvider {
+	p := newTestAlibabaCloudProvider(false)
+	p.mixedZoneTypes = true
+	return p
+}
+
+func TestAlibabaCloudProvider_Records_MixedZoneTypes(t *testing.T) {
+	p := newTestAlibabaCloudProviderWithMixedZoneTypes()
+	endpoints, err := p.Records(context.Background())
+	assert.NoError(t, err)
+
+	var public, private []*endpoint.Endpoint
+	for _, ep := range endpoints {
+		if ep.RecordType != "A" || ep.DNSName != "abc.container-service.top" {
+			continue
+		}
+		if zoneType, ok := ep.GetProviderSpecificProperty(providerSpecificZoneType); ok && zoneType == "private" {
+			private = append(private, ep)
+		} else {
+			public = append(public, ep)
+		}
+	}
+	if assert.Len(t, public, 1) {
+		assert.Equal(t, "", public[0].SetIdentifier)
+		assert.Empty(t, public[0].ProviderSpecific)
+	}
+	if assert.Len(t, private, 1) {
+		assert.Equal(t, "private", private[0].SetIdentifier)
+	}
+}
+
+func TestAlibabaCloudProvider_AdjustEndpoints_MixedZoneTypes(t *testing.T) {
+	newEndpoints := func() []*endpoint.Endpoint {
+		return []*endpoint.Endpoint{
+			endpoint.NewEndpoint("abc.container-service.top", "A", "1.1.1.1"),
+			endpoint.NewEndpoint("abc.container-service.top", "A", "2.2.2.2").WithProviderSpecific(providerSpecificZoneType, "public"),
+			endpoint.NewEndpoint("abc.container-service.top", "A", "3.3.3.3").WithProviderSpecific(providerSpecificZoneType, "private"),
+			endpoint.NewEndpoint("abc.container-service.top", "A", "4.4.4.4").WithProviderSpecific(providerSpecificZoneType, "protected"),
+		}
+	}
+	private := endpoint.ProviderSpecific{{Name: providerSpecificZoneType, Value: "private"}}
+
+	t.Run("public zones preferred", func(t *testing.T) {
+		adjusted, err := newTestAlibabaCloudProviderWithMixedZoneTypes().AdjustEndpoints(newEndpoints())
+		assert.NoError(t, err)
+		for i, want := range []struct {
+			setIdentifier    string
+			providerSpecific endpoint.ProviderSpecific
+		}{{"", nil}, {"", nil}, {"private", private}, {"", nil}} {
+			assert.Equal(t, want.setIdentifier, adjusted[i].SetIdentifier, adjusted[i].Targets)
+			assert.ElementsMatch(t, want.providerSpecific, adjusted[i].ProviderSpecific, adjusted[i].Targets)
+		}
+	})
+
+	t.Run("Private Zones preferred", func(t *testing.T) {
+		p := newTestAlibabaCloudProviderWithMixedZoneTypes()
+		p.privateZone = true
+		adjusted, err := p.AdjustEndpoints(newEndpoints())
+		assert.NoError(t, err)
+		for i, want := range []struct {
+			setIdentifier    string
+			providerSpecific endpoint.ProviderSpecific
+		}{{"private", private}, {"", nil}, {"private", private}, {"private", private}} {
+			assert.Equal(t, want.setIdentifier, adjusted[i].SetIdentifier, adjusted[i].Targets)
+			assert.ElementsMatch(t, want.providerSpecific, adjusted[i].ProviderSpecific, adjusted[i].Targets)
+		}
+	})
+
+	t.Run("zone types not mixed", func(t *testing.T) {
+		adjusted, err := newTestAlibabaCloudProvider(false).AdjustEndpoints(newEndpoints())
+		assert.NoError(t, err)
+		for _, ep := range adjusted {
+			assert.Equal(t, "", ep.SetIdentifier)
+			assert.Empty(t, ep.ProviderSpecific)
+		}
+	})
+}
+
+func TestAlibabaCloudProvider_AdjustEndpoints_MixedZoneTypesByZoneName(t *testing.T) {
+	for _, tt := range []struct {
+		name            string
+		privateZoneName string
+		privateZone     bool
+		want            map[string]string
+	}{
+		{
+			name:            "name under a single zone",
+			privateZoneName: "internal.container-service.top",
+			want: map[string]string{
+				"www.example.org":                    "",
+				"app.internal.container-service.top": "private",
+				"internal.container-service.top":     "private",
+				"abc.container-service.top":          "",
+				"www.other.test":                     "",
+			},
+		},
+		{
+			name:            "names outside of every zone use the zone type of the provider",
+			privateZoneName: "internal.container-service.top",
+			privateZone:     true,
+			want: map[string]string{
+				"www.example.org":                    "",
+				"app.internal.container-service.top": "private",
+				"abc.container-service.top":          "",
+				"www.other.test":                     "private",
+			},
+		},
+		{
+			name:            "public zone and Private Zone of the same name",
+			privateZoneName: "container-service.top",
+			want: map[string]string{
+				"abc.container-service.top": "",
+				"www.example.org":           "",
+			},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			p := newTestAlibabaCloudProviderWithMixedZoneTypes()
+			p.privateZone = tt.privateZone
+			p.pvtzClient.(*MockAlibabaCloudPrivateZoneAPI).zone.ZoneName = tt.privateZoneName
+			dnsAPI := p.dnsClient.(*MockAlibabaCloudDNSAPI)
+			dnsAPI.records = append(dnsAPI.records, alidns.Record{
+				RecordId:   "example",
+				DomainName: "example.org",
+				Type:       "A",
+				TTL:        300,
+				RR:         "www",
+				Value:      "9.9.9.9",
+			})
+			_, err := p.Records(context.Background())
+			require.NoError(t, err)
+
+			var endpoints []*endpoint.Endpoint
+			for name := range tt.want {
+				endpoints = append(endpoints, endpoint.NewEndpoint(name, "A", "10.0.0.1"))
+			}
+			adjusted, err := p.AdjustEndpoints(endpoints)
+			require.NoError(t, err)
+			for _, ep := range adjusted {
+				assert.Equal(t, tt.want[ep.DNSName], ep.SetIdentifier, ep.DNSName)
+			}
+		})
+	}
+
+	t.Run("provider specific zone type wins", func(t *testing.T) {
+		p := newTestAlibabaCloudProviderWithMixedZoneTypes()
+		p.pvtzClient.(*MockAlibabaCloudPrivateZoneAPI).zone.ZoneName = "internal.container-service.top"
+		_, err := p.Records(context.Background())
+		require.NoError(t, err)
+
+		adjusted, err := p.AdjustEndpoints([]*endpoint.Endpoint{
+			endpoint.NewEndpoint("app.internal.container-service.top", "A", "1.1.1.1").WithProviderSpecific(providerSpecificZoneType, "public"),
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "", adjusted[0].SetIdentifier)
+	})
+}
+
+func TestAlibabaCloudProvider_ApplyChanges_MixedZoneTypes(t *testing.T) {
+	p := newTestAlibabaCloudProviderWithMixedZoneTypes()
+	dnsAPI := p.dnsClient.(*MockAlibabaCloudDNSAPI)
+	pvtzAPI := p.pvtzClient.(*MockAlibabaCloudPrivateZoneAPI)
+
+	desired, err := p.AdjustEndpoints([]*endpoint.Endpoint{
+		endpoint.NewEndpointWithTTL("xyz.container-service.top", "A", 300, "4.4.4.4"),
+		endpoint.NewEndpointWithTTL("xyz.container-service.top", "A", 300, "10.0.0.4").WithProviderSpecific(providerSpecificZoneType, "private"),
+	})
+	assert.NoError(t, err)
+	changes := plan.Changes{
+		Create: desired,
+		Delete: []*endpoint.Endpoint{
+			endpoint.NewEndpointWithTTL("abc.container-service.top", "A", 300, "1.2.3.4").
+				WithSetIdentifier("private").WithProviderSpecific(providerSpecificZoneType, "private"),
+		},
+	}
+	assert.NoError(t, p.ApplyChanges(context.Background(), &changes))
+
+	var publicValues []string
+	for _, record := range dnsAPI.records {
+		if record.Type == "A" {
+			publicValues = append(publicValues, record.RR+"="+record.Value)
+		}
+	}
+	assert.ElementsMatch(t, []string{"abc=1.2.3.4", "xyz=4.4.4.4"}, publicValues)
+
+	var privateValues []string
+	for _, record := range pvtzAPI.records {
+		if record.Type == "A" {
+			privateValues = append(privateValues, record.Rr+"="+record.Value)
+		}
+	}
+	assert.ElementsMatch(t, []string{"xyz=10.0.0.4"}, privateValues)
+
+	// the records read back match the desired endpoints, so that the plan is stable
+	endpoints, err := p.Records(context.Background())
+	assert.NoError(t, err)
+	var current []*endpoint.Endpoint
+	for _, ep := range endpoints {
+		if ep.DNSName == "xyz.container-service.top" {
+			current = append(current, ep)
+		}
+	}
+	if assert.Len(t, current, 2) {
+		for _, ep := range current {
+			i := slices.IndexFunc(desired, func(d *endpoint.Endpoint) bool { return d.Key() == ep.Key() })
+			if assert.NotEqual(t, -1, i, ep) {
+				assert.Equal(t, desired[i].Targets, ep.Targets)
+				assert.Equal(t, desired[i].ProviderSpecific, ep.ProviderSpecific)
+			}
+		}
+	}
+}
+
+func TestAlibabaCloudProvider_Records_PrivateZoneApex(t *testing.T) {
+	p := newTestAlibabaCloudProvider(true)
+	p.pvtzClient.(*MockAlibabaCloudPrivateZoneAPI).records = append(p.pvtzClient.(*MockAlibabaCloudPrivateZoneAPI).records, pvtz.Record{
+		RecordId: 4,
+		Type:     "A",
+		Ttl:      300,
+		Rr:       "@",
+		Value:    "5.6.7.8",
+	})
+
+	endpoints, err := p.Records(context.Background())
+	assert.NoError(t, err)
+	assert.Len(t, endpoints, 3)
+
+	var names []string
+	for _, ep := range endpoints {
+		names = append(names, ep.DNSName)
+	}
+	assert.Contains(t, names, "container-service.top")
+	assert.NotContains(t, names, "@.container-service.top")
+}
+
+func TestAlibabaCloudProvider_ApplyChanges_PrivateZone(t *testing.T) {
+	p := newTestAlibabaCloudProvider(true)
+	changes := plan.Changes{
+		Create: []*endpoint.Endpoint{
+			{
+				DNSName:    "xyz.container-service.top",
+				RecordType: "A",
+				RecordTTL:  300,
+				Targets:    endpoint.NewTargets("4.3.2.1"),
+			},
+		},
+		UpdateNew: []*endpoint.Endpoint{
+			{
+				DNSName:    "abc.container-service.top",
+				RecordType: "A",
+				RecordTTL:  500,
+				Targets:    endpoint.NewTargets("1.2.3.4", "5.6.7.8"),
+			},
+		},
+		Delete: []*endpoint.Endpoint{
+			{
+				DNSName:    "abc.container-service.top",
+				RecordType: "TXT",
+				RecordTTL:  300,
+				Targets:    endpoint.NewTargets("\"heritage=external-dns,external-dns/owner=default\""),
+			},
+		},
+	}
+	ctx := context.Background()
+	err := p.ApplyChanges(ctx, &changes)
+	assert.NoError(t, err)
+	endpoints, err := p.Records(ctx)
+	if err != nil {
+		t.Errorf("Failed to get records: %v", err)
+	} else {
+		if len(endpoints) != 2 {
+			t.Errorf("Incorrect number of records: %d", len(endpoints))
+		}
+		for _, ep := range endpoints {
+			t.Logf("Endpoint for %++v", *ep)
+		}
+	}
+}
+
+func TestAlibabaCloudProvider_splitDNSName(t *testing.T) {
+	p := newTestAlibabaCloudProvider(false)
+	endpoint := &endpoint.Endpoint{}
+	hostedZoneDomains := []string{"container-service.top", "example.org"}
+
+	var emptyZoneDomains []string
+
+	endpoint.DNSName = "www.example.org"
+	rr, domain := p.splitDNSName(endpoint.DNSName, hostedZoneDomains)
+	if rr != "www" || domain != "example.org" {
+		t.Errorf("Failed to splitDNSName for %s: rr=%s, domain=%s", endpoint.DNSName, rr, domain)
+	}
+	endpoint.DNSName = ".example.org"
+	rr, domain = p.splitDNSName(endpoint.DNSName, hostedZoneDomains)
+	if rr != "@" || domain != "example.org" {
+		t.Errorf("Failed to splitDNSName for %s: rr=%s, domain=%s", endpoint.DNSName, rr, domain)
+	}
+	endpoint.DNSName = "www"
+	rr, domain = p.splitDNSName(endpoint.DNSName, hostedZoneDomains)
+	if rr != "@" || domain != "" {
+		t.Errorf("Failed to splitDNSName for %s: rr=%s, domain=%s", endpoint.DNSName, rr, domain)
+	}
+	endpoint.DNSName = ""
+	rr, domain = p.splitDNSName(endpoint.DNSName, hostedZoneDomains)
+	if rr != "@" || domain != "" {
+		t.Errorf("Failed to splitDNSName for %s: rr=%s, domain=%s", endpoint.DNSName, rr, domain)
+	}
+	endpoint.DNSName = "_30000._tcp.container-service.top"
+	rr, domain = p.splitDNSName(endpoint.DNSName, hostedZoneDomains)
+	if rr != "_30000._tcp" || domain != "container-service.top" {
+		t.Errorf("Failed to splitDNSName for %s: rr=%s, domain=%s", endpoint.DNSName, rr, domain)
+	}
+	endpoint.DNSName = "container-service.top"
+	rr, domain = p.splitDNSName(endpoint.DNSName, hostedZoneDomains)
+	if rr != "@" || domain != "container-service.top" {
+		t.Errorf("Failed to splitDNSName for %s: rr=%s, domain=%s", endpoint.DNSName, rr, domain)
+	}
+	endpoint.DNSName = "a.b.container-service.top"
+	rr, domain = p.splitDNSName(endpoint.DNSName, hostedZoneDomains)
+	if rr != "a.b" || domain != "container-service.top" {
+		t.Errorf("Failed to splitDNSName for %s: rr=%s, domain=%s", endpoint.DNSName, rr, domain)
+	}
+	endpoint.DNSName = "a.b.c.container-service.top"
+	rr, domain = p.splitDNSName(endpoint.DNSName, hostedZoneDomains)
+	if rr != "a.b.c" || domain != "container-service.top" {
+		t.Errorf("Failed to splitDNSName for %s: rr=%s, domain=%s", endpoint.DNSName, rr, domain)
+	}
+	endpoint.DNSName = "a.b.c.container-service.top"
+	rr, domain = p.splitDNSName(endpoint.DNSName, []string{"c.container-service.top"})
+	if rr != "a.b" || domain != "c.container-service.top" {
+		t.Errorf("Failed to splitDNSName for %s: rr=%s, domain=%s", endpoint.DNSName, rr, domain)
+	}
+
+	endpoint.DNSName = "a.b.c.container-service.top"
+	rr, domain = p.splitDNSName(endpoint.DNSName, []string{"container-service.top", "c.container-service.top"})
+	if rr != "a.b" || domain != "c.container-service.top" {
+		t.Errorf("Failed to splitDNSName for %s: rr=%s, domain=%s", endpoint.DNSName, rr, domain)
+	}
+	endpoint.DNSName = "*.example.org"
+	rr, domain = p.splitDNSName(endpoint.DNSName, hostedZoneDomains)
+	if rr != "*" || domain != "example.org" {
+		t.Errorf("Failed to splitDNSName for %s: rr=%s, domain=%s", endpoint.DNSName, rr, domain)
+	}
+	endpoint.DNSName = "*.c.container-service.top"
+	rr, domain = p.splitDNSName(endpoint.DNSName, []string{"container-service.top", "c.container-service.top"})
+	if rr != "*" || domain != "c.container-service.top" {
+		t.Errorf("Failed to splitDNSName for %s: rr=%s, domain=%s", endpoint.DNSName, rr, domain)
+	}
+	endpoint.DNSName = "*.b.container-service.top"
+	rr, domain = p.splitDNSName(endpoint.DNSName, hostedZoneDomains)
+	if rr != "*.b" || domain != "container-service.top" {
+		t.Errorf("Failed to splitDNSName for %s: rr=%s, domain=%s", endpoint.DNSName, rr, domain)
+	}
+
+	endpoint.DNSName = "a.b.c.container-service.top"
+	rr, domain = p.splitDNSName(endpoint.DNSName, emptyZoneDomains)
+	if rr != "@" || domain != "" {
+		t.Errorf("Failed to splitDNSName with emptyZoneDomains for %s: rr=%s, domain=%s", endpoint.DNSName, rr, domain)
+	}
+	rr, domain = p.splitDNSName(endpoint.DNSName, []string{"example.com"})
+	if rr != "@" || domain != "" {
+		t.Errorf("Failed to splitDNSName for %s: rr=%s, domain=%s", endpoint.DNSName, rr, domain)
+	}
+}
+
+func TestAlibabaCloudProvider_ApplyChanges_Wildcard(t *testing.T) {
+	for _, private := range []bool{false, true} {
+		p := newTestAlibabaCloudProvider(private)
+		ctx := context.Background()
+		changes := plan.Changes{
+			Create: []*endpoint.Endpoint{
+				endpoint.NewEndpointWithTTL("*.container-service.top", "A", 300, "4.3.2.1"),
+			},
+		}
+		require.NoError(t, p.ApplyChanges(ctx, &changes))
+
+		var rrs []string
+		if private {
+			for _, record := range p.pvtzClient.(*MockAlibabaCloudPrivateZoneAPI).records {
+				rrs = append(rrs, record.Rr)
+			}
+		} else {
+			for _, record := range p.dnsClient.(*MockAlibabaCloudDNSAPI).records {
+				rrs = append(rrs, record.RR)
+			}
+		}
+		assert.Contains(t, rrs, "*", "private zone: %t", private)
+
+		endpoints, err := p.Records(ctx)
+		require.NoError(t, err)
+		i := slices.IndexFunc(endpoints, func(ep *endpoint.Endpoint) bool { return ep.DNSName == "*.container-service.top" })
+		if assert.NotEqual(t, -1, i, "private zone: %t", private) {
+			assert.Equal(t, endpoint.NewTargets("4.3.2.1"), endpoints[i].Targets)
+		}
+	}
+}
+
+func TestAlibabaCloudProvider_TXTEndpoint(t *testing.T) {
+	p := newTestAlibabaCloudProvider(false)
+	const recordValue = "heritage=external-dns,external-dns/owner=default"
 	const endpointTarget = "\"heritage=external-dns,external-dns/owner=default\""
 
 	if p.escapeTXTRecordValue(endpointTarget) != endpointTarget {
@@ -519,6 +2126,285 @@ func TestAlibabaCloudProvider_TXTEndpoint(t *testing.T) {
 	}
 }
 
+func TestAlibabaCloudProvider_TXTSeparator(t *testing.T) {
+	for _, tt := range []struct {
+		name      string
+		separator string
+		target    string
+		stored    string
+	}{
+		{
+			name:   "default separator keeps the target",
+			target: "\"heritage=external-dns,external-dns/owner=default,external-dns/resource=service/default/nginx\"",
+			stored: "\"heritage=external-dns,external-dns/owner=default,external-dns/resource=service/default/nginx\"",
+		},
+		{
+			name:      "comma separator keeps the target",
+			separator: ",",
+			target:    "\"heritage=external-dns,external-dns/owner=default,external-dns/resource=service/default/nginx\"",
+			stored:    "\"heritage=external-dns,external-dns/owner=default,external-dns/resource=service/default/nginx\"",
+		},
+		{
+			name:      "semicolon separator stores unquoted pairs",
+			separator: ";",
+			target:    "\"heritage=external-dns,external-dns/owner=default,external-dns/resource=service/default/nginx\"",
+			stored:    "heritage=external-dns;external-dns/owner=default;external-dns/resource=service/default/nginx",
+		},
+		{
+			name:      "semicolon separator keeps other TXT records",
+			separator: ";",
+			target:    "\"v=spf1 include:example.org ~all\"",
+			stored:    "\"v=spf1 include:example.org ~all\"",
+		},
+		{
+			name:      "semicolon separator keeps unquoted heritage strings",
+			separator: ";",
+			target:    "heritage=external-dns,external-dns/owner=default",
+			stored:    "heritage=external-dns,external-dns/owner=default",
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &AlibabaCloudProvider{txtSeparator: tt.separator}
+			stored := p.escapeTXTRecordValue(tt.target)
+			assert.Equal(t, tt.stored, stored)
+			if strings.HasPrefix(tt.target, "\"") {
+				assert.Equal(t, tt.target, p.unescapeTXTRecordValue(stored))
+			}
+		})
+	}
+
+	// Records stored with either separator are read back the same way.
+	for _, separator := range []string{"", ",", ";"} {
+		p := &AlibabaCloudProvider{txtSeparator: separator}
+		const target = "\"heritage=external-dns,external-dns/owner=default,external-dns/resource=ingress/default/nginx\""
+		assert.Equal(t, target, p.unescapeTXTRecordValue("heritage=external-dns;external-dns/owner=default;external-dns/resource=ingress/default/nginx"))
+		assert.Equal(t, target, p.unescapeTXTRecordValue("heritage=external-dns,external-dns/owner=default,external-dns/resource=ingress/default/nginx"))
+		assert.Equal(t, target, p.unescapeTXTRecordValue(target))
+	}
+}
+
+func TestAlibabaCloudProvider_ApplyChanges_TXTSeparator(t *testing.T) {
+	const target = "\"heritage=external-dns,external-dns/owner=default,external-dns/resource=service/default/nginx\""
+	for _, private := range []bool{false, true} {
+		p := newTestAlibabaCloudProvider(private)
+		p.txtSeparator = ";"
+		changes := plan.Changes{
+			Create: []*endpoint.Endpoint{
+				endpoint.NewEndpoint("nginx.container-service.top", endpoint.RecordTypeTXT, target),
+			},
+		}
+		ctx := context.Background()
+		assert.NoError(t, p.ApplyChanges(ctx, &changes))
+
+		if private {
+			records := p.pvtzClient.(*MockAlibabaCloudPrivateZoneAPI).records
+			assert.Equal(t, "heritage=external-dns;external-dns/owner=default;external-dns/resource=service/default/nginx", records[len(records)-1].Value)
+		} else {
+			records := p.dnsClient.(*MockAlibabaCloudDNSAPI).records
+			assert.Equal(t, "heritage=external-dns;external-dns/owner=default;external-dns/resource=service/default/nginx", records[len(records)-1].Value)
+		}
+
+		endpoints, err := p.Records(ctx)
+		assert.NoError(t, err)
+		var txt *endpoint.Endpoint
+		for _, ep := range endpoints {
+			if ep.DNSName == "nginx.container-service.top" {
+				txt = ep
+			}
+		}
+		if assert.NotNil(t, txt, "private zone: %t", private) {
+			assert.Equal(t, endpoint.NewTargets(target), txt.Targets)
+		}
+	}
+}
+
+func TestAlibabaCloudProvider_TXTChunks(t *testing.T) {
+	dkim := "v=DKIM1; k=rsa; p=" + strings.Repeat("A", 300)
+	heritage := "heritage=external-dns,external-dns/owner=default,external-dns/resource=" + strings.Repeat("b", 200)
+	for _, tt := range []struct {
+		name      string
+		separator string
+		target    string
+		stored    string
+	}{
+		{
+			name:   "short value is kept",
+			target: "v=spf1 include:example.org ~all",
+			stored: "v=spf1 include:example.org ~all",
+		},
+		{
+			name:   "value of exactly one character-string is kept",
+			target: strings.Repeat("a", 255),
+			stored: strings.Repeat("a", 255),
+		},
+		{
+			name:   "long value is split",
+			target: dkim,
+			stored: `"` + dkim[:255] + `" "` + dkim[255:] + `"`,
+		},
+		{
+			name:   "long heritage string is split without its quotes",
+			target: `"` + heritage + `"`,
+			stored: `"` + heritage[:255] + `" "` + heritage[255:] + `"`,
+		},
+		{
+			name:      "long heritage string with the semicolon separator",
+			separator: ";",
+			target:    `"` + heritage + `"`,
+			stored:    `"` + strings.ReplaceAll(heritage, ",", ";")[:255] + `" "` + strings.ReplaceAll(heritage, ",", ";")[255:] + `"`,
+		},
+		{
+			name:   "quotes and backslashes are escaped",
+			target: `"` + strings.Repeat("a", 253) + `\"b"`,
+			stored: `"\"` + strings.Repeat("a", 253) + `\\" "\"b\""`,
+		},
+		{
+			name:   "multi-byte characters are not split",
+			target: strings.Repeat("a", 254) + "ééé",
+			stored: `"` + strings.Repeat("a", 254) + `" "ééé"`,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &AlibabaCloudProvider{txtSeparator: tt.separator}
+			stored := p.escapeTXTRecordValue(tt.target)
+			assert.Equal(t, tt.stored, stored)
+			assert.Equal(t, tt.target, p.unescapeTXTRecordValue(stored))
+		})
+	}
+}
+
+func TestJoinTXTValue(t *testing.T) {
+	for _, tt := range []struct {
+		value  string
+		joined string
+		ok     bool
+	}{
+		{value: `"abc" "def"`, joined: "abcdef", ok: true},
+		{value: ` "a\"b"  "c\\d" `, joined: `a"bc\d`, ok: true},
+		{value: `"single string"`},
+		{value: `unquoted "value"`},
+		{value: `"abc""def"`},
+		{value: `"abc" def`},
+		{value: `"abc" "unterminated`},
+		{value: ""},
+	} {
+		t.Run(tt.value, func(t *testing.T) {
+			joined, ok := joinTXTValue(tt.value)
+			assert.Equal(t, tt.ok, ok)
+			if tt.ok {
+				assert.Equal(t, tt.joined, joined)
+			}
+		})
+	}
+}
+
+func TestAlibabaCloudProvider_ApplyChanges_TXTChunks(t *testing.T) {
+	targets := []string{
+		"v=DKIM1; k=rsa; p=" + strings.Repeat("A", 400),
+		"\"heritage=external-dns,external-dns/owner=default,external-dns/resource=" + strings.Repeat("b", 300) + "\"",
+	}
+	for _, private := range []bool{false, true} {
+		p := newTestAlibabaCloudProvider(private)
+		ctx := context.Background()
+		desired := []*endpoint.Endpoint{
+			endpoint.NewEndpoint("dkim.container-service.top", endpoint.RecordTypeTXT, targets[0]),
+			endpoint.NewEndpoint("owner.container-service.top", endpoint.RecordTypeTXT, targets[1]),
+		}
+		require.NoError(t, p.ApplyChanges(ctx, &plan.Changes{Create: desired}))
+
+		endpoints, err := p.Records(ctx)
+		require.NoError(t, err)
+		read := map[string]endpoint.Targets{}
+		for _, ep := range endpoints {
+			read[ep.DNSName] = ep.Targets
+		}
+		assert.Equal(t, endpoint.NewTargets(targets[0]), read["dkim.container-service.top"], "private zone: %t", private)
+		assert.Equal(t, endpoint.NewTargets(targets[1]), read["owner.container-service.top"], "private zone: %t", private)
+
+		changes := (&plan.Plan{
+			Current:        endpoints,
+			Desired:        append(endpoints[:0:0], desired...),
+			ManagedRecords: []string{endpoint.RecordTypeTXT},
+		}).Calculate().Changes
+		assert.Empty(t, changes.Create, "private zone: %t", private)
+		assert.Empty(t, changes.UpdateNew, "private zone: %t", private)
+	}
+}
+
+func TestAlibabaCloudProvider_ApplyChanges_DefaultTTL(t *testing.T) {
+	p := newTestAlibabaCloudProvider(false)
+	p.defaultRecordTTL = 300
+	api := p.dnsClient.(*MockAlibabaCloudDNSAPI)
+	api.minTTL = 60
+
+	changes := plan.Changes{
+		Create: []*endpoint.Endpoint{
+			endpoint.NewEndpoint("xyz.container-service.top", "A", "4.3.2.1"),
+			endpoint.NewEndpoint("uvw.container-service.top", "A", "4.3.2.2"),
+			endpoint.NewEndpointWithTTL("rst.container-service.top", "A", 60, "4.3.2.3"),
+		},
+	}
+	assert.NoError(t, p.ApplyChanges(context.Background(), &changes))
+
+	ttls := map[string]int64{}
+	for _, record := range api.records {
+		ttls[record.RR] = record.TTL
+	}
+	assert.Equal(t, int64(300), ttls["xyz"])
+	assert.Equal(t, int64(300), ttls["uvw"])
+	assert.Equal(t, int64(60), ttls["rst"])
+	// the plan of a zone is only described once per call
+	assert.Equal(t, 1, api.describeDomainInfoCalls)
+}
+
+func TestAlibabaCloudProvider_ApplyChanges_DefaultTTLBelowPlanMinimum(t *testing.T) {
+	p := newTestAlibabaCloudProvider(false)
+	p.defaultRecordTTL = 300
+	api := p.dnsClient.(*MockAlibabaCloudDNSAPI)
+	api.minTTL = 600
+
+	changes := plan.Changes{
+		Create: []*endpoint.Endpoint{
+			endpoint.NewEndpoint("xyz.container-service.top", "A", "4.3.2.1"),
+		},
+	}
+	err := p.ApplyChanges(context.Background(), &changes)
+	assert.EqualError(t, err, "default TTL 300 is below the minimum TTL 600 allowed by the Alibaba Cloud DNS plan of zone container-service.top")
+	assert.NotErrorIs(t, err, provider.SoftError)
+	assert.Len(t, api.records, 2)
+}
+
+func TestAlibabaCloudProvider_ApplyChanges_BuiltInDefaultTTL(t *testing.T) {
+	p := newTestAlibabaCloudProvider(false)
+	api := p.dnsClient.(*MockAlibabaCloudDNSAPI)
+	api.minTTL = 600
+
+	changes := plan.Changes{
+		Create: []*endpoint.Endpoint{
+			endpoint.NewEndpoint("xyz.container-service.top", "A", "4.3.2.1"),
+		},
+	}
+	assert.NoError(t, p.ApplyChanges(context.Background(), &changes))
+	assert.Equal(t, int64(defaultTTL), api.records[len(api.records)-1].TTL)
+	assert.Zero(t, api.describeDomainInfoCalls)
+}
+
+func TestNewAlibabaCloudProvider_InvalidDefaultTTL(t *testing.T) {
+	configFile := filepath.Join(t.TempDir(), "alibaba-cloud.yaml")
+	assert.NoError(t, os.WriteFile(configFile, []byte("defaultTTL: -1\n"), 0o600))
+
+	_, err := NewAlibabaCloudProvider(configFile, endpoint.NewDomainFilter(nil), provider.NewZoneIDFilter(nil), "public", false)
+	assert.ErrorContains(t, err, "invalid Alibaba Cloud default TTL")
+}
+
+func TestNewAlibabaCloudProvider_InvalidTXTSeparator(t *testing.T) {
+	configFile := filepath.Join(t.TempDir(), "alibaba-cloud.yaml")
+	assert.NoError(t, os.WriteFile(configFile, []byte("txtSeparator: \"|\"\n"), 0o600))
+
+	_, err := NewAlibabaCloudProvider(configFile, endpoint.NewDomainFilter(nil), provider.NewZoneIDFilter(nil), "public", false)
+	assert.ErrorContains(t, err, "invalid Alibaba Cloud TXT separator")
+}
+
 // TestAlibabaCloudProvider_TXTEndpoint_PrivateZone
 func TestAlibabaCloudProvider_TXTEndpoint_PrivateZone(t *testing.T) {
 	p := newTestAlibabaCloudProvider(true)
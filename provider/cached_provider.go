@@ -94,6 +94,11 @@ func (c *CachedProvider) ApplyChanges(ctx context.Context, changes *plan.Changes
 	return c.Provider.ApplyChanges(ctx, changes)
 }
 
+// Healthcheck checks the connectivity of the wrapped provider, bypassing the cache.
+func (c *CachedProvider) Healthcheck(ctx context.Context) error {
+	return Healthcheck(ctx, c.Provider)
+}
+
 func (c *CachedProvider) Reset() {
 	c.cache = nil
 	c.lastRead = time.Time{}
@@ -47,6 +47,13 @@ type piholeAPI interface {
 	deleteRecord(ctx context.Context, ep *endpoint.Endpoint) error
 }
 
+// piholeClients holds the constructors of the Pi-hole API clients, registered by API version.
+var piholeClients = provider.NewRegistry[func(PiholeConfig) (piholeAPI, error)]("Pi-hole API version")
+
+func init() {
+	piholeClients.Register("5", newPiholeClient)
+}
+
 // piholeClient implements the piholeAPI.
 type piholeClient struct {
 	cfg        PiholeConfig
@@ -73,7 +80,7 @@ func newPiholeClient(cfg PiholeConfig) (piholeAPI, error) {
 		},
 	}
 
-	cl := extdnshttp.NewInstrumentedClient(httpClient)
+	cl := extdnshttp.NewInstrumentedClient(httpClient, extdnshttp.WithUserAgent(cfg.UserAgent))
 
 	p := &piholeClient{
 		cfg:        cfg,
@@ -201,6 +208,17 @@ func (p *piholeClient) urlForRecordType(rtype string) (string, error) {
 	}
 }
 
+// recordLogger returns a logger carrying the fields of a single record target changed with the given action,
+// so that record changes can be indexed by log aggregators.
+func recordLogger(action string, ep *endpoint.Endpoint, target string) *log.Entry {
+	return log.WithFields(log.Fields{
+		"action":     action,
+		"dnsName":    ep.DNSName,
+		"recordType": ep.RecordType,
+		"target":     target,
+	})
+}
+
 type actionResponse struct {
 	Success bool   `json:"success"`
 	Message string `json:"message"`
@@ -218,11 +236,11 @@ func (p *piholeClient) apply(ctx context.Context, action string, ep *endpoint.En
 	}
 
 	if p.cfg.DryRun {
-		log.Infof("DRY RUN: %s %s IN %s -> %s", action, ep.DNSName, ep.RecordType, ep.Targets[0])
+		recordLogger(action, ep, ep.Targets[0]).Info("DRY RUN: changing record")
 		return nil
 	}
 
-	log.Infof("%s %s IN %s -> %s", action, ep.DNSName, ep.RecordType, ep.Targets[0])
+	recordLogger(action, ep, ep.Targets[0]).Info("Changing record")
 
 	form := p.newDNSActionForm(action, ep)
 	if strings.Contains(ep.DNSName, "*") {
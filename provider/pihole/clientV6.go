@@ -25,14 +25,19 @@ import (
 	"fmt"
 	"io"
 	"net/http"
-	"net/netip"
 	"net/url"
+	"slices"
 	"strconv"
 	"strings"
+	"sync"
+	"time"
 
+	"github.com/prometheus/client_golang/prometheus"
 	log "github.com/sirupsen/logrus"
 
 	extdnshttp "sigs.k8s.io/external-dns/pkg/http"
+	"sigs.k8s.io/external-dns/pkg/metrics"
+	"sigs.k8s.io/external-dns/pkg/tlsutils"
 
 	"sigs.k8s.io/external-dns/endpoint"
 	"sigs.k8s.io/external-dns/provider"
@@ -41,14 +46,110 @@ import (
 const (
 	contentTypeJSON = "application/json"
 	apiAuthPath     = "/api/auth"
+	apiConfig       = "/api/config"
 	apiConfigDNS    = "/api/config/dns"
+	apiConfigMisc   = "/api/config/misc"
+
+	defaultRequestTimeout = 30 * time.Second
+
+	apiErrorKeyBadRequest = "bad_request"
+)
+
+const (
+	operationAuth      = "auth"
+	operationConfigDNS = "config_dns"
+	operationOther     = "other"
+)
+
+var (
+	requestDurationMetric = metrics.NewSummaryVecWithOpts(
+		prometheus.SummaryOpts{
+			Name:       "request_duration_seconds",
+			Help:       "The Pi-hole API request latencies in seconds, partitioned by server and operation.",
+			Subsystem:  "pihole",
+			Objectives: map[float64]float64{0.5: 0.05, 0.9: 0.01, 0.99: 0.001},
+		},
+		[]string{"server", "operation", "method", "status"},
+	)
+	serverDurationMetric = metrics.NewHistogramVecWithOpts(
+		prometheus.HistogramOpts{
+			Name:      "server_duration_seconds",
+			Help:      "The time in seconds the Pi-hole server reports having spent on API requests, partitioned by server and operation.",
+			Subsystem: "pihole",
+			Buckets:   prometheus.ExponentialBuckets(0.001, 2, 12),
+		},
+		[]string{"server", "operation"},
+	)
 )
 
+func init() {
+	metrics.RegisterMetric.MustRegister(requestDurationMetric)
+	metrics.RegisterMetric.MustRegister(serverDurationMetric)
+	piholeClients.Register("6", newPiholeClientV6)
+}
+
+// operationRoundTripper records Pi-hole API request latencies labelled by server and API operation,
+// so that authentication traffic can be told apart from record reads and writes.
+type operationRoundTripper struct {
+	next     http.RoundTripper
+	server   string
+	authPath string
+}
+
+func (r *operationRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	resp, err := r.next.RoundTrip(req)
+
+	status := ""
+	if resp != nil {
+		status = strconv.Itoa(resp.StatusCode)
+	}
+	requestDurationMetric.SetWithLabels(time.Since(start).Seconds(), r.server, operationForPath(req.URL.Path, r.authPath), req.Method, status)
+
+	return resp, err
+}
+
+// operationForPath maps a Pi-hole API path to the operation label used in metrics.
+func operationForPath(path, authPath string) string {
+	switch {
+	case strings.HasSuffix(path, authPath):
+		return operationAuth
+	case strings.Contains(path, apiConfigDNS):
+		return operationConfigDNS
+	default:
+		return operationOther
+	}
+}
+
+// observeServerDuration records the time the Pi-hole server reports in the "took" field of a response,
+// which unlike the request latency leaves out the network.
+func (p *piholeClientV6) observeServerDuration(req *http.Request, body []byte) {
+	var response struct {
+		Took *float64 `json:"took"`
+	}
+	if err := json.Unmarshal(body, &response); err != nil || response.Took == nil {
+		return
+	}
+	serverDurationMetric.SetWithLabels(*response.Took, p.cfg.Server, operationForPath(req.URL.Path, p.cfg.AuthPath))
+}
+
 // piholeClient implements the piholeAPI.
 type piholeClientV6 struct {
 	cfg        PiholeConfig
 	httpClient *http.Client
-	token      string
+	// ownership stores TXT records in the dnsmasq lines, nil when TXT records are not stored.
+	ownership ownershipEncoding
+	tokenLock sync.RWMutex
+	token     string
+	// refreshLock serializes the renewals of the session token, see renewToken.
+	refreshLock sync.Mutex
+}
+
+// sessionID returns the current session token, which health checks may renew concurrently.
+func (p *piholeClientV6) sessionID() string {
+	p.tokenLock.RLock()
+	defer p.tokenLock.RUnlock()
+	return p.token
 }
 
 // newPiholeClient creates a new Pihole API V6 client.
@@ -57,20 +158,45 @@ func newPiholeClientV6(cfg PiholeConfig) (piholeAPI, error) {
 		return nil, ErrNoPiholeServer
 	}
 
-	// Setup an HTTP client
+	if cfg.AuthPath == "" {
+		cfg.AuthPath = apiAuthPath
+	}
+
+	timeout := cfg.RequestTimeout
+	if timeout <= 0 {
+		timeout = defaultRequestTimeout
+	}
+
+	tlsConfig, err := newTLSConfigV6(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	// Setup an HTTP client. The per-request context still applies on top of the client timeout.
 	httpClient := &http.Client{
-		Transport: &http.Transport{
-			TLSClientConfig: &tls.Config{
-				InsecureSkipVerify: cfg.TLSInsecureSkipVerify,
-			},
+		Timeout: timeout,
+		Transport: &operationRoundTripper{
+			server:   cfg.Server,
+			authPath: cfg.AuthPath,
+			// Pi-hole limits the rate of API requests: wait as long as asked and retry once,
+			// provided the wait leaves half of the timeout to the retried request.
+			next: extdnshttp.NewRetryTransport(
+				&http.Transport{
+					TLSClientConfig: tlsConfig,
+				},
+				extdnshttp.WithMaxRetries(1),
+				extdnshttp.WithBackoff(timeout/2, timeout/2),
+				extdnshttp.WithRetryPolicy(retryRateLimited(timeout)),
+			),
 		},
 	}
 
-	cl := extdnshttp.NewInstrumentedClient(httpClient)
+	cl := extdnshttp.NewInstrumentedClient(httpClient, extdnshttp.WithUserAgent(cfg.UserAgent))
 
 	p := &piholeClientV6{
 		cfg:        cfg,
 		httpClient: cl,
+		ownership:  ownershipEncodings[cfg.OwnershipMode],
 	}
 
 	if cfg.Password != "" {
@@ -82,6 +208,17 @@ func newPiholeClientV6(cfg PiholeConfig) (piholeAPI, error) {
 	return p, nil
 }
 
+// newTLSConfigV6 builds the TLS configuration for the Pi-hole client.
+// A configured CA certificate takes precedence over skipping verification.
+func newTLSConfigV6(cfg PiholeConfig) (*tls.Config, error) {
+	insecure := cfg.TLSInsecureSkipVerify
+	if cfg.CACertFile != "" && insecure {
+		log.Warnf("Both a CA certificate and TLS insecure skip verify are configured for Pi-hole, verifying the server against %s", cfg.CACertFile)
+		insecure = false
+	}
+	return tlsutils.NewTLSConfig("", "", cfg.CACertFile, "", insecure, 0)
+}
+
 func (p *piholeClientV6) getConfigValue(ctx context.Context, rtype string) ([]string, error) {
 	apiUrl, err := p.urlForRecordType(rtype)
 	if err != nil {
@@ -101,55 +238,42 @@ func (p *piholeClientV6) getConfigValue(ctx context.Context, rtype string) ([]st
 	}
 
 	// Parse JSON response
-	var apiResponse ApiRecordsResponse
-	if err := json.Unmarshal(jRes, &apiResponse); err != nil {
+	apiResponse, err := decodeRecordsResponse(jRes, p.cfg.StrictDecoding, rtype == endpoint.RecordTypeTXT)
+	if err != nil {
 		return nil, fmt.Errorf("failed to unmarshal error response: %w", err)
 	}
 
 	// Pi-Hole does not allow for a record to have multiple targets.
 	var results []string
-	if endpoint.RecordTypeCNAME == rtype {
+	switch rtype {
+	case endpoint.RecordTypeCNAME:
 		results = apiResponse.Config.DNS.CnameRecords
-	} else {
+	case endpoint.RecordTypeTXT:
+		results = apiResponse.Config.Misc.DnsmasqLines
+	default:
 		results = apiResponse.Config.DNS.Hosts
 	}
 
 	return results, nil
 }
 
-/**
- * isValidIPv4 checks if the given IP address is a valid IPv4 address.
- * It returns true if the IP address is valid, false otherwise.
- * If the IP address is in IPv6 format, it will return false.
- */
-func isValidIPv4(ip string) bool {
-	addr, err := netip.ParseAddr(ip)
-	if err != nil {
-		return false
-	}
-	return addr.Is4()
-}
-
-/**
- * isValidIPv6 checks if the given IP address is a valid IPv6 address.
- * It returns true if the IP address is valid, false otherwise.
- * If the IP address is in IPv6 with dual format y:y:y:y:y:y:x.x.x.x. , it will return true.
- */
-func isValidIPv6(ip string) bool {
-	addr, err := netip.ParseAddr(ip)
-	if err != nil {
-		return false
-	}
-	return addr.Is6()
-}
-
 func (p *piholeClientV6) listRecords(ctx context.Context, rtype string) ([]*endpoint.Endpoint, error) {
 	results, err := p.getConfigValue(ctx, rtype)
 	if err != nil {
 		return nil, err
 	}
 
-	endpoints := make(map[string]*endpoint.Endpoint)
+	endpoints := make([]*endpoint.Endpoint, 0, len(results))
+
+	if rtype == endpoint.RecordTypeTXT {
+		// Only the lines of the ownership encoding are TXT records, the others are left to the user.
+		for _, rec := range results {
+			if name, text, ok := p.ownership.decode(rec); ok {
+				endpoints = append(endpoints, endpoint.NewEndpoint(name, rtype, text))
+			}
+		}
+		return endpoint.MergeByNameType(endpoints), nil
+	}
 
 	for _, rec := range results {
 		recs := strings.FieldsFunc(rec, func(r rune) bool {
@@ -164,14 +288,10 @@ func (p *piholeClientV6) listRecords(ctx context.Context, rtype string) ([]*endp
 		// A/AAAA record format is target(IP) DNSName
 		DNSName, Target = recs[1], recs[0]
 		switch rtype {
-		case endpoint.RecordTypeA:
-			// PiHole return A and AAAA records. Filter to only keep the A records
-			if !isValidIPv4(Target) {
-				continue
-			}
-		case endpoint.RecordTypeAAAA:
-			// PiHole return A and AAAA records. Filter to only keep the AAAA records
-			if !isValidIPv6(Target) {
+		case endpoint.RecordTypeA, endpoint.RecordTypeAAAA:
+			// PiHole return A and AAAA records together. Filter to only keep the records of the requested type
+			ep := endpoint.NewEndpoint(DNSName, rtype, Target)
+			if errs := endpoint.ValidateTargetTypes([]*endpoint.Endpoint{ep}); len(errs) > 0 {
 				continue
 			}
 		case endpoint.RecordTypeCNAME:
@@ -188,20 +308,10 @@ func (p *piholeClientV6) listRecords(ctx context.Context, rtype string) ([]*endp
 			}
 		}
 
-		ep := endpoint.NewEndpointWithTTL(DNSName, rtype, Ttl, Target)
-
-		if oldEp, ok := endpoints[DNSName]; ok {
-			ep.Targets = append(oldEp.Targets, Target)
-		}
-
-		endpoints[DNSName] = ep
+		endpoints = append(endpoints, endpoint.NewEndpointWithTTL(DNSName, rtype, Ttl, Target))
 	}
 
-	out := make([]*endpoint.Endpoint, 0, len(endpoints))
-	for _, ep := range endpoints {
-		out = append(out, ep)
-	}
-	return out, nil
+	return endpoint.MergeByNameType(endpoints), nil
 }
 
 func (p *piholeClientV6) createRecord(ctx context.Context, ep *endpoint.Endpoint) error {
@@ -220,12 +330,21 @@ func (p *piholeClientV6) cnameRecordsScript() string {
 	return fmt.Sprintf("%s"+apiConfigDNS+"/cnameRecords", p.cfg.Server)
 }
 
+func (p *piholeClientV6) dnsmasqLinesScript() string {
+	return fmt.Sprintf("%s"+apiConfigMisc+"/dnsmasq_lines", p.cfg.Server)
+}
+
 func (p *piholeClientV6) urlForRecordType(rtype string) (string, error) {
 	switch rtype {
 	case endpoint.RecordTypeA, endpoint.RecordTypeAAAA:
 		return p.aRecordsScript(), nil
 	case endpoint.RecordTypeCNAME:
 		return p.cnameRecordsScript(), nil
+	case endpoint.RecordTypeTXT:
+		if p.ownership == nil {
+			return "", fmt.Errorf("unsupported record type: %s", rtype)
+		}
+		return p.dnsmasqLinesScript(), nil
 	default:
 		return "", fmt.Errorf("unsupported record type: %s", rtype)
 	}
@@ -254,6 +373,28 @@ type ApiErrorResponse struct {
 	Took float64 `json:"took"`
 }
 
+// apiStatusError is returned when the Pi-hole API answers with an unexpected status code.
+type apiStatusError struct {
+	StatusCode int
+	Response   ApiErrorResponse
+}
+
+func (e *apiStatusError) Error() string {
+	return fmt.Sprintf("received %d status code from request: [%s] %s (%s) - %fs", e.StatusCode, e.Response.Error.Key, e.Response.Error.Message, e.Response.Error.Hint, e.Response.Took)
+}
+
+// errItemAlreadyExists is returned when Pi-hole refuses to add a configuration entry it already holds.
+var errItemAlreadyExists = errors.New("item already present")
+
+// isBadRequest reports whether the request was rejected with the generic "bad_request" key, which Pi-hole
+// answers both for invalid entries and for entries it already holds, with a localized message.
+func isBadRequest(err error) bool {
+	var statusErr *apiStatusError
+	return errors.As(err, &statusErr) &&
+		statusErr.StatusCode == http.StatusBadRequest &&
+		statusErr.Response.Error.Key == apiErrorKeyBadRequest
+}
+
 // ApiRecordsResponse Define struct to match JSON structure
 type ApiRecordsResponse struct {
 	Config struct {
@@ -261,71 +402,358 @@ type ApiRecordsResponse struct {
 			Hosts        []string `json:"hosts"`
 			CnameRecords []string `json:"cnameRecords"`
 		} `json:"dns"`
+		Misc struct {
+			DnsmasqLines []string `json:"dnsmasq_lines"`
+		} `json:"misc"`
 	} `json:"config"`
 	Took float64 `json:"took"`
 }
 
+// decodeRecordsResponse parses a record listing of the Pi-hole API, of the config.misc section
+// for TXT records and of the config.dns section otherwise.
+// In strict mode unknown fields are rejected and the listed section has to be present,
+// so that an unexpected response is not mistaken for an empty list of records and deleted.
+func decodeRecordsResponse(data []byte, strict, misc bool) (ApiRecordsResponse, error) {
+	var apiResponse ApiRecordsResponse
+	if !strict {
+		err := json.Unmarshal(data, &apiResponse)
+		return apiResponse, err
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&apiResponse); err != nil {
+		return apiResponse, err
+	}
+
+	var sections struct {
+		Config *struct {
+			DNS  *json.RawMessage `json:"dns"`
+			Misc *json.RawMessage `json:"misc"`
+		} `json:"config"`
+	}
+	if err := json.Unmarshal(data, &sections); err != nil {
+		return apiResponse, err
+	}
+	switch {
+	case misc && (sections.Config == nil || sections.Config.Misc == nil):
+		return apiResponse, errors.New("missing config.misc section in response")
+	case !misc && (sections.Config == nil || sections.Config.DNS == nil):
+		return apiResponse, errors.New("missing config.dns section in response")
+	}
+	return apiResponse, nil
+}
+
 func (p *piholeClientV6) generateApiUrl(baseUrl, params string) string {
 	return fmt.Sprintf("%s/%s", baseUrl, url.PathEscape(params))
 }
 
-func (p *piholeClientV6) apply(ctx context.Context, action string, ep *endpoint.Endpoint) error {
+// checkEndpoint reports whether the endpoint should be written for the given action.
+// Endpoints that are filtered out or have nothing to write are skipped, while endpoints
+// Pi-hole cannot represent are rejected with a soft error.
+func (p *piholeClientV6) checkEndpoint(action string, ep *endpoint.Endpoint) (bool, error) {
 	if !p.cfg.DomainFilter.Match(ep.DNSName) {
 		log.Debugf("Skipping : %s %s that does not match domain filter", action, ep.DNSName)
-		return nil
+		return false, nil
 	}
-	apiUrl, err := p.urlForRecordType(ep.RecordType)
-	if err != nil {
+	if _, err := p.urlForRecordType(ep.RecordType); err != nil {
 		log.Warnf("Skipping : unsupported endpoint %s %s %v", ep.DNSName, ep.RecordType, ep.Targets)
-		return nil
+		return false, nil
 	}
 
 	if len(ep.Targets) == 0 {
 		log.Infof("Skipping : missing targets  %s %s %s", action, ep.DNSName, ep.RecordType)
-		return nil
+		return false, nil
 	}
 
 	// Get the current record
 	if strings.Contains(ep.DNSName, "*") {
-		return provider.NewSoftError(errors.New("UNSUPPORTED: Pihole DNS names cannot return wildcard"))
+		return false, provider.NewSoftError(errors.New("UNSUPPORTED: Pihole DNS names cannot return wildcard"))
 	}
 
 	if ep.RecordType == endpoint.RecordTypeCNAME && len(ep.Targets) > 1 {
-		return provider.NewSoftError(errors.New("UNSUPPORTED: Pihole CNAME records cannot have multiple targets"))
+		return false, provider.NewSoftError(errors.New("UNSUPPORTED: Pihole CNAME records cannot have multiple targets"))
+	}
+
+	// Reject targets that do not match the record type before writing them.
+	// Deletes are not validated so that invalid records can still be cleaned up.
+	if action != http.MethodDelete {
+		if errs := endpoint.ValidateTargetTypes([]*endpoint.Endpoint{ep}); len(errs) > 0 {
+			return false, provider.NewSoftErrorf("INVALID: %w", errors.Join(errs...))
+		}
+	}
+	return true, nil
+}
+
+func (p *piholeClientV6) apply(ctx context.Context, action string, ep *endpoint.Endpoint) error {
+	if ok, err := p.checkEndpoint(action, ep); !ok {
+		return err
+	}
+	apiUrl, err := p.urlForRecordType(ep.RecordType)
+	if err != nil {
+		return err
 	}
 
 	for _, target := range ep.Targets {
 		if p.cfg.DryRun {
-			log.Infof("DRY RUN: %s %s IN %s -> %s", action, ep.DNSName, ep.RecordType, target)
+			recordLogger(action, ep, target).Info("DRY RUN: changing record")
 			continue
 		}
 
-		log.Infof("%s %s IN %s -> %s", action, ep.DNSName, ep.RecordType, target)
-
-		targetApiUrl := apiUrl
+		recordLogger(action, ep, target).Info("Changing record")
 
-		switch ep.RecordType {
-		case endpoint.RecordTypeA, endpoint.RecordTypeAAAA:
-			targetApiUrl = p.generateApiUrl(targetApiUrl, fmt.Sprintf("%s %s", target, ep.DNSName))
-		case endpoint.RecordTypeCNAME:
-			if ep.RecordTTL.IsConfigured() {
-				targetApiUrl = p.generateApiUrl(targetApiUrl, fmt.Sprintf("%s,%s,%d", ep.DNSName, target, ep.RecordTTL))
+		err := p.applyEntry(ctx, action, apiUrl, ep, target)
+		if action == http.MethodPut && isBadRequest(err) {
+			// The entry may already be present, which only a lookup can tell.
+			if ep.RecordType == endpoint.RecordTypeCNAME {
+				err = p.correctExistingCNAME(ctx, apiUrl, ep, target, err)
 			} else {
-				targetApiUrl = p.generateApiUrl(targetApiUrl, fmt.Sprintf("%s,%s", ep.DNSName, target))
+				err = p.confirmExistingEntry(ctx, ep, target, err)
 			}
 		}
-		req, err := http.NewRequestWithContext(ctx, action, targetApiUrl, nil)
+		if errors.Is(err, errItemAlreadyExists) {
+			// Nothing to do if the entry already exists when adding a record
+			recordLogger(action, ep, target).Debug("Skipping record change, the record already exists")
+			continue
+		}
 		if err != nil {
 			return err
 		}
+	}
 
-		_, err = p.do(req)
-		if err != nil {
+	return nil
+}
+
+// applyEntry sends the configuration entry of a single record target to Pi-hole.
+func (p *piholeClientV6) applyEntry(ctx context.Context, action, apiUrl string, ep *endpoint.Endpoint, target string) error {
+	req, err := http.NewRequestWithContext(ctx, action, p.generateApiUrl(apiUrl, p.configEntry(ep, target)), nil)
+	if err != nil {
+		return err
+	}
+	_, err = p.do(req)
+	return err
+}
+
+// confirmExistingEntry looks up the entry of a record target whose creation failed with putErr, see isBadRequest.
+// It returns errItemAlreadyExists if Pi-hole holds the entry and putErr otherwise.
+func (p *piholeClientV6) confirmExistingEntry(ctx context.Context, ep *endpoint.Endpoint, target string, putErr error) error {
+	entries, err := p.getConfigValue(ctx, ep.RecordType)
+	if err != nil {
+		return errors.Join(putErr, err)
+	}
+	entry := p.configEntry(ep, target)
+	for _, current := range entries {
+		// The dnsmasq lines holding TXT records are compared as a whole.
+		if ep.RecordType == endpoint.RecordTypeTXT && strings.TrimSpace(current) == entry ||
+			ep.RecordType != endpoint.RecordTypeTXT && sameConfigEntry(current, entry) {
+			return fmt.Errorf("%w: %w", errItemAlreadyExists, putErr)
+		}
+	}
+	return putErr
+}
+
+// correctExistingCNAME handles a CNAME record whose creation failed with putErr, see isBadRequest.
+// Pi-hole rejects a CNAME of the same name pointing elsewhere too, in which case that record is replaced.
+// It returns putErr if Pi-hole holds no CNAME record of that name.
+func (p *piholeClientV6) correctExistingCNAME(ctx context.Context, apiUrl string, ep *endpoint.Endpoint, target string, putErr error) error {
+	existing, err := p.listRecords(ctx, endpoint.RecordTypeCNAME)
+	if err != nil {
+		return errors.Join(putErr, err)
+	}
+	for _, current := range existing {
+		// Names are case insensitive, Pi-hole may hold a CNAME written in another case.
+		if !strings.EqualFold(current.DNSName, ep.DNSName) {
+			continue
+		}
+		if slices.ContainsFunc(current.Targets, func(t string) bool { return strings.EqualFold(t, target) }) {
+			recordLogger(http.MethodPut, ep, target).Debug("Skipping record change, the record already exists")
+			return nil
+		}
+
+		recordLogger(http.MethodPut, ep, target).WithField("previousTargets", strings.Join(current.Targets, ",")).Info("Replacing record")
+		for _, stale := range current.Targets {
+			if err := p.applyEntry(ctx, http.MethodDelete, apiUrl, current, stale); err != nil {
+				return err
+			}
+		}
+		return p.applyEntry(ctx, http.MethodPut, apiUrl, ep, target)
+	}
+
+	return putErr
+}
+
+func (p *piholeClientV6) authURL() string {
+	return p.cfg.Server + p.cfg.AuthPath
+}
+
+// configEntry formats a single target of the endpoint the way Pi-hole stores it in its
+// DNS configuration: "target name" for A/AAAA records and "name,target[,ttl]" for CNAME records.
+// TXT records are formatted with the ownership encoding.
+func (p *piholeClientV6) configEntry(ep *endpoint.Endpoint, target string) string {
+	if ep.RecordType == endpoint.RecordTypeTXT {
+		return p.ownership.encode(ep.DNSName, target)
+	}
+	return configEntry(ep, target)
+}
+
+// configEntry formats a single target of an A, AAAA or CNAME endpoint, see piholeClientV6.configEntry.
+func configEntry(ep *endpoint.Endpoint, target string) string {
+	if ep.RecordType != endpoint.RecordTypeCNAME {
+		return fmt.Sprintf("%s %s", target, ep.DNSName)
+	}
+	if ep.RecordTTL.IsConfigured() {
+		return fmt.Sprintf("%s,%s,%d", ep.DNSName, target, ep.RecordTTL)
+	}
+	return fmt.Sprintf("%s,%s", ep.DNSName, target)
+}
+
+// sameConfigEntry reports whether two Pi-hole configuration entries describe the same record,
+// ignoring whitespace differences and, for CNAME records, the TTL.
+func sameConfigEntry(a, b string) bool {
+	split := func(entry string) []string {
+		return strings.FieldsFunc(entry, func(r rune) bool {
+			return r == ' ' || r == ','
+		})
+	}
+	fa, fb := split(a), split(b)
+	return len(fa) >= 2 && len(fb) >= 2 && fa[0] == fb[0] && fa[1] == fb[1]
+}
+
+// ApiConfigPatchRequest Define struct to match the JSON body of a /config PATCH request
+type ApiConfigPatchRequest struct {
+	Config struct {
+		DNS struct {
+			Hosts        []string `json:"hosts"`
+			CnameRecords []string `json:"cnameRecords"`
+		} `json:"dns"`
+		Misc *ApiConfigMiscPatch `json:"misc,omitempty"`
+	} `json:"config"`
+}
+
+// ApiConfigMiscPatch Define struct to match the misc section of a /config PATCH request, only sent
+// when the TXT records stored in the dnsmasq lines change
+type ApiConfigMiscPatch struct {
+	DnsmasqLines []string `json:"dnsmasq_lines"`
+}
+
+// applyBatch writes all the given changes to Pi-hole in a single request. It reads the current
+// hosts and cnameRecords arrays once, computes the desired arrays and patches the DNS configuration
+// with them. The dnsmasq lines holding TXT records are read and patched as well when TXT records are stored.
+// Records Pi-hole cannot hold are skipped, their soft errors being returned after the patch is sent.
+// It returns errBatchUnsupported when the server does not accept configuration patches.
+func (p *piholeClientV6) applyBatch(ctx context.Context, deletes, creates []*endpoint.Endpoint) error {
+	hosts, err := p.getConfigValue(ctx, endpoint.RecordTypeA)
+	if err != nil {
+		return err
+	}
+	cnames, err := p.getConfigValue(ctx, endpoint.RecordTypeCNAME)
+	if err != nil {
+		return err
+	}
+	var lines []string
+	if p.ownership != nil {
+		if lines, err = p.getConfigValue(ctx, endpoint.RecordTypeTXT); err != nil {
 			return err
 		}
 	}
+	entries := func(rtype string) *[]string {
+		switch rtype {
+		case endpoint.RecordTypeCNAME:
+			return &cnames
+		case endpoint.RecordTypeTXT:
+			return &lines
+		}
+		return &hosts
+	}
+	// TXT entries only match exactly, their text being free-form.
+	sameEntry := func(rtype string) func(a, b string) bool {
+		if rtype == endpoint.RecordTypeTXT {
+			return func(a, b string) bool { return strings.TrimSpace(a) == b }
+		}
+		return sameConfigEntry
+	}
 
-	return nil
+	// Rejected records are skipped and reported once the other changes are written.
+	var softErrs provider.SoftErrors
+	changed, linesChanged := false, false
+	for _, ep := range deletes {
+		ok, err := p.checkEndpoint(http.MethodDelete, ep)
+		if err := softErrs.Add(err); err != nil {
+			return err
+		}
+		if !ok {
+			continue
+		}
+		list, same := entries(ep.RecordType), sameEntry(ep.RecordType)
+		for _, target := range ep.Targets {
+			entry := p.configEntry(ep, target)
+			before := len(*list)
+			*list = slices.DeleteFunc(*list, func(existing string) bool {
+				return same(existing, entry)
+			})
+			if len(*list) != before {
+				recordLogger(http.MethodDelete, ep, target).Info("Changing record in batch")
+				changed = true
+				linesChanged = linesChanged || ep.RecordType == endpoint.RecordTypeTXT
+			}
+		}
+	}
+	for _, ep := range creates {
+		ok, err := p.checkEndpoint(http.MethodPut, ep)
+		if err := softErrs.Add(err); err != nil {
+			return err
+		}
+		if !ok {
+			continue
+		}
+		list, same := entries(ep.RecordType), sameEntry(ep.RecordType)
+		for _, target := range ep.Targets {
+			entry := p.configEntry(ep, target)
+			if slices.ContainsFunc(*list, func(existing string) bool {
+				return same(existing, entry)
+			}) {
+				continue
+			}
+			recordLogger(http.MethodPut, ep, target).Info("Changing record in batch")
+			*list = append(*list, entry)
+			changed = true
+			linesChanged = linesChanged || ep.RecordType == endpoint.RecordTypeTXT
+		}
+	}
+
+	if !changed {
+		log.Debug("Pi-hole DNS configuration is up to date, skipping batch update")
+		return softErrs.Err()
+	}
+	if p.cfg.DryRun {
+		log.Infof("DRY RUN: PATCH %s with %d hosts and %d cnameRecords", apiConfig, len(hosts), len(cnames))
+		return softErrs.Err()
+	}
+
+	var body ApiConfigPatchRequest
+	body.Config.DNS.Hosts = hosts
+	body.Config.DNS.CnameRecords = cnames
+	if linesChanged {
+		body.Config.Misc = &ApiConfigMiscPatch{DnsmasqLines: lines}
+	}
+	jsonData, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPatch, p.cfg.Server+apiConfig, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return err
+	}
+	_, err = p.do(req)
+	var apiErr *apiStatusError
+	if errors.As(err, &apiErr) && (apiErr.StatusCode == http.StatusNotFound || apiErr.StatusCode == http.StatusMethodNotAllowed) {
+		return fmt.Errorf("%w: %w", errBatchUnsupported, err)
+	}
+	if err != nil {
+		return err
+	}
+	return softErrs.Err()
 }
 
 func (p *piholeClientV6) retrieveNewToken(ctx context.Context) error {
@@ -333,7 +761,7 @@ func (p *piholeClientV6) retrieveNewToken(ctx context.Context) error {
 		return nil
 	}
 
-	apiUrl := fmt.Sprintf("%s"+apiAuthPath, p.cfg.Server)
+	apiUrl := p.authURL()
 	log.Debugf("Fetching new token from %s", apiUrl)
 
 	// Define the JSON payload
@@ -356,27 +784,60 @@ func (p *piholeClientV6) retrieveNewToken(ctx context.Context) error {
 	} else {
 		// Set the token
 		if apiResponse.Session.SID != "" {
+			p.tokenLock.Lock()
 			p.token = apiResponse.Session.SID
+			p.tokenLock.Unlock()
 		}
 	}
 	return err
 }
 
+// renewToken logs in again to replace the given stale session token. Renewals are serialized, and callers
+// waiting for one in progress reuse the token it retrieved instead of logging in again, so that requests
+// rejected together as the session expires during a long reconcile trigger a single login.
+func (p *piholeClientV6) renewToken(ctx context.Context, staleToken string) error {
+	p.refreshLock.Lock()
+	defer p.refreshLock.Unlock()
+
+	if p.sessionID() != staleToken {
+		log.Debug("Pihole token was renewed concurrently, reusing it")
+		return nil
+	}
+	return p.retrieveNewToken(ctx)
+}
+
+// healthcheck requests the state of the session from the authentication endpoint,
+// logging in again if the session has expired.
+func (p *piholeClientV6) healthcheck(ctx context.Context) error {
+	if valid, err := p.checkTokenValidity(ctx); err != nil || valid {
+		return err
+	}
+	if p.cfg.Password != "" {
+		return p.renewToken(ctx, p.sessionID())
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.authURL(), nil)
+	if err != nil {
+		return err
+	}
+	_, err = p.do(req)
+	return err
+}
+
 func (p *piholeClientV6) checkTokenValidity(ctx context.Context) (bool, error) {
-	if p.token == "" {
+	token := p.sessionID()
+	if token == "" {
 		return false, nil
 	}
 
-	apiUrl := fmt.Sprintf("%s"+apiAuthPath, p.cfg.Server)
+	apiUrl := p.authURL()
 
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, apiUrl, nil)
 	if err != nil {
 		return false, nil
 	}
+	p.setExtraHeaders(req)
 	req.Header.Add("content-type", contentTypeJSON)
-	if p.token != "" {
-		req.Header.Add("X-FTL-SID", p.token)
-	}
+	req.Header.Add("X-FTL-SID", token)
 	res, err := p.httpClient.Do(req)
 	if err != nil {
 		return false, err
@@ -387,6 +848,7 @@ func (p *piholeClientV6) checkTokenValidity(ctx context.Context) (bool, error) {
 	if err != nil {
 		return false, err
 	}
+	p.observeServerDuration(req, jRes)
 
 	// Parse JSON response
 	var apiResponse ApiAuthResponse
@@ -397,17 +859,14 @@ func (p *piholeClientV6) checkTokenValidity(ctx context.Context) (bool, error) {
 }
 
 func (p *piholeClientV6) do(req *http.Request) ([]byte, error) {
-	req.Header.Add("content-type", contentTypeJSON)
-	if p.token != "" {
-		req.Header.Add("X-FTL-SID", p.token)
-	}
-	res, err := p.httpClient.Do(req)
-	if err != nil {
-		return nil, err
-	}
-
-	jRes, err := io.ReadAll(res.Body)
-	defer res.Body.Close()
+	p.setExtraHeaders(req)
+	req.Header.Set("content-type", contentTypeJSON)
+	// Set rather than add the token, so that a request retried after a renewal carries the new one only.
+	token := p.sessionID()
+	if token != "" {
+		req.Header.Set("X-FTL-SID", token)
+	}
+	res, jRes, err := p.send(req)
 	if err != nil {
 		return nil, err
 	}
@@ -420,10 +879,6 @@ func (p *piholeClientV6) do(req *http.Request) ([]byte, error) {
 		if err := json.Unmarshal(jRes, &apiError); err != nil {
 			return nil, fmt.Errorf("failed to unmarshal error response: %w", err)
 		}
-		// Ignore if the entry already exists when adding a record
-		if strings.Contains(apiError.Error.Message, "Item already present") {
-			return jRes, nil
-		}
 		// Ignore if the entry does not exist when deleting a record
 		if res.StatusCode == http.StatusNotFound && req.Method == http.MethodDelete {
 			return jRes, nil
@@ -435,7 +890,8 @@ func (p *piholeClientV6) do(req *http.Request) ([]byte, error) {
 			}
 		}
 
-		if res.StatusCode == http.StatusUnauthorized && p.token != "" {
+		// Logging in again cannot help when the login itself is rejected.
+		if res.StatusCode == http.StatusUnauthorized && token != "" && req.URL.String() != p.authURL() {
 			tryCount := 1
 			maxRetries := 3
 			// Try to fetch a new token and redo the request.
@@ -446,9 +902,10 @@ func (p *piholeClientV6) do(req *http.Request) ([]byte, error) {
 				}
 				if !valid {
 					log.Debugf("Pihole token has expired, fetching a new one. Try (%d/%d)", tryCount, maxRetries)
-					if err := p.retrieveNewToken(req.Context()); err != nil {
+					if err := p.renewToken(req.Context(), token); err != nil {
 						return nil, err
 					}
+					token = p.sessionID()
 					tryCount++
 					continue
 				}
@@ -459,7 +916,50 @@ func (p *piholeClientV6) do(req *http.Request) ([]byte, error) {
 			}
 			return p.do(req)
 		}
-		return nil, fmt.Errorf("received %d status code from request: [%s] %s (%s) - %fs", res.StatusCode, apiError.Error.Key, apiError.Error.Message, apiError.Error.Hint, apiError.Took)
+		return nil, &apiStatusError{StatusCode: res.StatusCode, Response: apiError}
 	}
 	return jRes, nil
 }
+
+// setExtraHeaders sets the configured extra headers on the request, e.g. for an authenticating proxy in front of Pi-hole.
+func (p *piholeClientV6) setExtraHeaders(req *http.Request) {
+	for name, value := range p.cfg.ExtraHeaders {
+		req.Header.Set(name, value)
+	}
+}
+
+// send sends the request and reads the body of the response.
+func (p *piholeClientV6) send(req *http.Request) (*http.Response, []byte, error) {
+	res, err := p.httpClient.Do(req)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	jRes, err := io.ReadAll(res.Body)
+	defer res.Body.Close()
+	if err != nil {
+		return nil, nil, err
+	}
+	p.observeServerDuration(req, jRes)
+	return res, jRes, nil
+}
+
+// retryRateLimited selects the requests rejected by the rate limit of Pi-hole, which are not processed
+// whatever their method. The wait and the retried request count towards the timeout of the request,
+// so the wait asked by Pi-hole must leave half of the timeout before the deadline of the request.
+func retryRateLimited(timeout time.Duration) extdnshttp.RetryPolicy {
+	return func(req *http.Request, res *http.Response) bool {
+		if res.StatusCode != http.StatusTooManyRequests {
+			return false
+		}
+		wait, ok := extdnshttp.ParseRetryAfter(res.Header.Get("Retry-After"), time.Now())
+		if !ok {
+			return false
+		}
+		remaining := timeout
+		if deadline, ok := req.Context().Deadline(); ok {
+			remaining = min(remaining, time.Until(deadline))
+		}
+		return wait+timeout/2 <= remaining
+	}
+}
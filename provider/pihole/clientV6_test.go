@@ -17,75 +17,33 @@ limitations under the License.
 package pihole
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
+	"encoding/pem"
 	"errors"
 	"fmt"
+	"io"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
 	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/google/go-cmp/cmp"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	log "github.com/sirupsen/logrus"
 	"sigs.k8s.io/external-dns/endpoint"
+	"sigs.k8s.io/external-dns/internal/testutils"
+	"sigs.k8s.io/external-dns/pkg/apis/externaldns"
+	"sigs.k8s.io/external-dns/provider"
 )
 
-func TestIsValidIPv4(t *testing.T) {
-	tests := []struct {
-		ip       string
-		expected bool
-	}{
-		{"192.168.1.1", true},
-		{"255.255.255.255", true},
-		{"0.0.0.0", true},
-		{"", false},
-		{"256.256.256.256", false},
-		{"192.168.0.1/22", false},
-		{"192.168.1", false},
-		{"abc.def.ghi.jkl", false},
-		{"::ffff:192.168.20.3", false},
-	}
-
-	for _, test := range tests {
-		t.Run(test.ip, func(t *testing.T) {
-			if got := isValidIPv4(test.ip); got != test.expected {
-				t.Errorf("isValidIPv4(%s) = %v; want %v", test.ip, got, test.expected)
-			}
-		})
-	}
-}
-
-func TestIsValidIPv6(t *testing.T) {
-	tests := []struct {
-		ip       string
-		expected bool
-	}{
-		{"2001:0db8:85a3:0000:0000:8a2e:0370:7334", true},
-		{"2001:db8:85a3::8a2e:370:7334", true},
-		//IPv6 dual, the format is y:y:y:y:y:y:x.x.x.x.
-		{"::ffff:192.168.20.3", true},
-		{"::1", true},
-		{"::", true},
-		{"2001:db8::", true},
-		{"", false},
-		{":", false},
-		{"::ffff:", false},
-		{"192.168.20.3", false},
-		{"2001:db8:85a3:0:0:8a2e:370:7334:1234", false},
-		{"2001:db8:85a3::8a2e:370g:7334", false},
-		{"2001:db8:85a3::8a2e:370:7334::", false},
-		{"2001:db8:85a3::8a2e:370:7334::1", false},
-	}
-
-	for _, test := range tests {
-		t.Run(test.ip, func(t *testing.T) {
-			if got := isValidIPv6(test.ip); got != test.expected {
-				t.Errorf("isValidIPv6(%s) = %v; want %v", test.ip, got, test.expected)
-			}
-		})
-	}
-}
-
 func newTestServerV6(t *testing.T, hdlr http.HandlerFunc) *httptest.Server {
 	t.Helper()
 	svr := httptest.NewServer(hdlr)
@@ -599,6 +557,183 @@ func TestTokenValidity(t *testing.T) {
 	}
 }
 
+func TestConcurrentTokenRenewalV6(t *testing.T) {
+	var (
+		lock   sync.Mutex
+		sid    string
+		logins int
+	)
+	srvr := newTestServerV6(t, func(w http.ResponseWriter, r *http.Request) {
+		lock.Lock()
+		defer lock.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.URL.Path == "/api/auth" && r.Method == http.MethodPost:
+			logins++
+			sid = fmt.Sprintf("session-%d", logins)
+			w.Write([]byte(`{"session":{"valid":true,"sid":"` + sid + `","validity":1800},"took":0.1}`))
+		case r.URL.Path == "/api/auth" && r.Method == http.MethodGet:
+			valid := r.Header.Get("X-FTL-SID") == sid
+			w.Write([]byte(fmt.Sprintf(`{"session":{"valid":%t},"took":0.1}`, valid)))
+		case r.URL.Path == "/api/config/dns/hosts" && r.Method == http.MethodGet:
+			if r.Header.Get("X-FTL-SID") != sid {
+				w.WriteHeader(http.StatusUnauthorized)
+				w.Write([]byte(`{"error":{"key":"unauthorized","message":"Unauthorized"},"took":0.1}`))
+				return
+			}
+			w.Write([]byte(`{"config":{"dns":{"hosts":["192.168.1.1 test.example.com"]}},"took":0.1}`))
+		default:
+			http.NotFound(w, r)
+		}
+	})
+	defer srvr.Close()
+
+	cl, err := newPiholeClientV6(PiholeConfig{Server: srvr.URL, APIVersion: "6", Password: "correct"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The session expires before the requests are sent, each of them is rejected once.
+	lock.Lock()
+	sid = ""
+	lock.Unlock()
+
+	const requests = 20
+	var wg sync.WaitGroup
+	errs := make(chan error, requests)
+	for range requests {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			records, err := cl.listRecords(context.Background(), endpoint.RecordTypeA)
+			if err == nil && len(records) != 1 {
+				err = fmt.Errorf("expected 1 record, got %d", len(records))
+			}
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Error(err)
+		}
+	}
+
+	// The first login is the one of the client creation.
+	if logins != 2 {
+		t.Errorf("Expected a single login to renew the session, got %d", logins-1)
+	}
+	if token := cl.(*piholeClientV6).sessionID(); token != "session-2" {
+		t.Errorf("Unexpected session token %q", token)
+	}
+}
+
+func TestHealthcheckV6(t *testing.T) {
+	var authCalls []string
+	srvr := newTestServerV6(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/auth" {
+			http.NotFound(w, r)
+			return
+		}
+		authCalls = append(authCalls, r.Method)
+		var requestData map[string]string
+		if r.Method == http.MethodPost {
+			json.NewDecoder(r.Body).Decode(&requestData)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.Method == http.MethodPost && requestData["password"] == "correct":
+			w.Write([]byte(`{"session": {"valid": true, "sid": "supersecret"}}`))
+		case r.Method == http.MethodPost:
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"error": {"key": "unauthorized", "message": "Unauthorized", "hint": null}}`))
+		default:
+			valid := r.Header.Get("X-FTL-SID") == "supersecret"
+			w.Write([]byte(fmt.Sprintf(`{"session": {"valid": %t}}`, valid)))
+		}
+	})
+	defer srvr.Close()
+
+	cl, err := newPiholeClientV6(PiholeConfig{Server: srvr.URL, APIVersion: "6", Password: "correct"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := cl.(*piholeClientV6)
+
+	// A valid session only needs to be checked
+	authCalls = nil
+	if err := (&PiholeProvider{api: client}).Healthcheck(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if expected := []string{http.MethodGet}; !cmp.Equal(authCalls, expected) {
+		t.Errorf("Expected auth calls %v, got %v", expected, authCalls)
+	}
+
+	// An expired session is renewed
+	client.token = "expired"
+	authCalls = nil
+	if err := client.healthcheck(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if expected := []string{http.MethodGet, http.MethodPost}; !cmp.Equal(authCalls, expected) {
+		t.Errorf("Expected auth calls %v, got %v", expected, authCalls)
+	}
+	if client.sessionID() != "supersecret" {
+		t.Error("Expected the session to be renewed, got token", client.sessionID())
+	}
+
+	// A password that is not accepted anymore fails the check
+	client.token = "expired"
+	client.cfg.Password = "changed"
+	if err := client.healthcheck(context.Background()); err == nil {
+		t.Error("Expected error when the password is rejected")
+	}
+
+	// Servers without a password are checked without a session
+	unprotected, err := newPiholeClientV6(PiholeConfig{Server: srvr.URL, APIVersion: "6"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	authCalls = nil
+	if err := unprotected.(*piholeClientV6).healthcheck(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if expected := []string{http.MethodGet}; !cmp.Equal(authCalls, expected) {
+		t.Errorf("Expected auth calls %v, got %v", expected, authCalls)
+	}
+
+	srvr.Close()
+	if err := unprotected.(*piholeClientV6).healthcheck(context.Background()); err == nil {
+		t.Error("Expected error when the server is down")
+	}
+}
+
+func TestApplyLogFieldsV6(t *testing.T) {
+	hook := testutils.LogsUnderTestWithLogLevel(log.InfoLevel, t)
+
+	cl, err := newPiholeClientV6(PiholeConfig{Server: "http://pihole.example.com", APIVersion: "6", DryRun: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cl.createRecord(context.Background(), endpoint.NewEndpoint("test.example.com", endpoint.RecordTypeA, "192.168.1.1", "192.168.1.2")); err != nil {
+		t.Fatal(err)
+	}
+
+	var fields []log.Fields
+	for _, entry := range hook.AllEntries() {
+		if entry.Message == "DRY RUN: changing record" {
+			fields = append(fields, entry.Data)
+		}
+	}
+	expected := []log.Fields{
+		{"action": http.MethodPut, "dnsName": "test.example.com", "recordType": endpoint.RecordTypeA, "target": "192.168.1.1"},
+		{"action": http.MethodPut, "dnsName": "test.example.com", "recordType": endpoint.RecordTypeA, "target": "192.168.1.2"},
+	}
+	if diff := cmp.Diff(expected, fields); diff != "" {
+		t.Errorf("Unexpected log fields (-want +got):\n%s", diff)
+	}
+}
+
 func TestDo(t *testing.T) {
 
 	srvDo := newTestServerV6(t, func(w http.ResponseWriter, r *http.Request) {
@@ -785,6 +920,138 @@ func TestDoRetryOne(t *testing.T) {
 
 }
 
+func TestDoRateLimitedV6(t *testing.T) {
+	for _, tt := range []struct {
+		name       string
+		retryAfter string
+		limited    int
+		wantCalls  int
+		wantErr    bool
+	}{
+		{name: "retried after the asked delay", retryAfter: "0", limited: 1, wantCalls: 2},
+		{name: "retried only once", retryAfter: "0", limited: 2, wantCalls: 2, wantErr: true},
+		{name: "no Retry-After header", limited: 1, wantCalls: 1, wantErr: true},
+		{name: "Retry-After too long", retryAfter: "3600", limited: 1, wantCalls: 1, wantErr: true},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls int
+			srv := newTestServerV6(t, func(w http.ResponseWriter, r *http.Request) {
+				calls++
+				body, _ := io.ReadAll(r.Body)
+				if calls <= tt.limited {
+					if tt.retryAfter != "" {
+						w.Header().Set("Retry-After", tt.retryAfter)
+					}
+					w.WriteHeader(http.StatusTooManyRequests)
+					w.Write([]byte(`{"error": {"key": "rate_limiting", "message": "Rate-limiting", "hint": null}, "took": 0.001}`))
+					return
+				}
+				w.WriteHeader(http.StatusOK)
+				w.Write(body)
+			})
+			defer srv.Close()
+
+			cl, err := newPiholeClientV6(PiholeConfig{Server: srv.URL, APIVersion: "6"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			rq, _ := http.NewRequestWithContext(context.Background(), http.MethodPatch, srv.URL+apiConfig, bytes.NewBufferString(`{"config": {}}`))
+			resp, err := cl.(*piholeClientV6).do(rq)
+			if tt.wantErr {
+				var statusErr *apiStatusError
+				if !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusTooManyRequests {
+					t.Errorf("expected a rate limiting error, got %v", err)
+				}
+			} else if err != nil {
+				t.Fatal("Should succeed", err)
+			} else if string(resp) != `{"config": {}}` {
+				t.Errorf("expected the request body to be sent again, got %q", resp)
+			}
+			if calls != tt.wantCalls {
+				t.Errorf("expected %d calls, got %d", tt.wantCalls, calls)
+			}
+		})
+	}
+}
+
+func TestDoRateLimitedRequestTimeoutV6(t *testing.T) {
+	for _, tt := range []struct {
+		name       string
+		retryAfter string
+		wantCalls  int
+		wantErr    bool
+	}{
+		// The wait and the retried request have to fit in the timeout of the request.
+		{name: "Retry-After leaving half of the timeout", retryAfter: "1", wantCalls: 2},
+		{name: "Retry-After close to the timeout", retryAfter: "3", wantCalls: 1, wantErr: true},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls int
+			srv := newTestServerV6(t, func(w http.ResponseWriter, r *http.Request) {
+				calls++
+				if calls == 1 {
+					w.Header().Set("Retry-After", tt.retryAfter)
+					w.WriteHeader(http.StatusTooManyRequests)
+					w.Write([]byte(`{"error": {"key": "rate_limiting", "message": "Rate-limiting", "hint": null}, "took": 0.001}`))
+					return
+				}
+				w.WriteHeader(http.StatusOK)
+				w.Write([]byte(`{"config": {}}`))
+			})
+			defer srv.Close()
+
+			cl, err := newPiholeClientV6(PiholeConfig{Server: srv.URL, APIVersion: "6", RequestTimeout: 4 * time.Second})
+			if err != nil {
+				t.Fatal(err)
+			}
+			rq, _ := http.NewRequestWithContext(context.Background(), http.MethodGet, srv.URL+apiConfigDNS, nil)
+			start := time.Now()
+			_, err = cl.(*piholeClientV6).do(rq)
+			if tt.wantErr {
+				var statusErr *apiStatusError
+				if !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusTooManyRequests {
+					t.Errorf("expected a rate limiting error, got %v", err)
+				}
+				if elapsed := time.Since(start); elapsed > time.Second {
+					t.Errorf("expected the rate limiting error without waiting, got it after %s", elapsed)
+				}
+			} else if err != nil {
+				t.Fatal("Should succeed", err)
+			}
+			if calls != tt.wantCalls {
+				t.Errorf("expected %d calls, got %d", tt.wantCalls, calls)
+			}
+		})
+	}
+}
+
+func TestDoRateLimitedContextCanceledV6(t *testing.T) {
+	var calls int
+	srv := newTestServerV6(t, func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Header().Set("Retry-After", "10")
+		w.WriteHeader(http.StatusTooManyRequests)
+		w.Write([]byte(`{"error": {"key": "rate_limiting", "message": "Rate-limiting", "hint": null}, "took": 0.001}`))
+	})
+	defer srv.Close()
+
+	cl, err := newPiholeClientV6(PiholeConfig{Server: srv.URL, APIVersion: "6"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The context is canceled while waiting, a deadline would leave no time for the retry.
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	time.AfterFunc(20*time.Millisecond, cancel)
+	rq, _ := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL+apiConfigDNS, nil)
+	if _, err := cl.(*piholeClientV6).do(rq); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected the context error, got %v", err)
+	}
+	if calls != 1 {
+		t.Errorf("expected 1 call, got %d", calls)
+	}
+}
+
 func TestCreateRecordV6(t *testing.T) {
 	var ep *endpoint.Endpoint
 	srvr := newTestServerV6(t, func(w http.ResponseWriter, r *http.Request) {
@@ -897,6 +1164,20 @@ func TestCreateRecordV6(t *testing.T) {
 		t.Fatal(err)
 	}
 
+	// Test create records whose targets do not match the record type and ensure they fail softly
+	for _, ep := range []*endpoint.Endpoint{
+		{DNSName: "source4.example.com", Targets: []string{"192.168.1.1"}, RecordType: endpoint.RecordTypeCNAME},
+		{DNSName: "source4.example.com", Targets: []string{"fc00::1:192:168:1:1"}, RecordType: endpoint.RecordTypeCNAME},
+		{DNSName: "test.example.com", Targets: []string{"fc00::1:192:168:1:1"}, RecordType: endpoint.RecordTypeA},
+		{DNSName: "test.example.com", Targets: []string{"target1.domain.com"}, RecordType: endpoint.RecordTypeA},
+		{DNSName: "test.example.com", Targets: []string{"192.168.1.1"}, RecordType: endpoint.RecordTypeAAAA},
+	} {
+		err := cl.createRecord(context.Background(), ep)
+		if !errors.Is(err, provider.SoftError) {
+			t.Fatalf("Expected soft error for %s, got %v", ep, err)
+		}
+	}
+
 	// Skip not matching domain
 	ep = &endpoint.Endpoint{
 		DNSName:    "foo.bar.com",
@@ -1019,3 +1300,910 @@ func TestDeleteRecordV6(t *testing.T) {
 		t.Fatal(err)
 	}
 }
+
+func TestItemAlreadyExistsV6(t *testing.T) {
+	srvr := newTestServerV6(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/config/dns/hosts":
+			w.Write([]byte(`{"config":{"dns":{"hosts":["192.168.1.1 present.example.com"]}},"took":0.1}`))
+		case "/api/config/misc/dnsmasq_lines":
+			w.Write([]byte(`{"config":{"misc":{"dnsmasq_lines":["# external-dns: a-present.example.com \"heritage=external-dns\""]}},"took":0.1}`))
+		case "/api/config/dns/hosts/192.168.1.1 present.example.com",
+			"/api/config/misc/dnsmasq_lines/# external-dns: a-present.example.com \"heritage=external-dns\"":
+			// The message is localized, only the key tells the kind of error apart.
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{
+			"error": {
+				"key": "bad_request",
+				"message": "Element bereits vorhanden",
+				"hint": "Uniqueness of items is enforced"
+			},
+			"took": 0.01
+			}`))
+		case "/api/config/dns/hosts/192.168.1.2 invalid.example.com":
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{
+			"error": {
+				"key": "bad_request",
+				"message": "Item already present",
+				"hint": "Invalid value"
+			},
+			"took": 0.01
+			}`))
+		case "/api/config/dns/hosts/192.168.1.3 broken.example.com":
+			w.WriteHeader(http.StatusInternalServerError)
+			w.Write([]byte(`{
+			"error": {
+				"key": "database_error",
+				"message": "Item already present: could not write configuration",
+				"hint": null
+			},
+			"took": 0.01
+			}`))
+		default:
+			http.NotFound(w, r)
+		}
+	})
+	defer srvr.Close()
+
+	cl, err := newPiholeClientV6(PiholeConfig{
+		Server:        srvr.URL,
+		APIVersion:    "6",
+		OwnershipMode: OwnershipModeComment,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// An existing entry is a no-op for creates
+	if err := cl.createRecord(context.Background(), endpoint.NewEndpoint("present.example.com", endpoint.RecordTypeA, "192.168.1.1")); err != nil {
+		t.Fatal(err)
+	}
+	if err := cl.createRecord(context.Background(), endpoint.NewEndpoint("a-present.example.com", endpoint.RecordTypeTXT, `"heritage=external-dns"`)); err != nil {
+		t.Fatal(err)
+	}
+
+	// The same answer is not a no-op for other methods
+	rq, _ := http.NewRequestWithContext(context.Background(), http.MethodDelete, srvr.URL+"/api/config/dns/hosts/192.168.1.1 present.example.com", nil)
+	if _, err := cl.(*piholeClientV6).do(rq); err == nil || errors.Is(err, errItemAlreadyExists) {
+		t.Fatal("Expected a regular error for a DELETE, got:", err)
+	}
+
+	// Bad requests for entries that are not present still fail, whatever their message
+	err = cl.createRecord(context.Background(), endpoint.NewEndpoint("invalid.example.com", endpoint.RecordTypeA, "192.168.1.2"))
+	if err == nil || errors.Is(err, errItemAlreadyExists) || !isBadRequest(err) {
+		t.Fatal("Expected a regular error for an invalid value, got:", err)
+	}
+
+	// Server errors whose message happens to contain the text still fail
+	err = cl.createRecord(context.Background(), endpoint.NewEndpoint("broken.example.com", endpoint.RecordTypeA, "192.168.1.3"))
+	if err == nil || !strings.HasPrefix(err.Error(), "received 500 status code from request") {
+		t.Fatal("Expected error for unexpected status code, got:", err)
+	}
+}
+
+func TestCNAMEAlreadyPresentV6(t *testing.T) {
+	cnames := []string{"foo.example.com,old.example.com", "bar.example.com,same.example.com,300", "Mixed.example.com,old.example.com"}
+	var deletes []string
+	srvr := newTestServerV6(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		entry, isEntry := strings.CutPrefix(r.URL.Path, "/api/config/dns/cnameRecords/")
+		switch {
+		case r.URL.Path == "/api/config/dns/cnameRecords" && r.Method == http.MethodGet:
+			body, _ := json.Marshal(cnames)
+			w.Write([]byte(`{"config":{"dns":{"cnameRecords":` + string(body) + `}},"took":0.1}`))
+		case isEntry && r.Method == http.MethodPut && strings.HasPrefix(entry, "invalid."):
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"error":{"key":"bad_request","message":"Invalid value","hint":null},"took":0.01}`))
+		case isEntry && r.Method == http.MethodPut:
+			// Pi-hole enforces a single CNAME per name
+			name, _, _ := strings.Cut(entry, ",")
+			for _, cname := range cnames {
+				if existing, _, _ := strings.Cut(cname, ","); strings.EqualFold(existing, name) {
+					w.WriteHeader(http.StatusBadRequest)
+					w.Write([]byte(`{"error":{"key":"bad_request","message":"Item already present","hint":"Uniqueness of items is enforced"},"took":0.01}`))
+					return
+				}
+			}
+			cnames = append(cnames, entry)
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte(`{"took":0.01}`))
+		case isEntry && r.Method == http.MethodDelete:
+			if !slices.Contains(cnames, entry) {
+				http.NotFound(w, r)
+				return
+			}
+			cnames = slices.DeleteFunc(cnames, func(cname string) bool { return cname == entry })
+			deletes = append(deletes, entry)
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			http.NotFound(w, r)
+		}
+	})
+	defer srvr.Close()
+
+	cl, err := newPiholeClientV6(PiholeConfig{
+		Server:     srvr.URL,
+		APIVersion: "6",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A CNAME pointing elsewhere is replaced
+	if err := cl.createRecord(context.Background(), endpoint.NewEndpoint("foo.example.com", endpoint.RecordTypeCNAME, "new.example.com")); err != nil {
+		t.Fatal(err)
+	}
+	// A CNAME pointing at the same target is left alone
+	if err := cl.createRecord(context.Background(), endpoint.NewEndpoint("bar.example.com", endpoint.RecordTypeCNAME, "same.example.com")); err != nil {
+		t.Fatal(err)
+	}
+
+	// A CNAME stored with another case is replaced too
+	if err := cl.createRecord(context.Background(), endpoint.NewEndpoint("mixed.example.com", endpoint.RecordTypeCNAME, "new.example.com")); err != nil {
+		t.Fatal(err)
+	}
+	// Other bad requests still fail
+	if err := cl.createRecord(context.Background(), endpoint.NewEndpoint("invalid.example.com", endpoint.RecordTypeCNAME, "new.example.com")); !isBadRequest(err) {
+		t.Fatal("Expected a bad request error, got:", err)
+	}
+
+	expectedCnames := []string{"bar.example.com,same.example.com,300", "foo.example.com,new.example.com", "mixed.example.com,new.example.com"}
+	if diff := cmp.Diff(expectedCnames, cnames); diff != "" {
+		t.Errorf("Unexpected cnameRecords (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff([]string{"foo.example.com,old.example.com", "Mixed.example.com,old.example.com"}, deletes); diff != "" {
+		t.Errorf("Unexpected deletes (-want +got):\n%s", diff)
+	}
+}
+
+func TestOperationForPath(t *testing.T) {
+	tests := []struct {
+		path     string
+		expected string
+	}{
+		{"/api/auth", operationAuth},
+		{"/admin/api/auth", operationAuth},
+		{"/api/config/dns/hosts", operationConfigDNS},
+		{"/api/config/dns/cnameRecords/foo.example.com,bar.example.com", operationConfigDNS},
+		{"/api/info/version", operationOther},
+		{"/custom/login", operationOther},
+	}
+
+	for _, test := range tests {
+		t.Run(test.path, func(t *testing.T) {
+			if got := operationForPath(test.path, apiAuthPath); got != test.expected {
+				t.Errorf("operationForPath(%s) = %s; want %s", test.path, got, test.expected)
+			}
+		})
+	}
+}
+
+func TestRequestDurationMetricV6(t *testing.T) {
+	srvr := newTestServerV6(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/api/auth":
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"session":{"valid":false,"message":"password incorrect"},"took":0.1}`))
+		case "/api/config/dns/hosts":
+			w.Write([]byte(`{"config":{"dns":{"hosts":[]}},"took":0.1}`))
+		default:
+			http.NotFound(w, r)
+		}
+	})
+	defer srvr.Close()
+
+	sampleCount := func(labels prometheus.Labels) uint64 {
+		observer, err := requestDurationMetric.SummaryVec.GetMetricWith(labels)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var m dto.Metric
+		if err := observer.(prometheus.Metric).Write(&m); err != nil {
+			t.Fatal(err)
+		}
+		return m.GetSummary().GetSampleCount()
+	}
+	authLabels := prometheus.Labels{"server": srvr.URL, "operation": operationAuth, "method": "post", "status": "401"}
+	listLabels := prometheus.Labels{"server": srvr.URL, "operation": operationConfigDNS, "method": "get", "status": "200"}
+	authBefore, listBefore := sampleCount(authLabels), sampleCount(listLabels)
+
+	// Authentication failure is recorded under the auth operation
+	if _, err := newPiholeClientV6(PiholeConfig{Server: srvr.URL, APIVersion: "6", Password: "wrong"}); err == nil {
+		t.Fatal("Expected error for creating client with invalid password")
+	}
+
+	// Record listing is recorded under the config_dns operation
+	cl, err := newPiholeClientV6(PiholeConfig{Server: srvr.URL, APIVersion: "6"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cl.listRecords(context.Background(), endpoint.RecordTypeA); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := sampleCount(authLabels) - authBefore; got != 1 {
+		t.Errorf("Expected 1 auth request to be recorded, got %d", got)
+	}
+	if got := sampleCount(listLabels) - listBefore; got != 1 {
+		t.Errorf("Expected 1 config_dns request to be recorded, got %d", got)
+	}
+}
+
+func TestServerDurationMetricV6(t *testing.T) {
+	srvr := newTestServerV6(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/api/auth":
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"session":{"valid":false,"message":"password incorrect"},"took":0.5}`))
+		case "/api/config/dns/hosts":
+			w.Write([]byte(`{"config":{"dns":{"hosts":[]}},"took":0.25}`))
+		case "/api/config/dns/cnameRecords":
+			w.Write([]byte(`{"config":{"dns":{"cnameRecords":[]}}}`))
+		default:
+			http.NotFound(w, r)
+		}
+	})
+	defer srvr.Close()
+
+	histogram := func(operation string) *dto.Histogram {
+		observer, err := serverDurationMetric.HistogramVec.GetMetricWith(prometheus.Labels{"server": srvr.URL, "operation": operation})
+		if err != nil {
+			t.Fatal(err)
+		}
+		var m dto.Metric
+		if err := observer.(prometheus.Metric).Write(&m); err != nil {
+			t.Fatal(err)
+		}
+		return m.GetHistogram()
+	}
+	authBefore, listBefore := histogram(operationAuth), histogram(operationConfigDNS)
+
+	// The duration of a failed authentication is recorded from the error response
+	if _, err := newPiholeClientV6(PiholeConfig{Server: srvr.URL, APIVersion: "6", Password: "wrong"}); err == nil {
+		t.Fatal("Expected error for creating client with invalid password")
+	}
+
+	// Responses without a duration are not recorded
+	cl, err := newPiholeClientV6(PiholeConfig{Server: srvr.URL, APIVersion: "6"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, recordType := range []string{endpoint.RecordTypeA, endpoint.RecordTypeCNAME} {
+		if _, err := cl.listRecords(context.Background(), recordType); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	auth, list := histogram(operationAuth), histogram(operationConfigDNS)
+	if got := auth.GetSampleCount() - authBefore.GetSampleCount(); got != 1 {
+		t.Errorf("Expected 1 auth duration to be recorded, got %d", got)
+	}
+	if got := auth.GetSampleSum() - authBefore.GetSampleSum(); got != 0.5 {
+		t.Errorf("Expected an auth duration of 0.5s to be recorded, got %fs", got)
+	}
+	if got := list.GetSampleCount() - listBefore.GetSampleCount(); got != 1 {
+		t.Errorf("Expected 1 config_dns duration to be recorded, got %d", got)
+	}
+	if got := list.GetSampleSum() - listBefore.GetSampleSum(); got != 0.25 {
+		t.Errorf("Expected a config_dns duration of 0.25s to be recorded, got %fs", got)
+	}
+}
+
+func TestRequestDurationMetricPerServerV6(t *testing.T) {
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"config":{"dns":{"hosts":[]}},"took":0.1}`))
+	}
+	primary := newTestServerV6(t, handler)
+	defer primary.Close()
+	secondary := newTestServerV6(t, handler)
+	defer secondary.Close()
+
+	sampleCount := func(server string) uint64 {
+		observer, err := requestDurationMetric.SummaryVec.GetMetricWith(prometheus.Labels{
+			"server": server, "operation": operationConfigDNS, "method": "get", "status": "200",
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		var m dto.Metric
+		if err := observer.(prometheus.Metric).Write(&m); err != nil {
+			t.Fatal(err)
+		}
+		return m.GetSummary().GetSampleCount()
+	}
+	primaryBefore, secondaryBefore := sampleCount(primary.URL), sampleCount(secondary.URL)
+
+	for server, calls := range map[string]int{primary.URL: 2, secondary.URL: 1} {
+		cl, err := newPiholeClientV6(PiholeConfig{Server: server, APIVersion: "6"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		for range calls {
+			if _, err := cl.listRecords(context.Background(), endpoint.RecordTypeA); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	if got := sampleCount(primary.URL) - primaryBefore; got != 2 {
+		t.Errorf("Expected 2 requests to be recorded for %s, got %d", primary.URL, got)
+	}
+	if got := sampleCount(secondary.URL) - secondaryBefore; got != 1 {
+		t.Errorf("Expected 1 request to be recorded for %s, got %d", secondary.URL, got)
+	}
+}
+
+func TestRequestTimeoutV6(t *testing.T) {
+	srvr := newTestServerV6(t, func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(200 * time.Millisecond)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"config":{"dns":{"hosts":[]}},"took":0.1}`))
+	})
+	defer srvr.Close()
+
+	// Default timeout is applied when none is configured
+	cl, err := newPiholeClientV6(PiholeConfig{Server: srvr.URL, APIVersion: "6"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := cl.(*piholeClientV6).httpClient.Timeout; got != defaultRequestTimeout {
+		t.Errorf("Expected default timeout %s, got %s", defaultRequestTimeout, got)
+	}
+
+	// The configured timeout fires on a hung server
+	cl, err = newPiholeClientV6(PiholeConfig{Server: srvr.URL, APIVersion: "6", RequestTimeout: 50 * time.Millisecond})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cl.listRecords(context.Background(), endpoint.RecordTypeA); err == nil {
+		t.Error("Expected timeout error, got nil")
+	}
+
+	// The request context is still respected when shorter than the client timeout
+	cl, err = newPiholeClientV6(PiholeConfig{Server: srvr.URL, APIVersion: "6", RequestTimeout: time.Minute})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := cl.listRecords(ctx, endpoint.RecordTypeA); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected context deadline exceeded, got %v", err)
+	}
+}
+
+func TestCustomAuthPathV6(t *testing.T) {
+	var authCalls []string
+	srvr := newTestServerV6(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/proxy/pihole/auth" {
+			http.NotFound(w, r)
+			return
+		}
+		authCalls = append(authCalls, r.Method)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{
+			"session": {
+				"valid": true,
+				"totp": false,
+				"sid": "supersecret",
+				"csrf": "csrfvalue",
+				"validity": 1800,
+				"message": "password correct"
+			},
+			"took": 0.18
+		}`))
+	})
+	defer srvr.Close()
+
+	// The default auth path is not served and fails the login
+	if _, err := newPiholeClientV6(PiholeConfig{Server: srvr.URL, APIVersion: "6", Password: "correct"}); err == nil {
+		t.Error("Expected error when authenticating against the default auth path")
+	}
+
+	cl, err := newPiholeClientV6(PiholeConfig{
+		Server:     srvr.URL,
+		APIVersion: "6",
+		Password:   "correct",
+		AuthPath:   "/proxy/pihole/auth",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cl.(*piholeClientV6).token != "supersecret" {
+		t.Error("Parsed invalid token from login response:", cl.(*piholeClientV6).token)
+	}
+
+	validity, err := cl.(*piholeClientV6).checkTokenValidity(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !validity {
+		t.Error("Expected token to be valid")
+	}
+
+	expected := []string{http.MethodPost, http.MethodGet}
+	if !cmp.Equal(authCalls, expected) {
+		t.Errorf("Expected auth calls %v on the custom path, got %v", expected, authCalls)
+	}
+}
+
+func TestUserAgentV6(t *testing.T) {
+	for _, tt := range []struct {
+		name      string
+		userAgent string
+		expected  string
+	}{
+		{name: "default", expected: externaldns.UserAgent()},
+		{name: "configured", userAgent: "my-gateway/1.0", expected: "my-gateway/1.0"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			var userAgents []string
+			srvr := newTestServerV6(t, func(w http.ResponseWriter, r *http.Request) {
+				userAgents = append(userAgents, r.UserAgent())
+				w.Header().Set("Content-Type", "application/json")
+				w.Write([]byte(`{
+					"session": {
+						"valid": true,
+						"totp": false,
+						"sid": "supersecret",
+						"csrf": "csrfvalue",
+						"validity": 1800,
+						"message": "password correct"
+					},
+					"took": 0.18
+				}`))
+			})
+			defer srvr.Close()
+
+			cl, err := newPiholeClientV6(PiholeConfig{Server: srvr.URL, APIVersion: "6", Password: "correct", UserAgent: tt.userAgent})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := cl.(*piholeClientV6).checkTokenValidity(context.Background()); err != nil {
+				t.Fatal(err)
+			}
+
+			expected := []string{tt.expected, tt.expected}
+			if !cmp.Equal(userAgents, expected) {
+				t.Errorf("Expected user agents %v, got %v", expected, userAgents)
+			}
+		})
+	}
+}
+
+func TestExtraHeadersV6(t *testing.T) {
+	var requests []string
+	srvr := newTestServerV6(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Auth-Token") != "proxy-token" || r.Header.Get("Cf-Access-Jwt-Assertion") != "jwt" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		requests = append(requests, r.Method+" "+r.URL.Path)
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Path == "/api/config/dns/hosts" {
+			w.Write([]byte(`{"config":{"dns":{"hosts":["192.168.1.1 test.example.com"]}},"took":0.1}`))
+			return
+		}
+		w.Write([]byte(`{
+			"session": {
+				"valid": true,
+				"totp": false,
+				"sid": "supersecret",
+				"csrf": "csrfvalue",
+				"validity": 1800,
+				"message": "password correct"
+			},
+			"took": 0.18
+		}`))
+	})
+	defer srvr.Close()
+
+	cl, err := newPiholeClientV6(PiholeConfig{
+		Server:     srvr.URL,
+		APIVersion: "6",
+		Password:   "correct",
+		ExtraHeaders: map[string]string{
+			"X-Auth-Token":            "proxy-token",
+			"Cf-Access-Jwt-Assertion": "jwt",
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if valid, err := cl.(*piholeClientV6).checkTokenValidity(context.Background()); err != nil || !valid {
+		t.Fatalf("Expected a valid token, got %v, %v", valid, err)
+	}
+	if _, err := cl.listRecords(context.Background(), endpoint.RecordTypeA); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{"POST /api/auth", "GET /api/auth", "GET /api/config/dns/hosts"}
+	if diff := cmp.Diff(expected, requests); diff != "" {
+		t.Errorf("Unexpected requests with the extra headers (-want +got):\n%s", diff)
+	}
+}
+
+func TestCACertFileV6(t *testing.T) {
+	srvr := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"config":{"dns":{"hosts":["192.168.1.1 test.example.com"]}},"took":0.1}`))
+	}))
+	defer srvr.Close()
+
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	caPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: srvr.Certificate().Raw})
+	if err := os.WriteFile(caFile, caPEM, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	// Without the CA the self-signed certificate is rejected
+	cl, err := newPiholeClientV6(PiholeConfig{Server: srvr.URL, APIVersion: "6"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cl.listRecords(context.Background(), endpoint.RecordTypeA); err == nil {
+		t.Error("Expected certificate verification error without CA")
+	}
+
+	// With the CA the server is trusted
+	cl, err = newPiholeClientV6(PiholeConfig{Server: srvr.URL, APIVersion: "6", CACertFile: caFile})
+	if err != nil {
+		t.Fatal(err)
+	}
+	records, err := cl.listRecords(context.Background(), endpoint.RecordTypeA)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 1 {
+		t.Errorf("Expected 1 record, got %d", len(records))
+	}
+
+	// The CA takes precedence over skipping verification
+	tlsConfig, err := newTLSConfigV6(PiholeConfig{CACertFile: caFile, TLSInsecureSkipVerify: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tlsConfig.InsecureSkipVerify || tlsConfig.RootCAs == nil {
+		t.Error("Expected the CA certificate to be used instead of skipping verification")
+	}
+
+	// An unreadable CA file fails client creation
+	if _, err := newPiholeClientV6(PiholeConfig{Server: srvr.URL, APIVersion: "6", CACertFile: filepath.Join(t.TempDir(), "missing.pem")}); err == nil {
+		t.Error("Expected error for missing CA file")
+	}
+}
+
+func TestApplyBatchV6(t *testing.T) {
+	var patches []ApiConfigPatchRequest
+	patchStatus := http.StatusOK
+	srvr := newTestServerV6(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.URL.Path == "/api/config/dns/hosts" && r.Method == http.MethodGet:
+			w.Write([]byte(`{"config":{"dns":{"hosts":["192.168.1.1 keep.example.com","192.168.1.2 old.example.com"]}},"took":0.1}`))
+		case r.URL.Path == "/api/config/dns/cnameRecords" && r.Method == http.MethodGet:
+			w.Write([]byte(`{"config":{"dns":{"cnameRecords":["alias.example.com,keep.example.com,300"]}},"took":0.1}`))
+		case r.URL.Path == "/api/config" && r.Method == http.MethodPatch:
+			if patchStatus != http.StatusOK {
+				w.WriteHeader(patchStatus)
+				w.Write([]byte(`{"error":{"key":"not_found","message":"Not found","hint":null},"took":0.1}`))
+				return
+			}
+			var body ApiConfigPatchRequest
+			if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+				t.Error(err)
+			}
+			patches = append(patches, body)
+			w.Write([]byte(`{"took":0.1}`))
+		default:
+			http.NotFound(w, r)
+		}
+	})
+	defer srvr.Close()
+
+	cl, err := newPiholeClientV6(PiholeConfig{
+		Server:       srvr.URL,
+		APIVersion:   "6",
+		DomainFilter: endpoint.NewDomainFilter([]string{"example.com"}),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	batcher := cl.(*piholeClientV6)
+
+	deletes := []*endpoint.Endpoint{
+		endpoint.NewEndpoint("old.example.com", endpoint.RecordTypeA, "192.168.1.2"),
+		endpoint.NewEndpoint("alias.example.com", endpoint.RecordTypeCNAME, "keep.example.com"),
+	}
+	creates := []*endpoint.Endpoint{
+		endpoint.NewEndpoint("new.example.com", endpoint.RecordTypeA, "192.168.1.3"),
+		endpoint.NewEndpoint("new.example.com", endpoint.RecordTypeAAAA, "fc00::1"),
+		endpoint.NewEndpointWithTTL("alias.example.com", endpoint.RecordTypeCNAME, 600, "new.example.com"),
+		// Already present, must not be duplicated
+		endpoint.NewEndpoint("keep.example.com", endpoint.RecordTypeA, "192.168.1.1"),
+		// Filtered out by the domain filter
+		endpoint.NewEndpoint("new.other.org", endpoint.RecordTypeA, "192.168.1.4"),
+	}
+	if err := batcher.applyBatch(context.Background(), deletes, creates); err != nil {
+		t.Fatal(err)
+	}
+	if len(patches) != 1 {
+		t.Fatalf("Expected a single PATCH request, got %d", len(patches))
+	}
+	expectedHosts := []string{"192.168.1.1 keep.example.com", "192.168.1.3 new.example.com", "fc00::1 new.example.com"}
+	if diff := cmp.Diff(expectedHosts, patches[0].Config.DNS.Hosts); diff != "" {
+		t.Errorf("Unexpected hosts (-want +got):\n%s", diff)
+	}
+	expectedCnames := []string{"alias.example.com,new.example.com,600"}
+	if diff := cmp.Diff(expectedCnames, patches[0].Config.DNS.CnameRecords); diff != "" {
+		t.Errorf("Unexpected cnameRecords (-want +got):\n%s", diff)
+	}
+
+	// Nothing to change does not issue a request
+	if err := batcher.applyBatch(context.Background(), nil, []*endpoint.Endpoint{
+		endpoint.NewEndpoint("keep.example.com", endpoint.RecordTypeA, "192.168.1.1"),
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if len(patches) != 1 {
+		t.Fatalf("Expected no additional PATCH request, got %d", len(patches))
+	}
+
+	// Invalid endpoints are skipped without blocking the other changes of the batch
+	err = batcher.applyBatch(context.Background(), nil, []*endpoint.Endpoint{
+		endpoint.NewEndpoint("bad.example.com", endpoint.RecordTypeCNAME, "192.168.1.1"),
+		endpoint.NewEndpoint("*.example.com", endpoint.RecordTypeA, "192.168.1.5"),
+		endpoint.NewEndpoint("good.example.com", endpoint.RecordTypeA, "192.168.1.6"),
+	})
+	if !errors.Is(err, provider.SoftError) {
+		t.Fatalf("Expected soft error, got %v", err)
+	}
+	if len(patches) != 2 {
+		t.Fatalf("Expected the valid changes to be written, got %d PATCH requests", len(patches))
+	}
+	expectedHosts = []string{"192.168.1.1 keep.example.com", "192.168.1.2 old.example.com", "192.168.1.6 good.example.com"}
+	if diff := cmp.Diff(expectedHosts, patches[1].Config.DNS.Hosts); diff != "" {
+		t.Errorf("Unexpected hosts (-want +got):\n%s", diff)
+	}
+
+	// Invalid endpoints alone do not issue a request
+	err = batcher.applyBatch(context.Background(), nil, []*endpoint.Endpoint{
+		endpoint.NewEndpoint("bad.example.com", endpoint.RecordTypeCNAME, "192.168.1.1"),
+	})
+	if !errors.Is(err, provider.SoftError) {
+		t.Fatalf("Expected soft error, got %v", err)
+	}
+	if len(patches) != 2 {
+		t.Fatalf("Expected no additional PATCH request, got %d", len(patches))
+	}
+
+	// Servers without configuration patch support report batching as unsupported
+	patchStatus = http.StatusNotFound
+	err = batcher.applyBatch(context.Background(), deletes, nil)
+	if !errors.Is(err, errBatchUnsupported) {
+		t.Fatalf("Expected errBatchUnsupported, got %v", err)
+	}
+}
+
+func TestStrictDecodingV6(t *testing.T) {
+	for _, tt := range []struct {
+		name          string
+		response      string
+		strict        bool
+		expectError   bool
+		expectedHosts int
+	}{
+		{
+			name:          "valid response",
+			response:      `{"config":{"dns":{"hosts":["192.168.1.1 test.example.com"]}},"took":0.1}`,
+			strict:        true,
+			expectedHosts: 1,
+		},
+		{
+			name:     "missing config key is read as empty by default",
+			response: `{"took":0.1}`,
+		},
+		{
+			name:        "missing config key",
+			response:    `{"took":0.1}`,
+			strict:      true,
+			expectError: true,
+		},
+		{
+			name:        "missing dns section",
+			response:    `{"config":{},"took":0.1}`,
+			strict:      true,
+			expectError: true,
+		},
+		{
+			name:        "null dns section",
+			response:    `{"config":{"dns":null},"took":0.1}`,
+			strict:      true,
+			expectError: true,
+		},
+		{
+			name:        "unknown field",
+			response:    `{"configuration":{"dns":{"hosts":["192.168.1.1 test.example.com"]}},"took":0.1}`,
+			strict:      true,
+			expectError: true,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			srvr := newTestServerV6(t, func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				w.Write([]byte(tt.response))
+			})
+			defer srvr.Close()
+
+			cl, err := newPiholeClientV6(PiholeConfig{Server: srvr.URL, APIVersion: "6", StrictDecoding: tt.strict})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			records, err := cl.listRecords(context.Background(), endpoint.RecordTypeA)
+			if tt.expectError {
+				if err == nil {
+					t.Fatalf("Expected error, got %d records", len(records))
+				}
+				if !strings.HasPrefix(err.Error(), "failed to unmarshal error response:") {
+					t.Errorf("Expected unmarshalling error, got: %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(records) != tt.expectedHosts {
+				t.Errorf("Expected %d records, got %d", tt.expectedHosts, len(records))
+			}
+		})
+	}
+}
+
+func TestTXTRecordsV6(t *testing.T) {
+	const owner = `"heritage=external-dns,external-dns/owner=default"`
+	for _, tt := range []struct {
+		mode  string
+		lines []string
+		entry string
+	}{
+		{
+			mode: OwnershipModeTXT,
+			lines: []string{
+				"address=/example.org/192.168.1.1",
+				"txt-record=a-test.example.com," + owner,
+				"# external-dns: cname-test.example.com " + owner,
+			},
+			entry: "txt-record=a-new.example.com," + owner,
+		},
+		{
+			mode: OwnershipModeComment,
+			lines: []string{
+				"address=/example.org/192.168.1.1",
+				"# external-dns: a-test.example.com " + owner,
+				"txt-record=cname-test.example.com," + owner,
+			},
+			entry: "# external-dns: a-new.example.com " + owner,
+		},
+	} {
+		t.Run(tt.mode, func(t *testing.T) {
+			var written []string
+			srvr := newTestServerV6(t, func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				switch {
+				case r.URL.Path == "/api/config/misc/dnsmasq_lines" && r.Method == http.MethodGet:
+					lines, _ := json.Marshal(tt.lines)
+					w.Write([]byte(`{"config":{"misc":{"dnsmasq_lines":` + string(lines) + `}},"took":0.1}`))
+				case strings.HasPrefix(r.URL.Path, "/api/config/misc/dnsmasq_lines/"):
+					written = append(written, r.Method+" "+strings.TrimPrefix(r.URL.Path, "/api/config/misc/dnsmasq_lines/"))
+					w.WriteHeader(http.StatusNoContent)
+				default:
+					http.NotFound(w, r)
+				}
+			})
+			defer srvr.Close()
+
+			cl, err := newPiholeClientV6(PiholeConfig{Server: srvr.URL, APIVersion: "6", StrictDecoding: true, OwnershipMode: tt.mode})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// Only the lines of the ownership mode are TXT records
+			records, err := cl.listRecords(context.Background(), endpoint.RecordTypeTXT)
+			if err != nil {
+				t.Fatal(err)
+			}
+			expected := []*endpoint.Endpoint{endpoint.NewEndpoint("a-test.example.com", endpoint.RecordTypeTXT, owner)}
+			if diff := cmp.Diff(expected, records); diff != "" {
+				t.Errorf("Unexpected records (-want +got):\n%s", diff)
+			}
+
+			ep := endpoint.NewEndpoint("a-new.example.com", endpoint.RecordTypeTXT, owner)
+			if err := cl.createRecord(context.Background(), ep); err != nil {
+				t.Fatal(err)
+			}
+			if err := cl.deleteRecord(context.Background(), ep); err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff([]string{"PUT " + tt.entry, "DELETE " + tt.entry}, written); diff != "" {
+				t.Errorf("Unexpected writes (-want +got):\n%s", diff)
+			}
+		})
+	}
+
+	// TXT records are not supported when ownership is not stored
+	cl, err := newPiholeClientV6(PiholeConfig{Server: "test", APIVersion: "6", OwnershipMode: OwnershipModeNone})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cl.listRecords(context.Background(), endpoint.RecordTypeTXT); err == nil {
+		t.Error("Expected an error listing TXT records without ownership")
+	}
+}
+
+func TestApplyBatchTXTV6(t *testing.T) {
+	var patches []ApiConfigPatchRequest
+	srvr := newTestServerV6(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.URL.Path == "/api/config/dns/hosts" && r.Method == http.MethodGet:
+			w.Write([]byte(`{"config":{"dns":{"hosts":["192.168.1.1 keep.example.com"]}},"took":0.1}`))
+		case r.URL.Path == "/api/config/dns/cnameRecords" && r.Method == http.MethodGet:
+			w.Write([]byte(`{"config":{"dns":{"cnameRecords":[]}},"took":0.1}`))
+		case r.URL.Path == "/api/config/misc/dnsmasq_lines" && r.Method == http.MethodGet:
+			w.Write([]byte(`{"config":{"misc":{"dnsmasq_lines":["address=/example.org/192.168.1.1","# external-dns: a-old.example.com \"owner=default\""]}},"took":0.1}`))
+		case r.URL.Path == "/api/config" && r.Method == http.MethodPatch:
+			var body ApiConfigPatchRequest
+			if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+				t.Error(err)
+			}
+			patches = append(patches, body)
+			w.Write([]byte(`{"took":0.1}`))
+		default:
+			http.NotFound(w, r)
+		}
+	})
+	defer srvr.Close()
+
+	cl, err := newPiholeClientV6(PiholeConfig{Server: srvr.URL, APIVersion: "6", OwnershipMode: OwnershipModeComment})
+	if err != nil {
+		t.Fatal(err)
+	}
+	batcher := cl.(*piholeClientV6)
+
+	// Changes to the records only leave the dnsmasq lines out of the patch
+	if err := batcher.applyBatch(context.Background(), nil, []*endpoint.Endpoint{
+		endpoint.NewEndpoint("new.example.com", endpoint.RecordTypeA, "192.168.1.2"),
+		endpoint.NewEndpoint("a-old.example.com", endpoint.RecordTypeTXT, `"owner=default"`),
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if len(patches) != 1 {
+		t.Fatalf("Expected a single PATCH request, got %d", len(patches))
+	}
+	if patches[0].Config.Misc != nil {
+		t.Errorf("Unexpected dnsmasq lines in patch: %v", patches[0].Config.Misc.DnsmasqLines)
+	}
+
+	// Changed TXT records patch the dnsmasq lines, keeping the other lines
+	if err := batcher.applyBatch(context.Background(), []*endpoint.Endpoint{
+		endpoint.NewEndpoint("a-old.example.com", endpoint.RecordTypeTXT, `"owner=default"`),
+	}, []*endpoint.Endpoint{
+		endpoint.NewEndpoint("a-new.example.com", endpoint.RecordTypeTXT, `"owner=default"`),
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if len(patches) != 2 {
+		t.Fatalf("Expected a second PATCH request, got %d", len(patches))
+	}
+	if patches[1].Config.Misc == nil {
+		t.Fatal("Expected dnsmasq lines in patch")
+	}
+	expectedLines := []string{"address=/example.org/192.168.1.1", `# external-dns: a-new.example.com "owner=default"`}
+	if diff := cmp.Diff(expectedLines, patches[1].Config.Misc.DnsmasqLines); diff != "" {
+		t.Errorf("Unexpected dnsmasq lines (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,86 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pihole
+
+import (
+	"strings"
+)
+
+const (
+	// OwnershipModeNone does not store TXT records, so that ownership cannot be tracked by the TXT registry.
+	OwnershipModeNone = "none"
+	// OwnershipModeTXT stores TXT records as dnsmasq txt-record lines, served by Pi-hole like any other record (V6 only).
+	OwnershipModeTXT = "txt"
+	// OwnershipModeComment stores TXT records as dnsmasq comment lines, kept in the Pi-hole configuration
+	// without being served (V6 only).
+	OwnershipModeComment = "comment"
+)
+
+const (
+	// txtRecordPrefix starts the dnsmasq lines declaring a TXT record.
+	txtRecordPrefix = "txt-record="
+	// ownershipCommentPrefix starts the dnsmasq comment lines holding a TXT record.
+	ownershipCommentPrefix = "# external-dns: "
+)
+
+// ownershipEncoding stores the TXT records the registry tracks ownership in as entries
+// of the dnsmasq_lines array of the Pi-hole configuration, which has no TXT record list of its own.
+type ownershipEncoding interface {
+	// encode formats a target of a TXT record as a configuration entry.
+	encode(name, text string) string
+	// decode parses a configuration entry, reporting false for the entries that are not TXT records.
+	decode(entry string) (name, text string, ok bool)
+}
+
+// ownershipEncodings are the encodings of the ownership modes storing TXT records.
+var ownershipEncodings = map[string]ownershipEncoding{
+	OwnershipModeTXT:     txtRecordEncoding{},
+	OwnershipModeComment: commentEncoding{},
+}
+
+// txtRecordEncoding writes TXT records as "txt-record=name,text" lines. The text is kept as is,
+// it has to be quoted for dnsmasq to serve a text holding commas as a single string.
+type txtRecordEncoding struct{}
+
+func (txtRecordEncoding) encode(name, text string) string {
+	return txtRecordPrefix + name + "," + text
+}
+
+func (txtRecordEncoding) decode(entry string) (string, string, bool) {
+	record, found := strings.CutPrefix(strings.TrimSpace(entry), txtRecordPrefix)
+	if !found {
+		return "", "", false
+	}
+	name, text, found := strings.Cut(record, ",")
+	return name, text, found && name != ""
+}
+
+// commentEncoding writes TXT records as "# external-dns: name text" lines, ignored by dnsmasq.
+type commentEncoding struct{}
+
+func (commentEncoding) encode(name, text string) string {
+	return ownershipCommentPrefix + name + " " + text
+}
+
+func (commentEncoding) decode(entry string) (string, string, bool) {
+	record, found := strings.CutPrefix(strings.TrimSpace(entry), ownershipCommentPrefix)
+	if !found {
+		return "", "", false
+	}
+	name, text, found := strings.Cut(record, " ")
+	return name, text, found && name != ""
+}
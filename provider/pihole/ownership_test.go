@@ -0,0 +1,67 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pihole
+
+import (
+	"testing"
+)
+
+func TestOwnershipEncodings(t *testing.T) {
+	const (
+		name = "a-app.example.com"
+		text = `"heritage=external-dns,external-dns/owner=default,external-dns/resource=service/default/app"`
+	)
+	for _, tt := range []struct {
+		mode     string
+		expected string
+		foreign  []string
+	}{
+		{
+			mode:     OwnershipModeTXT,
+			expected: `txt-record=a-app.example.com,"heritage=external-dns,external-dns/owner=default,external-dns/resource=service/default/app"`,
+			foreign:  []string{"address=/example.org/192.168.1.1", "txt-record=", "# a comment"},
+		},
+		{
+			mode:     OwnershipModeComment,
+			expected: `# external-dns: a-app.example.com "heritage=external-dns,external-dns/owner=default,external-dns/resource=service/default/app"`,
+			foreign:  []string{"txt-record=app.example.com,text", "# a comment", "# external-dns: app.example.com"},
+		},
+	} {
+		t.Run(tt.mode, func(t *testing.T) {
+			encoding := ownershipEncodings[tt.mode]
+			entry := encoding.encode(name, text)
+			if entry != tt.expected {
+				t.Errorf("Unexpected entry %q, want %q", entry, tt.expected)
+			}
+
+			gotName, gotText, ok := encoding.decode("  " + entry + " ")
+			if !ok || gotName != name || gotText != text {
+				t.Errorf("Entry %q decoded as %q %q %v", entry, gotName, gotText, ok)
+			}
+
+			for _, line := range tt.foreign {
+				if gotName, gotText, ok := encoding.decode(line); ok {
+					t.Errorf("Line %q decoded as %q %q, want it skipped", line, gotName, gotText)
+				}
+			}
+		})
+	}
+
+	if _, ok := ownershipEncodings[OwnershipModeNone]; ok {
+		t.Error("No encoding expected for the none ownership mode")
+	}
+}
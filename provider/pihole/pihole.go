@@ -19,9 +19,16 @@ package pihole
 import (
 	"context"
 	"errors"
+	"fmt"
+	"maps"
 	"slices"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/google/go-cmp/cmp"
+	"github.com/miekg/dns"
+	log "github.com/sirupsen/logrus"
 
 	"sigs.k8s.io/external-dns/endpoint"
 	"sigs.k8s.io/external-dns/plan"
@@ -32,11 +39,50 @@ import (
 // in the environment.
 var ErrNoPiholeServer = errors.New("no pihole server found in the environment or flags")
 
+const (
+	// CNAMETargetConflictIgnore does not check the targets of CNAME records.
+	CNAMETargetConflictIgnore = "ignore"
+	// CNAMETargetConflictWarn logs a warning when a CNAME record points at a name also managed as an A or AAAA record.
+	CNAMETargetConflictWarn = "warn"
+	// CNAMETargetConflictReject skips CNAME records pointing at a name also managed as an A or AAAA record.
+	CNAMETargetConflictReject = "reject"
+)
+
+// defaultPiholeAPIVersion is the Pi-hole API version used when none is configured.
+const defaultPiholeAPIVersion = "5"
+
+// piholeRecordTypes are the record types Pi-hole local DNS can hold, in the order they are listed.
+var piholeRecordTypes = []string{endpoint.RecordTypeA, endpoint.RecordTypeAAAA, endpoint.RecordTypeCNAME}
+
+// errBatchUnsupported is returned when the Pi-hole server does not support batched writes.
+var errBatchUnsupported = errors.New("batched writes are not supported by the pihole server")
+
+// piholeBatchAPI is implemented by Pi-hole API clients able to write a whole set of changes at once.
+type piholeBatchAPI interface {
+	// applyBatch deletes and creates the given records in a single write.
+	applyBatch(ctx context.Context, deletes, creates []*endpoint.Endpoint) error
+}
+
+// piholeHealthAPI is implemented by Pi-hole API clients able to check that the server can be reached.
+type piholeHealthAPI interface {
+	// healthcheck makes a cheap authenticated request to the server.
+	healthcheck(ctx context.Context) error
+}
+
 // PiholeProvider is an implementation of Provider for Pi-hole Local DNS.
 type PiholeProvider struct {
 	provider.BaseProvider
-	api        piholeAPI
-	apiVersion string
+	api                 piholeAPI
+	apiVersion          string
+	batchWrites         bool
+	cnameTargetConflict string
+	domainFilter        *endpoint.DomainFilter
+	dryRun              bool
+	managedRecordTypes  []string
+	orderCreates        bool
+	ownershipMode       string
+	preserveNameCase    bool
+	recordsCache        *recordsCache
 }
 
 // PiholeConfig is used for configuring a PiholeProvider.
@@ -47,12 +93,70 @@ type PiholeConfig struct {
 	Password string
 	// Disable verification of TLS certificates.
 	TLSInsecureSkipVerify bool
+	// Path to a PEM encoded CA certificate used to verify the server (V6 only).
+	// Takes precedence over TLSInsecureSkipVerify when both are set.
+	CACertFile string
 	// A filter to apply when looking up and applying records.
 	DomainFilter *endpoint.DomainFilter
 	// Do nothing and log what would have changed to stdout.
 	DryRun bool
-	// PiHole API version =<5 or >=6, default is 5
+	// PiHole API version, either 5 or 6, default is 5
 	APIVersion string
+	// The User-Agent header of the requests to the Pi-hole server, defaults to ExternalDNS/<version> when unset.
+	UserAgent string
+	// Headers added to every request to the Pi-hole API (V6 only), e.g. the token expected by an
+	// authenticating reverse proxy in front of the server.
+	ExtraHeaders map[string]string
+	// Timeout for requests to the Pi-hole API (V6 only), defaults to 30s when unset.
+	RequestTimeout time.Duration
+	// Path of the authentication endpoint (V6 only), defaults to /api/auth when unset.
+	AuthPath string
+	// Write all changes in a single configuration update instead of one request per record (V6 only).
+	// Falls back to per-record writes when the server does not support it.
+	BatchWrites bool
+	// What to do when a CNAME record points at a name also managed as an A or AAAA record, either
+	// CNAMETargetConflictIgnore, CNAMETargetConflictWarn or CNAMETargetConflictReject. Defaults to ignore.
+	CNAMETargetConflict string
+	// Create records before the CNAME records pointing at them within the same changes,
+	// so that no CNAME is left dangling while its target is being created.
+	OrderCreates bool
+	// Reject record listings of an unexpected shape, such as unknown fields or a missing
+	// config.dns section, instead of reading them as empty (V6 only).
+	StrictDecoding bool
+	// Keep the case of DNS names as emitted by sources. By default names are lowercased when
+	// writing and listing records, so that mixed-case names do not cause needless updates.
+	PreserveNameCase bool
+	// The record types to list and change, defaults to all the types Pi-hole supports (A, AAAA and CNAME)
+	// when empty. Records of other types are left untouched, even when changes include them.
+	ManagedRecordTypes []string
+	// How long the records listed by Records are served from memory, disabled when zero.
+	// The cache is invalidated whenever ApplyChanges writes to the server.
+	RecordsCacheTTL time.Duration
+	// How the TXT records the registry tracks ownership in are stored, either OwnershipModeNone (the default),
+	// OwnershipModeTXT or OwnershipModeComment. The last two write to the dnsmasq lines of the Pi-hole
+	// configuration and require an API version able to store them. TXT records are managed in addition
+	// to ManagedRecordTypes unless the mode is OwnershipModeNone.
+	OwnershipMode string
+}
+
+// PiholeFeatures tells which features the Pi-hole API version in use supports.
+type PiholeFeatures struct {
+	// CNAMETTL is set when CNAME records carry a TTL.
+	CNAMETTL bool
+	// MultipleTargets is set when a name can have several targets of the same record type.
+	MultipleTargets bool
+	// BatchWrites is set when all changes can be written in a single configuration update.
+	BatchWrites bool
+	// Ownership is set when the TXT records the registry tracks ownership in can be stored.
+	Ownership bool
+}
+
+// piholeFeatures returns the features of the given Pi-hole API version.
+func piholeFeatures(apiVersion string) PiholeFeatures {
+	if apiVersion == "6" {
+		return PiholeFeatures{CNAMETTL: true, MultipleTargets: true, BatchWrites: true, Ownership: true}
+	}
+	return PiholeFeatures{}
 }
 
 // Helper struct for de-duping DNS entry updates.
@@ -63,74 +167,259 @@ type piholeEntryKey struct {
 
 // NewPiholeProvider initializes a new Pi-hole Local DNS based Provider.
 func NewPiholeProvider(cfg PiholeConfig) (*PiholeProvider, error) {
-	var api piholeAPI
-	var err error
-	switch cfg.APIVersion {
-	case "6":
-		api, err = newPiholeClientV6(cfg)
+	switch cfg.CNAMETargetConflict {
+	case "":
+		cfg.CNAMETargetConflict = CNAMETargetConflictIgnore
+	case CNAMETargetConflictIgnore, CNAMETargetConflictWarn, CNAMETargetConflictReject:
+	default:
+		return nil, fmt.Errorf("invalid CNAME target conflict behavior %q, must be one of %q, %q or %q", cfg.CNAMETargetConflict, CNAMETargetConflictIgnore, CNAMETargetConflictWarn, CNAMETargetConflictReject)
+	}
+
+	var managedRecordTypes []string
+	if len(cfg.ManagedRecordTypes) > 0 {
+		managedRecordTypes = slices.DeleteFunc(slices.Clone(piholeRecordTypes), func(recordType string) bool {
+			return !slices.Contains(cfg.ManagedRecordTypes, recordType)
+		})
+	}
+
+	if cfg.APIVersion == "" {
+		cfg.APIVersion = defaultPiholeAPIVersion
+	}
+	switch cfg.OwnershipMode {
+	case "":
+		cfg.OwnershipMode = OwnershipModeNone
+	case OwnershipModeNone:
+	case OwnershipModeTXT, OwnershipModeComment:
+		if !piholeFeatures(cfg.APIVersion).Ownership {
+			return nil, fmt.Errorf("ownership mode %q is not supported by the Pi-hole API version %s, only %q is", cfg.OwnershipMode, cfg.APIVersion, OwnershipModeNone)
+		}
 	default:
-		api, err = newPiholeClient(cfg)
+		return nil, fmt.Errorf("invalid ownership mode %q, must be one of %q, %q or %q", cfg.OwnershipMode, OwnershipModeNone, OwnershipModeTXT, OwnershipModeComment)
+	}
+
+	newClient, err := piholeClients.Get(cfg.APIVersion)
+	if err != nil {
+		return nil, err
 	}
+	api, err := newClient(cfg)
 	if err != nil {
 		return nil, err
 	}
-	return &PiholeProvider{api: api, apiVersion: cfg.APIVersion}, nil
+	var cache *recordsCache
+	if cfg.RecordsCacheTTL > 0 {
+		cache = &recordsCache{ttl: cfg.RecordsCacheTTL, now: time.Now}
+	}
+	return &PiholeProvider{
+		api:                 api,
+		apiVersion:          cfg.APIVersion,
+		batchWrites:         cfg.BatchWrites,
+		cnameTargetConflict: cfg.CNAMETargetConflict,
+		domainFilter:        cfg.DomainFilter,
+		dryRun:              cfg.DryRun,
+		managedRecordTypes:  managedRecordTypes,
+		orderCreates:        cfg.OrderCreates,
+		ownershipMode:       cfg.OwnershipMode,
+		preserveNameCase:    cfg.PreserveNameCase,
+		recordsCache:        cache,
+	}, nil
+}
+
+// Features returns the features supported by the Pi-hole API version the provider talks to.
+func (p *PiholeProvider) Features() PiholeFeatures {
+	return piholeFeatures(p.apiVersion)
 }
 
 // Records implements Provider, populating a slice of endpoints from
 // Pi-Hole local DNS.
+// Only the managed record types are listed.
+// When the records cache is enabled, records listed less than its TTL ago are returned instead.
 func (p *PiholeProvider) Records(ctx context.Context) ([]*endpoint.Endpoint, error) {
-	aRecords, err := p.api.listRecords(ctx, endpoint.RecordTypeA)
-	if err != nil {
-		return nil, err
+	cached, generation, ok := p.recordsCache.get()
+	if ok {
+		log.Debug("Returning Pi-hole records from cache")
+		return cached, nil
 	}
-	aaaaRecords, err := p.api.listRecords(ctx, endpoint.RecordTypeAAAA)
-	if err != nil {
-		return nil, err
+
+	var records []*endpoint.Endpoint
+	for _, recordType := range p.recordTypes() {
+		// Stop between listings, so that a cancelled reconcile does not wait for all of them.
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		typeRecords, err := p.api.listRecords(ctx, recordType)
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, typeRecords...)
+	}
+	if !p.preserveNameCase {
+		records = lowercaseNames(records)
 	}
-	cnameRecords, err := p.api.listRecords(ctx, endpoint.RecordTypeCNAME)
+	p.recordsCache.set(records, generation)
+	return records, nil
+}
+
+// recordsCache holds the records listed from Pi-hole until its TTL expires.
+// A nil recordsCache caches nothing.
+type recordsCache struct {
+	ttl time.Duration
+	now func() time.Time
+
+	lock       sync.Mutex
+	records    []*endpoint.Endpoint
+	expiry     time.Time
+	generation uint64
+}
+
+// get returns a copy of the cached records, if any are cached and not expired.
+// The returned generation has to be given to set when the records are listed again.
+func (c *recordsCache) get() ([]*endpoint.Endpoint, uint64, bool) {
+	if c == nil {
+		return nil, 0, false
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if c.records == nil || !c.now().Before(c.expiry) {
+		return nil, c.generation, false
+	}
+	return copyEndpoints(c.records), c.generation, true
+}
+
+// set caches a copy of the records, unless the cache was invalidated since the given generation
+// was returned by get, in which case the records may already be stale.
+func (c *recordsCache) set(records []*endpoint.Endpoint, generation uint64) {
+	if c == nil {
+		return
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if generation != c.generation {
+		return
+	}
+	c.records = copyEndpoints(records)
+	if c.records == nil {
+		c.records = []*endpoint.Endpoint{}
+	}
+	c.expiry = c.now().Add(c.ttl)
+}
+
+// invalidate drops the cached records.
+func (c *recordsCache) invalidate() {
+	if c == nil {
+		return
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.records = nil
+	c.generation++
+}
+
+// copyEndpoints returns deep copies of the endpoints, so that callers cannot modify cached records.
+func copyEndpoints(eps []*endpoint.Endpoint) []*endpoint.Endpoint {
+	if eps == nil {
+		return nil
+	}
+	copies := make([]*endpoint.Endpoint, 0, len(eps))
+	for _, ep := range eps {
+		copies = append(copies, ep.DeepCopy())
+	}
+	return copies
+}
+
+// lowercaseNames returns the endpoints with their DNS names in lower case.
+// Endpoints whose name changes are copied, leaving the given endpoints untouched.
+func lowercaseNames(eps []*endpoint.Endpoint) []*endpoint.Endpoint {
+	result := make([]*endpoint.Endpoint, 0, len(eps))
+	for _, ep := range eps {
+		if name := strings.ToLower(ep.DNSName); name != ep.DNSName {
+			ep = ep.DeepCopy()
+			ep.DNSName = name
+		}
+		result = append(result, ep)
+	}
+	return result
+}
+
+// ExportZoneFile lists the Pi-hole local DNS records and renders them in BIND zone-file syntax,
+// one resource record per target, sorted by name. Records without a TTL are written without one,
+// leaving it to the $TTL directive of the zone they are imported into.
+func (p *PiholeProvider) ExportZoneFile(ctx context.Context) (string, error) {
+	records, err := p.Records(ctx)
 	if err != nil {
-		return nil, err
+		return "", err
+	}
+	slices.SortStableFunc(records, func(a, b *endpoint.Endpoint) int {
+		return strings.Compare(dns.Fqdn(a.DNSName), dns.Fqdn(b.DNSName))
+	})
+
+	var zone strings.Builder
+	zone.WriteString("; Pi-hole local DNS records\n")
+	for _, ep := range records {
+		ttl := ""
+		if ep.RecordTTL.IsConfigured() {
+			ttl = fmt.Sprintf("%d\t", ep.RecordTTL)
+		}
+		for _, target := range ep.Targets {
+			if ep.RecordType == endpoint.RecordTypeCNAME {
+				target = dns.Fqdn(target)
+			}
+			fmt.Fprintf(&zone, "%s\t%sIN\t%s\t%s\n", dns.Fqdn(ep.DNSName), ttl, ep.RecordType, target)
+		}
 	}
-	aRecords = append(aRecords, aaaaRecords...)
-	return append(aRecords, cnameRecords...), nil
+	return zone.String(), nil
 }
 
 // ApplyChanges implements Provider, syncing desired state with the Pi-hole server Local DNS.
+// Changes of record types which are not managed are skipped.
 func (p *PiholeProvider) ApplyChanges(ctx context.Context, changes *plan.Changes) error {
-	// Handle pure deletes first.
-	for _, ep := range changes.Delete {
-		if err := p.api.deleteRecord(ctx, ep); err != nil {
-			return err
+	changes = &plan.Changes{
+		Create:    p.managedRecords(changes.Create),
+		UpdateOld: p.managedRecords(changes.UpdateOld),
+		UpdateNew: p.managedRecords(changes.UpdateNew),
+		Delete:    p.managedRecords(changes.Delete),
+	}
+	if !p.preserveNameCase {
+		changes = &plan.Changes{
+			Create:    lowercaseNames(changes.Create),
+			UpdateOld: lowercaseNames(changes.UpdateOld),
+			UpdateNew: lowercaseNames(changes.UpdateNew),
+			Delete:    lowercaseNames(changes.Delete),
 		}
 	}
 
+	// Handle pure deletes first.
+	deletes := endpoint.CanonicalizeNames(changes.Delete)
+
+	features := p.Features()
+
 	// Handle updated state - there are no endpoints for updating in place.
 	updateNew := make(map[piholeEntryKey]*endpoint.Endpoint)
-	for _, ep := range changes.UpdateNew {
+	for _, ep := range endpoint.CanonicalizeNames(changes.UpdateNew) {
 		key := piholeEntryKey{ep.DNSName, ep.RecordType}
 
-		// If the API version is 6, we need to handle multiple targets for the same DNS name.
-		if p.apiVersion == "6" {
+		// Merge the targets of a DNS name if the API version supports multiple targets.
+		if features.MultipleTargets {
 			if existing, ok := updateNew[key]; ok {
-				existing.Targets = append(existing.Targets, ep.Targets...)
+				// Merge into a copy, leaving the endpoints of the given changes untouched.
+				merged := existing.DeepCopy()
+				merged.Targets = append(merged.Targets, ep.Targets...)
 
 				// Deduplicate targets
-				slices.Sort(existing.Targets)
-				existing.Targets = slices.Compact(existing.Targets)
+				slices.Sort(merged.Targets)
+				merged.Targets = slices.Compact(merged.Targets)
 
-				ep = existing
+				ep = merged
 			}
 		}
 		updateNew[key] = ep
 	}
 
-	for _, ep := range changes.UpdateOld {
+	for _, ep := range endpoint.CanonicalizeNames(changes.UpdateOld) {
 		// Check if this existing entry has an exact match for an updated entry and skip it if so.
 		key := piholeEntryKey{ep.DNSName, ep.RecordType}
 		if newRecord := updateNew[key]; newRecord != nil {
-			// If the API version is 6, we need to handle multiple targets for the same DNS name.
-			if p.apiVersion == "6" {
+			// Compare all targets if the API version supports multiple targets.
+			if features.MultipleTargets {
 				if cmp.Diff(ep.Targets, newRecord.Targets) == "" {
 					delete(updateNew, key)
 					continue
@@ -143,23 +432,249 @@ func (p *PiholeProvider) ApplyChanges(ctx context.Context, changes *plan.Changes
 				}
 			}
 
-			if err := p.api.deleteRecord(ctx, ep); err != nil {
-				return err
-			}
+			deletes = append(deletes, ep)
 		}
 	}
 
 	// Handle pure creates before applying new updated state.
-	for _, ep := range changes.Create {
-		if err := p.api.createRecord(ctx, ep); err != nil {
+	creates := endpoint.CanonicalizeNames(changes.Create)
+	updates := make([]*endpoint.Endpoint, 0, len(updateNew))
+	for _, ep := range updateNew {
+		creates = append(creates, ep)
+		updates = append(updates, ep)
+	}
+
+	var softErrs provider.SoftErrors
+	deletes, creates, conflictErr := p.checkCNAMETargets(ctx, deletes, creates)
+	if err := softErrs.Add(conflictErr); err != nil {
+		return err
+	}
+
+	if p.orderCreates {
+		creates = orderCreates(creates)
+	}
+
+	writeErr := p.write(ctx, deletes, creates)
+	if !p.dryRun {
+		// Even a failed write may have changed some records.
+		p.recordsCache.invalidate()
+	}
+	if err := softErrs.Add(writeErr); err != nil {
+		return err
+	}
+
+	if p.dryRun {
+		summarizeChanges(changes.Create, updates, changes.Delete).log()
+	}
+	return softErrs.Err()
+}
+
+// recordTypes returns the managed record types, all the ones Pi-hole supports unless restricted,
+// followed by TXT when ownership is stored.
+func (p *PiholeProvider) recordTypes() []string {
+	recordTypes := piholeRecordTypes
+	if p.managedRecordTypes != nil {
+		recordTypes = p.managedRecordTypes
+	}
+	if p.storesOwnership() {
+		return append(slices.Clone(recordTypes), endpoint.RecordTypeTXT)
+	}
+	return recordTypes
+}
+
+// managedRecords returns the endpoints of the managed record types, logging the others.
+func (p *PiholeProvider) managedRecords(eps []*endpoint.Endpoint) []*endpoint.Endpoint {
+	managed := make([]*endpoint.Endpoint, 0, len(eps))
+	for _, ep := range eps {
+		if !slices.Contains(p.recordTypes(), ep.RecordType) {
+			log.Debugf("Skipping %s record %s, the record type is not managed", ep.RecordType, ep.DNSName)
+			continue
+		}
+		managed = append(managed, ep)
+	}
+	return managed
+}
+
+// changeCounts holds the number of record changes of a single record type.
+type changeCounts struct {
+	Creates int
+	Updates int
+	Deletes int
+}
+
+// changeSummary holds the number of record changes per record type.
+type changeSummary map[string]*changeCounts
+
+// summarizeChanges counts the creates, updates and deletes per record type.
+// On Pi-hole an update is performed as a delete of the old record followed by a create.
+func summarizeChanges(creates, updates, deletes []*endpoint.Endpoint) changeSummary {
+	summary := make(changeSummary)
+	counts := func(recordType string) *changeCounts {
+		if _, ok := summary[recordType]; !ok {
+			summary[recordType] = &changeCounts{}
+		}
+		return summary[recordType]
+	}
+	for _, ep := range creates {
+		counts(ep.RecordType).Creates++
+	}
+	for _, ep := range updates {
+		counts(ep.RecordType).Updates++
+	}
+	for _, ep := range deletes {
+		counts(ep.RecordType).Deletes++
+	}
+	return summary
+}
+
+func (s changeSummary) log() {
+	if len(s) == 0 {
+		log.Info("DRY RUN: no changes would be performed")
+		return
+	}
+	for _, recordType := range slices.Sorted(maps.Keys(s)) {
+		c := s[recordType]
+		log.Infof("DRY RUN: %s records: %d to create, %d to update, %d to delete", recordType, c.Creates, c.Updates, c.Deletes)
+	}
+}
+
+// Healthcheck checks that the Pi-hole server can be reached with the configured password.
+// It is a no-op for API versions without a cheap way to do so.
+func (p *PiholeProvider) Healthcheck(ctx context.Context) error {
+	if checker, ok := p.api.(piholeHealthAPI); ok {
+		return checker.healthcheck(ctx)
+	}
+	return nil
+}
+
+// write deletes and creates the given records, in a single batch when enabled and supported.
+// Soft errors of individual records are combined and returned once all the others are written.
+func (p *PiholeProvider) write(ctx context.Context, deletes, creates []*endpoint.Endpoint) error {
+	if batcher, ok := p.api.(piholeBatchAPI); ok && p.batchWrites {
+		err := batcher.applyBatch(ctx, deletes, creates)
+		if !errors.Is(err, errBatchUnsupported) {
 			return err
 		}
+		log.Warnf("Falling back to per-record writes: %v", err)
 	}
-	for _, ep := range updateNew {
-		if err := p.api.createRecord(ctx, ep); err != nil {
+
+	// Records rejected with a soft error are skipped, so that they do not prevent the other changes.
+	var softErrs provider.SoftErrors
+	for _, ep := range deletes {
+		if err := softErrs.Add(p.api.deleteRecord(ctx, ep)); err != nil {
+			return err
+		}
+	}
+	for _, ep := range creates {
+		if err := softErrs.Add(p.api.createRecord(ctx, ep)); err != nil {
 			return err
 		}
 	}
 
-	return nil
+	return softErrs.Err()
+}
+
+// orderCreates sorts creates so that every record comes after the records of the names its CNAME
+// targets point at, keeping the original order otherwise. CNAME cycles are broken at the first record reached.
+func orderCreates(creates []*endpoint.Endpoint) []*endpoint.Endpoint {
+	byName := make(map[string][]int)
+	for i, ep := range creates {
+		name := strings.ToLower(ep.DNSName)
+		byName[name] = append(byName[name], i)
+	}
+
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	state := make([]int, len(creates))
+	ordered := make([]*endpoint.Endpoint, 0, len(creates))
+	var visit func(i int)
+	visit = func(i int) {
+		if state[i] != unvisited {
+			return
+		}
+		state[i] = visiting
+		if creates[i].RecordType == endpoint.RecordTypeCNAME {
+			for _, target := range creates[i].Targets {
+				for _, j := range byName[strings.ToLower(strings.TrimSuffix(target, "."))] {
+					visit(j)
+				}
+			}
+		}
+		state[i] = visited
+		ordered = append(ordered, creates[i])
+	}
+	for i := range creates {
+		visit(i)
+	}
+	return ordered
+}
+
+// checkCNAMETargets detects CNAME records pointing at a name that is managed as an A or AAAA record,
+// either listed from Pi-hole already, see Records, or created by the same changes. Conflicts are logged,
+// and with CNAMETargetConflictReject the offending CNAME records are removed from creates, along with the
+// deletes of the records they update so that these are kept, and reported as a soft error.
+func (p *PiholeProvider) checkCNAMETargets(ctx context.Context, deletes, creates []*endpoint.Endpoint) ([]*endpoint.Endpoint, []*endpoint.Endpoint, error) {
+	if p.cnameTargetConflict == CNAMETargetConflictIgnore || p.cnameTargetConflict == "" ||
+		!slices.ContainsFunc(creates, func(ep *endpoint.Endpoint) bool { return ep.RecordType == endpoint.RecordTypeCNAME }) {
+		return deletes, creates, nil
+	}
+
+	existing, err := p.Records(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	managed := make(map[string]bool)
+	for _, ep := range existing {
+		if ep.RecordType == endpoint.RecordTypeA || ep.RecordType == endpoint.RecordTypeAAAA {
+			managed[strings.ToLower(ep.DNSName)] = true
+		}
+	}
+	for _, ep := range deletes {
+		if ep.RecordType == endpoint.RecordTypeA || ep.RecordType == endpoint.RecordTypeAAAA {
+			delete(managed, strings.ToLower(ep.DNSName))
+		}
+	}
+	for _, ep := range creates {
+		if ep.RecordType == endpoint.RecordTypeA || ep.RecordType == endpoint.RecordTypeAAAA {
+			managed[strings.ToLower(ep.DNSName)] = true
+		}
+	}
+
+	var conflicts []string
+	rejected := make(map[string]bool)
+	creates = slices.DeleteFunc(creates, func(ep *endpoint.Endpoint) bool {
+		if ep.RecordType != endpoint.RecordTypeCNAME {
+			return false
+		}
+		for _, target := range ep.Targets {
+			if !managed[strings.ToLower(strings.TrimSuffix(target, "."))] {
+				continue
+			}
+			if p.cnameTargetConflict == CNAMETargetConflictReject {
+				log.Errorf("Skipping CNAME %s -> %s: target is also managed as an A/AAAA record", ep.DNSName, target)
+				conflicts = append(conflicts, ep.DNSName)
+				rejected[strings.ToLower(ep.DNSName)] = true
+				return true
+			}
+			log.Warnf("CNAME %s -> %s points at a name also managed as an A/AAAA record", ep.DNSName, target)
+		}
+		return false
+	})
+
+	if len(conflicts) > 0 {
+		// Keep the records the rejected CNAME records were meant to replace.
+		deletes = slices.DeleteFunc(deletes, func(ep *endpoint.Endpoint) bool {
+			return ep.RecordType == endpoint.RecordTypeCNAME && rejected[strings.ToLower(ep.DNSName)]
+		})
+		return deletes, creates, provider.NewSoftErrorf("CNAME records pointing at names managed as A/AAAA records were skipped: %s", strings.Join(conflicts, ", "))
+	}
+	return deletes, creates, nil
+}
+
+// storesOwnership reports whether the TXT records the registry tracks ownership in are stored.
+func (p *PiholeProvider) storesOwnership() bool {
+	return p.ownershipMode == OwnershipModeTXT || p.ownershipMode == OwnershipModeComment
 }
@@ -20,11 +20,17 @@ import (
 	"context"
 	"errors"
 	"reflect"
+	"slices"
+	"strings"
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
+	log "github.com/sirupsen/logrus"
+
 	"sigs.k8s.io/external-dns/endpoint"
+	"sigs.k8s.io/external-dns/internal/testutils"
 	"sigs.k8s.io/external-dns/plan"
+	"sigs.k8s.io/external-dns/provider"
 )
 
 type testPiholeClientV6 struct {
@@ -110,8 +116,10 @@ func TestErrorHandling(t *testing.T) {
 func TestNewPiholeProviderV6(t *testing.T) {
 	// Test invalid configuration
 	_, err := NewPiholeProvider(PiholeConfig{APIVersion: "7"})
-	if err == nil {
-		t.Error("Expected error from invalid configuration")
+	if !errors.Is(err, provider.ErrUnsupported) {
+		t.Error("Expected unsupported API version error from invalid configuration, got:", err)
+	} else if err.Error() != `unsupported Pi-hole API version "7", must be one of 5, 6` {
+		t.Error("Unexpected error message:", err)
 	}
 	// Test valid configuration
 	_, err = NewPiholeProvider(PiholeConfig{Server: "test.example.com", APIVersion: "6"})
@@ -120,6 +128,87 @@ func TestNewPiholeProviderV6(t *testing.T) {
 	}
 }
 
+func TestNewPiholeProviderOwnershipMode(t *testing.T) {
+	for _, tt := range []struct {
+		apiVersion  string
+		mode        string
+		expected    string
+		expectError string
+	}{
+		{apiVersion: "6", expected: OwnershipModeNone},
+		{apiVersion: "6", mode: OwnershipModeComment, expected: OwnershipModeComment},
+		{apiVersion: "6", mode: OwnershipModeTXT, expected: OwnershipModeTXT},
+		{apiVersion: "6", mode: OwnershipModeNone, expected: OwnershipModeNone},
+		{apiVersion: "5", expected: OwnershipModeNone},
+		{apiVersion: "5", mode: OwnershipModeNone, expected: OwnershipModeNone},
+		{apiVersion: "5", mode: OwnershipModeComment, expectError: `ownership mode "comment" is not supported by the Pi-hole API version 5, only "none" is`},
+		{apiVersion: "6", mode: "hosts", expectError: `invalid ownership mode "hosts", must be one of "none", "txt" or "comment"`},
+	} {
+		t.Run(tt.apiVersion+"/"+tt.mode, func(t *testing.T) {
+			p, err := NewPiholeProvider(PiholeConfig{Server: "test.example.com", APIVersion: tt.apiVersion, OwnershipMode: tt.mode})
+			if tt.expectError != "" {
+				if err == nil || err.Error() != tt.expectError {
+					t.Fatalf("Expected error %q, got %v", tt.expectError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if p.ownershipMode != tt.expected {
+				t.Errorf("Unexpected ownership mode %q, want %q", p.ownershipMode, tt.expected)
+			}
+		})
+	}
+}
+
+func TestProviderOwnershipRecordTypesV6(t *testing.T) {
+	requests := requestTrackerV6{}
+	p, err := NewPiholeProvider(PiholeConfig{Server: "test.example.com", APIVersion: "6", ManagedRecordTypes: []string{endpoint.RecordTypeA}, OwnershipMode: OwnershipModeComment})
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.api = &testPiholeClientV6{
+		endpoints: []*endpoint.Endpoint{
+			endpoint.NewEndpoint("test.example.com", endpoint.RecordTypeA, "192.168.1.1"),
+			endpoint.NewEndpoint("alias.example.com", endpoint.RecordTypeCNAME, "test.example.com"),
+			endpoint.NewEndpoint("a-test.example.com", endpoint.RecordTypeTXT, `"heritage=external-dns,external-dns/owner=default"`),
+		},
+		requests: &requests,
+	}
+
+	// TXT records are managed in addition to the managed record types
+	records, err := p.Records(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, ep := range records {
+		names = append(names, ep.DNSName)
+	}
+	if diff := cmp.Diff([]string{"test.example.com", "a-test.example.com"}, names); diff != "" {
+		t.Errorf("Unexpected records (-want +got):\n%s", diff)
+	}
+
+	txt := endpoint.NewEndpoint("a-new.example.com", endpoint.RecordTypeTXT, `"heritage=external-dns,external-dns/owner=default"`)
+	if err := p.ApplyChanges(context.Background(), &plan.Changes{Create: []*endpoint.Endpoint{txt}}); err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([]*endpoint.Endpoint{txt}, requests.createRequests); diff != "" {
+		t.Errorf("Unexpected creates (-want +got):\n%s", diff)
+	}
+
+	// Without ownership TXT records are left alone
+	p.ownershipMode = OwnershipModeNone
+	requests.clear()
+	if err := p.ApplyChanges(context.Background(), &plan.Changes{Create: []*endpoint.Endpoint{txt}}); err != nil {
+		t.Fatal(err)
+	}
+	if len(requests.createRequests) != 0 {
+		t.Errorf("Unexpected creates %v", requests.createRequests)
+	}
+}
+
 func TestProviderV6(t *testing.T) {
 	requests := requestTrackerV6{}
 	p := &PiholeProvider{
@@ -440,3 +529,599 @@ func TestProviderV6(t *testing.T) {
 
 	requests.clear()
 }
+
+func TestProviderV6CanonicalizesNames(t *testing.T) {
+	requests := requestTrackerV6{}
+	p := &PiholeProvider{
+		api:        &testPiholeClientV6{endpoints: make([]*endpoint.Endpoint, 0), requests: &requests},
+		apiVersion: "6",
+	}
+
+	changes := &plan.Changes{
+		Create: []*endpoint.Endpoint{
+			{DNSName: "foo.com", RecordType: endpoint.RecordTypeA, Targets: endpoint.Targets{"192.168.1.1"}},
+			{DNSName: "foo.com.", RecordType: endpoint.RecordTypeA, Targets: endpoint.Targets{"192.168.1.1"}},
+		},
+	}
+	if err := p.ApplyChanges(context.Background(), changes); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []*endpoint.Endpoint{
+		{DNSName: "foo.com", RecordType: endpoint.RecordTypeA, Targets: endpoint.Targets{"192.168.1.1"}},
+	}
+	if diff := cmp.Diff(expected, requests.createRequests); diff != "" {
+		t.Errorf("Unexpected create requests (-want +got):\n%s", diff)
+	}
+}
+
+func TestProviderV6LowercasesNames(t *testing.T) {
+	requests := requestTrackerV6{}
+	p := &PiholeProvider{
+		api:        &testPiholeClientV6{endpoints: make([]*endpoint.Endpoint, 0), requests: &requests},
+		apiVersion: "6",
+	}
+
+	desired := []*endpoint.Endpoint{
+		endpoint.NewEndpoint("Foo.Example.com", endpoint.RecordTypeA, "192.168.1.1"),
+		endpoint.NewEndpoint("WWW.example.com", endpoint.RecordTypeCNAME, "foo.example.com"),
+	}
+	reconcile := func() {
+		t.Helper()
+		requests.clear()
+		current, err := p.Records(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		changes := (&plan.Plan{
+			Current:        current,
+			Desired:        desired,
+			ManagedRecords: []string{endpoint.RecordTypeA, endpoint.RecordTypeCNAME},
+		}).Calculate().Changes
+		if err := p.ApplyChanges(context.Background(), changes); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	reconcile()
+	var created []string
+	for _, ep := range requests.createRequests {
+		created = append(created, ep.DNSName)
+	}
+	slices.Sort(created)
+	if diff := cmp.Diff([]string{"foo.example.com", "www.example.com"}, created); diff != "" {
+		t.Errorf("Unexpected create requests (-want +got):\n%s", diff)
+	}
+	if desired[0].DNSName != "Foo.Example.com" {
+		t.Errorf("Expected desired endpoints to be left untouched, got %q", desired[0].DNSName)
+	}
+
+	for range 2 {
+		reconcile()
+		if len(requests.createRequests) != 0 || len(requests.deleteRequests) != 0 {
+			t.Errorf("Expected no changes on later reconciles, got creates %v and deletes %v", requests.createRequests, requests.deleteRequests)
+		}
+	}
+
+	records, err := p.Records(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, ep := range records {
+		if ep.DNSName != strings.ToLower(ep.DNSName) {
+			t.Errorf("Expected lowercase record names, got %q", ep.DNSName)
+		}
+	}
+}
+
+func TestProviderV6MergeLeavesChangesUntouched(t *testing.T) {
+	requests := requestTrackerV6{}
+	p := &PiholeProvider{
+		api: &testPiholeClientV6{
+			endpoints: []*endpoint.Endpoint{endpoint.NewEndpoint("test.example.com", endpoint.RecordTypeA, "192.168.1.1")},
+			requests:  &requests,
+		},
+		apiVersion: "6",
+	}
+
+	// Endpoints with distinct set identifiers are only merged by the provider.
+	first := endpoint.NewEndpoint("test.example.com", endpoint.RecordTypeA, "10.0.0.2").WithSetIdentifier("first")
+	// spare capacity, which appending the merged targets in place would write into
+	first.Targets = slices.Grow(first.Targets, 1)
+	second := endpoint.NewEndpoint("test.example.com", endpoint.RecordTypeA, "10.0.0.1").WithSetIdentifier("second")
+	if err := p.ApplyChanges(context.Background(), &plan.Changes{
+		UpdateOld: []*endpoint.Endpoint{endpoint.NewEndpoint("test.example.com", endpoint.RecordTypeA, "192.168.1.1")},
+		UpdateNew: []*endpoint.Endpoint{first, second},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(requests.createRequests) != 1 {
+		t.Fatal("Expected 1 create request, got:", requests.createRequests)
+	}
+	if diff := cmp.Diff(endpoint.Targets{"10.0.0.1", "10.0.0.2"}, requests.createRequests[0].Targets); diff != "" {
+		t.Errorf("Unexpected merged targets (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff(endpoint.Targets{"10.0.0.2"}, first.Targets); diff != "" {
+		t.Errorf("Expected the changes to be left untouched (-want +got):\n%s", diff)
+	}
+	if spare := first.Targets[:2][1]; spare != "" {
+		t.Errorf("Expected the spare capacity of the changes to be left untouched, got %q", spare)
+	}
+}
+
+// softErrorPiholeClientV6 rejects wildcard records with a soft error, like the V6 client does.
+type softErrorPiholeClientV6 struct {
+	*testPiholeClientV6
+}
+
+func (t softErrorPiholeClientV6) createRecord(ctx context.Context, ep *endpoint.Endpoint) error {
+	if strings.Contains(ep.DNSName, "*") {
+		return provider.NewSoftErrorf("UNSUPPORTED: %s", ep.DNSName)
+	}
+	return t.testPiholeClientV6.createRecord(ctx, ep)
+}
+
+func TestProviderV6SoftErrorsDoNotAbortChanges(t *testing.T) {
+	requests := requestTrackerV6{}
+	p := &PiholeProvider{
+		api:        softErrorPiholeClientV6{&testPiholeClientV6{endpoints: make([]*endpoint.Endpoint, 0), requests: &requests}},
+		apiVersion: "6",
+	}
+
+	err := p.ApplyChanges(context.Background(), &plan.Changes{
+		Create: []*endpoint.Endpoint{
+			endpoint.NewEndpoint("a.example.com", endpoint.RecordTypeA, "192.168.1.1"),
+			endpoint.NewEndpoint("*.example.com", endpoint.RecordTypeA, "192.168.1.2"),
+			endpoint.NewEndpoint("b.example.com", endpoint.RecordTypeA, "192.168.1.3"),
+			endpoint.NewEndpoint("*.other.example.com", endpoint.RecordTypeA, "192.168.1.4"),
+		},
+	})
+	if !errors.Is(err, provider.SoftError) {
+		t.Fatalf("Expected a soft error, got: %v", err)
+	}
+	for _, name := range []string{"*.example.com", "*.other.example.com"} {
+		if !strings.Contains(err.Error(), name) {
+			t.Errorf("Expected the error to mention %s, got: %v", name, err)
+		}
+	}
+
+	var created []string
+	for _, ep := range requests.createRequests {
+		created = append(created, ep.DNSName)
+	}
+	if diff := cmp.Diff([]string{"a.example.com", "b.example.com"}, created); diff != "" {
+		t.Errorf("Unexpected create requests (-want +got):\n%s", diff)
+	}
+}
+
+func TestProviderV6PreserveNameCase(t *testing.T) {
+	requests := requestTrackerV6{}
+	p := &PiholeProvider{
+		api:              &testPiholeClientV6{endpoints: make([]*endpoint.Endpoint, 0), requests: &requests},
+		apiVersion:       "6",
+		preserveNameCase: true,
+	}
+
+	if err := p.ApplyChanges(context.Background(), &plan.Changes{
+		Create: []*endpoint.Endpoint{endpoint.NewEndpoint("Foo.Example.com", endpoint.RecordTypeA, "192.168.1.1")},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	records, err := p.Records(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 1 || records[0].DNSName != "Foo.Example.com" {
+		t.Errorf("Expected the record name to keep its case, got %v", records)
+	}
+}
+
+type testBatchPiholeClientV6 struct {
+	*testPiholeClientV6
+	batchErr error
+	batches  [][2][]*endpoint.Endpoint
+}
+
+func (t *testBatchPiholeClientV6) applyBatch(_ context.Context, deletes, creates []*endpoint.Endpoint) error {
+	t.batches = append(t.batches, [2][]*endpoint.Endpoint{deletes, creates})
+	return t.batchErr
+}
+
+func TestProviderV6BatchWrites(t *testing.T) {
+	changes := &plan.Changes{
+		Create: []*endpoint.Endpoint{
+			endpoint.NewEndpoint("test1.example.com", endpoint.RecordTypeA, "192.168.1.1"),
+		},
+		UpdateOld: []*endpoint.Endpoint{
+			endpoint.NewEndpoint("test2.example.com", endpoint.RecordTypeA, "192.168.1.2"),
+		},
+		UpdateNew: []*endpoint.Endpoint{
+			endpoint.NewEndpoint("test2.example.com", endpoint.RecordTypeA, "192.168.1.3"),
+		},
+		Delete: []*endpoint.Endpoint{
+			endpoint.NewEndpoint("test3.example.com", endpoint.RecordTypeCNAME, "target.example.com"),
+		},
+	}
+
+	for _, tt := range []struct {
+		name            string
+		batchWrites     bool
+		batchErr        error
+		expectedBatches int
+		expectedCreates int
+		expectedDeletes int
+		expectErr       bool
+	}{
+		{
+			name:            "batching disabled uses per-record writes",
+			batchWrites:     false,
+			expectedBatches: 0,
+			expectedCreates: 2,
+			expectedDeletes: 2,
+		},
+		{
+			name:            "batching enabled writes everything at once",
+			batchWrites:     true,
+			expectedBatches: 1,
+		},
+		{
+			name:            "batching unsupported falls back to per-record writes",
+			batchWrites:     true,
+			batchErr:        errBatchUnsupported,
+			expectedBatches: 1,
+			expectedCreates: 2,
+			expectedDeletes: 2,
+		},
+		{
+			name:            "batching errors are returned",
+			batchWrites:     true,
+			batchErr:        errors.New("boom"),
+			expectedBatches: 1,
+			expectErr:       true,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			requests := requestTrackerV6{}
+			api := &testBatchPiholeClientV6{
+				testPiholeClientV6: &testPiholeClientV6{endpoints: make([]*endpoint.Endpoint, 0), requests: &requests},
+				batchErr:           tt.batchErr,
+			}
+			p := &PiholeProvider{api: api, apiVersion: "6", batchWrites: tt.batchWrites}
+
+			err := p.ApplyChanges(context.Background(), changes)
+			if tt.expectErr != (err != nil) {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if len(api.batches) != tt.expectedBatches {
+				t.Fatalf("Expected %d batches, got %d", tt.expectedBatches, len(api.batches))
+			}
+			if tt.expectedBatches > 0 {
+				deletes, creates := api.batches[0][0], api.batches[0][1]
+				if len(deletes) != 2 || len(creates) != 2 {
+					t.Errorf("Expected 2 deletes and 2 creates in batch, got %v and %v", deletes, creates)
+				}
+			}
+			if len(requests.createRequests) != tt.expectedCreates {
+				t.Errorf("Expected %d create requests, got %v", tt.expectedCreates, requests.createRequests)
+			}
+			if len(requests.deleteRequests) != tt.expectedDeletes {
+				t.Errorf("Expected %d delete requests, got %v", tt.expectedDeletes, requests.deleteRequests)
+			}
+		})
+	}
+}
+
+func TestProviderV6CNAMETargetConflict(t *testing.T) {
+	for _, tt := range []struct {
+		name            string
+		behavior        string
+		ownershipMode   string
+		existing        []*endpoint.Endpoint
+		create          []*endpoint.Endpoint
+		delete          []*endpoint.Endpoint
+		expectedCreates int
+		expectSoftError bool
+	}{
+		{
+			name:     "warn creates the CNAME",
+			behavior: CNAMETargetConflictWarn,
+			create: []*endpoint.Endpoint{
+				endpoint.NewEndpoint("bar.example.com", endpoint.RecordTypeA, "192.168.1.1"),
+				endpoint.NewEndpoint("foo.example.com", endpoint.RecordTypeCNAME, "bar.example.com"),
+			},
+			expectedCreates: 2,
+		},
+		{
+			name:     "reject skips the CNAME",
+			behavior: CNAMETargetConflictReject,
+			create: []*endpoint.Endpoint{
+				endpoint.NewEndpoint("bar.example.com", endpoint.RecordTypeA, "192.168.1.1"),
+				endpoint.NewEndpoint("foo.example.com", endpoint.RecordTypeCNAME, "bar.example.com"),
+			},
+			expectedCreates: 1,
+			expectSoftError: true,
+		},
+		{
+			name:     "ignore does not check CNAME targets",
+			behavior: CNAMETargetConflictIgnore,
+			create: []*endpoint.Endpoint{
+				endpoint.NewEndpoint("bar.example.com", endpoint.RecordTypeA, "192.168.1.1"),
+				endpoint.NewEndpoint("foo.example.com", endpoint.RecordTypeCNAME, "bar.example.com"),
+			},
+			expectedCreates: 2,
+		},
+		{
+			name:     "reject detects A records already in Pi-hole",
+			behavior: CNAMETargetConflictReject,
+			existing: []*endpoint.Endpoint{
+				endpoint.NewEndpoint("bar.example.com", endpoint.RecordTypeA, "192.168.1.1"),
+				endpoint.NewEndpoint("a-bar.example.com", endpoint.RecordTypeTXT, `"heritage=external-dns,external-dns/owner=default"`),
+			},
+			create: []*endpoint.Endpoint{
+				endpoint.NewEndpoint("foo.example.com", endpoint.RecordTypeCNAME, "bar.example.com"),
+			},
+			expectedCreates: 0,
+			expectSoftError: true,
+		},
+		{
+			name:          "reject detects A records in Pi-hole without stored ownership",
+			behavior:      CNAMETargetConflictReject,
+			ownershipMode: OwnershipModeNone,
+			existing: []*endpoint.Endpoint{
+				endpoint.NewEndpoint("bar.example.com", endpoint.RecordTypeA, "192.168.1.1"),
+			},
+			create: []*endpoint.Endpoint{
+				endpoint.NewEndpoint("foo.example.com", endpoint.RecordTypeCNAME, "bar.example.com"),
+				endpoint.NewEndpoint("qux.example.com", endpoint.RecordTypeCNAME, "external.example.org"),
+			},
+			expectedCreates: 1,
+			expectSoftError: true,
+		},
+		{
+			name:     "reject ignores A records being deleted",
+			behavior: CNAMETargetConflictReject,
+			existing: []*endpoint.Endpoint{
+				endpoint.NewEndpoint("bar.example.com", endpoint.RecordTypeA, "192.168.1.1"),
+				endpoint.NewEndpoint("a-bar.example.com", endpoint.RecordTypeTXT, `"heritage=external-dns,external-dns/owner=default"`),
+			},
+			create: []*endpoint.Endpoint{
+				endpoint.NewEndpoint("foo.example.com", endpoint.RecordTypeCNAME, "bar.example.com"),
+			},
+			delete: []*endpoint.Endpoint{
+				endpoint.NewEndpoint("bar.example.com", endpoint.RecordTypeA, "192.168.1.1"),
+			},
+			expectedCreates: 1,
+		},
+		{
+			name:     "reject allows CNAMEs to unmanaged names",
+			behavior: CNAMETargetConflictReject,
+			create: []*endpoint.Endpoint{
+				endpoint.NewEndpoint("bar.example.com", endpoint.RecordTypeA, "192.168.1.1"),
+				endpoint.NewEndpoint("foo.example.com", endpoint.RecordTypeCNAME, "external.example.org"),
+			},
+			expectedCreates: 2,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			ownershipMode := OwnershipModeComment
+			if tt.ownershipMode != "" {
+				ownershipMode = tt.ownershipMode
+			}
+			requests := requestTrackerV6{}
+			p := &PiholeProvider{
+				api:                 &testPiholeClientV6{endpoints: tt.existing, requests: &requests},
+				apiVersion:          "6",
+				cnameTargetConflict: tt.behavior,
+				ownershipMode:       ownershipMode,
+			}
+
+			err := p.ApplyChanges(context.Background(), &plan.Changes{Create: tt.create, Delete: tt.delete})
+			if tt.expectSoftError {
+				if !errors.Is(err, provider.SoftError) {
+					t.Fatalf("Expected soft error, got %v", err)
+				}
+			} else if err != nil {
+				t.Fatal(err)
+			}
+			if len(requests.createRequests) != tt.expectedCreates {
+				t.Errorf("Expected %d create requests, got %v", tt.expectedCreates, requests.createRequests)
+			}
+		})
+	}
+}
+
+func TestProviderV6OrderCreates(t *testing.T) {
+	for _, tt := range []struct {
+		name     string
+		order    bool
+		create   []*endpoint.Endpoint
+		expected []string
+	}{
+		{
+			name:  "CNAME is created after its A target",
+			order: true,
+			create: []*endpoint.Endpoint{
+				endpoint.NewEndpoint("foo.example.com", endpoint.RecordTypeCNAME, "bar.example.com"),
+				endpoint.NewEndpoint("bar.example.com", endpoint.RecordTypeA, "192.168.1.1"),
+			},
+			expected: []string{"bar.example.com", "foo.example.com"},
+		},
+		{
+			name:  "CNAME chain is created from its end",
+			order: true,
+			create: []*endpoint.Endpoint{
+				endpoint.NewEndpoint("foo.example.com", endpoint.RecordTypeCNAME, "bar.example.com"),
+				endpoint.NewEndpoint("bar.example.com", endpoint.RecordTypeCNAME, "baz.example.com."),
+				endpoint.NewEndpoint("baz.example.com", endpoint.RecordTypeAAAA, "fc00::1"),
+				endpoint.NewEndpoint("qux.example.com", endpoint.RecordTypeA, "192.168.1.2"),
+			},
+			expected: []string{"baz.example.com", "bar.example.com", "foo.example.com", "qux.example.com"},
+		},
+		{
+			name:  "CNAME cycle is broken at its first record",
+			order: true,
+			create: []*endpoint.Endpoint{
+				endpoint.NewEndpoint("foo.example.com", endpoint.RecordTypeCNAME, "bar.example.com"),
+				endpoint.NewEndpoint("bar.example.com", endpoint.RecordTypeCNAME, "foo.example.com"),
+			},
+			expected: []string{"bar.example.com", "foo.example.com"},
+		},
+		{
+			name: "order is unchanged when disabled",
+			create: []*endpoint.Endpoint{
+				endpoint.NewEndpoint("foo.example.com", endpoint.RecordTypeCNAME, "bar.example.com"),
+				endpoint.NewEndpoint("bar.example.com", endpoint.RecordTypeA, "192.168.1.1"),
+			},
+			expected: []string{"foo.example.com", "bar.example.com"},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			requests := requestTrackerV6{}
+			p := &PiholeProvider{
+				api:                 &testPiholeClientV6{requests: &requests},
+				apiVersion:          "6",
+				cnameTargetConflict: CNAMETargetConflictWarn,
+				orderCreates:        tt.order,
+			}
+
+			if err := p.ApplyChanges(context.Background(), &plan.Changes{Create: tt.create}); err != nil {
+				t.Fatal(err)
+			}
+			var created []string
+			for _, ep := range requests.createRequests {
+				created = append(created, ep.DNSName)
+			}
+			if !reflect.DeepEqual(created, tt.expected) {
+				t.Errorf("Expected creates in order %v, got %v", tt.expected, created)
+			}
+		})
+	}
+}
+
+func TestProviderV6CNAMETargetConflictRejectedUpdate(t *testing.T) {
+	requests := requestTrackerV6{}
+	old := endpoint.NewEndpoint("foo.example.com", endpoint.RecordTypeCNAME, "external.example.org")
+	p := &PiholeProvider{
+		api: &testPiholeClientV6{
+			endpoints: []*endpoint.Endpoint{
+				endpoint.NewEndpoint("bar.example.com", endpoint.RecordTypeA, "192.168.1.1"),
+				endpoint.NewEndpoint("a-bar.example.com", endpoint.RecordTypeTXT, `"heritage=external-dns,external-dns/owner=default"`),
+				old,
+			},
+			requests: &requests,
+		},
+		apiVersion:          "6",
+		cnameTargetConflict: CNAMETargetConflictReject,
+		ownershipMode:       OwnershipModeComment,
+	}
+
+	err := p.ApplyChanges(context.Background(), &plan.Changes{
+		UpdateOld: []*endpoint.Endpoint{old},
+		UpdateNew: []*endpoint.Endpoint{endpoint.NewEndpoint("foo.example.com", endpoint.RecordTypeCNAME, "bar.example.com")},
+	})
+	if !errors.Is(err, provider.SoftError) {
+		t.Fatalf("Expected soft error, got %v", err)
+	}
+	// The record the rejected update was meant to replace is kept.
+	if len(requests.createRequests) != 0 || len(requests.deleteRequests) != 0 {
+		t.Errorf("Expected no changes, got creates %v and deletes %v", requests.createRequests, requests.deleteRequests)
+	}
+}
+
+func TestNewPiholeProviderCNAMETargetConflict(t *testing.T) {
+	p, err := NewPiholeProvider(PiholeConfig{Server: "test.example.com", APIVersion: "6"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.cnameTargetConflict != CNAMETargetConflictIgnore {
+		t.Errorf("Expected default behavior %q, got %q", CNAMETargetConflictIgnore, p.cnameTargetConflict)
+	}
+
+	if _, err := NewPiholeProvider(PiholeConfig{Server: "test.example.com", APIVersion: "6", CNAMETargetConflict: "fail"}); err == nil {
+		t.Error("Expected error for invalid CNAME target conflict behavior")
+	}
+}
+
+func TestProviderV6ExportZoneFile(t *testing.T) {
+	p := &PiholeProvider{
+		api: &testPiholeClientV6{
+			endpoints: []*endpoint.Endpoint{
+				endpoint.NewEndpoint("www.example.com", endpoint.RecordTypeCNAME, "web.example.com"),
+				endpoint.NewEndpointWithTTL("web.example.com", endpoint.RecordTypeA, 300, "192.168.1.1", "192.168.1.2"),
+				endpoint.NewEndpoint("web.example.com", endpoint.RecordTypeAAAA, "fc00::1"),
+			},
+			requests: &requestTrackerV6{},
+		},
+		apiVersion: "6",
+	}
+
+	zone, err := p.ExportZoneFile(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "; Pi-hole local DNS records\n" +
+		"web.example.com.\t300\tIN\tA\t192.168.1.1\n" +
+		"web.example.com.\t300\tIN\tA\t192.168.1.2\n" +
+		"web.example.com.\tIN\tAAAA\tfc00::1\n" +
+		"www.example.com.\tIN\tCNAME\tweb.example.com.\n"
+	if diff := cmp.Diff(expected, zone); diff != "" {
+		t.Errorf("Unexpected zone file (-want +got):\n%s", diff)
+	}
+
+	p.api.(*testPiholeClientV6).trigger = "AERROR"
+	if _, err := p.ExportZoneFile(context.Background()); err == nil {
+		t.Error("Expected an error when records cannot be listed")
+	}
+}
+
+func TestSummarizeChanges(t *testing.T) {
+	summary := summarizeChanges(
+		[]*endpoint.Endpoint{
+			endpoint.NewEndpoint("test1.example.com", endpoint.RecordTypeA, "192.168.1.1"),
+			endpoint.NewEndpoint("test2.example.com", endpoint.RecordTypeA, "192.168.1.2"),
+			endpoint.NewEndpoint("test1.example.com", endpoint.RecordTypeCNAME, "target.example.com"),
+		},
+		[]*endpoint.Endpoint{
+			endpoint.NewEndpoint("test3.example.com", endpoint.RecordTypeAAAA, "fc00::1"),
+		},
+		[]*endpoint.Endpoint{
+			endpoint.NewEndpoint("test4.example.com", endpoint.RecordTypeA, "192.168.1.4"),
+		},
+	)
+
+	expected := changeSummary{
+		endpoint.RecordTypeA:     {Creates: 2, Deletes: 1},
+		endpoint.RecordTypeAAAA:  {Updates: 1},
+		endpoint.RecordTypeCNAME: {Creates: 1},
+	}
+	if diff := cmp.Diff(expected, summary); diff != "" {
+		t.Errorf("Unexpected summary (-want +got):\n%s", diff)
+	}
+}
+
+func TestProviderV6DryRunSummary(t *testing.T) {
+	hook := testutils.LogsUnderTestWithLogLevel(log.InfoLevel, t)
+
+	requests := requestTrackerV6{}
+	p := &PiholeProvider{
+		api:        &testPiholeClientV6{endpoints: make([]*endpoint.Endpoint, 0), requests: &requests},
+		apiVersion: "6",
+		dryRun:     true,
+	}
+	if err := p.ApplyChanges(context.Background(), &plan.Changes{
+		Create: []*endpoint.Endpoint{
+			endpoint.NewEndpoint("test1.example.com", endpoint.RecordTypeA, "192.168.1.1"),
+		},
+		UpdateOld: []*endpoint.Endpoint{
+			endpoint.NewEndpoint("test2.example.com", endpoint.RecordTypeA, "192.168.1.2"),
+		},
+		UpdateNew: []*endpoint.Endpoint{
+			endpoint.NewEndpoint("test2.example.com", endpoint.RecordTypeA, "192.168.1.3"),
+		},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	testutils.TestHelperLogContains("DRY RUN: A records: 1 to create, 1 to update, 0 to delete", hook, t)
+}
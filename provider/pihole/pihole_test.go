@@ -18,8 +18,12 @@ package pihole
 
 import (
 	"context"
+	"errors"
 	"reflect"
 	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
 
 	"sigs.k8s.io/external-dns/endpoint"
 	"sigs.k8s.io/external-dns/plan"
@@ -395,3 +399,184 @@ func TestProvider(t *testing.T) {
 
 	requests.clear()
 }
+
+func TestProviderFeatures(t *testing.T) {
+	v5 := &PiholeProvider{api: &testPiholeClient{}, apiVersion: "5"}
+	v6 := &PiholeProvider{api: &testPiholeClient{}, apiVersion: "6"}
+	unset := &PiholeProvider{api: &testPiholeClient{}}
+
+	if got, want := v5.Features(), (PiholeFeatures{}); got != want {
+		t.Errorf("unexpected V5 features: got %+v, want %+v", got, want)
+	}
+	if got, want := unset.Features(), v5.Features(); got != want {
+		t.Errorf("unset API version should default to V5 features: got %+v, want %+v", got, want)
+	}
+	if got, want := v6.Features(), (PiholeFeatures{CNAMETTL: true, MultipleTargets: true, BatchWrites: true, Ownership: true}); got != want {
+		t.Errorf("unexpected V6 features: got %+v, want %+v", got, want)
+	}
+	if v5.Features() == v6.Features() {
+		t.Error("expected V5 and V6 features to differ")
+	}
+}
+
+func TestProviderHealthcheckUnsupported(t *testing.T) {
+	p := &PiholeProvider{api: &testPiholeClient{endpoints: make([]*endpoint.Endpoint, 0), requests: &requestTracker{}}}
+	if err := p.Healthcheck(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestProviderManagedRecordTypes(t *testing.T) {
+	p, err := NewPiholeProvider(PiholeConfig{Server: "test.example.com", ManagedRecordTypes: []string{endpoint.RecordTypeA, endpoint.RecordTypeTXT}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([]string{endpoint.RecordTypeA}, p.managedRecordTypes); diff != "" {
+		t.Errorf("Unexpected managed record types (-want +got):\n%s", diff)
+	}
+
+	requests := requestTracker{}
+	p.api = &testPiholeClient{
+		endpoints: []*endpoint.Endpoint{
+			endpoint.NewEndpoint("a.example.com", endpoint.RecordTypeA, "192.168.1.1"),
+			endpoint.NewEndpoint("a.example.com", endpoint.RecordTypeAAAA, "fc00::1"),
+			endpoint.NewEndpoint("cname.example.com", endpoint.RecordTypeCNAME, "a.example.com"),
+		},
+		requests: &requests,
+	}
+
+	records, err := p.Records(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([]*endpoint.Endpoint{endpoint.NewEndpoint("a.example.com", endpoint.RecordTypeA, "192.168.1.1")}, records); diff != "" {
+		t.Errorf("Unexpected records (-want +got):\n%s", diff)
+	}
+
+	if err := p.ApplyChanges(context.Background(), &plan.Changes{
+		Create: []*endpoint.Endpoint{
+			endpoint.NewEndpoint("b.example.com", endpoint.RecordTypeA, "192.168.1.2"),
+			endpoint.NewEndpoint("other.example.com", endpoint.RecordTypeCNAME, "b.example.com"),
+		},
+		Delete: []*endpoint.Endpoint{
+			endpoint.NewEndpoint("cname.example.com", endpoint.RecordTypeCNAME, "a.example.com"),
+			endpoint.NewEndpoint("a.example.com", endpoint.RecordTypeAAAA, "fc00::1"),
+		},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([]*endpoint.Endpoint{endpoint.NewEndpoint("b.example.com", endpoint.RecordTypeA, "192.168.1.2")}, requests.createRequests); diff != "" {
+		t.Errorf("Unexpected create requests (-want +got):\n%s", diff)
+	}
+	if len(requests.deleteRequests) != 0 {
+		t.Error("Expected no delete requests for unmanaged record types, got:", requests.deleteRequests)
+	}
+
+	// All the record types Pi-hole supports are managed by default
+	p, err = NewPiholeProvider(PiholeConfig{Server: "test.example.com"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(piholeRecordTypes, p.recordTypes()); diff != "" {
+		t.Errorf("Unexpected managed record types (-want +got):\n%s", diff)
+	}
+}
+
+// countingPiholeClient counts the record listings made to a testPiholeClient.
+type countingPiholeClient struct {
+	*testPiholeClient
+	listCalls int
+}
+
+func (c *countingPiholeClient) listRecords(ctx context.Context, rtype string) ([]*endpoint.Endpoint, error) {
+	c.listCalls++
+	return c.testPiholeClient.listRecords(ctx, rtype)
+}
+
+func TestProviderRecordsCache(t *testing.T) {
+	p, err := NewPiholeProvider(PiholeConfig{Server: "test.example.com", RecordsCacheTTL: time.Minute})
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	p.recordsCache.now = func() time.Time { return now }
+	api := &countingPiholeClient{testPiholeClient: &testPiholeClient{
+		endpoints: []*endpoint.Endpoint{endpoint.NewEndpoint("a.example.com", endpoint.RecordTypeA, "192.168.1.1")},
+		requests:  &requestTracker{},
+	}}
+	p.api = api
+
+	expectRecords := func(listCalls int, expected ...*endpoint.Endpoint) {
+		t.Helper()
+		records, err := p.Records(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if diff := cmp.Diff(expected, records); diff != "" {
+			t.Errorf("Unexpected records (-want +got):\n%s", diff)
+		}
+		if api.listCalls != listCalls {
+			t.Errorf("Expected %d list calls, got %d", listCalls, api.listCalls)
+		}
+	}
+	a := endpoint.NewEndpoint("a.example.com", endpoint.RecordTypeA, "192.168.1.1")
+	b := endpoint.NewEndpoint("b.example.com", endpoint.RecordTypeA, "192.168.1.2")
+
+	// The first call after startup lists the records, the next ones are served from the cache
+	expectRecords(3, a)
+	expectRecords(3, a)
+
+	// Modifying the returned records does not modify the cache
+	records, err := p.Records(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	records[0].Targets[0] = "10.0.0.1"
+	expectRecords(3, a)
+
+	// Records are listed again once the cache expired
+	now = now.Add(time.Minute)
+	expectRecords(6, a)
+
+	// Applying changes invalidates the cache
+	if err := p.ApplyChanges(context.Background(), &plan.Changes{Create: []*endpoint.Endpoint{b}}); err != nil {
+		t.Fatal(err)
+	}
+	expectRecords(9, a, b)
+	expectRecords(9, a, b)
+
+	// A cancelled context stops the listing, and the failure is not cached
+	now = now.Add(time.Minute)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := p.Records(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected a context canceled error, got %v", err)
+	}
+	if api.listCalls != 9 {
+		t.Errorf("Expected no list calls with a cancelled context, got %d", api.listCalls-9)
+	}
+	expectRecords(12, a, b)
+
+	// The cache is disabled by default
+	p, err = NewPiholeProvider(PiholeConfig{Server: "test.example.com"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	api.listCalls = 0
+	p.api = api
+	expectRecords(3, a, b)
+	expectRecords(6, a, b)
+}
+
+func TestRecordsCacheInvalidatedWhileListing(t *testing.T) {
+	cache := &recordsCache{ttl: time.Minute, now: time.Now}
+	_, generation, ok := cache.get()
+	if ok {
+		t.Fatal("Expected an empty cache")
+	}
+	cache.invalidate()
+	cache.set([]*endpoint.Endpoint{endpoint.NewEndpoint("a.example.com", endpoint.RecordTypeA, "192.168.1.1")}, generation)
+	if _, _, ok := cache.get(); ok {
+		t.Error("Expected records listed before an invalidation not to be cached")
+	}
+}
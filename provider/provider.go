@@ -42,6 +42,27 @@ func NewSoftError(err error) error {
 	return errors.Join(SoftError, err)
 }
 
+// SoftErrors accumulates the soft errors met while applying a plan, so that a provider can carry
+// on with the remaining changes and report all the skipped ones at the end. The zero value is ready to use.
+type SoftErrors struct {
+	errs []error
+}
+
+// Add records err if it is a soft error and returns nil, so that the caller can go on.
+// Any other error is returned as-is for the caller to abort. A nil err is ignored.
+func (s *SoftErrors) Add(err error) error {
+	if err == nil || !errors.Is(err, SoftError) {
+		return err
+	}
+	s.errs = append(s.errs, err)
+	return nil
+}
+
+// Err returns a soft error combining all the recorded ones, or nil if there are none.
+func (s *SoftErrors) Err() error {
+	return errors.Join(s.errs...)
+}
+
 // Provider defines the interface DNS providers should implement.
 type Provider interface {
 	Records(ctx context.Context) ([]*endpoint.Endpoint, error)
@@ -57,6 +78,21 @@ type Provider interface {
 	GetDomainFilter() endpoint.DomainFilterInterface
 }
 
+// Healthchecker is implemented by providers able to check that they can talk to their DNS service,
+// with a request cheap enough to be made by a health endpoint.
+type Healthchecker interface {
+	Healthcheck(ctx context.Context) error
+}
+
+// Healthcheck checks the connectivity of p to its DNS service if it is a Healthchecker.
+// Other providers are always reported healthy.
+func Healthcheck(ctx context.Context, p Provider) error {
+	if checker, ok := p.(Healthchecker); ok {
+		return checker.Healthcheck(ctx)
+	}
+	return nil
+}
+
 type BaseProvider struct{}
 
 func (b BaseProvider) AdjustEndpoints(endpoints []*endpoint.Endpoint) ([]*endpoint.Endpoint, error) {
@@ -67,6 +103,11 @@ func (b BaseProvider) GetDomainFilter() endpoint.DomainFilterInterface {
 	return &endpoint.DomainFilter{}
 }
 
+// Healthcheck reports the provider healthy, providers able to check their connectivity override it.
+func (b BaseProvider) Healthcheck(_ context.Context) error {
+	return nil
+}
+
 type contextKey struct {
 	name string
 }
@@ -17,12 +17,19 @@ limitations under the License.
 package provider
 
 import (
+	"context"
+	"errors"
 	"io"
 	"os"
 	"testing"
+	"time"
 
 	log "github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"sigs.k8s.io/external-dns/endpoint"
+	"sigs.k8s.io/external-dns/plan"
 )
 
 func TestMain(m *testing.M) {
@@ -54,3 +61,48 @@ func TestDifference(t *testing.T) {
 	assert.Equal(t, []string{"foo"}, remove)
 	assert.Equal(t, []string{"bar"}, leave)
 }
+
+func TestSoftErrors(t *testing.T) {
+	var softErrs SoftErrors
+	require.NoError(t, softErrs.Err())
+
+	require.NoError(t, softErrs.Add(nil))
+	require.NoError(t, softErrs.Add(NewSoftErrorf("skipped %s", "wildcard.example.com")))
+	hardErr := errors.New("connection refused")
+	assert.Equal(t, hardErr, softErrs.Add(hardErr))
+	require.NoError(t, softErrs.Add(NewSoftError(errors.New("skipped cname.example.com"))))
+
+	err := softErrs.Err()
+	require.ErrorIs(t, err, SoftError)
+	assert.NotErrorIs(t, err, hardErr)
+	assert.ErrorContains(t, err, "skipped wildcard.example.com")
+	assert.ErrorContains(t, err, "skipped cname.example.com")
+}
+
+type healthcheckProvider struct {
+	BaseProvider
+	err error
+}
+
+func (p *healthcheckProvider) Records(_ context.Context) ([]*endpoint.Endpoint, error) {
+	return nil, nil
+}
+
+func (p *healthcheckProvider) ApplyChanges(_ context.Context, _ *plan.Changes) error {
+	return nil
+}
+
+func (p *healthcheckProvider) Healthcheck(_ context.Context) error {
+	return p.err
+}
+
+func TestHealthcheck(t *testing.T) {
+	ctx := context.Background()
+	unreachable := errors.New("connection refused")
+
+	require.NoError(t, Healthcheck(ctx, &testProviderFunc{}))
+	require.NoError(t, Healthcheck(ctx, &healthcheckProvider{}))
+	assert.Equal(t, unreachable, Healthcheck(ctx, &healthcheckProvider{err: unreachable}))
+	assert.Equal(t, unreachable, Healthcheck(ctx, NewCachedProvider(&healthcheckProvider{err: unreachable}, time.Minute)))
+	require.NoError(t, Healthcheck(ctx, NewCachedProvider(&testProviderFunc{}, time.Minute)))
+}
@@ -0,0 +1,81 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package provider
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// ErrUnsupported is returned by Registry.Get for keys that nothing was registered under.
+var ErrUnsupported = errors.New("unsupported")
+
+// Registry holds values, typically client constructors, registered under a key such as an API version,
+// so that providers look up the variant to use instead of switching on configuration strings.
+// It is safe for concurrent use.
+type Registry[T any] struct {
+	kind    string
+	lock    sync.RWMutex
+	entries map[string]T
+}
+
+// NewRegistry returns an empty Registry. The kind names what the keys stand for, e.g. "API version",
+// and shows in the errors about unknown keys.
+func NewRegistry[T any](kind string) *Registry[T] {
+	return &Registry[T]{kind: kind, entries: make(map[string]T)}
+}
+
+// Register adds value under the given key. It panics if the key is empty or already registered,
+// since that is a programming error best caught when the registering package is initialized.
+func (r *Registry[T]) Register(key string, value T) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if key == "" {
+		panic(fmt.Sprintf("cannot register an empty %s", r.kind))
+	}
+	if _, found := r.entries[key]; found {
+		panic(fmt.Sprintf("%s %q is already registered", r.kind, key))
+	}
+	r.entries[key] = value
+}
+
+// Get returns the value registered under the given key, or an error wrapping ErrUnsupported
+// and listing the registered keys if there is none.
+func (r *Registry[T]) Get(key string) (T, error) {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	value, found := r.entries[key]
+	if !found {
+		keys := slices.Sorted(maps.Keys(r.entries))
+		return value, fmt.Errorf("%w %s %q, must be one of %s", ErrUnsupported, r.kind, key, strings.Join(keys, ", "))
+	}
+	return value, nil
+}
+
+// Keys returns the registered keys in lexicographical order.
+func (r *Registry[T]) Keys() []string {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	return slices.Sorted(maps.Keys(r.entries))
+}
@@ -0,0 +1,49 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package provider
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegistry(t *testing.T) {
+	registry := NewRegistry[func() string]("API version")
+	registry.Register("6", func() string { return "v6" })
+	registry.Register("5", func() string { return "v5" })
+
+	newClient, err := registry.Get("6")
+	require.NoError(t, err)
+	assert.Equal(t, "v6", newClient())
+
+	newClient, err = registry.Get("7")
+	assert.ErrorIs(t, err, ErrUnsupported)
+	assert.EqualError(t, err, `unsupported API version "7", must be one of 5, 6`)
+	assert.Nil(t, newClient)
+
+	assert.Equal(t, []string{"5", "6"}, registry.Keys())
+}
+
+func TestRegistryRegisterInvalid(t *testing.T) {
+	registry := NewRegistry[int]("API version")
+	registry.Register("5", 5)
+
+	assert.PanicsWithValue(t, `API version "5" is already registered`, func() { registry.Register("5", 6) })
+	assert.PanicsWithValue(t, "cannot register an empty API version", func() { registry.Register("", 0) })
+}
@@ -40,8 +40,14 @@ const (
 	SetIdentifierKey = AnnotationKeyPrefix + "set-identifier"
 	AliasKey         = AnnotationKeyPrefix + "alias"
 	TargetKey        = AnnotationKeyPrefix + "target"
+	// The annotation used for forcing the record type of the targets, resolving hostname targets to IP addresses
+	TargetRecordTypeKey = AnnotationKeyPrefix + "target-record-type"
+	// The annotation used for setting the TTL, targets and target record type at once, as a JSON EndpointConfig
+	ConfigKey = AnnotationKeyPrefix + "config"
 	// The annotation used for figuring out which controller is responsible
 	ControllerKey = AnnotationKeyPrefix + "controller"
+	// The annotation used for excluding a resource from processing when set to "true"
+	ExcludeKey = AnnotationKeyPrefix + "exclude"
 	// The annotation used for defining the desired hostname
 	HostnameKey = AnnotationKeyPrefix + "hostname"
 	// The annotation used for specifying whether the public or private interface address is used
@@ -14,6 +14,8 @@ limitations under the License.
 package annotations
 
 import (
+	"encoding/json"
+	"maps"
 	"strconv"
 	"strings"
 	"time"
@@ -98,6 +100,60 @@ func TargetsFromTargetAnnotation(annotations map[string]string) endpoint.Targets
 	return targets
 }
 
+// TargetRecordTypeFromAnnotations extracts the record type forced by the optional "target-record-type" annotation
+// of the given resource, either A, AAAA or CNAME. Returns an empty string if none or an invalid one is set.
+func TargetRecordTypeFromAnnotations(annotations map[string]string, resource string) string {
+	value, ok := annotations[TargetRecordTypeKey]
+	if !ok {
+		return ""
+	}
+	switch recordType := strings.ToUpper(strings.TrimSpace(value)); recordType {
+	case endpoint.RecordTypeA, endpoint.RecordTypeAAAA, endpoint.RecordTypeCNAME:
+		return recordType
+	}
+	log.Warnf("%s: %q is not a valid target record type, must be %s, %s or %s", resource, value, endpoint.RecordTypeA, endpoint.RecordTypeAAAA, endpoint.RecordTypeCNAME)
+	return ""
+}
+
+// EndpointConfig is the JSON value of the config annotation, e.g. {"ttl":300,"targets":["1.2.3.4"],"recordType":"A"}.
+// Its fields stand for the ttl, target and target-record-type annotations respectively.
+type EndpointConfig struct {
+	TTL        *int64   `json:"ttl,omitempty"`
+	Targets    []string `json:"targets,omitempty"`
+	RecordType string   `json:"recordType,omitempty"`
+}
+
+// ApplyConfigAnnotation returns the annotations of the given resource with the ttl, target and target-record-type
+// annotations overridden by the fields set in the config annotation, and whether they were overridden.
+// The given annotations are left untouched. An invalid config annotation is logged and ignored, so that
+// the individual annotations still apply.
+func ApplyConfigAnnotation(annotations map[string]string, resource string) (map[string]string, bool) {
+	value, ok := annotations[ConfigKey]
+	if !ok {
+		return annotations, false
+	}
+
+	var config EndpointConfig
+	decoder := json.NewDecoder(strings.NewReader(value))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&config); err != nil {
+		log.Warnf("%s: %q is not a valid %s annotation, using the individual annotations instead: %v", resource, value, ConfigKey, err)
+		return annotations, false
+	}
+
+	result := maps.Clone(annotations)
+	if config.TTL != nil {
+		result[TtlKey] = strconv.FormatInt(*config.TTL, 10)
+	}
+	if len(config.Targets) > 0 {
+		result[TargetKey] = strings.Join(config.Targets, ",")
+	}
+	if config.RecordType != "" {
+		result[TargetRecordTypeKey] = config.RecordType
+	}
+	return result, true
+}
+
 // HostnamesFromAnnotations extracts the hostnames from the given annotations map.
 // It returns a slice of hostnames if the HostnameKey annotation is present, otherwise it returns nil.
 func HostnamesFromAnnotations(input map[string]string) []string {
@@ -15,6 +15,7 @@ package annotations
 
 import (
 	"fmt"
+	"maps"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -117,6 +118,98 @@ func TestTargetsFromTargetAnnotation(t *testing.T) {
 	}
 }
 
+func TestTargetRecordTypeFromAnnotations(t *testing.T) {
+	tests := []struct {
+		name        string
+		annotations map[string]string
+		expected    string
+	}{
+		{
+			name:        "no target record type annotation",
+			annotations: map[string]string{},
+			expected:    "",
+		},
+		{
+			name:        "A",
+			annotations: map[string]string{TargetRecordTypeKey: "A"},
+			expected:    endpoint.RecordTypeA,
+		},
+		{
+			name:        "lowercase AAAA with spaces",
+			annotations: map[string]string{TargetRecordTypeKey: " aaaa "},
+			expected:    endpoint.RecordTypeAAAA,
+		},
+		{
+			name:        "lowercase CNAME",
+			annotations: map[string]string{TargetRecordTypeKey: "cname"},
+			expected:    endpoint.RecordTypeCNAME,
+		},
+		{
+			name:        "invalid value",
+			annotations: map[string]string{TargetRecordTypeKey: "foo"},
+			expected:    "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, TargetRecordTypeFromAnnotations(tt.annotations, "gateway/default/foo"))
+		})
+	}
+}
+
+func TestApplyConfigAnnotation(t *testing.T) {
+	tests := []struct {
+		name        string
+		annotations map[string]string
+		expected    map[string]string
+		applied     bool
+	}{
+		{
+			name:        "no config annotation",
+			annotations: map[string]string{TtlKey: "60"},
+			expected:    map[string]string{TtlKey: "60"},
+		},
+		{
+			name:        "all fields",
+			annotations: map[string]string{ConfigKey: `{"ttl":300,"targets":["1.2.3.4","lb.example.com"],"recordType":"A"}`},
+			expected: map[string]string{
+				ConfigKey:           `{"ttl":300,"targets":["1.2.3.4","lb.example.com"],"recordType":"A"}`,
+				TtlKey:              "300",
+				TargetKey:           "1.2.3.4,lb.example.com",
+				TargetRecordTypeKey: "A",
+			},
+			applied: true,
+		},
+		{
+			name:        "overrides only the fields set",
+			annotations: map[string]string{ConfigKey: `{"ttl":300}`, TtlKey: "60", TargetKey: "9.9.9.9"},
+			expected:    map[string]string{ConfigKey: `{"ttl":300}`, TtlKey: "300", TargetKey: "9.9.9.9"},
+			applied:     true,
+		},
+		{
+			name:        "invalid JSON",
+			annotations: map[string]string{ConfigKey: `{"ttl":300`, TtlKey: "60"},
+			expected:    map[string]string{ConfigKey: `{"ttl":300`, TtlKey: "60"},
+		},
+		{
+			name:        "unknown field",
+			annotations: map[string]string{ConfigKey: `{"tll":300}`, TtlKey: "60"},
+			expected:    map[string]string{ConfigKey: `{"tll":300}`, TtlKey: "60"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			original := maps.Clone(tt.annotations)
+			result, applied := ApplyConfigAnnotation(tt.annotations, "gateway/default/foo")
+			assert.Equal(t, tt.expected, result)
+			assert.Equal(t, tt.applied, applied)
+			assert.Equal(t, original, tt.annotations)
+		})
+	}
+}
+
 func TestTTLFromAnnotations(t *testing.T) {
 	tests := []struct {
 		name        string
@@ -81,6 +81,29 @@ func EndpointsForHostname(hostname string, targets endpoint.Targets, ttl endpoin
 	return endpoints
 }
 
+// EndpointsForHostnameWithRecordType is like EndpointsForHostname, but publishes all the targets as a single
+// record of the given type instead of guessing the type of each target from its shape. This allows, for instance,
+// an IP address to be published as a CNAME target. An empty record type falls back to EndpointsForHostname.
+func EndpointsForHostnameWithRecordType(hostname string, targets endpoint.Targets, recordType string, ttl endpoint.TTL, providerSpecific endpoint.ProviderSpecific, setIdentifier string, resource string) []*endpoint.Endpoint {
+	if recordType == "" {
+		return EndpointsForHostname(hostname, targets, ttl, providerSpecific, setIdentifier, resource)
+	}
+	if len(targets) == 0 {
+		return nil
+	}
+
+	ep := endpoint.NewEndpointWithTTL(hostname, recordType, ttl, targets...)
+	if ep == nil {
+		return nil
+	}
+	ep.ProviderSpecific = providerSpecific
+	ep.SetIdentifier = setIdentifier
+	if resource != "" {
+		ep.Labels[endpoint.ResourceLabelKey] = resource
+	}
+	return []*endpoint.Endpoint{ep}
+}
+
 func EndpointTargetsFromServices(svcInformer coreinformers.ServiceInformer, namespace string, selector map[string]string) (endpoint.Targets, error) {
 	targets := endpoint.Targets{}
 
@@ -123,6 +123,72 @@ func TestEndpointsForHostname(t *testing.T) {
 	}
 }
 
+func TestEndpointsForHostnameWithRecordType(t *testing.T) {
+	providerSpecific := endpoint.ProviderSpecific{{Name: "provider", Value: "value"}}
+
+	tests := []struct {
+		name       string
+		targets    endpoint.Targets
+		recordType string
+		expected   []*endpoint.Endpoint
+	}{
+		{
+			name:       "IP address targets published as CNAME",
+			targets:    endpoint.Targets{"192.0.2.1", "cname.example.com"},
+			recordType: endpoint.RecordTypeCNAME,
+			expected: []*endpoint.Endpoint{
+				{
+					DNSName:          "example.com",
+					Targets:          endpoint.Targets{"192.0.2.1", "cname.example.com"},
+					RecordType:       endpoint.RecordTypeCNAME,
+					RecordTTL:        endpoint.TTL(300),
+					ProviderSpecific: providerSpecific,
+					SetIdentifier:    "identifier",
+					Labels:           map[string]string{endpoint.ResourceLabelKey: "resource"},
+				},
+			},
+		},
+		{
+			name:       "no record type guesses from the targets",
+			targets:    endpoint.Targets{"192.0.2.1", "2001:db8::1"},
+			recordType: "",
+			expected: []*endpoint.Endpoint{
+				{
+					DNSName:          "example.com",
+					Targets:          endpoint.Targets{"192.0.2.1"},
+					RecordType:       endpoint.RecordTypeA,
+					RecordTTL:        endpoint.TTL(300),
+					ProviderSpecific: providerSpecific,
+					SetIdentifier:    "identifier",
+					Labels:           map[string]string{endpoint.ResourceLabelKey: "resource"},
+				},
+				{
+					DNSName:          "example.com",
+					Targets:          endpoint.Targets{"2001:db8::1"},
+					RecordType:       endpoint.RecordTypeAAAA,
+					RecordTTL:        endpoint.TTL(300),
+					ProviderSpecific: providerSpecific,
+					SetIdentifier:    "identifier",
+					Labels:           map[string]string{endpoint.ResourceLabelKey: "resource"},
+				},
+			},
+		},
+		{
+			name:       "no targets",
+			targets:    endpoint.Targets{},
+			recordType: endpoint.RecordTypeCNAME,
+			expected:   []*endpoint.Endpoint(nil),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := EndpointsForHostnameWithRecordType("example.com", tt.targets, tt.recordType, endpoint.TTL(300), providerSpecific, "identifier", "resource")
+			assert.Equal(t, tt.expected, result)
+		})
+	}
+}
+
 func TestEndpointTargetsFromServices(t *testing.T) {
 	tests := []struct {
 		name      string
@@ -17,17 +17,29 @@ limitations under the License.
 package source
 
 import (
+	"cmp"
 	"context"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
 	"fmt"
+	"net"
+	"net/netip"
+	"slices"
 	"sort"
 	"strings"
 	"text/template"
 
+	"github.com/prometheus/client_golang/prometheus"
 	log "github.com/sirupsen/logrus"
+	istionetworking "istio.io/api/networking/v1beta1"
+	networkingv1 "istio.io/client-go/pkg/apis/networking/v1"
 	networkingv1beta1 "istio.io/client-go/pkg/apis/networking/v1beta1"
 	istioclient "istio.io/client-go/pkg/clientset/versioned"
 	istioinformers "istio.io/client-go/pkg/informers/externalversions"
 	networkingv1beta1informer "istio.io/client-go/pkg/informers/externalversions/networking/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	networkv1 "k8s.io/api/networking/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
 	kubeinformers "k8s.io/client-go/informers"
@@ -36,6 +48,7 @@ import (
 	"k8s.io/client-go/tools/cache"
 
 	"sigs.k8s.io/external-dns/endpoint"
+	"sigs.k8s.io/external-dns/pkg/metrics"
 	"sigs.k8s.io/external-dns/source/annotations"
 	"sigs.k8s.io/external-dns/source/fqdn"
 	"sigs.k8s.io/external-dns/source/informers"
@@ -45,43 +58,194 @@ import (
 // instead of a standard LoadBalancer service type
 const IstioGatewayIngressSource = "external-dns.alpha.kubernetes.io/ingress"
 
+const (
+	// IstioGatewayUnmatchedSelectorSkip skips Istio Gateways whose selector matches no service, logging a warning.
+	IstioGatewayUnmatchedSelectorSkip = "skip"
+	// IstioGatewayUnmatchedSelectorError fails the source when the selector of an Istio Gateway matches no service.
+	IstioGatewayUnmatchedSelectorError = "error"
+)
+
+const (
+	// IstioGatewayHostConflictIgnore passes the endpoints of a host declared by several gateways with different targets on to the planner.
+	IstioGatewayHostConflictIgnore = "ignore"
+	// IstioGatewayHostConflictMerge publishes the targets of all the gateways declaring the same host.
+	IstioGatewayHostConflictMerge = "merge"
+	// IstioGatewayHostConflictFirst publishes the targets of the first gateway declaring a host, by namespace and name.
+	IstioGatewayHostConflictFirst = "first"
+)
+
+// errGatewaySelectorUnmatched is returned when the selector of a gateway matches none of the watched services.
+var errGatewaySelectorUnmatched = errors.New("gateway selector matches no service")
+
+var istioGatewaysUnmatchedSelector = metrics.NewGaugeWithOpts(
+	prometheus.GaugeOpts{
+		Subsystem: "source",
+		Name:      "istio_gateways_unmatched_selector",
+		Help:      "Number of Istio Gateways skipped because their selector matches no service.",
+	},
+)
+
+func init() {
+	metrics.RegisterMetric.MustRegister(istioGatewaysUnmatchedSelector)
+}
+
 // gatewaySource is an implementation of Source for Istio Gateway objects.
 // The gateway implementation uses the spec.servers.hosts values for the hostnames.
 // Use targetAnnotationKey to explicitly set Endpoint.
+// When virtualServiceHosts is set, wildcard hosts are completed with the hosts of the VirtualServices bound to the gateway.
+// When credentialHosts is set, TLS servers without hosts contribute the DNS names of the certificates of their credentials.
+// Gateways whose selector matches no service are handled according to unmatchedSelector.
+// Hosts declared by several gateways with different targets are handled according to hostConflict.
 type gatewaySource struct {
 	kubeClient               kubernetes.Interface
 	istioClient              istioclient.Interface
-	namespace                string
+	namespaces               []string
 	annotationFilter         string
 	fqdnTemplate             *template.Template
 	combineFQDNAnnotation    bool
 	ignoreHostnameAnnotation bool
-	serviceInformer          coreinformers.ServiceInformer
-	gatewayInformer          networkingv1beta1informer.GatewayInformer
+	labelSelector            labels.Selector
+	informers                []gatewayNamespaceInformers
+	nsInformer               coreinformers.NamespaceInformer
+	gatewayV1                bool
+	virtualServiceHosts      bool
+	credentialHosts          bool
+	unmatchedSelector        string
+	hostConflict             string
+	// resolveLoadBalancerHostname publishes A and AAAA records of the addresses hostname targets resolve to, instead of a CNAME.
+	resolveLoadBalancerHostname bool
+	// lookupNetIP resolves hostname targets when the target record type is forced by annotation,
+	// or when resolveLoadBalancerHostname is set.
+	lookupNetIP func(ctx context.Context, network, host string) ([]netip.Addr, error)
 }
 
-// NewIstioGatewaySource creates a new gatewaySource with the given config.
+// gatewayNamespaceInformers holds the informers of a single watched namespace, where "" stands for all namespaces.
+type gatewayNamespaceInformers struct {
+	namespace        string
+	serviceInformer  coreinformers.ServiceInformer
+	gatewayInformer  cache.SharedIndexInformer
+	vServiceInformer networkingv1beta1informer.VirtualServiceInformer
+	secretInformer   coreinformers.SecretInformer
+}
+
+// IstioGatewayOptions configures the Istio Gateway source.
+type IstioGatewayOptions struct {
+	// Namespaces are the namespaces gateways are watched in, all namespaces if empty.
+	Namespaces               []string
+	AnnotationFilter         string
+	FQDNTemplate             string
+	CombineFQDNAnnotation    bool
+	IgnoreHostnameAnnotation bool
+	// LabelSelector filters the gateways by label, all gateways are kept if nil.
+	LabelSelector labels.Selector
+	// VirtualServiceHosts completes wildcard gateway hosts with the hosts of the VirtualServices bound to the gateway.
+	VirtualServiceHosts bool
+	// CredentialHosts publishes the DNS names of the certificates referenced by TLS servers without hosts,
+	// which requires watching the Secrets of the gateway namespaces.
+	CredentialHosts bool
+	// UnmatchedSelector is one of IstioGatewayUnmatchedSelectorSkip (the default) or IstioGatewayUnmatchedSelectorError.
+	UnmatchedSelector           string
+	ResolveLoadBalancerHostname bool
+	// HostConflict is one of IstioGatewayHostConflictIgnore (the default), IstioGatewayHostConflictMerge
+	// or IstioGatewayHostConflictFirst.
+	HostConflict string
+}
+
+// NewIstioGatewaySource creates a new gatewaySource with the given options.
 func NewIstioGatewaySource(
 	ctx context.Context,
 	kubeClient kubernetes.Interface,
 	istioClient istioclient.Interface,
-	namespace string,
-	annotationFilter string,
-	fqdnTemplate string,
-	combineFQDNAnnotation bool,
-	ignoreHostnameAnnotation bool,
+	opts IstioGatewayOptions,
 ) (Source, error) {
-	tmpl, err := fqdn.ParseTemplate(fqdnTemplate)
+	unmatchedSelector := opts.UnmatchedSelector
+	switch unmatchedSelector {
+	case "":
+		unmatchedSelector = IstioGatewayUnmatchedSelectorSkip
+	case IstioGatewayUnmatchedSelectorSkip, IstioGatewayUnmatchedSelectorError:
+	default:
+		return nil, fmt.Errorf("invalid unmatched gateway selector behavior %q, must be one of %q or %q", unmatchedSelector, IstioGatewayUnmatchedSelectorSkip, IstioGatewayUnmatchedSelectorError)
+	}
+
+	hostConflict := opts.HostConflict
+	switch hostConflict {
+	case "":
+		hostConflict = IstioGatewayHostConflictIgnore
+	case IstioGatewayHostConflictIgnore, IstioGatewayHostConflictMerge, IstioGatewayHostConflictFirst:
+	default:
+		return nil, fmt.Errorf("invalid gateway host conflict behavior %q, must be one of %q, %q or %q", hostConflict, IstioGatewayHostConflictIgnore, IstioGatewayHostConflictMerge, IstioGatewayHostConflictFirst)
+	}
+
+	tmpl, err := fqdn.ParseTemplate(opts.FQDNTemplate)
 	if err != nil {
 		return nil, err
 	}
 
+	namespaces := normalizeGatewayNamespaces(opts.Namespaces)
+
+	// Gateways are watched in networking.istio.io/v1 when the cluster serves it, falling back to v1beta1 otherwise.
+	gatewayV1 := servesIstioGatewayV1(istioClient)
+
+	var nsInformers []gatewayNamespaceInformers
+	for _, namespace := range watchedGatewayNamespaces(namespaces) {
+		nsInformer, err := newGatewayNamespaceInformers(ctx, kubeClient, istioClient, namespace, gatewayV1, opts)
+		if err != nil {
+			return nil, err
+		}
+		nsInformers = append(nsInformers, nsInformer)
+	}
+
+	// Namespaces are watched cluster-wide to skip the gateways of those being deleted.
+	kubeInformerFactory := kubeinformers.NewSharedInformerFactory(kubeClient, 0)
+	namespaceInformer := kubeInformerFactory.Core().V1().Namespaces()
+	namespaceInformer.Informer() // Register with factory before starting.
+	kubeInformerFactory.Start(ctx.Done())
+	if err := informers.WaitForCacheSync(context.Background(), kubeInformerFactory); err != nil {
+		return nil, err
+	}
+
+	return &gatewaySource{
+		kubeClient:                  kubeClient,
+		istioClient:                 istioClient,
+		namespaces:                  namespaces,
+		annotationFilter:            opts.AnnotationFilter,
+		fqdnTemplate:                tmpl,
+		combineFQDNAnnotation:       opts.CombineFQDNAnnotation,
+		ignoreHostnameAnnotation:    opts.IgnoreHostnameAnnotation,
+		labelSelector:               opts.LabelSelector,
+		informers:                   nsInformers,
+		nsInformer:                  namespaceInformer,
+		gatewayV1:                   gatewayV1,
+		virtualServiceHosts:         opts.VirtualServiceHosts,
+		credentialHosts:             opts.CredentialHosts,
+		unmatchedSelector:           unmatchedSelector,
+		hostConflict:                hostConflict,
+		resolveLoadBalancerHostname: opts.ResolveLoadBalancerHostname,
+		lookupNetIP:                 net.DefaultResolver.LookupNetIP,
+	}, nil
+}
+
+// newGatewayNamespaceInformers starts the informers of a single namespace and waits for their caches to be populated.
+func newGatewayNamespaceInformers(
+	ctx context.Context,
+	kubeClient kubernetes.Interface,
+	istioClient istioclient.Interface,
+	namespace string,
+	gatewayV1 bool,
+	opts IstioGatewayOptions,
+) (gatewayNamespaceInformers, error) {
 	// Use shared informers to listen for add/update/delete of services/pods/nodes in the specified namespace.
 	// Set resync period to 0, to prevent processing when nothing has changed
 	informerFactory := kubeinformers.NewSharedInformerFactoryWithOptions(kubeClient, 0, kubeinformers.WithNamespace(namespace))
 	serviceInformer := informerFactory.Core().V1().Services()
-	istioInformerFactory := istioinformers.NewSharedInformerFactory(istioClient, 0)
-	gatewayInformer := istioInformerFactory.Networking().V1beta1().Gateways()
+	istioInformerFactory := istioinformers.NewSharedInformerFactoryWithOptions(istioClient, 0, istioinformers.WithNamespace(namespace))
+
+	var gatewayInformer cache.SharedIndexInformer
+	if gatewayV1 {
+		gatewayInformer = istioInformerFactory.Networking().V1().Gateways().Informer()
+	} else {
+		gatewayInformer = istioInformerFactory.Networking().V1beta1().Gateways().Informer()
+	}
 
 	// Add default resource event handlers to properly initialize informer.
 	_, _ = serviceInformer.Informer().AddEventHandler(
@@ -92,7 +256,7 @@ func NewIstioGatewaySource(
 		},
 	)
 
-	_, _ = gatewayInformer.Informer().AddEventHandler(
+	_, _ = gatewayInformer.AddEventHandler(
 		cache.ResourceEventHandlerFuncs{
 			AddFunc: func(obj interface{}) {
 				log.Debug("gateway added")
@@ -100,49 +264,177 @@ func NewIstioGatewaySource(
 		},
 	)
 
+	// VirtualServices are only watched when their hosts are published for wildcard gateway hosts.
+	var virtualServiceInformer networkingv1beta1informer.VirtualServiceInformer
+	if opts.VirtualServiceHosts {
+		virtualServiceInformer = istioInformerFactory.Networking().V1beta1().VirtualServices()
+		_, _ = virtualServiceInformer.Informer().AddEventHandler(
+			cache.ResourceEventHandlerFuncs{
+				AddFunc: func(obj interface{}) {
+					log.Debug("virtual service added")
+				},
+			},
+		)
+	}
+
+	// Secrets are only watched when the certificates of TLS credentials are read.
+	var secretInformer coreinformers.SecretInformer
+	if opts.CredentialHosts {
+		secretInformer = informerFactory.Core().V1().Secrets()
+		_, _ = secretInformer.Informer().AddEventHandler(
+			cache.ResourceEventHandlerFuncs{
+				AddFunc: func(obj interface{}) {
+					log.Debug("secret added")
+				},
+			},
+		)
+	}
+
 	informerFactory.Start(ctx.Done())
 	istioInformerFactory.Start(ctx.Done())
 
 	// wait for the local cache to be populated.
 	if err := informers.WaitForCacheSync(context.Background(), informerFactory); err != nil {
-		return nil, err
+		return gatewayNamespaceInformers{}, err
 	}
 	if err := informers.WaitForCacheSync(context.Background(), istioInformerFactory); err != nil {
-		return nil, err
+		return gatewayNamespaceInformers{}, err
 	}
 
-	return &gatewaySource{
-		kubeClient:               kubeClient,
-		istioClient:              istioClient,
-		namespace:                namespace,
-		annotationFilter:         annotationFilter,
-		fqdnTemplate:             tmpl,
-		combineFQDNAnnotation:    combineFQDNAnnotation,
-		ignoreHostnameAnnotation: ignoreHostnameAnnotation,
-		serviceInformer:          serviceInformer,
-		gatewayInformer:          gatewayInformer,
+	return gatewayNamespaceInformers{
+		namespace:        namespace,
+		serviceInformer:  serviceInformer,
+		gatewayInformer:  gatewayInformer,
+		vServiceInformer: virtualServiceInformer,
+		secretInformer:   secretInformer,
 	}, nil
 }
 
+// normalizeGatewayNamespaces drops empty and duplicated namespaces. An empty namespace
+// stands for all namespaces, in which case nil is returned.
+func normalizeGatewayNamespaces(namespaces []string) []string {
+	var result []string
+	for _, namespace := range namespaces {
+		namespace = strings.TrimSpace(namespace)
+		if namespace == "" {
+			return nil
+		}
+		if !slices.Contains(result, namespace) {
+			result = append(result, namespace)
+		}
+	}
+	return result
+}
+
+// watchedGatewayNamespaces returns the namespaces to list and watch resources in, "" standing for all namespaces.
+func watchedGatewayNamespaces(namespaces []string) []string {
+	if len(namespaces) == 0 {
+		return []string{""}
+	}
+	return namespaces
+}
+
+// watchesNamespace reports whether resources of the given namespace are watched by the source.
+func (sc *gatewaySource) watchesNamespace(namespace string) bool {
+	return len(sc.namespaces) == 0 || slices.Contains(sc.namespaces, namespace)
+}
+
+// servesIstioGatewayV1 reports whether the cluster serves Istio Gateways in networking.istio.io/v1.
+func servesIstioGatewayV1(istioClient istioclient.Interface) bool {
+	groupVersion := networkingv1.SchemeGroupVersion.String()
+	resources, err := istioClient.Discovery().ServerResourcesForGroupVersion(groupVersion)
+	if err != nil {
+		log.Debugf("Falling back to Istio Gateways in networking.istio.io/v1beta1, listing resources in %s failed: %v", groupVersion, err)
+		return false
+	}
+	return slices.ContainsFunc(resources.APIResources, func(resource metav1.APIResource) bool {
+		return resource.Kind == "Gateway"
+	})
+}
+
+// listGateways returns the gateways in the source's namespace(s) from the served API version.
+// Gateways in networking.istio.io/v1 are returned as v1beta1 Gateways, both versions sharing the same spec.
+// Gateways are read from the informer caches, a namespace whose cache is not synced yet is listed live instead.
+// The returned gateways may be shared with the caches and must not be modified.
+func (sc *gatewaySource) listGateways(ctx context.Context) ([]*networkingv1beta1.Gateway, error) {
+	var gateways []*networkingv1beta1.Gateway
+	for _, nsInformer := range sc.informers {
+		if !nsInformer.gatewayInformer.HasSynced() {
+			log.Debugf("Gateway cache of namespace %q is not synced, listing gateways from the API server", nsInformer.namespace)
+			nsGateways, err := sc.listGatewaysLive(ctx, nsInformer.namespace)
+			if err != nil {
+				return nil, err
+			}
+			gateways = append(gateways, nsGateways...)
+			continue
+		}
+
+		for _, obj := range nsInformer.gatewayInformer.GetIndexer().List() {
+			switch gateway := obj.(type) {
+			case *networkingv1beta1.Gateway:
+				gateways = append(gateways, gateway)
+			case *networkingv1.Gateway:
+				gateways = append(gateways, (*networkingv1beta1.Gateway)(gateway))
+			}
+		}
+	}
+
+	// Keep the order of a live List, the caches being unordered.
+	slices.SortFunc(gateways, func(a, b *networkingv1beta1.Gateway) int {
+		return cmp.Or(cmp.Compare(a.Namespace, b.Namespace), cmp.Compare(a.Name, b.Name))
+	})
+	return gateways, nil
+}
+
+// listGatewaysLive lists the gateways of a namespace from the API server.
+func (sc *gatewaySource) listGatewaysLive(ctx context.Context, namespace string) ([]*networkingv1beta1.Gateway, error) {
+	if !sc.gatewayV1 {
+		gwList, err := sc.istioClient.NetworkingV1beta1().Gateways(namespace).List(ctx, metav1.ListOptions{})
+		if err != nil {
+			return nil, err
+		}
+		return gwList.Items, nil
+	}
+
+	gwList, err := sc.istioClient.NetworkingV1().Gateways(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	gateways := make([]*networkingv1beta1.Gateway, 0, len(gwList.Items))
+	for _, gateway := range gwList.Items {
+		gateways = append(gateways, (*networkingv1beta1.Gateway)(gateway))
+	}
+	return gateways, nil
+}
+
 // Endpoints returns endpoint objects for each host-target combination that should be processed.
 // Retrieves all gateway resources in the source's namespace(s).
 func (sc *gatewaySource) Endpoints(ctx context.Context) ([]*endpoint.Endpoint, error) {
-	gwList, err := sc.istioClient.NetworkingV1beta1().Gateways(sc.namespace).List(ctx, metav1.ListOptions{})
+	gateways, err := sc.listGateways(ctx)
 	if err != nil {
 		return nil, err
 	}
 
-	gateways := gwList.Items
 	gateways, err = sc.filterByAnnotations(gateways)
 	if err != nil {
 		return nil, err
 	}
 
+	gateways = sc.filterByLabels(gateways)
+
+	gateways = sc.filterByTerminatingNamespaces(gateways)
+
 	var endpoints []*endpoint.Endpoint
+	var unmatched int
 
-	log.Debugf("Found %d gateways in namespace %s", len(gateways), sc.namespace)
+	log.Debugf("Found %d gateways in namespaces %q", len(gateways), sc.namespaces)
 
 	for _, gateway := range gateways {
+		if gateway.Annotations[excludeAnnotationKey] == "true" {
+			log.Debugf("Skipping gateway %s/%s because it is excluded by the %s annotation", gateway.Namespace, gateway.Name, excludeAnnotationKey)
+			continue
+		}
+
 		// Check controller annotation to see if we are responsible.
 		controller, ok := gateway.Annotations[controllerAnnotationKey]
 		if ok && controller != controllerAnnotationValue {
@@ -151,7 +443,7 @@ func (sc *gatewaySource) Endpoints(ctx context.Context) ([]*endpoint.Endpoint, e
 			continue
 		}
 
-		gwHostnames, err := sc.hostNamesFromGateway(gateway)
+		gwHostnames, err := sc.hostNamesFromGateway(ctx, gateway)
 		if err != nil {
 			return nil, err
 		}
@@ -170,7 +462,8 @@ func (sc *gatewaySource) Endpoints(ctx context.Context) ([]*endpoint.Endpoint, e
 			}
 		}
 
-		log.Debugf("Processing gateway '%s/%s.%s' and hosts %q", gateway.Namespace, gateway.APIVersion, gateway.Name, strings.Join(gwHostnames, ","))
+		gatewayLog := log.WithField("gateway", gateway.Namespace+"/"+gateway.Name)
+		gatewayLog.WithField("hosts", strings.Join(gwHostnames, ",")).Debug("Processing gateway")
 
 		if len(gwHostnames) == 0 {
 			log.Debugf("No hostnames could be generated from gateway %s/%s", gateway.Namespace, gateway.Name)
@@ -178,6 +471,11 @@ func (sc *gatewaySource) Endpoints(ctx context.Context) ([]*endpoint.Endpoint, e
 		}
 
 		gwEndpoints, err := sc.endpointsFromGateway(ctx, gwHostnames, gateway)
+		if errors.Is(err, errGatewaySelectorUnmatched) && sc.unmatchedSelector == IstioGatewayUnmatchedSelectorSkip {
+			log.Warnf("Skipping gateway %s/%s because its selector %q matches no service", gateway.Namespace, gateway.Name, labels.Set(gateway.Spec.Selector).String())
+			unmatched++
+			continue
+		}
 		if err != nil {
 			return nil, err
 		}
@@ -187,23 +485,113 @@ func (sc *gatewaySource) Endpoints(ctx context.Context) ([]*endpoint.Endpoint, e
 			continue
 		}
 
-		log.Debugf("Endpoints generated from %q '%s/%s.%s': %q", gateway.Kind, gateway.Namespace, gateway.APIVersion, gateway.Name, gwEndpoints)
+		for _, ep := range gwEndpoints {
+			gatewayLog.WithFields(log.Fields{
+				"dnsName":    ep.DNSName,
+				"recordType": ep.RecordType,
+				"targets":    strings.Join(ep.Targets, ","),
+			}).Debug("Endpoint generated from gateway")
+		}
 		endpoints = append(endpoints, gwEndpoints...)
 	}
 
+	istioGatewaysUnmatchedSelector.Gauge.Set(float64(unmatched))
+
 	// TODO: sort on endpoint creation
 	for _, ep := range endpoints {
 		sort.Sort(ep.Targets)
 	}
 
-	return endpoints, nil
+	return resolveGatewayHostConflicts(dedupeGatewayEndpoints(endpoints), sc.hostConflict), nil
+}
+
+// dedupeGatewayEndpoints drops endpoints that are identical to one already seen,
+// which happens when several gateways share a load balancer and declare the same hosts.
+// Endpoints are only considered identical if they also agree on TTL and provider specific
+// properties, so conflicting configuration is still passed on to the planner.
+// Targets must be sorted beforehand.
+func dedupeGatewayEndpoints(endpoints []*endpoint.Endpoint) []*endpoint.Endpoint {
+	seen := make(map[endpoint.EndpointKey][]*endpoint.Endpoint)
+	result := make([]*endpoint.Endpoint, 0, len(endpoints))
+
+	for _, ep := range endpoints {
+		key := ep.Key()
+		duplicate := slices.ContainsFunc(seen[key], func(other *endpoint.Endpoint) bool {
+			return ep.RecordTTL == other.RecordTTL &&
+				slices.Equal(ep.Targets, other.Targets) &&
+				slices.Equal(ep.ProviderSpecific, other.ProviderSpecific)
+		})
+		if duplicate {
+			log.Debugf("Skipping duplicated endpoint %s from %s", ep, ep.Labels[endpoint.ResourceLabelKey])
+			continue
+		}
+		seen[key] = append(seen[key], ep)
+		result = append(result, ep)
+	}
+
+	return result
+}
+
+// resolveGatewayHostConflicts handles the endpoints left with the same name, record type and set identifier
+// by dedupeGatewayEndpoints, which happens when several gateways declare the same host with different targets.
+// Such conflicts are logged, then the endpoints are either all kept, merged into one with the targets of all
+// of them and the highest TTL, or dropped but the first one, according to the given behavior. As gateways are
+// listed by namespace and name, the first endpoint is the one of the first gateway in that order.
+func resolveGatewayHostConflicts(endpoints []*endpoint.Endpoint, behavior string) []*endpoint.Endpoint {
+	conflicts := make(map[endpoint.EndpointKey][]*endpoint.Endpoint)
+	for _, ep := range endpoints {
+		conflicts[ep.Key()] = append(conflicts[ep.Key()], ep)
+	}
+
+	result := make([]*endpoint.Endpoint, 0, len(endpoints))
+	for _, ep := range endpoints {
+		conflicting := conflicts[ep.Key()]
+		if len(conflicting) == 1 {
+			result = append(result, ep)
+			continue
+		}
+		if conflicting[0] != ep {
+			// Conflicts are resolved with their first endpoint.
+			if behavior == IstioGatewayHostConflictIgnore {
+				result = append(result, ep)
+			}
+			continue
+		}
+
+		resources := make([]string, 0, len(conflicting))
+		for _, other := range conflicting {
+			resources = append(resources, other.Labels[endpoint.ResourceLabelKey])
+		}
+		switch behavior {
+		case IstioGatewayHostConflictMerge:
+			log.Warnf("Merging the %s record targets of %s declared with different targets by %s", ep.RecordType, ep.DNSName, strings.Join(resources, ", "))
+			merged := endpoint.MergeByNameType(conflicting)[0]
+			sort.Sort(merged.Targets)
+			result = append(result, merged)
+		case IstioGatewayHostConflictFirst:
+			log.Warnf("Keeping the %s record targets of %s from %s only, it is declared with different targets by %s", ep.RecordType, ep.DNSName, resources[0], strings.Join(resources, ", "))
+			result = append(result, ep)
+		default:
+			log.Warnf("The %s record %s is declared with different targets by %s", ep.RecordType, ep.DNSName, strings.Join(resources, ", "))
+			result = append(result, ep)
+		}
+	}
+	return result
 }
 
 // AddEventHandler adds an event handler that should be triggered if the watched Istio Gateway changes.
 func (sc *gatewaySource) AddEventHandler(ctx context.Context, handler func()) {
 	log.Debug("Adding event handler for Istio Gateway")
 
-	_, _ = sc.gatewayInformer.Informer().AddEventHandler(eventHandlerFunc(handler))
+	for _, nsInformer := range sc.informers {
+		_, _ = nsInformer.gatewayInformer.AddEventHandler(eventHandlerFunc(handler))
+		if nsInformer.vServiceInformer != nil {
+			_, _ = nsInformer.vServiceInformer.Informer().AddEventHandler(eventHandlerFunc(handler))
+		}
+		if nsInformer.secretInformer != nil {
+			_, _ = nsInformer.secretInformer.Informer().AddEventHandler(eventHandlerFunc(handler))
+		}
+	}
 }
 
 // filterByAnnotations filters a list of configs by a given annotation selector.
@@ -230,7 +618,46 @@ func (sc *gatewaySource) filterByAnnotations(gateways []*networkingv1beta1.Gatew
 	return filteredList, nil
 }
 
-func (sc *gatewaySource) targetsFromIngress(ctx context.Context, ingressStr string, gateway *networkingv1beta1.Gateway) (endpoint.Targets, error) {
+// filterByLabels filters a list of gateways by the label selector of the source.
+func (sc *gatewaySource) filterByLabels(gateways []*networkingv1beta1.Gateway) []*networkingv1beta1.Gateway {
+	// empty selector returns original list
+	if sc.labelSelector == nil || sc.labelSelector.Empty() {
+		return gateways
+	}
+
+	var filteredList []*networkingv1beta1.Gateway
+
+	for _, gw := range gateways {
+		if sc.labelSelector.Matches(labels.Set(gw.Labels)) {
+			filteredList = append(filteredList, gw)
+		}
+	}
+
+	return filteredList
+}
+
+// filterByTerminatingNamespaces drops the gateways of namespaces being deleted, which may linger
+// for a while and would otherwise have their records deleted and created again until they are removed.
+// Namespaces are read from the informer cache; the gateways of namespaces missing from it are kept.
+func (sc *gatewaySource) filterByTerminatingNamespaces(gateways []*networkingv1beta1.Gateway) []*networkingv1beta1.Gateway {
+	var filteredList []*networkingv1beta1.Gateway
+
+	for _, gw := range gateways {
+		ns, err := sc.nsInformer.Lister().Get(gw.Namespace)
+		if err != nil {
+			log.Debugf("Cannot check whether namespace %s is being deleted: %v", gw.Namespace, err)
+		} else if ns.DeletionTimestamp != nil || ns.Status.Phase == corev1.NamespaceTerminating {
+			log.Debugf("Skipping gateway %s/%s because its namespace is being deleted", gw.Namespace, gw.Name)
+			continue
+		}
+		filteredList = append(filteredList, gw)
+	}
+
+	return filteredList
+}
+
+// ingressFromGateway returns the Ingress referenced by the ingress annotation of the gateway.
+func (sc *gatewaySource) ingressFromGateway(ctx context.Context, ingressStr string, gateway *networkingv1beta1.Gateway) (*networkv1.Ingress, error) {
 	namespace, name, err := ParseIngress(ingressStr)
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse Ingress annotation on Gateway (%s/%s): %w", gateway.Namespace, gateway.Name, err)
@@ -239,35 +666,95 @@ func (sc *gatewaySource) targetsFromIngress(ctx context.Context, ingressStr stri
 		namespace = gateway.Namespace
 	}
 
-	targets := make(endpoint.Targets, 0)
-
 	ingress, err := sc.kubeClient.NetworkingV1().Ingresses(namespace).Get(ctx, name, metav1.GetOptions{})
 	if err != nil {
 		log.Error(err)
 		return nil, err
 	}
-	for _, lb := range ingress.Status.LoadBalancer.Ingress {
-		if lb.IP != "" {
-			targets = append(targets, lb.IP)
-		} else if lb.Hostname != "" {
-			targets = append(targets, lb.Hostname)
-		}
-	}
-	return targets, nil
+	return ingress, nil
 }
 
-func (sc *gatewaySource) targetsFromGateway(ctx context.Context, gateway *networkingv1beta1.Gateway) (endpoint.Targets, error) {
+// targetsFromGateway returns the targets of the gateway. When the gateway delegates its targets
+// to an Ingress through the ingress annotation, that Ingress is returned as well.
+func (sc *gatewaySource) targetsFromGateway(ctx context.Context, gateway *networkingv1beta1.Gateway) (endpoint.Targets, *networkv1.Ingress, error) {
 	targets := annotations.TargetsFromTargetAnnotation(gateway.Annotations)
 	if len(targets) > 0 {
-		return targets, nil
+		return targets, nil, nil
 	}
 
 	ingressStr, ok := gateway.Annotations[IstioGatewayIngressSource]
 	if ok && ingressStr != "" {
-		return sc.targetsFromIngress(ctx, ingressStr, gateway)
+		ingress, err := sc.ingressFromGateway(ctx, ingressStr, gateway)
+		if err != nil {
+			return nil, nil, err
+		}
+		// Every load balancer entry may carry both an IP and a hostname, keep all distinct ones.
+		return uniqueTargets(targetsFromIngressStatus(ingress.Status)), ingress, nil
+	}
+
+	for _, nsInformer := range sc.informers {
+		nsTargets, err := EndpointTargetsFromServices(nsInformer.serviceInformer, nsInformer.namespace, gateway.Spec.Selector)
+		if err != nil {
+			return nil, nil, err
+		}
+		targets = append(targets, nsTargets...)
+	}
+	if len(targets) > 0 {
+		return targets, nil, nil
+	}
+
+	matched, err := sc.selectorMatchesService(gateway.Spec.Selector)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !matched {
+		return nil, nil, fmt.Errorf("%w: gateway %s/%s selects %q", errGatewaySelectorUnmatched, gateway.Namespace, gateway.Name, labels.Set(gateway.Spec.Selector).String())
+	}
+	return targets, nil, nil
+}
+
+// selectorMatchesService reports whether the gateway selector matches any of the watched services.
+func (sc *gatewaySource) selectorMatchesService(selector map[string]string) (bool, error) {
+	for _, nsInformer := range sc.informers {
+		services, err := nsInformer.serviceInformer.Lister().Services(nsInformer.namespace).List(labels.Everything())
+		if err != nil {
+			return false, fmt.Errorf("failed to list services in namespace %q: %w", nsInformer.namespace, err)
+		}
+		for _, service := range services {
+			if MatchesServiceSelector(selector, service.Spec.Selector) {
+				return true, nil
+			}
+		}
 	}
+	return false, nil
+}
+
+// targetsFromServices reports whether the targets of the gateway come from the services it selects,
+// rather than from its target or ingress annotation.
+func targetsFromServices(gateway *networkingv1beta1.Gateway) bool {
+	return len(annotations.TargetsFromTargetAnnotation(gateway.Annotations)) == 0 && gateway.Annotations[IstioGatewayIngressSource] == ""
+}
 
-	return EndpointTargetsFromServices(sc.serviceInformer, sc.namespace, gateway.Spec.Selector)
+// ttlFromServices returns the TTL annotated on the services selected by the gateway.
+// When several services carry a TTL, the lowest one is used.
+func (sc *gatewaySource) ttlFromServices(gateway *networkingv1beta1.Gateway) (endpoint.TTL, error) {
+	var ttl endpoint.TTL
+	for _, nsInformer := range sc.informers {
+		services, err := nsInformer.serviceInformer.Lister().Services(nsInformer.namespace).List(labels.Everything())
+		if err != nil {
+			return 0, fmt.Errorf("failed to list services in namespace %q: %w", nsInformer.namespace, err)
+		}
+		for _, service := range services {
+			if !MatchesServiceSelector(gateway.Spec.Selector, service.Spec.Selector) {
+				continue
+			}
+			serviceTTL := annotations.TTLFromAnnotations(service.Annotations, fmt.Sprintf("service/%s/%s", service.Namespace, service.Name))
+			if serviceTTL.IsConfigured() && (!ttl.IsConfigured() || serviceTTL < ttl) {
+				ttl = serviceTTL
+			}
+		}
+	}
+	return ttl, nil
 }
 
 // endpointsFromGatewayConfig extracts the endpoints from an Istio Gateway Config object
@@ -275,44 +762,222 @@ func (sc *gatewaySource) endpointsFromGateway(ctx context.Context, hostnames []s
 	var endpoints []*endpoint.Endpoint
 	var err error
 
-	targets, err := sc.targetsFromGateway(ctx, gateway)
+	resource := fmt.Sprintf("gateway/%s/%s", gateway.Namespace, gateway.Name)
+	if config, ok := annotations.ApplyConfigAnnotation(gateway.Annotations, resource); ok {
+		// The config annotation overrides the individual annotations, work on a copy so that the cached gateway is left untouched.
+		gateway = gateway.DeepCopy()
+		gateway.Annotations = config
+	}
+
+	targets, ingress, err := sc.targetsFromGateway(ctx, gateway)
 	if err != nil {
 		return nil, err
 	}
+	targets = canonicalIPTargets(targets)
 
 	if len(targets) == 0 {
 		return endpoints, nil
 	}
 
-	resource := fmt.Sprintf("gateway/%s/%s", gateway.Namespace, gateway.Name)
+	// The record type forced by annotation takes precedence over resolving hostname targets,
+	// which takes precedence over guessing the record type from the shape of each target.
+	recordType := annotations.TargetRecordTypeFromAnnotations(gateway.Annotations, resource)
+	switch {
+	case recordType == endpoint.RecordTypeCNAME:
+		// All targets are published as is, IP addresses included.
+	case recordType != "":
+		targets = sc.resolveTargets(ctx, targets, recordType)
+	case sc.resolveLoadBalancerHostname:
+		targets = sc.resolveHostnameTargets(ctx, targets, gateway)
+	}
+
+	// Hostname targets are published as a CNAME record, which cannot coexist with A or AAAA records of the same name.
+	if hostnameTargets, ipTargets := splitTargetsByKind(targets); recordType != endpoint.RecordTypeCNAME && len(hostnameTargets) > 0 && len(ipTargets) > 0 {
+		log.Warnf("Ignoring hostname targets %q of gateway %s/%s because they are mixed with IP address targets %q, a name cannot have both a CNAME and A or AAAA records",
+			[]string(hostnameTargets), gateway.Namespace, gateway.Name, []string(ipTargets))
+		targets = ipTargets
+	}
 	ttl := annotations.TTLFromAnnotations(gateway.Annotations, resource)
+	if !ttl.IsConfigured() && targetsFromServices(gateway) {
+		// The TTL annotation of the gateway takes precedence over the one of its selected services.
+		ttl, err = sc.ttlFromServices(gateway)
+		if err != nil {
+			return nil, err
+		}
+	}
 	providerSpecific, setIdentifier := annotations.ProviderSpecificAnnotations(gateway.Annotations)
+	if ingress != nil {
+		// The gateway delegates its targets to the Ingress, so carry over the provider-specific
+		// properties defined there, the ones of the gateway taking precedence.
+		ingressProviderSpecific, ingressSetIdentifier := annotations.ProviderSpecificAnnotations(ingress.Annotations)
+		providerSpecific = mergeProviderSpecific(providerSpecific, ingressProviderSpecific)
+		if setIdentifier == "" {
+			setIdentifier = ingressSetIdentifier
+		}
+	}
 
 	for _, host := range hostnames {
-		endpoints = append(endpoints, EndpointsForHostname(host, targets, ttl, providerSpecific, setIdentifier, resource)...)
+		endpoints = append(endpoints, EndpointsForHostnameWithRecordType(host, targets, recordType, ttl, providerSpecific, setIdentifier, resource)...)
 	}
 
 	return endpoints, nil
 }
 
-func (sc *gatewaySource) hostNamesFromGateway(gateway *networkingv1beta1.Gateway) ([]string, error) {
+// mergeProviderSpecific returns the properties of primary, completed with the properties of
+// secondary whose name is not set in primary.
+func mergeProviderSpecific(primary, secondary endpoint.ProviderSpecific) endpoint.ProviderSpecific {
+	merged := slices.Clone(primary)
+	for _, property := range secondary {
+		if !slices.ContainsFunc(primary, func(p endpoint.ProviderSpecificProperty) bool { return p.Name == property.Name }) {
+			merged = append(merged, property)
+		}
+	}
+	return merged
+}
+
+// resolveHostnameTargets replaces the hostname targets with the IPv4 and IPv6 addresses they resolve to.
+// When a hostname cannot be resolved, the targets are returned unchanged so that a CNAME record is published.
+func (sc *gatewaySource) resolveHostnameTargets(ctx context.Context, targets endpoint.Targets, gateway *networkingv1beta1.Gateway) endpoint.Targets {
+	var resolved endpoint.Targets
+	for _, target := range targets {
+		if suitableType(target) != endpoint.RecordTypeCNAME {
+			resolved = append(resolved, target)
+			continue
+		}
+		addrs, err := sc.lookupNetIP(ctx, "ip", target)
+		if err != nil {
+			log.Warnf("Unable to resolve hostname target %q of gateway %s/%s, falling back to a CNAME record: %v", target, gateway.Namespace, gateway.Name, err)
+			return targets
+		}
+		for _, addr := range addrs {
+			resolved = append(resolved, addr.Unmap().String())
+		}
+	}
+	return uniqueTargets(resolved)
+}
+
+// resolveTargets turns the targets into IP addresses of the given record type, A or AAAA.
+// Hostname targets are resolved, IP addresses of the other family are dropped.
+func (sc *gatewaySource) resolveTargets(ctx context.Context, targets endpoint.Targets, recordType string) endpoint.Targets {
+	network := "ip4"
+	if recordType == endpoint.RecordTypeAAAA {
+		network = "ip6"
+	}
+
+	var resolved endpoint.Targets
+	for _, target := range targets {
+		switch suitableType(target) {
+		case recordType:
+			resolved = append(resolved, target)
+		case endpoint.RecordTypeCNAME:
+			addrs, err := sc.lookupNetIP(ctx, network, target)
+			if err != nil {
+				log.Warnf("Unable to resolve target %q to %s records: %v", target, recordType, err)
+				continue
+			}
+			for _, addr := range addrs {
+				resolved = append(resolved, addr.Unmap().String())
+			}
+		}
+	}
+	return uniqueTargets(resolved)
+}
+
+// canonicalIPTargets rewrites IP address targets in their canonical form, and IPv4-mapped IPv6 addresses
+// as IPv4 addresses, so that every address ends up in a record of its family, A or AAAA, however the status
+// of the service or Ingress spells it. Duplicates are dropped, other targets are left as they are.
+func canonicalIPTargets(targets endpoint.Targets) endpoint.Targets {
+	seen := make(map[string]bool, len(targets))
+	result := make(endpoint.Targets, 0, len(targets))
+	for _, target := range targets {
+		if addr, err := netip.ParseAddr(target); err == nil && addr.Zone() == "" {
+			target = addr.Unmap().String()
+		}
+		if seen[target] {
+			continue
+		}
+		seen[target] = true
+		result = append(result, target)
+	}
+	return result
+}
+
+// splitTargetsByKind splits targets into hostnames, published as CNAME records, and IP addresses.
+func splitTargetsByKind(targets endpoint.Targets) (hostnames, ips endpoint.Targets) {
+	for _, target := range targets {
+		if suitableType(target) == endpoint.RecordTypeCNAME {
+			hostnames = append(hostnames, target)
+		} else {
+			ips = append(ips, target)
+		}
+	}
+	return hostnames, ips
+}
+
+// parseGatewayHost splits a gateway server host of the form [namespace/]hostname.
+// The namespace is "*" for all namespaces, which is also the default, and "." resolves to the gateway namespace.
+func parseGatewayHost(host, gatewayNamespace string) (namespace, hostname string, ok bool) {
+	parts := strings.Split(host, "/")
+	switch {
+	case len(parts) == 1:
+		return "*", host, true
+	case len(parts) != 2 || parts[0] == "" || parts[1] == "":
+		return "", "", false
+	case parts[0] == ".":
+		return gatewayNamespace, parts[1], true
+	default:
+		return parts[0], parts[1], true
+	}
+}
+
+// hostNamesFromGateway returns the hosts of all servers of the gateway, whatever their protocol.
+// When credentialHosts is set, servers without hosts contribute the DNS names of the certificates referenced by their TLS credentials.
+func (sc *gatewaySource) hostNamesFromGateway(ctx context.Context, gateway *networkingv1beta1.Gateway) ([]string, error) {
 	var hostnames []string
+	hasWildcard := false
 	for _, server := range gateway.Spec.Servers {
-		for _, host := range server.Hosts {
+		hosts := server.Hosts
+		if len(hosts) == 0 && server.Tls != nil && sc.credentialHosts {
+			hosts = sc.hostNamesFromCredentials(gateway, server.Tls)
+		}
+
+		for _, host := range hosts {
 			if host == "" {
 				continue
 			}
 
-			parts := strings.Split(host, "/")
+			// If the input hostname is of the form my-namespace/foo.bar.com, ./foo.bar.com or */foo.bar.com,
+			// remove the namespace scope before appending it to the list of endpoints to create
+			namespace, hostname, ok := parseGatewayHost(host, gateway.Namespace)
+			if !ok {
+				log.Debugf("Gateway %s/%s has invalid host %s", gateway.Namespace, gateway.Name, host)
+				continue
+			}
+
+			// Hosts qualified with a namespace are only served to that namespace, skip those the source does not watch.
+			if namespace != "*" && !sc.watchesNamespace(namespace) {
+				log.Debugf("Skipping host %s of gateway %s/%s, namespace %s is not watched", host, gateway.Namespace, gateway.Name, namespace)
+				continue
+			}
 
-			// If the input hostname is of the form my-namespace/foo.bar.com, remove the namespace
-			// before appending it to the list of endpoints to create
-			if len(parts) == 2 {
-				host = parts[1]
+			if hostname == "*" || strings.HasPrefix(hostname, "*.") {
+				hasWildcard = true
 			}
 
-			if host != "*" {
-				hostnames = append(hostnames, host)
+			if hostname != "*" {
+				hostnames = append(hostnames, hostname)
+			}
+		}
+	}
+
+	if sc.virtualServiceHosts && hasWildcard {
+		vsHostnames, err := sc.hostNamesFromVirtualServices(gateway)
+		if err != nil {
+			return nil, err
+		}
+		for _, hostname := range vsHostnames {
+			if !slices.Contains(hostnames, hostname) {
+				hostnames = append(hostnames, hostname)
 			}
 		}
 	}
@@ -323,3 +988,116 @@ func (sc *gatewaySource) hostNamesFromGateway(gateway *networkingv1beta1.Gateway
 
 	return hostnames, nil
 }
+
+// hostNamesFromCredentials returns the DNS names of the certificates referenced by the TLS settings of a server.
+// Istio looks the credentials up in the namespace of the gateway, with the certificate in the tls.crt or cert key.
+// Credentials are read from the Secret caches, those that cannot be found or parsed are skipped.
+func (sc *gatewaySource) hostNamesFromCredentials(gateway *networkingv1beta1.Gateway, tls *istionetworking.ServerTLSSettings) []string {
+	credentialNames := tls.CredentialNames
+	if tls.CredentialName != "" {
+		credentialNames = append([]string{tls.CredentialName}, credentialNames...)
+	}
+
+	var hostnames []string
+	for _, credentialName := range credentialNames {
+		secret, err := sc.getSecret(gateway.Namespace, credentialName)
+		if err != nil {
+			log.Warnf("Skipping credential %s of gateway %s/%s: %v", credentialName, gateway.Namespace, gateway.Name, err)
+			continue
+		}
+
+		certificate := secret.Data[corev1.TLSCertKey]
+		if len(certificate) == 0 {
+			certificate = secret.Data["cert"]
+		}
+		dnsNames, err := certificateDNSNames(certificate)
+		if err != nil {
+			log.Warnf("Skipping credential %s of gateway %s/%s: %v", credentialName, gateway.Namespace, gateway.Name, err)
+			continue
+		}
+		for _, dnsName := range dnsNames {
+			if !slices.Contains(hostnames, dnsName) {
+				hostnames = append(hostnames, dnsName)
+			}
+		}
+	}
+	return hostnames
+}
+
+// getSecret returns a Secret from the cache of the informers watching its namespace.
+func (sc *gatewaySource) getSecret(namespace, name string) (*corev1.Secret, error) {
+	for _, nsInformer := range sc.informers {
+		if nsInformer.secretInformer != nil && (nsInformer.namespace == "" || nsInformer.namespace == namespace) {
+			return nsInformer.secretInformer.Lister().Secrets(namespace).Get(name)
+		}
+	}
+	return nil, fmt.Errorf("secrets of namespace %s are not watched", namespace)
+}
+
+// certificateDNSNames returns the DNS subject alternative names of the first certificate of a PEM bundle,
+// which is the leaf certificate of the chain.
+func certificateDNSNames(data []byte) ([]string, error) {
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			return nil, errors.New("no certificate found")
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		certificate, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		return certificate.DNSNames, nil
+	}
+}
+
+// hostNamesFromVirtualServices returns the concrete hosts of the VirtualServices in the source's namespace(s)
+// that reference the gateway and bind to one of its hosts.
+func (sc *gatewaySource) hostNamesFromVirtualServices(gateway *networkingv1beta1.Gateway) ([]string, error) {
+	var virtualServices []*networkingv1beta1.VirtualService
+	for _, nsInformer := range sc.informers {
+		nsVirtualServices, err := nsInformer.vServiceInformer.Lister().VirtualServices(nsInformer.namespace).List(labels.Everything())
+		if err != nil {
+			return nil, err
+		}
+		virtualServices = append(virtualServices, nsVirtualServices...)
+	}
+
+	var hostnames []string
+	for _, vService := range virtualServices {
+		if !virtualServiceReferencesGateway(vService, gateway) {
+			continue
+		}
+		for _, vsHost := range vService.Spec.Hosts {
+			if vsHost == "" || strings.Contains(vsHost, "*") {
+				continue
+			}
+			if virtualServiceBindsToGateway(vService, gateway, vsHost) {
+				hostnames = append(hostnames, vsHost)
+			}
+		}
+	}
+	return hostnames, nil
+}
+
+// virtualServiceReferencesGateway reports whether the gateway is listed in spec.gateways of the VirtualService.
+// Gateway references without a namespace resolve to the namespace of the VirtualService.
+func virtualServiceReferencesGateway(vService *networkingv1beta1.VirtualService, gateway *networkingv1beta1.Gateway) bool {
+	for _, gatewayStr := range vService.Spec.Gateways {
+		if gatewayStr == "" || gatewayStr == IstioMeshGateway {
+			continue
+		}
+		namespace, name, err := ParseIngress(gatewayStr)
+		if err != nil {
+			log.Debugf("Failed parsing gatewayStr %s of VirtualService %s/%s", gatewayStr, vService.Namespace, vService.Name)
+			continue
+		}
+		if cmp.Or(namespace, vService.Namespace) == gateway.Namespace && name == gateway.Name {
+			return true
+		}
+	}
+	return false
+}
@@ -18,21 +18,40 @@ package source
 
 import (
 	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
 	"errors"
+	"maps"
+	"math/big"
+	"net/netip"
+	"strconv"
+	"sync/atomic"
 	"testing"
+	"time"
 
+	dto "github.com/prometheus/client_model/go"
+	log "github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
 	istionetworking "istio.io/api/networking/v1beta1"
+	networkingv1 "istio.io/client-go/pkg/apis/networking/v1"
 	networkingv1beta1 "istio.io/client-go/pkg/apis/networking/v1beta1"
 	istiofake "istio.io/client-go/pkg/clientset/versioned/fake"
+	istioinformers "istio.io/client-go/pkg/informers/externalversions"
 	v1 "k8s.io/api/core/v1"
 	networkv1 "k8s.io/api/networking/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	fakediscovery "k8s.io/client-go/discovery/fake"
 	"k8s.io/client-go/kubernetes/fake"
 
 	"sigs.k8s.io/external-dns/endpoint"
+	"sigs.k8s.io/external-dns/internal/testutils"
 )
 
 // This is a compile-time validation that gatewaySource is a Source.
@@ -90,16 +109,9 @@ func (suite *GatewaySuite) SetupTest() {
 		suite.NoError(err, "should succeed")
 	}
 
-	suite.source, err = NewIstioGatewaySource(
-		context.TODO(),
-		fakeKubernetesClient,
-		fakeIstioClient,
-		"",
-		"",
-		"{{.Name}}",
-		false,
-		false,
-	)
+	suite.source, err = NewIstioGatewaySource(context.TODO(), fakeKubernetesClient, fakeIstioClient, IstioGatewayOptions{
+		FQDNTemplate: "{{.Name}}",
+	})
 	suite.NoError(err, "should initialize gateway source")
 	suite.NoError(err, "should succeed")
 }
@@ -164,16 +176,11 @@ func TestNewIstioGatewaySource(t *testing.T) {
 		t.Run(ti.title, func(t *testing.T) {
 			t.Parallel()
 
-			_, err := NewIstioGatewaySource(
-				context.TODO(),
-				fake.NewClientset(),
-				istiofake.NewSimpleClientset(),
-				"",
-				ti.annotationFilter,
-				ti.fqdnTemplate,
-				ti.combineFQDNAndAnnotation,
-				false,
-			)
+			_, err := NewIstioGatewaySource(context.TODO(), fake.NewClientset(), istiofake.NewSimpleClientset(), IstioGatewayOptions{
+				AnnotationFilter:      ti.annotationFilter,
+				FQDNTemplate:          ti.fqdnTemplate,
+				CombineFQDNAnnotation: ti.combineFQDNAndAnnotation,
+			})
 			if ti.expectError {
 				assert.Error(t, err)
 			} else {
@@ -233,6 +240,66 @@ func testEndpointsFromGatewayConfig(t *testing.T) {
 				},
 			},
 		},
+		{
+			title: "one current namespace rule.host one lb.hostname",
+			lbServices: []fakeIngressGatewayService{
+				{
+					hostnames: []string{"lb.com"},
+				},
+			},
+			config: fakeGatewayConfig{
+				dnsnames: [][]string{
+					{"./foo.com"},
+				},
+			},
+			expected: []*endpoint.Endpoint{
+				{
+					DNSName:    "foo.com",
+					RecordType: endpoint.RecordTypeCNAME,
+					Targets:    endpoint.Targets{"lb.com"},
+				},
+			},
+		},
+		{
+			title: "one all namespaces rule.host one lb.hostname",
+			lbServices: []fakeIngressGatewayService{
+				{
+					hostnames: []string{"lb.com"},
+				},
+			},
+			config: fakeGatewayConfig{
+				dnsnames: [][]string{
+					{"*/foo.com"},
+				},
+			},
+			expected: []*endpoint.Endpoint{
+				{
+					DNSName:    "foo.com",
+					RecordType: endpoint.RecordTypeCNAME,
+					Targets:    endpoint.Targets{"lb.com"},
+				},
+			},
+		},
+		{
+			title: "one prod namespace rule.host one lb.hostname",
+			lbServices: []fakeIngressGatewayService{
+				{
+					hostnames: []string{"lb.com"},
+				},
+			},
+			config: fakeGatewayConfig{
+				dnsnames: [][]string{
+					{"prod/foo.com"},
+				},
+			},
+			expected: []*endpoint.Endpoint{
+				{
+					DNSName:    "foo.com",
+					RecordType: endpoint.RecordTypeCNAME,
+					Targets:    endpoint.Targets{"lb.com"},
+				},
+			},
+		},
 		{
 			title: "one rule.host one lb.IP",
 			lbServices: []fakeIngressGatewayService{
@@ -296,11 +363,6 @@ func testEndpointsFromGatewayConfig(t *testing.T) {
 					RecordType: endpoint.RecordTypeA,
 					Targets:    endpoint.Targets{"8.8.8.8", "127.0.0.1"},
 				},
-				{
-					DNSName:    "foo.bar",
-					RecordType: endpoint.RecordTypeCNAME,
-					Targets:    endpoint.Targets{"elb.com", "alb.com"},
-				},
 			},
 		},
 		{
@@ -326,11 +388,6 @@ func testEndpointsFromGatewayConfig(t *testing.T) {
 					RecordType: endpoint.RecordTypeA,
 					Targets:    endpoint.Targets{"8.8.8.8", "127.0.0.1"},
 				},
-				{
-					DNSName:    "foo.bar",
-					RecordType: endpoint.RecordTypeCNAME,
-					Targets:    endpoint.Targets{"elb.com", "alb.com"},
-				},
 			},
 		},
 		{
@@ -499,7 +556,7 @@ func testEndpointsFromGatewayConfig(t *testing.T) {
 			gatewayCfg := ti.config.Config()
 			if source, err := newTestGatewaySource(ti.lbServices, ti.ingresses); err != nil {
 				require.NoError(t, err)
-			} else if hostnames, err := source.hostNamesFromGateway(gatewayCfg); err != nil {
+			} else if hostnames, err := source.hostNamesFromGateway(context.Background(), gatewayCfg); err != nil {
 				require.NoError(t, err)
 			} else if endpoints, err := source.endpointsFromGateway(context.Background(), hostnames, gatewayCfg); err != nil {
 				require.NoError(t, err)
@@ -525,6 +582,7 @@ func testGatewayEndpoints(t *testing.T) {
 		fqdnTemplate             string
 		combineFQDNAndAnnotation bool
 		ignoreHostnameAnnotation bool
+		gatewayLabelSelector     labels.Selector
 	}{
 		{
 			title:           "no gateway",
@@ -557,20 +615,76 @@ func testGatewayEndpoints(t *testing.T) {
 					RecordType: endpoint.RecordTypeA,
 					Targets:    endpoint.Targets{"8.8.8.8"},
 				},
+				{
+					DNSName:    "new.org",
+					RecordType: endpoint.RecordTypeA,
+					Targets:    endpoint.Targets{"8.8.8.8"},
+				},
+			},
+		},
+		{
+			title:           "two gateways with the same host, one ingressgateway loadbalancer service",
+			targetNamespace: "",
+			lbServices: []fakeIngressGatewayService{
+				{
+					ips:       []string{"8.8.8.8"},
+					hostnames: []string{"lb.com"},
+				},
+			},
+			configItems: []fakeGatewayConfig{
+				{
+					name:      "fake1",
+					namespace: "",
+					dnsnames:  [][]string{{"example.org"}},
+				},
+				{
+					name:      "fake2",
+					namespace: "",
+					dnsnames:  [][]string{{"example.org"}},
+				},
+			},
+			expected: []*endpoint.Endpoint{
 				{
 					DNSName:    "example.org",
-					RecordType: endpoint.RecordTypeCNAME,
-					Targets:    endpoint.Targets{"lb.com"},
+					RecordType: endpoint.RecordTypeA,
+					Targets:    endpoint.Targets{"8.8.8.8"},
+				},
+			},
+		},
+		{
+			title:           "two gateways with the same host and different ttl, one ingressgateway loadbalancer service",
+			targetNamespace: "",
+			lbServices: []fakeIngressGatewayService{
+				{
+					ips: []string{"8.8.8.8"},
 				},
+			},
+			configItems: []fakeGatewayConfig{
 				{
-					DNSName:    "new.org",
+					name:      "fake1",
+					namespace: "",
+					dnsnames:  [][]string{{"example.org"}},
+				},
+				{
+					name:      "fake2",
+					namespace: "",
+					annotations: map[string]string{
+						ttlAnnotationKey: "60",
+					},
+					dnsnames: [][]string{{"example.org"}},
+				},
+			},
+			expected: []*endpoint.Endpoint{
+				{
+					DNSName:    "example.org",
 					RecordType: endpoint.RecordTypeA,
 					Targets:    endpoint.Targets{"8.8.8.8"},
 				},
 				{
-					DNSName:    "new.org",
-					RecordType: endpoint.RecordTypeCNAME,
-					Targets:    endpoint.Targets{"lb.com"},
+					DNSName:    "example.org",
+					RecordType: endpoint.RecordTypeA,
+					RecordTTL:  endpoint.TTL(60),
+					Targets:    endpoint.Targets{"8.8.8.8"},
 				},
 			},
 		},
@@ -601,21 +715,11 @@ func testGatewayEndpoints(t *testing.T) {
 					RecordType: endpoint.RecordTypeA,
 					Targets:    endpoint.Targets{"8.8.8.8"},
 				},
-				{
-					DNSName:    "example.org",
-					RecordType: endpoint.RecordTypeCNAME,
-					Targets:    endpoint.Targets{"lb.com"},
-				},
 				{
 					DNSName:    "new.org",
 					RecordType: endpoint.RecordTypeA,
 					Targets:    endpoint.Targets{"8.8.8.8"},
 				},
-				{
-					DNSName:    "new.org",
-					RecordType: endpoint.RecordTypeCNAME,
-					Targets:    endpoint.Targets{"lb.com"},
-				},
 			},
 		},
 		{
@@ -641,11 +745,6 @@ func testGatewayEndpoints(t *testing.T) {
 					RecordType: endpoint.RecordTypeA,
 					Targets:    endpoint.Targets{"8.8.8.8"},
 				},
-				{
-					DNSName:    "example.org",
-					RecordType: endpoint.RecordTypeCNAME,
-					Targets:    endpoint.Targets{"lb.com"},
-				},
 			},
 		},
 		{
@@ -675,11 +774,6 @@ func testGatewayEndpoints(t *testing.T) {
 					RecordType: endpoint.RecordTypeA,
 					Targets:    endpoint.Targets{"8.8.8.8"},
 				},
-				{
-					DNSName:    "example.org",
-					RecordType: endpoint.RecordTypeCNAME,
-					Targets:    endpoint.Targets{"lb.com"},
-				},
 			},
 		},
 		{
@@ -800,6 +894,71 @@ func testGatewayEndpoints(t *testing.T) {
 			},
 			expected: []*endpoint.Endpoint{},
 		},
+		{
+			title:                "valid matching label selector",
+			gatewayLabelSelector: labels.SelectorFromSet(labels.Set{"app": "web-external"}),
+			lbServices: []fakeIngressGatewayService{
+				{
+					ips: []string{"8.8.8.8"},
+				},
+			},
+			configItems: []fakeGatewayConfig{
+				{
+					name:     "fake1",
+					labels:   map[string]string{"app": "web-external", "name": "reverse-proxy"},
+					dnsnames: [][]string{{"example.org"}},
+				},
+				{
+					name:     "fake2",
+					labels:   map[string]string{"app": "web-internal", "name": "reverse-proxy"},
+					dnsnames: [][]string{{"new.org"}},
+				},
+			},
+			expected: []*endpoint.Endpoint{
+				{
+					DNSName:    "example.org",
+					RecordType: endpoint.RecordTypeA,
+					Targets:    endpoint.Targets{"8.8.8.8"},
+				},
+			},
+		},
+		{
+			title:                "label selector and annotation filter must both match",
+			annotationFilter:     "kubernetes.io/gateway.class=nginx",
+			gatewayLabelSelector: labels.SelectorFromSet(labels.Set{"app": "web-external"}),
+			lbServices: []fakeIngressGatewayService{
+				{
+					ips: []string{"8.8.8.8"},
+				},
+			},
+			configItems: []fakeGatewayConfig{
+				{
+					name:        "fake1",
+					labels:      map[string]string{"app": "web-external"},
+					annotations: map[string]string{"kubernetes.io/gateway.class": "nginx"},
+					dnsnames:    [][]string{{"example.org"}},
+				},
+				{
+					name:        "fake2",
+					labels:      map[string]string{"app": "web-external"},
+					annotations: map[string]string{"kubernetes.io/gateway.class": "alb"},
+					dnsnames:    [][]string{{"alb.org"}},
+				},
+				{
+					name:        "fake3",
+					labels:      map[string]string{"app": "web-internal"},
+					annotations: map[string]string{"kubernetes.io/gateway.class": "nginx"},
+					dnsnames:    [][]string{{"internal.org"}},
+				},
+			},
+			expected: []*endpoint.Endpoint{
+				{
+					DNSName:    "example.org",
+					RecordType: endpoint.RecordTypeA,
+					Targets:    endpoint.Targets{"8.8.8.8"},
+				},
+			},
+		},
 		{
 			title:           "our controller type is dns-controller",
 			targetNamespace: "",
@@ -846,6 +1005,62 @@ func testGatewayEndpoints(t *testing.T) {
 			},
 			expected: []*endpoint.Endpoint{},
 		},
+		{
+			title:           "excluded gateways are ignored",
+			targetNamespace: "",
+			lbServices: []fakeIngressGatewayService{
+				{
+					ips: []string{"8.8.8.8"},
+				},
+			},
+			configItems: []fakeGatewayConfig{
+				{
+					name:      "fake1",
+					namespace: "",
+					annotations: map[string]string{
+						excludeAnnotationKey:    "true",
+						controllerAnnotationKey: controllerAnnotationValue,
+					},
+					dnsnames: [][]string{{"example.org"}},
+				},
+				{
+					name:      "fake2",
+					namespace: "",
+					annotations: map[string]string{
+						excludeAnnotationKey: "false",
+					},
+					dnsnames: [][]string{{"new.org"}},
+				},
+			},
+			expected: []*endpoint.Endpoint{
+				{
+					DNSName:    "new.org",
+					RecordType: endpoint.RecordTypeA,
+					Targets:    endpoint.Targets{"8.8.8.8"},
+				},
+			},
+		},
+		{
+			title:           "excluded gateways are ignored even with template",
+			targetNamespace: "",
+			lbServices: []fakeIngressGatewayService{
+				{
+					ips: []string{"8.8.8.8"},
+				},
+			},
+			configItems: []fakeGatewayConfig{
+				{
+					name:      "fake1",
+					namespace: "",
+					annotations: map[string]string{
+						excludeAnnotationKey: "true",
+					},
+					dnsnames: [][]string{},
+				},
+			},
+			expected:     []*endpoint.Endpoint{},
+			fqdnTemplate: "{{.Name}}.ext-dns.test.com",
+		},
 		{
 			title:           "template for gateway if host is missing",
 			targetNamespace: "",
@@ -871,11 +1086,6 @@ func testGatewayEndpoints(t *testing.T) {
 					RecordType: endpoint.RecordTypeA,
 					Targets:    endpoint.Targets{"8.8.8.8"},
 				},
-				{
-					DNSName:    "fake1.ext-dns.test.com",
-					RecordType: endpoint.RecordTypeCNAME,
-					Targets:    endpoint.Targets{"elb.com"},
-				},
 			},
 			fqdnTemplate: "{{.Name}}.ext-dns.test.com",
 		},
@@ -1371,21 +1581,11 @@ func testGatewayEndpoints(t *testing.T) {
 					RecordType: endpoint.RecordTypeA,
 					Targets:    endpoint.Targets{"8.8.8.8"},
 				},
-				{
-					DNSName:    "example.org",
-					RecordType: endpoint.RecordTypeCNAME,
-					Targets:    endpoint.Targets{"lb.com"},
-				},
 				{
 					DNSName:    "new.org",
 					RecordType: endpoint.RecordTypeA,
 					Targets:    endpoint.Targets{"8.8.8.8"},
 				},
-				{
-					DNSName:    "new.org",
-					RecordType: endpoint.RecordTypeCNAME,
-					Targets:    endpoint.Targets{"lb.com"},
-				},
 			},
 			ignoreHostnameAnnotation: true,
 		},
@@ -1498,16 +1698,14 @@ func testGatewayEndpoints(t *testing.T) {
 				require.NoError(t, err)
 			}
 
-			gatewaySource, err := NewIstioGatewaySource(
-				context.TODO(),
-				fakeKubernetesClient,
-				fakeIstioClient,
-				ti.targetNamespace,
-				ti.annotationFilter,
-				ti.fqdnTemplate,
-				ti.combineFQDNAndAnnotation,
-				ti.ignoreHostnameAnnotation,
-			)
+			gatewaySource, err := NewIstioGatewaySource(context.TODO(), fakeKubernetesClient, fakeIstioClient, IstioGatewayOptions{
+				Namespaces:               []string{ti.targetNamespace},
+				AnnotationFilter:         ti.annotationFilter,
+				FQDNTemplate:             ti.fqdnTemplate,
+				CombineFQDNAnnotation:    ti.combineFQDNAndAnnotation,
+				IgnoreHostnameAnnotation: ti.ignoreHostnameAnnotation,
+				LabelSelector:            ti.gatewayLabelSelector,
+			})
 			require.NoError(t, err)
 
 			res, err := gatewaySource.Endpoints(context.Background())
@@ -1590,16 +1788,7 @@ func TestGatewaySource_GWSelectorMatchServiceSelector(t *testing.T) {
 			fakeKubeClient := fake.NewClientset()
 			fakeIstioClient := istiofake.NewSimpleClientset()
 
-			src, err := NewIstioGatewaySource(
-				t.Context(),
-				fakeKubeClient,
-				fakeIstioClient,
-				"",
-				"",
-				"",
-				false,
-				false,
-			)
+			src, err := NewIstioGatewaySource(t.Context(), fakeKubeClient, fakeIstioClient, IstioGatewayOptions{})
 			require.NoError(t, err)
 			require.NotNil(t, src)
 
@@ -1632,9 +1821,1326 @@ func TestGatewaySource_GWSelectorMatchServiceSelector(t *testing.T) {
 	}
 }
 
-// gateway specific helper functions
-func newTestGatewaySource(loadBalancerList []fakeIngressGatewayService, ingressList []fakeIngress) (*gatewaySource, error) {
-	fakeKubernetesClient := fake.NewClientset()
+func TestGatewaySourceNetworkingV1(t *testing.T) {
+	for _, tt := range []struct {
+		title     string
+		servesV1  bool
+		gatewayV1 bool
+	}{
+		{
+			title:     "v1 Gateways when the cluster serves networking.istio.io/v1",
+			servesV1:  true,
+			gatewayV1: true,
+		},
+		{
+			title: "v1beta1 Gateways when the cluster does not serve networking.istio.io/v1",
+		},
+	} {
+		t.Run(tt.title, func(t *testing.T) {
+			fakeKubernetesClient := fake.NewClientset()
+			fakeIstioClient := istiofake.NewSimpleClientset()
+			if tt.servesV1 {
+				fakeIstioClient.Discovery().(*fakediscovery.FakeDiscovery).Resources = []*metav1.APIResourceList{
+					{
+						GroupVersion: networkingv1.SchemeGroupVersion.String(),
+						APIResources: []metav1.APIResource{{Name: "gateways", Kind: "Gateway", Namespaced: true}},
+					},
+				}
+			}
+
+			service := fakeIngressGatewayService{
+				namespace: "istio-system",
+				name:      "istio-ingressgateway",
+				ips:       []string{"8.8.8.8"},
+				selector:  map[string]string{"istio": "ingressgateway"},
+			}.Service()
+			_, err := fakeKubernetesClient.CoreV1().Services(service.Namespace).Create(context.Background(), service, metav1.CreateOptions{})
+			require.NoError(t, err)
+
+			gateway := fakeGatewayConfig{
+				namespace: "istio-system",
+				name:      "foo",
+				dnsnames:  [][]string{{"foo.example.org"}},
+				selector:  map[string]string{"istio": "ingressgateway"},
+			}.Config()
+			if tt.gatewayV1 {
+				_, err = fakeIstioClient.NetworkingV1().Gateways(gateway.Namespace).Create(context.Background(), (*networkingv1.Gateway)(gateway), metav1.CreateOptions{})
+			} else {
+				_, err = fakeIstioClient.NetworkingV1beta1().Gateways(gateway.Namespace).Create(context.Background(), gateway, metav1.CreateOptions{})
+			}
+			require.NoError(t, err)
+
+			src, err := NewIstioGatewaySource(context.TODO(), fakeKubernetesClient, fakeIstioClient, IstioGatewayOptions{})
+			require.NoError(t, err)
+			assert.Equal(t, tt.gatewayV1, src.(*gatewaySource).gatewayV1)
+
+			endpoints, err := src.Endpoints(context.Background())
+			require.NoError(t, err)
+			validateEndpoints(t, endpoints, []*endpoint.Endpoint{
+				endpoint.NewEndpoint("foo.example.org", endpoint.RecordTypeA, "8.8.8.8").
+					WithLabel(endpoint.ResourceLabelKey, "gateway/istio-system/foo"),
+			})
+		})
+	}
+}
+
+func TestParseGatewayHost(t *testing.T) {
+	for _, tt := range []struct {
+		host      string
+		namespace string
+		hostname  string
+		ok        bool
+	}{
+		{host: "foo.bar.com", namespace: "*", hostname: "foo.bar.com", ok: true},
+		{host: "*/foo.bar.com", namespace: "*", hostname: "foo.bar.com", ok: true},
+		{host: "./foo.bar.com", namespace: "istio-system", hostname: "foo.bar.com", ok: true},
+		{host: "my-namespace/foo.bar.com", namespace: "my-namespace", hostname: "foo.bar.com", ok: true},
+		{host: "prod/foo.com", namespace: "prod", hostname: "foo.com", ok: true},
+		{host: "./*", namespace: "istio-system", hostname: "*", ok: true},
+		{host: "a/b/foo.bar.com"},
+		{host: "/foo.bar.com"},
+		{host: "my-namespace/"},
+	} {
+		t.Run(tt.host, func(t *testing.T) {
+			namespace, hostname, ok := parseGatewayHost(tt.host, "istio-system")
+			assert.Equal(t, tt.ok, ok)
+			assert.Equal(t, tt.namespace, namespace)
+			assert.Equal(t, tt.hostname, hostname)
+		})
+	}
+}
+
+func TestGatewaySourceHostNamespaceQualifiers(t *testing.T) {
+	gateway := fakeGatewayConfig{
+		namespace: "team-a",
+		name:      "foo",
+		dnsnames: [][]string{{
+			"plain.example.org",
+			"*/any.example.org",
+			"./own.example.org",
+			"team-a/team-a.example.org",
+			"team-b/team-b.example.org",
+			"a/b/invalid.example.org",
+		}},
+	}.Config()
+
+	for _, tt := range []struct {
+		title      string
+		namespaces []string
+		expected   []string
+	}{
+		{
+			title:    "all namespaces watched",
+			expected: []string{"plain.example.org", "any.example.org", "own.example.org", "team-a.example.org", "team-b.example.org"},
+		},
+		{
+			title:      "gateway namespace watched",
+			namespaces: []string{"team-a"},
+			expected:   []string{"plain.example.org", "any.example.org", "own.example.org", "team-a.example.org"},
+		},
+		{
+			title:      "several namespaces watched",
+			namespaces: []string{"team-a", "team-b"},
+			expected:   []string{"plain.example.org", "any.example.org", "own.example.org", "team-a.example.org", "team-b.example.org"},
+		},
+	} {
+		t.Run(tt.title, func(t *testing.T) {
+			sc := &gatewaySource{namespaces: tt.namespaces, ignoreHostnameAnnotation: true}
+			hostnames, err := sc.hostNamesFromGateway(context.Background(), gateway)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, hostnames)
+		})
+	}
+}
+
+func TestGatewaySourceVirtualServiceHosts(t *testing.T) {
+	for _, tt := range []struct {
+		title               string
+		namespaces          []string
+		virtualServiceHosts bool
+		expected            []string
+	}{
+		{
+			title:    "virtual service hosts are not published by default",
+			expected: []string{"*.example.org", "static.example.org"},
+		},
+		{
+			title:               "hosts of bound virtual services are published for wildcard hosts",
+			virtualServiceHosts: true,
+			expected:            []string{"*.example.org", "app.example.org", "exported.example.org", "static.example.org"},
+		},
+		{
+			title:               "virtual services outside the watched namespace are ignored",
+			namespaces:          []string{"istio-system"},
+			virtualServiceHosts: true,
+			expected:            []string{"*.example.org", "exported.example.org", "static.example.org"},
+		},
+	} {
+		t.Run(tt.title, func(t *testing.T) {
+			fakeKubernetesClient := fake.NewClientset()
+			fakeIstioClient := istiofake.NewSimpleClientset()
+
+			service := fakeIngressGatewayService{
+				namespace: "istio-system",
+				name:      "istio-ingressgateway",
+				ips:       []string{"8.8.8.8"},
+				selector:  map[string]string{"istio": "ingressgateway"},
+			}.Service()
+			_, err := fakeKubernetesClient.CoreV1().Services(service.Namespace).Create(context.Background(), service, metav1.CreateOptions{})
+			require.NoError(t, err)
+
+			gateway := fakeGatewayConfig{
+				namespace: "istio-system",
+				name:      "foo",
+				dnsnames:  [][]string{{"*.example.org"}, {"static.example.org"}},
+				selector:  map[string]string{"istio": "ingressgateway"},
+			}.Config()
+			_, err = fakeIstioClient.NetworkingV1beta1().Gateways(gateway.Namespace).Create(context.Background(), gateway, metav1.CreateOptions{})
+			require.NoError(t, err)
+
+			for _, config := range []fakeVirtualServiceConfig{
+				{
+					namespace: "app",
+					name:      "bound",
+					gateways:  []string{"istio-system/foo", IstioMeshGateway},
+					dnsnames:  []string{"app.example.org", "app.example.com", "*.example.org", "static.example.org"},
+				},
+				{
+					namespace: "istio-system",
+					name:      "local",
+					gateways:  []string{"foo"},
+					dnsnames:  []string{"exported.example.org"},
+				},
+				{
+					namespace: "app",
+					name:      "other-gateway",
+					gateways:  []string{"other"},
+					dnsnames:  []string{"other.example.org"},
+				},
+				{
+					namespace: "app",
+					name:      "private",
+					gateways:  []string{"istio-system/foo"},
+					dnsnames:  []string{"private.example.org"},
+					exportTo:  ".",
+				},
+			} {
+				vService := config.Config()
+				_, err = fakeIstioClient.NetworkingV1beta1().VirtualServices(vService.Namespace).Create(context.Background(), vService, metav1.CreateOptions{})
+				require.NoError(t, err)
+			}
+
+			src, err := NewIstioGatewaySource(context.TODO(), fakeKubernetesClient, fakeIstioClient, IstioGatewayOptions{
+				Namespaces:          tt.namespaces,
+				VirtualServiceHosts: tt.virtualServiceHosts,
+			})
+			require.NoError(t, err)
+
+			endpoints, err := src.Endpoints(context.Background())
+			require.NoError(t, err)
+
+			var expected []*endpoint.Endpoint
+			for _, host := range tt.expected {
+				expected = append(expected, endpoint.NewEndpoint(host, endpoint.RecordTypeA, "8.8.8.8").
+					WithLabel(endpoint.ResourceLabelKey, "gateway/istio-system/foo"))
+			}
+			validateEndpoints(t, endpoints, expected)
+		})
+	}
+}
+
+func TestGatewaySourceTargetsFromIngress(t *testing.T) {
+	ingress := &networkv1.Ingress{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "istio-system", Name: "ingress1"},
+		Status: networkv1.IngressStatus{
+			LoadBalancer: networkv1.IngressLoadBalancerStatus{
+				Ingress: []networkv1.IngressLoadBalancerIngress{
+					{IP: "8.8.8.8", Hostname: "lb.example.com"},
+					{IP: "2001:db8::1", Hostname: "lb.example.com"},
+					{IP: "8.8.8.8"},
+				},
+			},
+		},
+	}
+	kubeClient := fake.NewClientset(ingress)
+	sc := &gatewaySource{kubeClient: kubeClient}
+	gateway := &networkingv1beta1.Gateway{ObjectMeta: metav1.ObjectMeta{
+		Namespace:   "istio-system",
+		Name:        "foo",
+		Annotations: map[string]string{IstioGatewayIngressSource: "ingress1"},
+	}}
+
+	targets, delegated, err := sc.targetsFromGateway(context.Background(), gateway)
+	require.NoError(t, err)
+	assert.Equal(t, endpoint.Targets{"2001:db8::1", "8.8.8.8", "lb.example.com"}, targets)
+	assert.Equal(t, "ingress1", delegated.Name)
+}
+
+func TestGatewaySourceProviderSpecificFromIngress(t *testing.T) {
+	for _, tt := range []struct {
+		title                 string
+		gatewayAnnotations    map[string]string
+		ingressAnnotations    map[string]string
+		expectedProviders     endpoint.ProviderSpecific
+		expectedSetIdentifier string
+	}{
+		{
+			title: "properties of the ingress are carried over",
+			gatewayAnnotations: map[string]string{
+				IstioGatewayIngressSource: "ingress1",
+			},
+			ingressAnnotations: map[string]string{
+				"external-dns.alpha.kubernetes.io/aws-weight":     "10",
+				"external-dns.alpha.kubernetes.io/set-identifier": "eu-west",
+			},
+			expectedProviders:     endpoint.ProviderSpecific{{Name: "aws/weight", Value: "10"}},
+			expectedSetIdentifier: "eu-west",
+		},
+		{
+			title: "properties of the gateway take precedence",
+			gatewayAnnotations: map[string]string{
+				IstioGatewayIngressSource:                         "ingress1",
+				"external-dns.alpha.kubernetes.io/aws-weight":     "20",
+				"external-dns.alpha.kubernetes.io/set-identifier": "us-east",
+			},
+			ingressAnnotations: map[string]string{
+				"external-dns.alpha.kubernetes.io/aws-weight":          "10",
+				"external-dns.alpha.kubernetes.io/aws-health-check-id": "abc",
+				"external-dns.alpha.kubernetes.io/set-identifier":      "eu-west",
+			},
+			expectedProviders: endpoint.ProviderSpecific{
+				{Name: "aws/weight", Value: "20"},
+				{Name: "aws/health-check-id", Value: "abc"},
+			},
+			expectedSetIdentifier: "us-east",
+		},
+		{
+			title: "ingress is ignored with a target annotation",
+			gatewayAnnotations: map[string]string{
+				IstioGatewayIngressSource: "ingress1",
+				targetAnnotationKey:       "9.9.9.9",
+			},
+			ingressAnnotations: map[string]string{
+				"external-dns.alpha.kubernetes.io/aws-weight":     "10",
+				"external-dns.alpha.kubernetes.io/set-identifier": "eu-west",
+			},
+			expectedProviders: endpoint.ProviderSpecific{},
+		},
+	} {
+		t.Run(tt.title, func(t *testing.T) {
+			ingress := fakeIngress{namespace: "istio-system", name: "ingress1", ips: []string{"8.8.8.8"}, annotations: tt.ingressAnnotations}.Ingress()
+			sc := &gatewaySource{kubeClient: fake.NewClientset(ingress)}
+			gateway := fakeGatewayConfig{namespace: "istio-system", name: "foo", annotations: tt.gatewayAnnotations}.Config()
+
+			endpoints, err := sc.endpointsFromGateway(context.Background(), []string{"foo.example.org"}, gateway)
+			require.NoError(t, err)
+			require.Len(t, endpoints, 1)
+			assert.ElementsMatch(t, tt.expectedProviders, endpoints[0].ProviderSpecific)
+			assert.Equal(t, tt.expectedSetIdentifier, endpoints[0].SetIdentifier)
+		})
+	}
+}
+
+func TestGatewaySourceMultipleNamespaces(t *testing.T) {
+	fakeKubernetesClient := fake.NewClientset()
+	fakeIstioClient := istiofake.NewSimpleClientset()
+
+	for _, cfg := range []struct {
+		namespace string
+		ip        string
+		host      string
+	}{
+		{namespace: "team-a", ip: "1.1.1.1", host: "a.example.org"},
+		{namespace: "team-b", ip: "2.2.2.2", host: "b.example.org"},
+		{namespace: "team-c", ip: "3.3.3.3", host: "c.example.org"},
+	} {
+		service := fakeIngressGatewayService{
+			namespace: cfg.namespace,
+			name:      "istio-ingressgateway",
+			ips:       []string{cfg.ip},
+			selector:  map[string]string{"istio": cfg.namespace},
+		}.Service()
+		_, err := fakeKubernetesClient.CoreV1().Services(service.Namespace).Create(context.Background(), service, metav1.CreateOptions{})
+		require.NoError(t, err)
+
+		gateway := fakeGatewayConfig{
+			namespace: cfg.namespace,
+			name:      "foo",
+			dnsnames:  [][]string{{cfg.host}},
+			selector:  map[string]string{"istio": cfg.namespace},
+		}.Config()
+		_, err = fakeIstioClient.NetworkingV1beta1().Gateways(gateway.Namespace).Create(context.Background(), gateway, metav1.CreateOptions{})
+		require.NoError(t, err)
+	}
+
+	src, err := NewIstioGatewaySource(t.Context(), fakeKubernetesClient, fakeIstioClient, IstioGatewayOptions{
+		Namespaces: []string{"team-a", "team-b", "team-a"},
+	})
+	require.NoError(t, err)
+	gwsrc := src.(*gatewaySource)
+	assert.Equal(t, []string{"team-a", "team-b"}, gwsrc.namespaces)
+	assert.Len(t, gwsrc.informers, 2)
+
+	endpoints, err := src.Endpoints(context.Background())
+	require.NoError(t, err)
+	validateEndpoints(t, endpoints, []*endpoint.Endpoint{
+		endpoint.NewEndpoint("a.example.org", endpoint.RecordTypeA, "1.1.1.1").
+			WithLabel(endpoint.ResourceLabelKey, "gateway/team-a/foo"),
+		endpoint.NewEndpoint("b.example.org", endpoint.RecordTypeA, "2.2.2.2").
+			WithLabel(endpoint.ResourceLabelKey, "gateway/team-b/foo"),
+	})
+
+	// Handlers are notified of the existing gateways of every watched namespace, then of new ones.
+	var counter atomic.Int32
+	src.AddEventHandler(t.Context(), func() {
+		counter.Add(1)
+	})
+	require.Eventually(t, func() bool {
+		return counter.Load() == 2
+	}, time.Second, 10*time.Millisecond)
+
+	gateway := fakeGatewayConfig{namespace: "team-b", name: "bar", dnsnames: [][]string{{"bar.example.org"}}}.Config()
+	_, err = fakeIstioClient.NetworkingV1beta1().Gateways(gateway.Namespace).Create(context.Background(), gateway, metav1.CreateOptions{})
+	require.NoError(t, err)
+	require.Eventually(t, func() bool {
+		return counter.Load() == 3
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestGatewaySourceTerminatingNamespace(t *testing.T) {
+	fakeKubernetesClient := fake.NewClientset()
+	fakeIstioClient := istiofake.NewSimpleClientset()
+
+	for _, ns := range []*v1.Namespace{
+		{ObjectMeta: metav1.ObjectMeta{Name: "active"}},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "terminating", DeletionTimestamp: &metav1.Time{Time: time.Now()}},
+			Status:     v1.NamespaceStatus{Phase: v1.NamespaceTerminating},
+		},
+	} {
+		_, err := fakeKubernetesClient.CoreV1().Namespaces().Create(context.Background(), ns, metav1.CreateOptions{})
+		require.NoError(t, err)
+	}
+
+	service := fakeIngressGatewayService{
+		namespace: "active",
+		name:      "istio-ingressgateway",
+		ips:       []string{"1.1.1.1"},
+		selector:  map[string]string{"istio": "ingressgateway"},
+	}.Service()
+	_, err := fakeKubernetesClient.CoreV1().Services(service.Namespace).Create(context.Background(), service, metav1.CreateOptions{})
+	require.NoError(t, err)
+
+	for _, cfg := range []struct {
+		namespace string
+		host      string
+	}{
+		{namespace: "active", host: "active.example.org"},
+		{namespace: "terminating", host: "terminating.example.org"},
+		// Gateways of namespaces that cannot be looked up are kept.
+		{namespace: "unknown", host: "unknown.example.org"},
+	} {
+		gateway := fakeGatewayConfig{
+			namespace: cfg.namespace,
+			name:      "foo",
+			dnsnames:  [][]string{{cfg.host}},
+			selector:  map[string]string{"istio": "ingressgateway"},
+		}.Config()
+		_, err = fakeIstioClient.NetworkingV1beta1().Gateways(gateway.Namespace).Create(context.Background(), gateway, metav1.CreateOptions{})
+		require.NoError(t, err)
+	}
+
+	src, err := NewIstioGatewaySource(t.Context(), fakeKubernetesClient, fakeIstioClient, IstioGatewayOptions{})
+	require.NoError(t, err)
+
+	endpoints, err := src.Endpoints(context.Background())
+	require.NoError(t, err)
+	validateEndpoints(t, endpoints, []*endpoint.Endpoint{
+		endpoint.NewEndpoint("active.example.org", endpoint.RecordTypeA, "1.1.1.1").
+			WithLabel(endpoint.ResourceLabelKey, "gateway/active/foo"),
+		endpoint.NewEndpoint("unknown.example.org", endpoint.RecordTypeA, "1.1.1.1").
+			WithLabel(endpoint.ResourceLabelKey, "gateway/unknown/foo"),
+	})
+
+	// Namespaces deleted once the source is running are picked up from the informer.
+	ns, err := fakeKubernetesClient.CoreV1().Namespaces().Get(context.Background(), "active", metav1.GetOptions{})
+	require.NoError(t, err)
+	ns.Status.Phase = v1.NamespaceTerminating
+	_, err = fakeKubernetesClient.CoreV1().Namespaces().Update(context.Background(), ns, metav1.UpdateOptions{})
+	require.NoError(t, err)
+	require.Eventually(t, func() bool {
+		endpoints, err := src.Endpoints(context.Background())
+		return err == nil && len(endpoints) == 1 && endpoints[0].DNSName == "unknown.example.org"
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestNormalizeGatewayNamespaces(t *testing.T) {
+	assert.Nil(t, normalizeGatewayNamespaces(nil))
+	assert.Nil(t, normalizeGatewayNamespaces([]string{""}))
+	assert.Nil(t, normalizeGatewayNamespaces([]string{"team-a", ""}))
+	assert.Equal(t, []string{"team-a", "team-b"}, normalizeGatewayNamespaces([]string{"team-a", " team-b ", "team-a"}))
+}
+
+func TestGatewaySourceServerHosts(t *testing.T) {
+	fakeKubernetesClient := fake.NewClientset()
+	fakeIstioClient := istiofake.NewSimpleClientset()
+
+	service := fakeIngressGatewayService{
+		namespace: "istio-system",
+		name:      "istio-ingressgateway",
+		ips:       []string{"8.8.8.8"},
+		selector:  map[string]string{"istio": "ingressgateway"},
+	}.Service()
+	_, err := fakeKubernetesClient.CoreV1().Services(service.Namespace).Create(context.Background(), service, metav1.CreateOptions{})
+	require.NoError(t, err)
+
+	for name, data := range map[string]map[string][]byte{
+		"tls-cert":     {v1.TLSCertKey: newTestCertificatePEM(t, "san.example.org", "*.san.example.org")},
+		"generic-cert": {"cert": newTestCertificatePEM(t, "generic.example.org")},
+		"not-a-cert":   {v1.TLSCertKey: []byte("garbage")},
+		"unused-cert":  {v1.TLSCertKey: newTestCertificatePEM(t, "unused.example.org")},
+	} {
+		secret := &v1.Secret{ObjectMeta: metav1.ObjectMeta{Namespace: "istio-system", Name: name}, Data: data}
+		_, err = fakeKubernetesClient.CoreV1().Secrets(secret.Namespace).Create(context.Background(), secret, metav1.CreateOptions{})
+		require.NoError(t, err)
+	}
+
+	gateway := &networkingv1beta1.Gateway{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "istio-system", Name: "foo"},
+		Spec: istionetworking.Gateway{
+			Selector: map[string]string{"istio": "ingressgateway"},
+			Servers: []*istionetworking.Server{
+				{
+					Port:  &istionetworking.Port{Number: 80, Name: "http", Protocol: "HTTP"},
+					Hosts: []string{"http.example.org"},
+				},
+				{
+					Port:  &istionetworking.Port{Number: 443, Name: "https", Protocol: "HTTPS"},
+					Hosts: []string{"https.example.org"},
+					Tls:   &istionetworking.ServerTLSSettings{Mode: istionetworking.ServerTLSSettings_SIMPLE, CredentialName: "unused-cert"},
+				},
+				{
+					Port:  &istionetworking.Port{Number: 8443, Name: "tls", Protocol: "TLS"},
+					Hosts: []string{"tls.example.org"},
+					Tls:   &istionetworking.ServerTLSSettings{Mode: istionetworking.ServerTLSSettings_PASSTHROUGH},
+				},
+				{
+					Port: &istionetworking.Port{Number: 9443, Name: "https-credential", Protocol: "HTTPS"},
+					Tls:  &istionetworking.ServerTLSSettings{Mode: istionetworking.ServerTLSSettings_SIMPLE, CredentialName: "tls-cert"},
+				},
+				{
+					Port: &istionetworking.Port{Number: 10443, Name: "https-credentials", Protocol: "HTTPS"},
+					Tls: &istionetworking.ServerTLSSettings{
+						Mode:            istionetworking.ServerTLSSettings_SIMPLE,
+						CredentialNames: []string{"missing-cert", "not-a-cert", "generic-cert"},
+					},
+				},
+			},
+		},
+	}
+	_, err = fakeIstioClient.NetworkingV1beta1().Gateways(gateway.Namespace).Create(context.Background(), gateway, metav1.CreateOptions{})
+	require.NoError(t, err)
+
+	for _, tt := range []struct {
+		name            string
+		credentialHosts bool
+		hosts           []string
+	}{
+		{
+			name:  "credentials are not read by default",
+			hosts: []string{"http.example.org", "https.example.org", "tls.example.org"},
+		},
+		{
+			name:            "credentials contribute the DNS names of their certificates",
+			credentialHosts: true,
+			hosts:           []string{"http.example.org", "https.example.org", "tls.example.org", "san.example.org", "*.san.example.org", "generic.example.org"},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			hook := testutils.LogsUnderTestWithLogLevel(log.WarnLevel, t)
+
+			src, err := NewIstioGatewaySource(t.Context(), fakeKubernetesClient, fakeIstioClient, IstioGatewayOptions{
+				CredentialHosts: tt.credentialHosts,
+			})
+			require.NoError(t, err)
+
+			endpoints, err := src.Endpoints(context.Background())
+			require.NoError(t, err)
+
+			var expected []*endpoint.Endpoint
+			for _, host := range tt.hosts {
+				expected = append(expected, endpoint.NewEndpoint(host, endpoint.RecordTypeA, "8.8.8.8").
+					WithLabel(endpoint.ResourceLabelKey, "gateway/istio-system/foo"))
+			}
+			validateEndpoints(t, endpoints, expected)
+
+			if tt.credentialHosts {
+				testutils.TestHelperLogContains("Skipping credential missing-cert of gateway istio-system/foo", hook, t)
+				testutils.TestHelperLogContains("Skipping credential not-a-cert of gateway istio-system/foo", hook, t)
+			} else {
+				testutils.TestHelperLogNotContains("Skipping credential", hook, t)
+			}
+		})
+	}
+}
+
+// newTestCertificatePEM returns a PEM encoded self-signed certificate for the given DNS names.
+func newTestCertificatePEM(t *testing.T, dnsNames ...string) []byte {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: dnsNames[0]},
+		DNSNames:     dnsNames,
+		NotBefore:    time.Now(),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+}
+
+func TestGatewaySourceUnmatchedSelector(t *testing.T) {
+	for _, tt := range []struct {
+		title             string
+		unmatchedSelector string
+		expectError       bool
+	}{
+		{title: "skipped by default"},
+		{title: "skipped", unmatchedSelector: IstioGatewayUnmatchedSelectorSkip},
+		{title: "error", unmatchedSelector: IstioGatewayUnmatchedSelectorError, expectError: true},
+	} {
+		t.Run(tt.title, func(t *testing.T) {
+			hook := testutils.LogsUnderTestWithLogLevel(log.WarnLevel, t)
+			fakeKubernetesClient := fake.NewClientset()
+			fakeIstioClient := istiofake.NewSimpleClientset()
+
+			service := fakeIngressGatewayService{
+				namespace: "istio-system",
+				name:      "istio-ingressgateway",
+				ips:       []string{"8.8.8.8"},
+				selector:  map[string]string{"istio": "ingressgateway"},
+			}.Service()
+			_, err := fakeKubernetesClient.CoreV1().Services(service.Namespace).Create(context.Background(), service, metav1.CreateOptions{})
+			require.NoError(t, err)
+
+			for _, gateway := range []*networkingv1beta1.Gateway{
+				fakeGatewayConfig{
+					namespace: "istio-system",
+					name:      "matched",
+					dnsnames:  [][]string{{"matched.example.org"}},
+					selector:  map[string]string{"istio": "ingressgateway"},
+				}.Config(),
+				fakeGatewayConfig{
+					namespace: "istio-system",
+					name:      "unmatched",
+					dnsnames:  [][]string{{"unmatched.example.org"}},
+					selector:  map[string]string{"istio": "typo"},
+				}.Config(),
+			} {
+				_, err = fakeIstioClient.NetworkingV1beta1().Gateways(gateway.Namespace).Create(context.Background(), gateway, metav1.CreateOptions{})
+				require.NoError(t, err)
+			}
+
+			src, err := NewIstioGatewaySource(t.Context(), fakeKubernetesClient, fakeIstioClient, IstioGatewayOptions{
+				UnmatchedSelector: tt.unmatchedSelector,
+			})
+			require.NoError(t, err)
+
+			endpoints, err := src.Endpoints(context.Background())
+			if tt.expectError {
+				require.ErrorIs(t, err, errGatewaySelectorUnmatched)
+				assert.ErrorContains(t, err, `gateway istio-system/unmatched selects "istio=typo"`)
+				return
+			}
+			require.NoError(t, err)
+			validateEndpoints(t, endpoints, []*endpoint.Endpoint{
+				endpoint.NewEndpoint("matched.example.org", endpoint.RecordTypeA, "8.8.8.8").
+					WithLabel(endpoint.ResourceLabelKey, "gateway/istio-system/matched"),
+			})
+			testutils.TestHelperLogContains(`Skipping gateway istio-system/unmatched because its selector "istio=typo" matches no service`, hook, t)
+
+			var m dto.Metric
+			require.NoError(t, istioGatewaysUnmatchedSelector.Gauge.Write(&m))
+			assert.InDelta(t, 1, m.GetGauge().GetValue(), 0)
+		})
+	}
+}
+
+func TestNewIstioGatewaySourceInvalidUnmatchedSelector(t *testing.T) {
+	_, err := NewIstioGatewaySource(t.Context(), fake.NewClientset(), istiofake.NewSimpleClientset(), IstioGatewayOptions{
+		UnmatchedSelector: "ignore",
+	})
+	require.ErrorContains(t, err, `invalid unmatched gateway selector behavior "ignore"`)
+}
+
+func TestGatewaySourceHostConflict(t *testing.T) {
+	for _, tt := range []struct {
+		title        string
+		hostConflict string
+		expected     []*endpoint.Endpoint
+		expectedLog  string
+	}{
+		{
+			title: "ignored by default",
+			expected: []*endpoint.Endpoint{
+				endpoint.NewEndpoint("shared.example.org", endpoint.RecordTypeA, "1.1.1.1").
+					WithLabel(endpoint.ResourceLabelKey, "gateway/istio-system/a"),
+				endpoint.NewEndpoint("shared.example.org", endpoint.RecordTypeA, "2.2.2.2").
+					WithLabel(endpoint.ResourceLabelKey, "gateway/istio-system/b"),
+			},
+			expectedLog: "The A record shared.example.org is declared with different targets by gateway/istio-system/a, gateway/istio-system/b",
+		},
+		{
+			title:        "merged",
+			hostConflict: IstioGatewayHostConflictMerge,
+			expected: []*endpoint.Endpoint{
+				endpoint.NewEndpoint("shared.example.org", endpoint.RecordTypeA, "1.1.1.1", "2.2.2.2").
+					WithLabel(endpoint.ResourceLabelKey, "gateway/istio-system/a"),
+			},
+			expectedLog: "Merging the A record targets of shared.example.org declared with different targets by gateway/istio-system/a, gateway/istio-system/b",
+		},
+		{
+			title:        "first gateway kept",
+			hostConflict: IstioGatewayHostConflictFirst,
+			expected: []*endpoint.Endpoint{
+				endpoint.NewEndpoint("shared.example.org", endpoint.RecordTypeA, "1.1.1.1").
+					WithLabel(endpoint.ResourceLabelKey, "gateway/istio-system/a"),
+			},
+			expectedLog: "Keeping the A record targets of shared.example.org from gateway/istio-system/a only",
+		},
+	} {
+		t.Run(tt.title, func(t *testing.T) {
+			hook := testutils.LogsUnderTestWithLogLevel(log.WarnLevel, t)
+			fakeKubernetesClient := fake.NewClientset()
+			fakeIstioClient := istiofake.NewSimpleClientset()
+
+			for _, cfg := range []struct {
+				name string
+				ip   string
+			}{
+				// Created in reverse order, the first gateway is the first by name.
+				{name: "b", ip: "2.2.2.2"},
+				{name: "a", ip: "1.1.1.1"},
+			} {
+				service := fakeIngressGatewayService{
+					namespace: "istio-system",
+					name:      "ingressgateway-" + cfg.name,
+					ips:       []string{cfg.ip},
+					selector:  map[string]string{"istio": cfg.name},
+				}.Service()
+				_, err := fakeKubernetesClient.CoreV1().Services(service.Namespace).Create(context.Background(), service, metav1.CreateOptions{})
+				require.NoError(t, err)
+
+				gateway := fakeGatewayConfig{
+					namespace: "istio-system",
+					name:      cfg.name,
+					dnsnames:  [][]string{{"shared.example.org", cfg.name + ".example.org"}},
+					selector:  map[string]string{"istio": cfg.name},
+				}.Config()
+				_, err = fakeIstioClient.NetworkingV1beta1().Gateways(gateway.Namespace).Create(context.Background(), gateway, metav1.CreateOptions{})
+				require.NoError(t, err)
+			}
+
+			src, err := NewIstioGatewaySource(t.Context(), fakeKubernetesClient, fakeIstioClient, IstioGatewayOptions{
+				HostConflict: tt.hostConflict,
+			})
+			require.NoError(t, err)
+
+			endpoints, err := src.Endpoints(context.Background())
+			require.NoError(t, err)
+			validateEndpoints(t, endpoints, append([]*endpoint.Endpoint{
+				endpoint.NewEndpoint("a.example.org", endpoint.RecordTypeA, "1.1.1.1").
+					WithLabel(endpoint.ResourceLabelKey, "gateway/istio-system/a"),
+				endpoint.NewEndpoint("b.example.org", endpoint.RecordTypeA, "2.2.2.2").
+					WithLabel(endpoint.ResourceLabelKey, "gateway/istio-system/b"),
+			}, tt.expected...))
+			testutils.TestHelperLogContains(tt.expectedLog, hook, t)
+		})
+	}
+}
+
+func TestResolveGatewayHostConflictsLeavesInputUntouched(t *testing.T) {
+	endpoints := []*endpoint.Endpoint{
+		endpoint.NewEndpointWithTTL("shared.example.org", endpoint.RecordTypeA, 60, "2.2.2.2").
+			WithLabel(endpoint.ResourceLabelKey, "gateway/istio-system/a"),
+		endpoint.NewEndpointWithTTL("shared.example.org", endpoint.RecordTypeA, 300, "1.1.1.1").
+			WithLabel(endpoint.ResourceLabelKey, "gateway/istio-system/b"),
+	}
+
+	merged := resolveGatewayHostConflicts(endpoints, IstioGatewayHostConflictMerge)
+	require.Len(t, merged, 1)
+	assert.Equal(t, endpoint.Targets{"1.1.1.1", "2.2.2.2"}, merged[0].Targets)
+	assert.Equal(t, endpoint.TTL(300), merged[0].RecordTTL)
+	assert.Equal(t, endpoint.Targets{"2.2.2.2"}, endpoints[0].Targets)
+	assert.Equal(t, endpoint.TTL(60), endpoints[0].RecordTTL)
+}
+
+func TestNewIstioGatewaySourceInvalidHostConflict(t *testing.T) {
+	_, err := NewIstioGatewaySource(t.Context(), fake.NewClientset(), istiofake.NewSimpleClientset(), IstioGatewayOptions{
+		HostConflict: "last",
+	})
+	require.ErrorContains(t, err, `invalid gateway host conflict behavior "last"`)
+}
+
+func TestGatewaySourceListsGatewaysFromCache(t *testing.T) {
+	fakeKubernetesClient := fake.NewClientset()
+	fakeIstioClient := istiofake.NewSimpleClientset()
+
+	service := fakeIngressGatewayService{
+		namespace: "istio-system",
+		name:      "istio-ingressgateway",
+		ips:       []string{"8.8.8.8"},
+	}.Service()
+	_, err := fakeKubernetesClient.CoreV1().Services(service.Namespace).Create(context.Background(), service, metav1.CreateOptions{})
+	require.NoError(t, err)
+
+	for _, name := range []string{"foo", "bar"} {
+		gateway := fakeGatewayConfig{namespace: "istio-system", name: name, dnsnames: [][]string{{name + ".example.org"}}}.Config()
+		_, err = fakeIstioClient.NetworkingV1beta1().Gateways(gateway.Namespace).Create(context.Background(), gateway, metav1.CreateOptions{})
+		require.NoError(t, err)
+	}
+
+	src, err := NewIstioGatewaySource(t.Context(), fakeKubernetesClient, fakeIstioClient, IstioGatewayOptions{})
+	require.NoError(t, err)
+	fakeIstioClient.ClearActions()
+
+	gateways, err := src.(*gatewaySource).listGateways(context.Background())
+	require.NoError(t, err)
+	require.Len(t, gateways, 2)
+	assert.Equal(t, "bar", gateways[0].Name)
+	assert.Equal(t, "foo", gateways[1].Name)
+
+	endpoints, err := src.Endpoints(context.Background())
+	require.NoError(t, err)
+	assert.Len(t, endpoints, 2)
+	assert.Empty(t, fakeIstioClient.Actions(), "synced caches must not cause API requests")
+}
+
+func TestGatewaySourceListsGatewaysLiveUntilSynced(t *testing.T) {
+	fakeIstioClient := istiofake.NewSimpleClientset()
+	gateway := fakeGatewayConfig{namespace: "istio-system", name: "foo", dnsnames: [][]string{{"foo.example.org"}}}.Config()
+	_, err := fakeIstioClient.NetworkingV1beta1().Gateways(gateway.Namespace).Create(context.Background(), gateway, metav1.CreateOptions{})
+	require.NoError(t, err)
+	fakeIstioClient.ClearActions()
+
+	// The informer factory is never started, so its cache is never synced.
+	informerFactory := istioinformers.NewSharedInformerFactory(fakeIstioClient, 0)
+	src := &gatewaySource{
+		istioClient: fakeIstioClient,
+		informers: []gatewayNamespaceInformers{
+			{gatewayInformer: informerFactory.Networking().V1beta1().Gateways().Informer()},
+		},
+	}
+
+	gateways, err := src.listGateways(context.Background())
+	require.NoError(t, err)
+	require.Len(t, gateways, 1)
+	assert.Equal(t, "foo", gateways[0].Name)
+	require.Len(t, fakeIstioClient.Actions(), 1)
+	assert.Equal(t, "list", fakeIstioClient.Actions()[0].GetVerb())
+}
+
+func TestGatewaySourceLogFields(t *testing.T) {
+	hook := testutils.LogsUnderTestWithLogLevel(log.DebugLevel, t)
+	fakeKubernetesClient := fake.NewClientset()
+	fakeIstioClient := istiofake.NewSimpleClientset()
+
+	service := fakeIngressGatewayService{
+		namespace: "istio-system",
+		name:      "istio-ingressgateway",
+		ips:       []string{"8.8.8.8", "1.1.1.1"},
+		selector:  map[string]string{"istio": "ingressgateway"},
+	}.Service()
+	_, err := fakeKubernetesClient.CoreV1().Services(service.Namespace).Create(context.Background(), service, metav1.CreateOptions{})
+	require.NoError(t, err)
+	gateway := fakeGatewayConfig{
+		namespace: "istio-system",
+		name:      "foo",
+		dnsnames:  [][]string{{"foo.example.org"}},
+		selector:  map[string]string{"istio": "ingressgateway"},
+	}.Config()
+	_, err = fakeIstioClient.NetworkingV1beta1().Gateways(gateway.Namespace).Create(context.Background(), gateway, metav1.CreateOptions{})
+	require.NoError(t, err)
+
+	src, err := NewIstioGatewaySource(t.Context(), fakeKubernetesClient, fakeIstioClient, IstioGatewayOptions{})
+	require.NoError(t, err)
+	_, err = src.Endpoints(context.Background())
+	require.NoError(t, err)
+
+	var processed, generated []log.Fields
+	for _, entry := range hook.AllEntries() {
+		switch entry.Message {
+		case "Processing gateway":
+			processed = append(processed, entry.Data)
+		case "Endpoint generated from gateway":
+			generated = append(generated, entry.Data)
+		}
+	}
+	assert.Equal(t, []log.Fields{{"gateway": "istio-system/foo", "hosts": "foo.example.org"}}, processed)
+	assert.Equal(t, []log.Fields{{
+		"gateway":    "istio-system/foo",
+		"dnsName":    "foo.example.org",
+		"recordType": endpoint.RecordTypeA,
+		"targets":    "8.8.8.8,1.1.1.1",
+	}}, generated)
+}
+
+func TestGatewaySourceTargetKinds(t *testing.T) {
+	for _, tt := range []struct {
+		title      string
+		service    fakeIngressGatewayService
+		expected   []*endpoint.Endpoint
+		expectWarn bool
+	}{
+		{
+			title:   "hostnames only",
+			service: fakeIngressGatewayService{hostnames: []string{"elb.example.com", "alb.example.com"}},
+			expected: []*endpoint.Endpoint{
+				endpoint.NewEndpoint("foo.example.org", endpoint.RecordTypeCNAME, "alb.example.com", "elb.example.com"),
+			},
+		},
+		{
+			title:   "IPs only",
+			service: fakeIngressGatewayService{ips: []string{"8.8.8.8", "2001:db8::1"}},
+			expected: []*endpoint.Endpoint{
+				endpoint.NewEndpoint("foo.example.org", endpoint.RecordTypeA, "8.8.8.8"),
+				endpoint.NewEndpoint("foo.example.org", endpoint.RecordTypeAAAA, "2001:db8::1"),
+			},
+		},
+		{
+			title:   "hostnames mixed with IPs",
+			service: fakeIngressGatewayService{ips: []string{"8.8.8.8"}, hostnames: []string{"elb.example.com"}},
+			expected: []*endpoint.Endpoint{
+				endpoint.NewEndpoint("foo.example.org", endpoint.RecordTypeA, "8.8.8.8"),
+			},
+			expectWarn: true,
+		},
+	} {
+		t.Run(tt.title, func(t *testing.T) {
+			hook := testutils.LogsUnderTestWithLogLevel(log.WarnLevel, t)
+			tt.service.namespace = "istio-system"
+			tt.service.name = "istio-ingressgateway"
+			source, err := newTestGatewaySource([]fakeIngressGatewayService{tt.service}, nil)
+			require.NoError(t, err)
+
+			gateway := fakeGatewayConfig{namespace: "istio-system", name: "foo"}.Config()
+			endpoints, err := source.endpointsFromGateway(context.Background(), []string{"foo.example.org"}, gateway)
+			require.NoError(t, err)
+			for _, ep := range tt.expected {
+				ep.WithLabel(endpoint.ResourceLabelKey, "gateway/istio-system/foo")
+			}
+			validateEndpoints(t, endpoints, tt.expected)
+
+			if tt.expectWarn {
+				testutils.TestHelperLogContains(`Ignoring hostname targets ["elb.example.com"] of gateway istio-system/foo because they are mixed with IP address targets ["8.8.8.8"]`, hook, t)
+			} else {
+				testutils.TestHelperLogNotContains("Ignoring hostname targets", hook, t)
+			}
+		})
+	}
+}
+
+func TestGatewaySourceDualStack(t *testing.T) {
+	for _, tt := range []struct {
+		title    string
+		service  fakeIngressGatewayService
+		ingress  *fakeIngress
+		expected []*endpoint.Endpoint
+	}{
+		{
+			title:   "dual-stack load balancer of the service",
+			service: fakeIngressGatewayService{ips: []string{"2001:db8::1", "8.8.8.8"}},
+			expected: []*endpoint.Endpoint{
+				endpoint.NewEndpoint("foo.example.org", endpoint.RecordTypeA, "8.8.8.8"),
+				endpoint.NewEndpoint("foo.example.org", endpoint.RecordTypeAAAA, "2001:db8::1"),
+			},
+		},
+		{
+			title:   "IPv4-mapped IPv6 address published as A",
+			service: fakeIngressGatewayService{ips: []string{"::ffff:8.8.8.8", "2001:db8::1"}},
+			expected: []*endpoint.Endpoint{
+				endpoint.NewEndpoint("foo.example.org", endpoint.RecordTypeA, "8.8.8.8"),
+				endpoint.NewEndpoint("foo.example.org", endpoint.RecordTypeAAAA, "2001:db8::1"),
+			},
+		},
+		{
+			title:   "IPv6 addresses in canonical form",
+			service: fakeIngressGatewayService{ips: []string{"2001:DB8:0:0::1", "2001:db8::1", "8.8.8.8"}},
+			expected: []*endpoint.Endpoint{
+				endpoint.NewEndpoint("foo.example.org", endpoint.RecordTypeA, "8.8.8.8"),
+				endpoint.NewEndpoint("foo.example.org", endpoint.RecordTypeAAAA, "2001:db8::1"),
+			},
+		},
+		{
+			title:   "dual-stack load balancer of the Ingress",
+			ingress: &fakeIngress{namespace: "istio-system", name: "ingress1", ips: []string{"8.8.8.8", "2001:db8::2"}},
+			expected: []*endpoint.Endpoint{
+				endpoint.NewEndpoint("foo.example.org", endpoint.RecordTypeA, "8.8.8.8"),
+				endpoint.NewEndpoint("foo.example.org", endpoint.RecordTypeAAAA, "2001:db8::2"),
+			},
+		},
+	} {
+		t.Run(tt.title, func(t *testing.T) {
+			tt.service.namespace = "istio-system"
+			tt.service.name = "istio-ingressgateway"
+			var ingresses []fakeIngress
+			var annotations map[string]string
+			if tt.ingress != nil {
+				ingresses = append(ingresses, *tt.ingress)
+				annotations = map[string]string{IstioGatewayIngressSource: tt.ingress.name}
+			}
+			source, err := newTestGatewaySource([]fakeIngressGatewayService{tt.service}, ingresses)
+			require.NoError(t, err)
+
+			gateway := fakeGatewayConfig{namespace: "istio-system", name: "foo", annotations: annotations}.Config()
+			endpoints, err := source.endpointsFromGateway(context.Background(), []string{"foo.example.org"}, gateway)
+			require.NoError(t, err)
+			for _, ep := range tt.expected {
+				ep.WithLabel(endpoint.ResourceLabelKey, "gateway/istio-system/foo")
+			}
+			validateEndpoints(t, endpoints, tt.expected)
+		})
+	}
+}
+
+func TestCanonicalIPTargets(t *testing.T) {
+	targets := endpoint.Targets{"lb.example.com", "::ffff:1.2.3.4", "1.2.3.4", "2001:DB8::1", "fe80::1%eth0"}
+	assert.Equal(t, endpoint.Targets{"lb.example.com", "1.2.3.4", "2001:db8::1", "fe80::1%eth0"}, canonicalIPTargets(targets))
+}
+
+func TestGatewaySourceTTLFromServices(t *testing.T) {
+	ingressGateway := map[string]string{"istio": "ingressgateway"}
+	for _, tt := range []struct {
+		title       string
+		services    []fakeIngressGatewayService
+		annotations map[string]string
+		expected    endpoint.TTL
+	}{
+		{
+			title: "TTL on the service only",
+			services: []fakeIngressGatewayService{
+				{name: "istio-ingressgateway", selector: ingressGateway, annotations: map[string]string{ttlAnnotationKey: "120"}},
+			},
+			expected: 120,
+		},
+		{
+			title: "TTL on the gateway takes precedence",
+			services: []fakeIngressGatewayService{
+				{name: "istio-ingressgateway", selector: ingressGateway, annotations: map[string]string{ttlAnnotationKey: "120"}},
+			},
+			annotations: map[string]string{ttlAnnotationKey: "60"},
+			expected:    60,
+		},
+		{
+			title: "lowest TTL of the selected services",
+			services: []fakeIngressGatewayService{
+				{name: "istio-ingressgateway", selector: ingressGateway, annotations: map[string]string{ttlAnnotationKey: "300"}},
+				{name: "istio-ingressgateway-canary", selector: ingressGateway, annotations: map[string]string{ttlAnnotationKey: "120"}},
+				{name: "istio-ingressgateway-no-ttl", selector: ingressGateway},
+			},
+			expected: 120,
+		},
+		{
+			title: "TTL of services not selected by the gateway is ignored",
+			services: []fakeIngressGatewayService{
+				{name: "istio-ingressgateway", selector: ingressGateway},
+				{name: "istio-eastwestgateway", selector: map[string]string{"istio": "eastwestgateway"}, annotations: map[string]string{ttlAnnotationKey: "120"}},
+			},
+			expected: 0,
+		},
+		{
+			title: "TTL of the services is ignored with a target annotation",
+			services: []fakeIngressGatewayService{
+				{name: "istio-ingressgateway", selector: ingressGateway, annotations: map[string]string{ttlAnnotationKey: "120"}},
+			},
+			annotations: map[string]string{targetAnnotationKey: "9.9.9.9"},
+			expected:    0,
+		},
+	} {
+		t.Run(tt.title, func(t *testing.T) {
+			for i := range tt.services {
+				tt.services[i].namespace = "istio-system"
+				tt.services[i].ips = []string{"8.8.8.8"}
+			}
+			source, err := newTestGatewaySource(tt.services, nil)
+			require.NoError(t, err)
+
+			gateway := fakeGatewayConfig{namespace: "istio-system", name: "foo", selector: ingressGateway, annotations: tt.annotations}.Config()
+			endpoints, err := source.endpointsFromGateway(context.Background(), []string{"foo.example.org"}, gateway)
+			require.NoError(t, err)
+			require.Len(t, endpoints, 1)
+			assert.Equal(t, tt.expected, endpoints[0].RecordTTL)
+		})
+	}
+}
+
+func TestGatewaySourceConfigAnnotation(t *testing.T) {
+	for _, tt := range []struct {
+		title       string
+		annotations map[string]string
+		expected    []*endpoint.Endpoint
+		expectWarn  bool
+	}{
+		{
+			title:       "TTL, targets and record type",
+			annotations: map[string]string{configAnnotationKey: `{"ttl":300,"targets":["1.2.3.4","2001:db8::1"],"recordType":"A"}`},
+			expected: []*endpoint.Endpoint{
+				endpoint.NewEndpointWithTTL("foo.example.org", endpoint.RecordTypeA, 300, "1.2.3.4"),
+			},
+		},
+		{
+			title: "overrides the individual annotations",
+			annotations: map[string]string{
+				configAnnotationKey: `{"ttl":300,"targets":["1.2.3.4"]}`,
+				targetAnnotationKey: "9.9.9.9",
+				ttlAnnotationKey:    "60",
+			},
+			expected: []*endpoint.Endpoint{
+				endpoint.NewEndpointWithTTL("foo.example.org", endpoint.RecordTypeA, 300, "1.2.3.4"),
+			},
+		},
+		{
+			title: "fields missing from the config keep the individual annotations",
+			annotations: map[string]string{
+				configAnnotationKey: `{"ttl":300}`,
+				targetAnnotationKey: "9.9.9.9",
+			},
+			expected: []*endpoint.Endpoint{
+				endpoint.NewEndpointWithTTL("foo.example.org", endpoint.RecordTypeA, 300, "9.9.9.9"),
+			},
+		},
+		{
+			title:       "targets of the selected services",
+			annotations: map[string]string{configAnnotationKey: `{"ttl":300}`},
+			expected: []*endpoint.Endpoint{
+				endpoint.NewEndpointWithTTL("foo.example.org", endpoint.RecordTypeA, 300, "8.8.8.8"),
+			},
+		},
+		{
+			title: "invalid JSON falls back to the individual annotations",
+			annotations: map[string]string{
+				configAnnotationKey: `{"ttl":"5m"`,
+				targetAnnotationKey: "9.9.9.9",
+				ttlAnnotationKey:    "60",
+			},
+			expected: []*endpoint.Endpoint{
+				endpoint.NewEndpointWithTTL("foo.example.org", endpoint.RecordTypeA, 60, "9.9.9.9"),
+			},
+			expectWarn: true,
+		},
+	} {
+		t.Run(tt.title, func(t *testing.T) {
+			hook := testutils.LogsUnderTestWithLogLevel(log.WarnLevel, t)
+			service := fakeIngressGatewayService{namespace: "istio-system", name: "istio-ingressgateway", ips: []string{"8.8.8.8"}}
+			source, err := newTestGatewaySource([]fakeIngressGatewayService{service}, nil)
+			require.NoError(t, err)
+
+			gateway := fakeGatewayConfig{namespace: "istio-system", name: "foo", annotations: tt.annotations}.Config()
+			original := maps.Clone(gateway.Annotations)
+			endpoints, err := source.endpointsFromGateway(context.Background(), []string{"foo.example.org"}, gateway)
+			require.NoError(t, err)
+			for _, ep := range tt.expected {
+				ep.WithLabel(endpoint.ResourceLabelKey, "gateway/istio-system/foo")
+			}
+			validateEndpoints(t, endpoints, tt.expected)
+			assert.Equal(t, original, gateway.Annotations, "the annotations of the gateway must be left untouched")
+
+			if tt.expectWarn {
+				testutils.TestHelperLogContains("gateway/istio-system/foo: "+strconv.Quote(tt.annotations[configAnnotationKey])+" is not a valid", hook, t)
+			} else {
+				testutils.TestHelperLogNotContains("is not a valid", hook, t)
+			}
+		})
+	}
+}
+
+func TestGatewaySourceResolveLoadBalancerHostname(t *testing.T) {
+	lookupNetIP := func(_ context.Context, network, host string) ([]netip.Addr, error) {
+		if network != "ip" || host != "lb.example.com" {
+			return nil, errors.New("no such host")
+		}
+		return []netip.Addr{netip.MustParseAddr("1.2.3.4"), netip.MustParseAddr("::ffff:5.6.7.8"), netip.MustParseAddr("2001:db8::1")}, nil
+	}
+
+	for _, tt := range []struct {
+		title       string
+		service     fakeIngressGatewayService
+		annotations map[string]string
+		expected    []*endpoint.Endpoint
+		expectWarn  bool
+	}{
+		{
+			title:   "hostname resolved to A and AAAA",
+			service: fakeIngressGatewayService{hostnames: []string{"lb.example.com"}},
+			expected: []*endpoint.Endpoint{
+				endpoint.NewEndpoint("foo.example.org", endpoint.RecordTypeA, "1.2.3.4", "5.6.7.8"),
+				endpoint.NewEndpoint("foo.example.org", endpoint.RecordTypeAAAA, "2001:db8::1"),
+			},
+		},
+		{
+			title:   "hostname resolved along IP targets",
+			service: fakeIngressGatewayService{ips: []string{"8.8.8.8"}, hostnames: []string{"lb.example.com"}},
+			expected: []*endpoint.Endpoint{
+				endpoint.NewEndpoint("foo.example.org", endpoint.RecordTypeA, "1.2.3.4", "5.6.7.8", "8.8.8.8"),
+				endpoint.NewEndpoint("foo.example.org", endpoint.RecordTypeAAAA, "2001:db8::1"),
+			},
+		},
+		{
+			title:       "hostname of the target annotation resolved",
+			service:     fakeIngressGatewayService{ips: []string{"8.8.8.8"}},
+			annotations: map[string]string{targetAnnotationKey: "lb.example.com"},
+			expected: []*endpoint.Endpoint{
+				endpoint.NewEndpoint("foo.example.org", endpoint.RecordTypeA, "1.2.3.4", "5.6.7.8"),
+				endpoint.NewEndpoint("foo.example.org", endpoint.RecordTypeAAAA, "2001:db8::1"),
+			},
+		},
+		{
+			title:   "unresolvable hostname falls back to CNAME",
+			service: fakeIngressGatewayService{hostnames: []string{"unknown.example.com"}},
+			expected: []*endpoint.Endpoint{
+				endpoint.NewEndpoint("foo.example.org", endpoint.RecordTypeCNAME, "unknown.example.com"),
+			},
+			expectWarn: true,
+		},
+		{
+			title:       "target record type annotation takes precedence",
+			service:     fakeIngressGatewayService{hostnames: []string{"lb.example.com"}},
+			annotations: map[string]string{targetRecordTypeAnnotationKey: "AAAA"},
+			expected:    []*endpoint.Endpoint{},
+		},
+	} {
+		t.Run(tt.title, func(t *testing.T) {
+			hook := testutils.LogsUnderTestWithLogLevel(log.WarnLevel, t)
+			tt.service.namespace = "istio-system"
+			tt.service.name = "istio-ingressgateway"
+			source, err := newTestGatewaySource([]fakeIngressGatewayService{tt.service}, nil)
+			require.NoError(t, err)
+			source.resolveLoadBalancerHostname = true
+			source.lookupNetIP = lookupNetIP
+
+			gateway := fakeGatewayConfig{namespace: "istio-system", name: "foo", annotations: tt.annotations}.Config()
+			endpoints, err := source.endpointsFromGateway(context.Background(), []string{"foo.example.org"}, gateway)
+			require.NoError(t, err)
+			for _, ep := range tt.expected {
+				ep.WithLabel(endpoint.ResourceLabelKey, "gateway/istio-system/foo")
+			}
+			validateEndpoints(t, endpoints, tt.expected)
+
+			if tt.expectWarn {
+				testutils.TestHelperLogContains(`Unable to resolve hostname target "unknown.example.com" of gateway istio-system/foo, falling back to a CNAME record`, hook, t)
+			} else {
+				testutils.TestHelperLogNotContains("Unable to resolve hostname target", hook, t)
+			}
+		})
+	}
+}
+
+func TestGatewaySourceTargetRecordType(t *testing.T) {
+	lookupNetIP := func(_ context.Context, network, host string) ([]netip.Addr, error) {
+		if host != "lb.example.com" {
+			return nil, errors.New("no such host")
+		}
+		if network == "ip6" {
+			return []netip.Addr{netip.MustParseAddr("2001:db8::1")}, nil
+		}
+		return []netip.Addr{netip.MustParseAddr("1.2.3.4"), netip.MustParseAddr("::ffff:5.6.7.8")}, nil
+	}
+
+	for _, tt := range []struct {
+		title       string
+		service     fakeIngressGatewayService
+		annotations map[string]string
+		expected    []*endpoint.Endpoint
+	}{
+		{
+			title:       "hostname resolved to A",
+			service:     fakeIngressGatewayService{hostnames: []string{"lb.example.com"}},
+			annotations: map[string]string{targetRecordTypeAnnotationKey: "A"},
+			expected: []*endpoint.Endpoint{
+				endpoint.NewEndpoint("foo.example.org", endpoint.RecordTypeA, "1.2.3.4", "5.6.7.8"),
+			},
+		},
+		{
+			title:       "hostname resolved to AAAA",
+			service:     fakeIngressGatewayService{hostnames: []string{"lb.example.com"}},
+			annotations: map[string]string{targetRecordTypeAnnotationKey: "AAAA"},
+			expected: []*endpoint.Endpoint{
+				endpoint.NewEndpoint("foo.example.org", endpoint.RecordTypeAAAA, "2001:db8::1"),
+			},
+		},
+		{
+			title:       "hostname resolved along IP targets",
+			service:     fakeIngressGatewayService{ips: []string{"8.8.8.8", "2001:db8::2"}, hostnames: []string{"lb.example.com"}},
+			annotations: map[string]string{targetRecordTypeAnnotationKey: "A"},
+			expected: []*endpoint.Endpoint{
+				endpoint.NewEndpoint("foo.example.org", endpoint.RecordTypeA, "1.2.3.4", "5.6.7.8", "8.8.8.8"),
+			},
+		},
+		{
+			title:   "hostname of the target annotation resolved to A",
+			service: fakeIngressGatewayService{ips: []string{"8.8.8.8"}},
+			annotations: map[string]string{
+				targetAnnotationKey:           "lb.example.com",
+				targetRecordTypeAnnotationKey: "A",
+			},
+			expected: []*endpoint.Endpoint{
+				endpoint.NewEndpoint("foo.example.org", endpoint.RecordTypeA, "1.2.3.4", "5.6.7.8"),
+			},
+		},
+		{
+			title:       "unresolvable hostname is dropped",
+			service:     fakeIngressGatewayService{hostnames: []string{"unknown.example.com"}},
+			annotations: map[string]string{targetRecordTypeAnnotationKey: "A"},
+			expected:    []*endpoint.Endpoint{},
+		},
+		{
+			title:       "IP target published as CNAME",
+			service:     fakeIngressGatewayService{ips: []string{"8.8.8.8"}},
+			annotations: map[string]string{targetRecordTypeAnnotationKey: "CNAME"},
+			expected: []*endpoint.Endpoint{
+				endpoint.NewEndpoint("foo.example.org", endpoint.RecordTypeCNAME, "8.8.8.8"),
+			},
+		},
+		{
+			title:       "mixed targets published as CNAME",
+			service:     fakeIngressGatewayService{ips: []string{"8.8.8.8"}, hostnames: []string{"lb.example.com"}},
+			annotations: map[string]string{targetRecordTypeAnnotationKey: "CNAME"},
+			expected: []*endpoint.Endpoint{
+				endpoint.NewEndpoint("foo.example.org", endpoint.RecordTypeCNAME, "8.8.8.8", "lb.example.com"),
+			},
+		},
+		{
+			title:       "invalid record type is ignored",
+			service:     fakeIngressGatewayService{hostnames: []string{"lb.example.com"}},
+			annotations: map[string]string{targetRecordTypeAnnotationKey: "MX"},
+			expected: []*endpoint.Endpoint{
+				endpoint.NewEndpoint("foo.example.org", endpoint.RecordTypeCNAME, "lb.example.com"),
+			},
+		},
+	} {
+		t.Run(tt.title, func(t *testing.T) {
+			tt.service.namespace = "istio-system"
+			tt.service.name = "istio-ingressgateway"
+			source, err := newTestGatewaySource([]fakeIngressGatewayService{tt.service}, nil)
+			require.NoError(t, err)
+			source.lookupNetIP = lookupNetIP
+
+			gateway := fakeGatewayConfig{namespace: "istio-system", name: "foo", annotations: tt.annotations}.Config()
+			endpoints, err := source.endpointsFromGateway(context.Background(), []string{"foo.example.org"}, gateway)
+			require.NoError(t, err)
+			for _, ep := range tt.expected {
+				ep.WithLabel(endpoint.ResourceLabelKey, "gateway/istio-system/foo")
+			}
+			validateEndpoints(t, endpoints, tt.expected)
+		})
+	}
+}
+
+// gateway specific helper functions
+func newTestGatewaySource(loadBalancerList []fakeIngressGatewayService, ingressList []fakeIngress) (*gatewaySource, error) {
+	fakeKubernetesClient := fake.NewClientset()
 	fakeIstioClient := istiofake.NewSimpleClientset()
 
 	for _, lb := range loadBalancerList {
@@ -1652,16 +3158,9 @@ func newTestGatewaySource(loadBalancerList []fakeIngressGatewayService, ingressL
 		}
 	}
 
-	src, err := NewIstioGatewaySource(
-		context.TODO(),
-		fakeKubernetesClient,
-		fakeIstioClient,
-		"",
-		"",
-		"{{.Name}}",
-		false,
-		false,
-	)
+	src, err := NewIstioGatewaySource(context.TODO(), fakeKubernetesClient, fakeIstioClient, IstioGatewayOptions{
+		FQDNTemplate: "{{.Name}}",
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -1681,13 +3180,15 @@ type fakeIngressGatewayService struct {
 	name        string
 	selector    map[string]string
 	externalIPs []string
+	annotations map[string]string
 }
 
 func (ig fakeIngressGatewayService) Service() *v1.Service {
 	svc := &v1.Service{
 		ObjectMeta: metav1.ObjectMeta{
-			Namespace: ig.namespace,
-			Name:      ig.name,
+			Namespace:   ig.namespace,
+			Name:        ig.name,
+			Annotations: ig.annotations,
 		},
 		Status: v1.ServiceStatus{
 			LoadBalancer: v1.LoadBalancerStatus{
@@ -1718,6 +3219,7 @@ type fakeGatewayConfig struct {
 	namespace   string
 	name        string
 	annotations map[string]string
+	labels      map[string]string
 	dnsnames    [][]string
 	selector    map[string]string
 }
@@ -1728,6 +3230,7 @@ func (c fakeGatewayConfig) Config() *networkingv1beta1.Gateway {
 			Name:        c.name,
 			Namespace:   c.namespace,
 			Annotations: c.annotations,
+			Labels:      c.labels,
 		},
 		Spec: istionetworking.Gateway{
 			Servers:  nil,
@@ -387,27 +387,23 @@ func virtualServiceBindsToGateway(vService *v1beta1.VirtualService, gateway *v1b
 
 	for _, server := range gateway.Spec.Servers {
 		for _, host := range server.Hosts {
-			namespace := "*"
-			parts := strings.Split(host, "/")
-			if len(parts) == 2 {
-				namespace = parts[0]
-				host = parts[1]
-			} else if len(parts) != 1 {
+			namespace, hostname, ok := parseGatewayHost(host, gateway.Namespace)
+			if !ok {
 				log.Debugf("Gateway %s/%s has invalid host %s", gateway.Namespace, gateway.Name, host)
 				continue
 			}
 
-			if namespace == "*" || namespace == vService.Namespace || (namespace == "." && vService.Namespace == gateway.Namespace) {
-				if host == "*" {
+			if namespace == "*" || namespace == vService.Namespace {
+				if hostname == "*" {
 					return true
 				}
 
 				suffixMatch := false
-				if strings.HasPrefix(host, "*.") {
+				if strings.HasPrefix(hostname, "*.") {
 					suffixMatch = true
 				}
 
-				if host == vsHost || (suffixMatch && strings.HasSuffix(vsHost, host[1:])) {
+				if hostname == vsHost || (suffixMatch && strings.HasSuffix(vsHost, hostname[1:])) {
 					return true
 				}
 			}
@@ -432,16 +428,8 @@ func (sc *virtualServiceSource) targetsFromIngress(ctx context.Context, ingressS
 		return nil, err
 	}
 
-	targets := make(endpoint.Targets, 0)
-
-	for _, lb := range ingress.Status.LoadBalancer.Ingress {
-		if lb.IP != "" {
-			targets = append(targets, lb.IP)
-		} else if lb.Hostname != "" {
-			targets = append(targets, lb.Hostname)
-		}
-	}
-	return targets, nil
+	// Every load balancer entry may carry both an IP and a hostname, keep all distinct ones.
+	return uniqueTargets(targetsFromIngressStatus(ingress.Status)), nil
 }
 
 func (sc *virtualServiceSource) targetsFromGateway(ctx context.Context, gateway *v1beta1.Gateway) (endpoint.Targets, error) {
@@ -2120,6 +2120,28 @@ func (c fakeVirtualServiceConfig) Config() *networkingv1beta1.VirtualService {
 	}
 }
 
+func TestVirtualServiceSourceTargetsFromIngress(t *testing.T) {
+	ingress := &networkv1.Ingress{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "istio-system", Name: "ingress1"},
+		Status: networkv1.IngressStatus{
+			LoadBalancer: networkv1.IngressLoadBalancerStatus{
+				Ingress: []networkv1.IngressLoadBalancerIngress{
+					{IP: "8.8.8.8", Hostname: "lb.example.com"},
+					{IP: "2001:db8::1", Hostname: "lb.example.com"},
+					{IP: "8.8.8.8"},
+				},
+			},
+		},
+	}
+	kubeClient := fake.NewClientset(ingress)
+	sc := &virtualServiceSource{kubeClient: kubeClient}
+	gateway := &networkingv1beta1.Gateway{ObjectMeta: metav1.ObjectMeta{Namespace: "istio-system", Name: "foo"}}
+
+	targets, err := sc.targetsFromIngress(context.Background(), "ingress1", gateway)
+	require.NoError(t, err)
+	assert.Equal(t, endpoint.Targets{"2001:db8::1", "8.8.8.8", "lb.example.com"}, targets)
+}
+
 func TestVirtualServiceSourceGetGateway(t *testing.T) {
 	type fields struct {
 		virtualServiceSource *virtualServiceSource
@@ -2280,16 +2302,7 @@ func TestGatewaySource_GWVServiceSelectorMatchServiceSelector(t *testing.T) {
 			fakeKubeClient := fake.NewClientset()
 			fakeIstioClient := istiofake.NewSimpleClientset()
 
-			src, err := NewIstioGatewaySource(
-				t.Context(),
-				fakeKubeClient,
-				fakeIstioClient,
-				"",
-				"",
-				"",
-				false,
-				false,
-			)
+			src, err := NewIstioGatewaySource(t.Context(), fakeKubeClient, fakeIstioClient, IstioGatewayOptions{})
 			require.NoError(t, err)
 			require.NotNil(t, src)
 
@@ -29,10 +29,13 @@ import (
 
 const (
 	controllerAnnotationKey       = annotations.ControllerKey
+	excludeAnnotationKey          = annotations.ExcludeKey
 	hostnameAnnotationKey         = annotations.HostnameKey
 	accessAnnotationKey           = annotations.AccessKey
 	endpointsTypeAnnotationKey    = annotations.EndpointsTypeKey
 	targetAnnotationKey           = annotations.TargetKey
+	targetRecordTypeAnnotationKey = annotations.TargetRecordTypeKey
+	configAnnotationKey           = annotations.ConfigKey
 	ttlAnnotationKey              = annotations.TtlKey
 	aliasAnnotationKey            = annotations.AliasKey
 	ingressHostnameSourceKey      = annotations.IngressHostnameSourceKey
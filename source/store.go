@@ -67,6 +67,12 @@ type Config struct {
 	AnnotationFilter               string
 	LabelFilter                    labels.Selector
 	IngressClassNames              []string
+	IstioGatewayNamespaces         []string
+	IstioGatewayVSHosts            bool
+	IstioGatewayCredentialHosts    bool
+	IstioGatewayUnmatched          string
+	IstioGatewayResolveLBHostname  bool
+	IstioGatewayHostConflict       string
 	FQDNTemplate                   string
 	CombineFQDNAndAnnotation       bool
 	IgnoreHostnameAnnotation       bool
@@ -113,6 +119,12 @@ func NewSourceConfig(cfg *externaldns.Config) *Config {
 		AnnotationFilter:               cfg.AnnotationFilter,
 		LabelFilter:                    labelSelector,
 		IngressClassNames:              cfg.IngressClassNames,
+		IstioGatewayNamespaces:         cfg.IstioGatewayNamespaces,
+		IstioGatewayVSHosts:            cfg.IstioGatewayVSHosts,
+		IstioGatewayCredentialHosts:    cfg.IstioGatewayCredentialHosts,
+		IstioGatewayUnmatched:          cfg.IstioGatewayUnmatched,
+		IstioGatewayResolveLBHostname:  cfg.IstioGatewayResolveLBHostname,
+		IstioGatewayHostConflict:       cfg.IstioGatewayHostConflict,
 		FQDNTemplate:                   cfg.FQDNTemplate,
 		CombineFQDNAndAnnotation:       cfg.CombineFQDNAndAnnotation,
 		IgnoreHostnameAnnotation:       cfg.IgnoreHostnameAnnotation,
@@ -465,7 +477,24 @@ func buildIstioGatewaySource(ctx context.Context, p ClientGenerator, cfg *Config
 	if err != nil {
 		return nil, err
 	}
-	return NewIstioGatewaySource(ctx, kubernetesClient, istioClient, cfg.Namespace, cfg.AnnotationFilter, cfg.FQDNTemplate, cfg.CombineFQDNAndAnnotation, cfg.IgnoreHostnameAnnotation)
+	// Istio Gateways are limited to the namespace of the other sources unless namespaces of their own are given.
+	namespaces := cfg.IstioGatewayNamespaces
+	if len(namespaces) == 0 {
+		namespaces = []string{cfg.Namespace}
+	}
+	return NewIstioGatewaySource(ctx, kubernetesClient, istioClient, IstioGatewayOptions{
+		Namespaces:                  namespaces,
+		AnnotationFilter:            cfg.AnnotationFilter,
+		FQDNTemplate:                cfg.FQDNTemplate,
+		CombineFQDNAnnotation:       cfg.CombineFQDNAndAnnotation,
+		IgnoreHostnameAnnotation:    cfg.IgnoreHostnameAnnotation,
+		LabelSelector:               cfg.LabelFilter,
+		VirtualServiceHosts:         cfg.IstioGatewayVSHosts,
+		CredentialHosts:             cfg.IstioGatewayCredentialHosts,
+		UnmatchedSelector:           cfg.IstioGatewayUnmatched,
+		ResolveLoadBalancerHostname: cfg.IstioGatewayResolveLBHostname,
+		HostConflict:                cfg.IstioGatewayHostConflict,
+	})
 }
 
 // buildIstioVirtualServiceSource creates an Istio VirtualService source for exposing virtual services as DNS records.